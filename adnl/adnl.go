@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/xssnick/tonutils-go/adnl/address"
+	"github.com/xssnick/tonutils-go/observer"
 	"github.com/xssnick/tonutils-go/tl"
 	"log"
 	"reflect"
@@ -73,6 +74,7 @@ type ADNL struct {
 	queryHandler         QueryHandler
 	onDisconnect         DisconnectHandler
 	onChannel            func(ch *Channel)
+	observer             observer.Observer
 
 	lastReceiveAt time.Time
 
@@ -153,8 +155,11 @@ func (a *ADNL) processPacket(packet *PacketContent, ch *Channel) (err error) {
 		a.peerKey = packet.From.Key
 	}
 
+	var lost uint64
+	obs := a.observer
 	if a.confirmSeqno+1 < seqno {
-		a.loss += seqno - (a.confirmSeqno + 1)
+		lost = seqno - (a.confirmSeqno + 1)
+		a.loss += lost
 	}
 
 	if seqno > a.confirmSeqno {
@@ -188,6 +193,15 @@ func (a *ADNL) processPacket(packet *PacketContent, ch *Channel) (err error) {
 	}
 	a.mx.Unlock()
 
+	if lost > 0 {
+		observer.Notify(context.Background(), obs, &observer.Event{
+			Component: observer.ComponentADNL,
+			Kind:      observer.EventPacketLoss,
+			Peer:      a.addr,
+			Value:     int64(lost),
+		})
+	}
+
 	for i, message := range packet.Messages {
 		err = a.processMessage(message, ch)
 		if err != nil {
@@ -356,6 +370,19 @@ func (a *ADNL) GetDisconnectHandler() func(addr string, key ed25519.PublicKey) {
 	return a.onDisconnect
 }
 
+// SetObserver - sets observer of queries and packet loss
+func (a *ADNL) SetObserver(o observer.Observer) {
+	a.mx.Lock()
+	a.observer = o
+	a.mx.Unlock()
+}
+
+func (a *ADNL) getObserver() observer.Observer {
+	a.mx.RLock()
+	defer a.mx.RUnlock()
+	return a.observer
+}
+
 func (a *ADNL) SetChannelReadyHandler(handler func(ch *Channel)) {
 	a.onChannel = handler
 }
@@ -405,7 +432,12 @@ func (a *ADNL) Query(ctx context.Context, req, result tl.Serializable) error {
 	return a.query(ctx, nil, req, result)
 }
 
-func (a *ADNL) query(ctx context.Context, ch *Channel, req, result tl.Serializable) error {
+func (a *ADNL) query(ctx context.Context, ch *Channel, req, result tl.Serializable) (err error) {
+	ctx, tracker := observer.StartRequest(ctx, a.getObserver(), observer.ComponentADNL, tl.TypeName(req), a.addr)
+	defer func() {
+		tracker.Done(err)
+	}()
+
 	q, err := createQueryMessage(req)
 	if err != nil {
 		return fmt.Errorf("failed to create query message: %w", err)
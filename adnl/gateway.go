@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/xssnick/tonutils-go/adnl/address"
+	"github.com/xssnick/tonutils-go/observer"
 	"github.com/xssnick/tonutils-go/tl"
 	"net"
 	"net/netip"
@@ -65,6 +66,7 @@ type Gateway struct {
 	peers      map[string]*peerConn
 
 	connHandler func(client Peer) error
+	observer    observer.Observer
 
 	globalCtx       context.Context
 	globalCtxCancel func()
@@ -354,6 +356,7 @@ func (g *Gateway) registerClient(addr net.Addr, key ed25519.PublicKey, id string
 	addrList.Version = addrList.ReinitDate
 
 	a := initADNL(g.key)
+	a.observer = g.observer
 	a.SetAddresses(addrList)
 	a.peerKey = key
 	a.addr = addr.String()
@@ -415,6 +418,19 @@ func (g *Gateway) RegisterClient(addr string, key ed25519.PublicKey) (Peer, erro
 	return g.registerClient(udpAddr, key, string(clientId))
 }
 
+// SetObserver - sets observer for all peers of gateway, including already connected
+func (g *Gateway) SetObserver(o observer.Observer) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	g.observer = o
+	for _, peer := range g.peers {
+		if a, ok := peer.client.(*ADNL); ok {
+			a.SetObserver(o)
+		}
+	}
+}
+
 func (g *Gateway) SetConnectionHandler(handler func(client Peer) error) {
 	g.connHandler = handler
 }
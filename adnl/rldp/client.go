@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/xssnick/tonutils-go/adnl"
 	"github.com/xssnick/tonutils-go/adnl/rldp/raptorq"
+	"github.com/xssnick/tonutils-go/observer"
 	"github.com/xssnick/tonutils-go/tl"
 	"log"
 	"reflect"
@@ -37,6 +38,7 @@ type RLDP struct {
 
 	onQuery      func(transferId []byte, query *Query) error
 	onDisconnect func()
+	observer     observer.Observer
 
 	mx sync.RWMutex
 }
@@ -94,6 +96,19 @@ func (r *RLDP) SetOnDisconnect(handler func()) {
 	})
 }
 
+// SetObserver - sets observer of queries and transfers, should be called before usage
+func (r *RLDP) SetObserver(o observer.Observer) {
+	r.mx.Lock()
+	r.observer = o
+	r.mx.Unlock()
+}
+
+func (r *RLDP) getObserver() observer.Observer {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	return r.observer
+}
+
 func (r *RLDP) Close() {
 	r.adnl.Close()
 }
@@ -218,6 +233,7 @@ func (r *RLDP) handleMessage(msg *adnl.MessageCustom) error {
 				if err != nil {
 					return fmt.Errorf("failed to parse custom message: %w", err)
 				}
+				r.notifyTransfer(observer.EventTransferReceived, len(data))
 
 				var complete tl.Serializable = Complete{
 					TransferID: m.TransferID,
@@ -349,6 +365,7 @@ func (r *RLDP) sendMessageParts(ctx context.Context, transferId, data []byte) er
 			return ctx.Err()
 		case <-ch:
 			// we got complete from receiver, finish sending
+			r.notifyTransfer(observer.EventTransferSent, len(data))
 			return nil
 		default:
 		}
@@ -364,6 +381,7 @@ func (r *RLDP) sendMessageParts(ctx context.Context, transferId, data []byte) er
 				return ctx.Err()
 			case <-ch:
 				// we got complete from receiver, finish sending
+				r.notifyTransfer(observer.EventTransferSent, len(data))
 				return nil
 			case <-time.After(time.Duration(x) * _PacketWaitTime):
 				// send additional FEC recovery parts until complete
@@ -397,14 +415,36 @@ func (r *RLDP) sendMessageParts(ctx context.Context, transferId, data []byte) er
 	}
 }
 
-func (r *RLDP) DoQuery(ctx context.Context, maxAnswerSize int64, query, result tl.Serializable) error {
+func (r *RLDP) notifyTransfer(kind observer.EventKind, size int) {
+	o := r.getObserver()
+	if o == nil {
+		return
+	}
+
+	observer.Notify(context.Background(), o, &observer.Event{
+		Component: observer.ComponentRLDP,
+		Kind:      kind,
+		Peer:      r.adnl.RemoteAddr(),
+		Value:     int64(size),
+	})
+}
+
+func (r *RLDP) DoQuery(ctx context.Context, maxAnswerSize int64, query, result tl.Serializable) (err error) {
+	if o := r.getObserver(); o != nil {
+		var tracker *observer.RequestTracker
+		ctx, tracker = observer.StartRequest(ctx, o, observer.ComponentRLDP, tl.TypeName(query), r.adnl.RemoteAddr())
+		defer func() {
+			tracker.Done(err)
+		}()
+	}
+
 	timeout, ok := ctx.Deadline()
 	if !ok {
 		timeout = time.Now().Add(15 * time.Second)
 	}
 
 	qid := make([]byte, 32)
-	_, err := rand.Read(qid)
+	_, err = rand.Read(qid)
 	if err != nil {
 		return err
 	}
@@ -445,8 +485,7 @@ func (r *RLDP) DoQuery(ctx context.Context, maxAnswerSize int64, query, result t
 	}
 
 	go func() {
-		err = r.sendMessageParts(sndCtx, transferId, data)
-		if err != nil {
+		if err := r.sendMessageParts(sndCtx, transferId, data); err != nil {
 			res <- fmt.Errorf("failed to send query parts: %w", err)
 		}
 	}()
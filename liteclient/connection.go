@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/observer"
 	"github.com/xssnick/tonutils-go/tl"
 )
 
@@ -46,6 +47,9 @@ type connection struct {
 	weight       int64
 	lastRespTime int64
 
+	health  nodeHealth
+	limiter requestLimiter
+
 	pool *ConnectionPool
 }
 
@@ -211,6 +215,7 @@ func (c *ConnectionPool) AddConnection(ctx context.Context, addr, serverKey stri
 		go conn.startPings(5 * time.Second)
 
 		c.nodesMx.Lock()
+		conn.limiter.setLimits(c.nodeLimits)
 		c.activeNodes = append(c.activeNodes, conn)
 		c.nodesMx.Unlock()
 
@@ -542,6 +547,14 @@ func (c *ConnectionPool) DefaultReconnect(waitBeforeReconnect time.Duration, max
 			err := c.AddConnection(ctx, addr, key)
 			cancel()
 
+			observer.Notify(context.Background(), c.observer, &observer.Event{
+				Component: observer.ComponentLiteClient,
+				Kind:      observer.EventReconnect,
+				Peer:      addr,
+				Value:     1,
+				Err:       err,
+			})
+
 			if err != nil && (tries < maxTries || maxTries == -1) {
 				tries++
 				time.Sleep(waitBeforeReconnect)
@@ -0,0 +1,390 @@
+package liteclient
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const _StickyCtxStrictKey = "_ton_node_sticky_strict"
+
+// RTTBuckets - upper bounds of the node response time histogram buckets, last bucket is unbounded
+var RTTBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// NodeInfo - state of the node, reported by the health probe
+type NodeInfo struct {
+	MasterSeqno  uint32
+	Version      uint32
+	Capabilities uint64
+	// OldestMasterSeqno - oldest masterchain block available on node, 0 when unknown
+	OldestMasterSeqno uint32
+}
+
+// HealthProbe - requests state of the node, all requests with ctx are routed to the checked node only
+type HealthProbe func(ctx context.Context, pool *ConnectionPool) (*NodeInfo, error)
+
+// NodeHealth - snapshot of the node health metrics
+type NodeHealth struct {
+	ID   uint32
+	Addr string
+
+	// RTT - response time histogram, counters of responses by RTTBuckets
+	RTT      []uint64
+	AvgRTT   time.Duration
+	LastRTT  time.Duration
+	Requests uint64
+	Errors   uint64
+	// Weight - balancer weight, decreased by requests in progress
+	Weight int64
+
+	Info          *NodeInfo
+	InfoUpdatedAt time.Time
+	// Lag - number of masterchain blocks the node is behind the head, reported by most of the nodes
+	Lag       uint32
+	OutOfSync bool
+}
+
+// ErrorRate - part of the failed requests
+func (h NodeHealth) ErrorRate() float64 {
+	if h.Requests == 0 {
+		return 0
+	}
+	return float64(h.Errors) / float64(h.Requests)
+}
+
+// RoutingPolicy - selects node for the request, candidates are never empty.
+// Nodes which are out of sync are passed only when there are no other ones.
+type RoutingPolicy interface {
+	Select(candidates []NodeHealth) int
+}
+
+// WeightedRoutingPolicy - selects node with the biggest weight (less requests in progress),
+// and with the smallest last response time among equal
+type WeightedRoutingPolicy struct{}
+
+// LatencyRoutingPolicy - selects node with the smallest average response time, penalized by error rate
+type LatencyRoutingPolicy struct {
+	// ErrorPenalty - multiplier of the error rate applied to response time, 10 is used when zero
+	ErrorPenalty float64
+}
+
+type nodeHealth struct {
+	rtt      []uint64
+	rttSum   time.Duration
+	lastRTT  time.Duration
+	requests uint64
+	errors   uint64
+
+	info          *NodeInfo
+	infoUpdatedAt time.Time
+	lag           uint32
+	outOfSync     bool
+
+	mx sync.RWMutex
+}
+
+func (p WeightedRoutingPolicy) Select(candidates []NodeHealth) int {
+	best := 0
+	for i := 1; i < len(candidates); i++ {
+		nw, old := candidates[i].Weight, candidates[best].Weight
+		if nw > old || (nw == old && candidates[i].LastRTT < candidates[best].LastRTT) {
+			best = i
+		}
+	}
+	return best
+}
+
+func (p LatencyRoutingPolicy) Select(candidates []NodeHealth) int {
+	penalty := p.ErrorPenalty
+	if penalty == 0 {
+		penalty = 10
+	}
+
+	score := func(h NodeHealth) float64 {
+		rtt := h.AvgRTT
+		if h.Requests == 0 {
+			// give a chance to the new nodes
+			rtt = 0
+		}
+		return float64(rtt) * (1 + penalty*h.ErrorRate())
+	}
+
+	best := 0
+	for i := 1; i < len(candidates); i++ {
+		s, old := score(candidates[i]), score(candidates[best])
+		if s < old || (s == old && candidates[i].Weight > candidates[best].Weight) {
+			best = i
+		}
+	}
+	return best
+}
+
+func (h *nodeHealth) recordResponse(rtt time.Duration) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	if h.rtt == nil {
+		h.rtt = make([]uint64, len(RTTBuckets)+1)
+	}
+
+	i := 0
+	for i < len(RTTBuckets) && rtt > RTTBuckets[i] {
+		i++
+	}
+	h.rtt[i]++
+	h.rttSum += rtt
+	h.lastRTT = rtt
+	h.requests++
+}
+
+func (h *nodeHealth) recordError() {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.requests++
+	h.errors++
+}
+
+func (h *nodeHealth) isOutOfSync() bool {
+	h.mx.RLock()
+	defer h.mx.RUnlock()
+	return h.outOfSync
+}
+
+func (n *connection) healthSnapshot() NodeHealth {
+	n.health.mx.RLock()
+	defer n.health.mx.RUnlock()
+
+	s := NodeHealth{
+		ID:            n.id,
+		Addr:          n.addr,
+		RTT:           make([]uint64, len(RTTBuckets)+1),
+		LastRTT:       n.health.lastRTT,
+		Requests:      n.health.requests,
+		Errors:        n.health.errors,
+		Weight:        atomic.LoadInt64(&n.weight),
+		InfoUpdatedAt: n.health.infoUpdatedAt,
+		Lag:           n.health.lag,
+		OutOfSync:     n.health.outOfSync,
+	}
+	copy(s.RTT, n.health.rtt)
+
+	if responses := n.health.requests - n.health.errors; responses > 0 {
+		s.AvgRTT = n.health.rttSum / time.Duration(responses)
+	}
+	if n.health.info != nil {
+		info := *n.health.info
+		s.Info = &info
+	}
+	return s
+}
+
+// SetRoutingPolicy - sets policy to select nodes for not sticky requests and for balanced switch,
+// WeightedRoutingPolicy is used by default
+func (c *ConnectionPool) SetRoutingPolicy(policy RoutingPolicy) {
+	c.nodesMx.Lock()
+	c.policy = policy
+	c.nodesMx.Unlock()
+}
+
+// NodesHealth - returns health metrics of the active nodes
+func (c *ConnectionPool) NodesHealth() []NodeHealth {
+	c.nodesMx.RLock()
+	defer c.nodesMx.RUnlock()
+
+	list := make([]NodeHealth, 0, len(c.activeNodes))
+	for _, node := range c.activeNodes {
+		list = append(list, node.healthSnapshot())
+	}
+	return list
+}
+
+// StartHealthChecks - runs probe for every active node periodically, till pool is stopped.
+// Probe of each node is limited by timeout, every is used when it is not positive.
+// Head is the median of masterchain seqnos reported by nodes, so a single node cannot shift it.
+// Nodes which are behind the head more than maxLag, or failed the probe, are marked as out of sync
+// and are not used for routing while there are other nodes. Nodes which are ahead of the head more than maxLag
+// report unconfirmed blocks and are marked as out of sync too. At least 3 nodes are needed to detect such node.
+// Calling it again replaces the running checks, so only one checks loop exists per pool.
+func (c *ConnectionPool) StartHealthChecks(probe HealthProbe, every, timeout time.Duration, maxLag uint32) {
+	if timeout <= 0 {
+		timeout = every
+	}
+
+	ctx, cancel := context.WithCancel(c.globalCtx)
+
+	c.nodesMx.Lock()
+	if c.stopHealthChecks != nil {
+		c.stopHealthChecks()
+	}
+	c.stopHealthChecks = cancel
+	c.nodesMx.Unlock()
+
+	go func() {
+		for {
+			c.checkHealth(ctx, probe, timeout, maxLag)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(every):
+			}
+		}
+	}()
+}
+
+func (c *ConnectionPool) checkHealth(ctx context.Context, probe HealthProbe, timeout time.Duration, maxLag uint32) {
+	c.nodesMx.RLock()
+	nodes := append([]*connection{}, c.activeNodes...)
+	c.nodesMx.RUnlock()
+
+	type result struct {
+		node *connection
+		info *NodeInfo
+	}
+
+	results := make([]result, len(nodes))
+
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *connection) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			info, err := probe(c.StickyContextWithNodeIDStrict(ctx, node.id), c)
+			if err != nil {
+				info = nil
+			}
+			results[i] = result{node: node, info: info}
+		}(i, node)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		// checks were stopped or replaced, probe failures are not related to nodes
+		return
+	}
+
+	var seqnos []uint32
+	for _, r := range results {
+		if r.info != nil {
+			seqnos = append(seqnos, r.info.MasterSeqno)
+		}
+	}
+	head := medianSeqno(seqnos)
+
+	now := time.Now()
+	for _, r := range results {
+		h := &r.node.health
+		h.mx.Lock()
+		if r.info == nil {
+			h.outOfSync = true
+		} else {
+			h.info = r.info
+			h.infoUpdatedAt = now
+			h.lag = 0
+			if head > r.info.MasterSeqno {
+				h.lag = head - r.info.MasterSeqno
+			}
+			// node far ahead of others is suspect, its blocks are not confirmed by them
+			ahead := r.info.MasterSeqno > head && r.info.MasterSeqno-head > maxLag
+			h.outOfSync = h.lag > maxLag || ahead
+		}
+		h.mx.Unlock()
+	}
+}
+
+// medianSeqno - returns upper median of reported seqnos, so one node with wrong seqno cannot move it
+func medianSeqno(seqnos []uint32) uint32 {
+	if len(seqnos) == 0 {
+		return 0
+	}
+	sort.Slice(seqnos, func(i, j int) bool { return seqnos[i] < seqnos[j] })
+	return seqnos[len(seqnos)/2]
+}
+
+// selectNode - selects node using routing policy, skipping used ones, nodesMx should be locked by caller.
+// Nodes which have reached their request limits are used only when all other nodes are limited too.
+func (c *ConnectionPool) selectNode(usedNodes []uint32, priority RequestPriority) *connection {
+	var all, inSync, ready []*connection
+
+iter:
+	for _, node := range c.activeNodes {
+		for _, usedNode := range usedNodes {
+			if usedNode == node.id {
+				continue iter
+			}
+		}
+
+		all = append(all, node)
+		if !node.health.isOutOfSync() {
+			inSync = append(inSync, node)
+			if node.limiter.ready(priority) {
+				ready = append(ready, node)
+			}
+		}
+	}
+
+	candidates := ready
+	if len(candidates) == 0 {
+		candidates = inSync
+	}
+	if len(candidates) == 0 {
+		// demote only while there is something better
+		candidates = all
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	policy := c.policy
+	if policy == nil {
+		policy = WeightedRoutingPolicy{}
+	}
+
+	stats := make([]NodeHealth, len(candidates))
+	for i, node := range candidates {
+		stats[i] = node.healthSnapshot()
+	}
+
+	idx := policy.Select(stats)
+	if idx < 0 || idx >= len(candidates) {
+		return nil
+	}
+	return candidates[idx]
+}
+
+// randomNode - picks random node, preferring ones which are in sync, nodesMx should be locked by caller
+func (c *ConnectionPool) randomNode() *connection {
+	var inSync []*connection
+	for _, node := range c.activeNodes {
+		if !node.health.isOutOfSync() {
+			inSync = append(inSync, node)
+		}
+	}
+
+	list := inSync
+	if len(list) == 0 {
+		list = c.activeNodes
+	}
+
+	if len(list) == 0 {
+		return nil
+	}
+	return list[rand.Uint32()%uint32(len(list))]
+}
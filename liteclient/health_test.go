@@ -0,0 +1,179 @@
+package liteclient
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConnectionPool_HealthChecks(t *testing.T) {
+	pool := NewConnectionPool()
+	defer func() {
+		// synthetic nodes have no tcp connections to close
+		pool.activeNodes = nil
+		pool.Stop()
+	}()
+
+	a := &connection{id: 1, addr: "a", weight: 1000}
+	b := &connection{id: 2, addr: "b", weight: 1000}
+	c := &connection{id: 3, addr: "c", weight: 1000}
+	pool.activeNodes = []*connection{a, b, c}
+
+	seqnos := map[uint32]uint32{1: 100, 2: 70, 3: 99}
+	probe := func(ctx context.Context, p *ConnectionPool) (*NodeInfo, error) {
+		id := p.StickyNodeID(ctx)
+		if strict, _ := ctx.Value(_StickyCtxStrictKey).(bool); !strict {
+			t.Error("probe context should be strict")
+		}
+		if id == 3 {
+			return nil, errors.New("fail")
+		}
+		return &NodeInfo{MasterSeqno: seqnos[id], Version: 0x200}, nil
+	}
+
+	pool.checkHealth(context.Background(), probe, time.Second, 5)
+
+	health := pool.NodesHealth()
+	if len(health) != 3 {
+		t.Fatal("incorrect nodes num")
+	}
+	if health[0].OutOfSync || health[0].Lag != 0 || health[0].Info.Version != 0x200 {
+		t.Fatal("node a should be in sync")
+	}
+	if !health[1].OutOfSync || health[1].Lag != 30 {
+		t.Fatal("node b should be out of sync")
+	}
+	if !health[2].OutOfSync {
+		t.Fatal("failed node should be out of sync")
+	}
+
+	for i := 0; i < 10; i++ {
+		ctx := pool.StickyContext(context.Background())
+		if pool.StickyNodeID(ctx) != 1 {
+			t.Fatal("sticky context should prefer node in sync")
+		}
+	}
+
+	ctx, err := pool.StickyContextNextNodeBalanced(pool.StickyContextWithNodeID(context.Background(), 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := pool.StickyNodeID(ctx); id != 2 && id != 3 {
+		t.Fatal("lagging nodes should be used when nothing else left")
+	}
+
+	seqnos[2] = 100
+	pool.checkHealth(context.Background(), probe, time.Second, 5)
+	if node := pool.selectNode([]uint32{1}, PriorityNormal); node != b {
+		t.Fatal("node b should be back in sync")
+	}
+}
+
+func TestConnectionPool_StartHealthChecks(t *testing.T) {
+	pool := NewConnectionPool()
+	defer func() {
+		// synthetic nodes have no tcp connections to close
+		pool.nodesMx.Lock()
+		pool.stopHealthChecks()
+		pool.activeNodes = nil
+		pool.nodesMx.Unlock()
+		pool.Stop()
+	}()
+
+	pool.activeNodes = []*connection{{id: 1, addr: "a", weight: 1000}}
+
+	var first, second int32
+	pool.StartHealthChecks(func(ctx context.Context, p *ConnectionPool) (*NodeInfo, error) {
+		atomic.AddInt32(&first, 1)
+		return &NodeInfo{MasterSeqno: 1}, nil
+	}, 10*time.Millisecond, time.Second, 5)
+	time.Sleep(50 * time.Millisecond)
+
+	// probe hangs, it should be limited by timeout, not by interval
+	pool.StartHealthChecks(func(ctx context.Context, p *ConnectionPool) (*NodeInfo, error) {
+		atomic.AddInt32(&second, 1)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, time.Hour, 20*time.Millisecond, 5)
+	time.Sleep(20 * time.Millisecond)
+
+	stopped := atomic.LoadInt32(&first)
+	time.Sleep(100 * time.Millisecond)
+	if stopped == 0 || atomic.LoadInt32(&first) != stopped {
+		t.Fatal("previous checks should be replaced")
+	}
+	if atomic.LoadInt32(&second) != 1 {
+		t.Fatal("new checks should be started once")
+	}
+	if !pool.NodesHealth()[0].OutOfSync {
+		t.Fatal("node should be out of sync after probe timeout")
+	}
+}
+
+func TestConnectionPool_checkHealth(t *testing.T) {
+	pool := NewConnectionPool()
+	defer func() {
+		pool.nodesMx.Lock()
+		pool.activeNodes = nil
+		pool.nodesMx.Unlock()
+		pool.Stop()
+	}()
+
+	pool.activeNodes = []*connection{{id: 1}, {id: 2}, {id: 3}, {id: 4}, {id: 5}}
+
+	// node 4 reports seqno which nobody else has, node 3 is lagging a bit, node 5 fails
+	seqnos := map[uint32]uint32{1: 100, 2: 100, 3: 98, 4: 1000000}
+	pool.checkHealth(context.Background(), func(ctx context.Context, p *ConnectionPool) (*NodeInfo, error) {
+		seqno, ok := seqnos[p.StickyNodeID(ctx)]
+		if !ok {
+			return nil, errors.New("node failed")
+		}
+		return &NodeInfo{MasterSeqno: seqno}, nil
+	}, time.Second, 5)
+
+	health := pool.NodesHealth()
+	for _, h := range health[:3] {
+		if h.OutOfSync {
+			t.Fatal("honest node should be in sync", h.ID, h.Lag)
+		}
+	}
+	if health[2].Lag != 2 {
+		t.Fatal("incorrect lag", health[2].Lag)
+	}
+	if !health[3].OutOfSync || health[3].Lag != 0 {
+		t.Fatal("node far ahead of others should be suspect")
+	}
+	if !health[4].OutOfSync {
+		t.Fatal("failed node should be out of sync")
+	}
+
+	if medianSeqno(nil) != 0 || medianSeqno([]uint32{7}) != 7 || medianSeqno([]uint32{9, 1, 5}) != 5 {
+		t.Fatal("incorrect median")
+	}
+}
+
+func TestRoutingPolicies(t *testing.T) {
+	list := []NodeHealth{
+		{ID: 1, Weight: 1000, AvgRTT: 300 * time.Millisecond, Requests: 10, LastRTT: 300 * time.Millisecond},
+		{ID: 2, Weight: 999, AvgRTT: 50 * time.Millisecond, Requests: 10, LastRTT: 50 * time.Millisecond},
+		{ID: 3, Weight: 1000, AvgRTT: 40 * time.Millisecond, Requests: 10, Errors: 5, LastRTT: 40 * time.Millisecond},
+	}
+
+	if idx := (WeightedRoutingPolicy{}).Select(list); idx != 2 {
+		t.Fatal("weighted policy should select node 3, got", idx)
+	}
+
+	if idx := (LatencyRoutingPolicy{}).Select(list); idx != 1 {
+		t.Fatal("latency policy should select node 2, got", idx)
+	}
+
+	h := &nodeHealth{}
+	h.recordResponse(70 * time.Millisecond)
+	h.recordResponse(10 * time.Second)
+	h.recordError()
+	if h.rtt[1] != 1 || h.rtt[len(RTTBuckets)] != 1 || h.requests != 3 || h.errors != 1 {
+		t.Fatal("incorrect histogram")
+	}
+}
@@ -0,0 +1,235 @@
+package liteclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const _PriorityCtxKey = "_ton_request_priority"
+
+// RequestPriority - priority class of the request, when limits are reached,
+// requests with higher priority are executed first, lower ones are waiting
+type RequestPriority int
+
+const (
+	PriorityBackground RequestPriority = iota // historical scans, backfills
+	PriorityNormal                            // default for requests without priority
+	PriorityHigh                              // messages sending and other urgent requests
+
+	prioritiesNum = 3
+)
+
+// RequestLimits - limits of requests, zero values mean no limit
+type RequestLimits struct {
+	// RequestsPerSecond - rate of token bucket refill
+	RequestsPerSecond float64
+	// Burst - size of token bucket, 1 is used when zero and rate is set
+	Burst int
+	// MaxInFlight - max number of requests waiting for response at the same time
+	MaxInFlight int
+}
+
+type requestLimiter struct {
+	limits RequestLimits
+
+	tokens     float64
+	refilledAt time.Time
+	inFlight   int
+	waiting    [prioritiesNum]int
+	// notify - closed and replaced when slot is released or limits are changed
+	notify chan struct{}
+
+	mx sync.Mutex
+}
+
+// WithRequestPriority - sets priority of all requests made with this context,
+// it is kept together with StickyContext and other pool context values
+func WithRequestPriority(ctx context.Context, priority RequestPriority) context.Context {
+	return context.WithValue(ctx, _PriorityCtxKey, priority)
+}
+
+// WithDefaultRequestPriority - sets priority only when it was not set before
+func WithDefaultRequestPriority(ctx context.Context, priority RequestPriority) context.Context {
+	if _, ok := ctx.Value(_PriorityCtxKey).(RequestPriority); ok {
+		return ctx
+	}
+	return WithRequestPriority(ctx, priority)
+}
+
+// GetRequestPriority - returns priority of the context, PriorityNormal when not set
+func GetRequestPriority(ctx context.Context) RequestPriority {
+	p, ok := ctx.Value(_PriorityCtxKey).(RequestPriority)
+	if !ok {
+		return PriorityNormal
+	}
+	return p
+}
+
+func (p RequestPriority) index() int {
+	if p < PriorityBackground {
+		return int(PriorityBackground)
+	}
+	if p > PriorityHigh {
+		return int(PriorityHigh)
+	}
+	return int(p)
+}
+
+// SetNodeLimits - sets limits applied to each connection separately, including connections added later.
+// Sticky requests respect limits of their node and are waiting for it instead of switching to another one.
+func (c *ConnectionPool) SetNodeLimits(limits RequestLimits) {
+	c.nodesMx.Lock()
+	defer c.nodesMx.Unlock()
+
+	c.nodeLimits = limits
+	for _, node := range c.activeNodes {
+		node.limiter.setLimits(limits)
+	}
+}
+
+// SetGlobalLimits - sets limits for all requests of the pool together
+func (c *ConnectionPool) SetGlobalLimits(limits RequestLimits) {
+	c.limiter.setLimits(limits)
+}
+
+func (l *requestLimiter) setLimits(limits RequestLimits) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.limits = limits
+	l.tokens = float64(l.burst())
+	l.refilledAt = time.Now()
+	l.wakeup()
+}
+
+func (l *requestLimiter) burst() int {
+	if l.limits.Burst <= 0 {
+		return 1
+	}
+	return l.limits.Burst
+}
+
+// refill - adds tokens for the passed time, mx should be locked by caller
+func (l *requestLimiter) refill(now time.Time) {
+	if l.limits.RequestsPerSecond <= 0 {
+		return
+	}
+
+	l.tokens += now.Sub(l.refilledAt).Seconds() * l.limits.RequestsPerSecond
+	if max := float64(l.burst()); l.tokens > max {
+		l.tokens = max
+	}
+	l.refilledAt = now
+}
+
+// delay - returns time to wait for the free slot, 0 when request can be executed now,
+// and -1 when it should wait for released slot. mx should be locked by caller
+func (l *requestLimiter) delay(priority int) time.Duration {
+	for p := priority + 1; p < prioritiesNum; p++ {
+		if l.waiting[p] > 0 {
+			// more important requests first
+			return -1
+		}
+	}
+
+	if l.limits.MaxInFlight > 0 && l.inFlight >= l.limits.MaxInFlight {
+		return -1
+	}
+
+	if l.limits.RequestsPerSecond > 0 && l.tokens < 1 {
+		return time.Duration((1 - l.tokens) / l.limits.RequestsPerSecond * float64(time.Second))
+	}
+	return 0
+}
+
+// ready - checks that request with the given priority can be executed without waiting
+func (l *requestLimiter) ready(priority RequestPriority) bool {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.refill(time.Now())
+	return l.delay(priority.index()) == 0
+}
+
+// acquire - waits for the free slot, release should be called after request is done
+func (l *requestLimiter) acquire(ctx context.Context, priority RequestPriority) error {
+	p := priority.index()
+	queued := false
+
+	l.mx.Lock()
+	defer func() {
+		if queued {
+			l.waiting[p]--
+			// lower priority requests could wait for us
+			l.wakeup()
+		}
+		l.mx.Unlock()
+	}()
+
+	for {
+		l.refill(time.Now())
+
+		wait := l.delay(p)
+		if wait == 0 {
+			if l.limits.RequestsPerSecond > 0 {
+				l.tokens--
+			}
+			l.inFlight++
+			return nil
+		}
+
+		if !queued {
+			queued = true
+			l.waiting[p]++
+		}
+
+		if l.notify == nil {
+			l.notify = make(chan struct{})
+		}
+		notify := l.notify
+		l.mx.Unlock()
+
+		var tm *time.Timer
+		var timer <-chan time.Time
+		if wait > 0 {
+			tm = time.NewTimer(wait)
+			timer = tm.C
+		}
+
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-notify:
+		case <-timer:
+		}
+
+		if tm != nil {
+			tm.Stop()
+		}
+
+		l.mx.Lock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (l *requestLimiter) release() {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.inFlight > 0 {
+		l.inFlight--
+	}
+	l.wakeup()
+}
+
+// wakeup - notifies waiting requests, mx should be locked by caller
+func (l *requestLimiter) wakeup() {
+	if l.notify != nil {
+		close(l.notify)
+		l.notify = nil
+	}
+}
@@ -0,0 +1,176 @@
+package liteclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+)
+
+func TestRequestLimiter_Priorities(t *testing.T) {
+	l := &requestLimiter{}
+	l.setLimits(RequestLimits{MaxInFlight: 1})
+
+	if err := l.acquire(context.Background(), PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(ctx, PriorityHigh); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("in flight limit should be respected, got", err)
+	}
+
+	order := make(chan RequestPriority, 2)
+	for _, p := range []RequestPriority{PriorityBackground, PriorityHigh} {
+		go func(p RequestPriority) {
+			if err := l.acquire(context.Background(), p); err != nil {
+				t.Error(err)
+				return
+			}
+			order <- p
+			l.release()
+		}(p)
+		// make sure background request is waiting first
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	l.release()
+	if first := <-order; first != PriorityHigh {
+		t.Fatal("high priority request should be executed first")
+	}
+	if second := <-order; second != PriorityBackground {
+		t.Fatal("background request should be executed after")
+	}
+}
+
+func TestRequestLimiter_Rate(t *testing.T) {
+	l := &requestLimiter{}
+	l.setLimits(RequestLimits{RequestsPerSecond: 20, Burst: 2})
+
+	tm := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := l.acquire(context.Background(), PriorityNormal); err != nil {
+			t.Fatal(err)
+		}
+		l.release()
+	}
+
+	// 2 from burst and 2 more with 50ms interval
+	if took := time.Since(tm); took < 90*time.Millisecond || took > 500*time.Millisecond {
+		t.Fatal("incorrect rate limit, took", took)
+	}
+
+	if l.ready(PriorityNormal) {
+		t.Fatal("should be no tokens")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if !l.ready(PriorityNormal) {
+		t.Fatal("token should be refilled")
+	}
+}
+
+func TestWithDefaultRequestPriority(t *testing.T) {
+	ctx := WithDefaultRequestPriority(context.Background(), PriorityHigh)
+	if GetRequestPriority(ctx) != PriorityHigh {
+		t.Fatal("default priority should be set")
+	}
+
+	ctx = WithDefaultRequestPriority(WithRequestPriority(context.Background(), PriorityBackground), PriorityHigh)
+	if GetRequestPriority(ctx) != PriorityBackground {
+		t.Fatal("explicit priority should be kept")
+	}
+
+	if GetRequestPriority(context.Background()) != PriorityNormal {
+		t.Fatal("normal priority should be used by default")
+	}
+}
+
+// testLimitedNode - creates node which accepts requests but never answers, returns counter of sent bytes
+func testLimitedNode(t *testing.T, id uint32, addr string) (*connection, *int64) {
+	local, remote := net.Pipe()
+	t.Cleanup(func() {
+		_ = local.Close()
+		_ = remote.Close()
+	})
+
+	var sent int64
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := remote.Read(buf)
+			atomic.AddInt64(&sent, int64(n))
+			if err != nil {
+				if err != io.EOF && err != io.ErrClosedPipe {
+					t.Error(err)
+				}
+				return
+			}
+		}
+	}()
+
+	crypt, err := adnl.NewCipherCtr(make([]byte, 32), make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := &connection{id: id, addr: addr, weight: 1000, tcp: local, wCrypt: crypt}
+	node.limiter.setLimits(RequestLimits{MaxInFlight: 1})
+	return node, &sent
+}
+
+func TestConnectionPool_QueryLimits(t *testing.T) {
+	pool := NewConnectionPool()
+	defer func() {
+		// synthetic nodes are closed by test cleanup
+		pool.activeNodes = nil
+		pool.Stop()
+	}()
+	pool.SetGlobalLimits(RequestLimits{MaxInFlight: 2})
+
+	a, _ := testLimitedNode(t, 1, "a")
+	b, sentB := testLimitedNode(t, 2, "b")
+	pool.activeNodes = []*connection{a, b}
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(2)
+	// occupies the only slot of node a
+	go func() {
+		defer wg.Done()
+		var res TCPPong
+		_ = pool.QueryADNL(pool.StickyContextWithNodeIDStrict(ctx, 1), TCPPing{}, &res)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// waits for node a, it should not take the second global slot while waiting
+	go func() {
+		defer wg.Done()
+		var res TCPPong
+		_ = pool.QueryADNL(pool.StickyContextWithNodeIDStrict(WithRequestPriority(ctx, PriorityBackground), 1), TCPPing{}, &res)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	highCtx, highCancel := context.WithTimeout(WithRequestPriority(ctx, PriorityHigh), 200*time.Millisecond)
+	defer highCancel()
+
+	var res TCPPong
+	err := pool.QueryADNL(pool.StickyContextWithNodeIDStrict(highCtx, 2), TCPPing{}, &res)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("request should wait for response, got", err)
+	}
+	if atomic.LoadInt64(sentB) == 0 {
+		t.Fatal("high priority request should be sent to free node")
+	}
+}
@@ -7,17 +7,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	mRand "math/rand"
 	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/xssnick/tonutils-go/observer"
 	"github.com/xssnick/tonutils-go/tl"
 )
 
 const _StickyCtxKey = "_ton_node_sticky"
 const _StickyCtxUsedNodesKey = "_ton_used_nodes_sticky"
+const _RespondedNodeCtxKey = "_ton_node_responded"
 
 var (
 	ErrNoActiveConnections = errors.New("no active connections")
@@ -45,9 +46,17 @@ type ConnectionPool struct {
 
 	onDisconnect     func(addr, key string)
 	roundRobinOffset uint64
+	policy           RoutingPolicy
+
+	limiter    requestLimiter
+	nodeLimits RequestLimits
+
+	observer observer.Observer
 
 	authKey ed25519.PrivateKey
 
+	stopHealthChecks func()
+
 	globalCtx context.Context
 	stop      func()
 }
@@ -89,9 +98,9 @@ func (c *ConnectionPool) StickyContext(ctx context.Context) context.Context {
 	var id uint32
 
 	c.nodesMx.RLock()
-	if len(c.activeNodes) > 0 {
-		// pick random one
-		id = c.activeNodes[mRand.Uint32()%uint32(len(c.activeNodes))].id
+	// pick random one
+	if node := c.randomNode(); node != nil {
+		id = node.id
 	}
 	c.nodesMx.RUnlock()
 
@@ -109,6 +118,8 @@ func (c *ConnectionPool) StickyContextNextNode(ctx context.Context) (context.Con
 	c.nodesMx.RLock()
 	defer c.nodesMx.RUnlock()
 
+	var outOfSync *connection
+
 iter:
 	for _, node := range c.activeNodes {
 		for _, usedNode := range usedNodes {
@@ -117,9 +128,21 @@ iter:
 			}
 		}
 
+		if node.health.isOutOfSync() {
+			if outOfSync == nil {
+				outOfSync = node
+			}
+			continue
+		}
+
 		return context.WithValue(context.WithValue(ctx, _StickyCtxKey, node.id), _StickyCtxUsedNodesKey, usedNodes), nil
 	}
 
+	if outOfSync != nil {
+		// use lagging node only when there is nothing else
+		return context.WithValue(context.WithValue(ctx, _StickyCtxKey, outOfSync.id), _StickyCtxUsedNodesKey, usedNodes), nil
+	}
+
 	return ctx, ErrNoNodesLeft
 }
 
@@ -134,28 +157,7 @@ func (c *ConnectionPool) StickyContextNextNodeBalanced(ctx context.Context) (con
 	c.nodesMx.RLock()
 	defer c.nodesMx.RUnlock()
 
-	var reqNode *connection
-
-iter:
-	for _, node := range c.activeNodes {
-		for _, usedNode := range usedNodes {
-			if usedNode == node.id {
-				continue iter
-			}
-		}
-
-		if reqNode == nil {
-			reqNode = node
-			continue
-		}
-
-		// select best node on this moment
-		nw, old := atomic.LoadInt64(&node.weight), atomic.LoadInt64(&reqNode.weight)
-		if nw > old || (nw == old && atomic.LoadInt64(&node.lastRespTime) < atomic.LoadInt64(&reqNode.lastRespTime)) {
-			reqNode = node
-		}
-	}
-
+	reqNode := c.selectNode(usedNodes, GetRequestPriority(ctx))
 	if reqNode != nil {
 		return context.WithValue(context.WithValue(ctx, _StickyCtxKey, reqNode.id), _StickyCtxUsedNodesKey, usedNodes), nil
 	}
@@ -167,11 +169,32 @@ func (c *ConnectionPool) StickyContextWithNodeID(ctx context.Context, nodeId uin
 	return context.WithValue(ctx, _StickyCtxKey, nodeId)
 }
 
+// StickyContextWithNodeIDStrict - binds requests to the node, unlike StickyContextWithNodeID they are never
+// routed to another node, ErrNoActiveConnections is returned when the node is not connected
+func (c *ConnectionPool) StickyContextWithNodeIDStrict(ctx context.Context, nodeId uint32) context.Context {
+	return context.WithValue(c.StickyContextWithNodeID(ctx, nodeId), _StickyCtxStrictKey, true)
+}
+
 func (c *ConnectionPool) StickyNodeID(ctx context.Context) uint32 {
 	nodeID, _ := ctx.Value(_StickyCtxKey).(uint32)
 	return nodeID
 }
 
+// WithRespondedNode - returns context which records to id the node that answered the request,
+// it may differ from the sticky node when request was routed to another node.
+// 0 is left when request was not answered, or client does not report nodes.
+func WithRespondedNode(ctx context.Context, id *uint32) context.Context {
+	return context.WithValue(ctx, _RespondedNodeCtxKey, id)
+}
+
+// ReportRespondedNode - records the node that answered the request to context created by WithRespondedNode,
+// it should be called by LiteClient implementations
+func ReportRespondedNode(ctx context.Context, nodeID uint32) {
+	if id, ok := ctx.Value(_RespondedNodeCtxKey).(*uint32); ok {
+		atomic.StoreUint32(id, nodeID)
+	}
+}
+
 func (c *ConnectionPool) Stop() {
 	c.stop()
 
@@ -189,9 +212,18 @@ func (c *ConnectionPool) QueryLiteserver(ctx context.Context, request tl.Seriali
 }
 
 // QueryADNL - sends ADNL request to peer
-func (c *ConnectionPool) QueryADNL(ctx context.Context, request tl.Serializable, result tl.Serializable) error {
+func (c *ConnectionPool) QueryADNL(ctx context.Context, request tl.Serializable, result tl.Serializable) (err error) {
+	var node *connection
+	ctx, tracker := observer.StartRequest(ctx, c.observer, observer.ComponentLiteClient, queryName(request), "")
+	defer func() {
+		if node != nil {
+			tracker.SetPeer(node.addr)
+		}
+		tracker.Done(err)
+	}()
+
 	id := make([]byte, 32)
-	_, err := io.ReadFull(rand.Reader, id)
+	_, err = io.ReadFull(rand.Reader, id)
 	if err != nil {
 		return err
 	}
@@ -224,26 +256,32 @@ func (c *ConnectionPool) QueryADNL(ctx context.Context, request tl.Serializable,
 		c.reqMx.Unlock()
 	}()
 
-	tm := time.Now()
-
-	var node *connection
 	if nodeID, ok := ctx.Value(_StickyCtxKey).(uint32); ok && nodeID > 0 {
-		node, err = c.querySticky(nodeID, req)
+		strict, _ := ctx.Value(_StickyCtxStrictKey).(bool)
+		node, err = c.querySticky(ctx, nodeID, req, strict)
 		if err != nil {
 			return err
 		}
 	} else {
-		node, err = c.queryWithSmartBalancer(req)
+		node, err = c.queryWithSmartBalancer(ctx, req)
 		if err != nil {
 			return err
 		}
 	}
+	defer node.limiter.release()
+	defer c.limiter.release()
+
+	// node limit waiting time is not counted as response time
+	tm := time.Now()
 
 	// wait for response
 	select {
 	case resp := <-ch:
+		rtt := time.Since(tm)
 		atomic.AddInt64(&node.weight, 1)
-		atomic.StoreInt64(&node.lastRespTime, int64(time.Since(tm)))
+		atomic.StoreInt64(&node.lastRespTime, int64(rtt))
+		node.health.recordResponse(rtt)
+		ReportRespondedNode(ctx, node.id)
 
 		reflect.ValueOf(result).Elem().Set(reflect.ValueOf(resp.Data))
 		return nil
@@ -251,6 +289,8 @@ func (c *ConnectionPool) QueryADNL(ctx context.Context, request tl.Serializable,
 		if time.Since(tm) < 200*time.Millisecond {
 			// consider it as too short timeout to punish node
 			atomic.AddInt64(&node.weight, 1)
+		} else {
+			node.health.recordError()
 		}
 
 		if !hasDeadline {
@@ -261,55 +301,90 @@ func (c *ConnectionPool) QueryADNL(ctx context.Context, request tl.Serializable,
 	}
 }
 
-func (c *ConnectionPool) querySticky(id uint32, req *ADNLRequest) (*connection, error) {
+func (c *ConnectionPool) querySticky(ctx context.Context, id uint32, req *ADNLRequest, strict bool) (*connection, error) {
+	var stickyNode *connection
 	c.nodesMx.RLock()
 	for _, node := range c.activeNodes {
 		if node.id == id {
-			atomic.AddInt64(&node.weight, -1)
-			_, err := node.queryAdnl(req.QueryID, req.Data)
-			if err == nil {
-				c.nodesMx.RUnlock()
-				return node, nil
-			}
+			stickyNode = node
 			break
 		}
 	}
 	c.nodesMx.RUnlock()
 
-	// fallback if bounded node is not available
-	return c.queryWithSmartBalancer(req)
-}
-
-func (c *ConnectionPool) queryWithSmartBalancer(req *ADNLRequest) (*connection, error) {
-	var reqNode *connection
-
-	c.nodesMx.RLock()
-	for _, node := range c.activeNodes {
-		if reqNode == nil {
-			reqNode = node
-			continue
+	if stickyNode != nil {
+		err := c.queryNode(ctx, stickyNode, req)
+		if err == nil {
+			return stickyNode, nil
 		}
 
-		nw, old := atomic.LoadInt64(&node.weight), atomic.LoadInt64(&reqNode.weight)
-		if nw > old || (nw == old && atomic.LoadInt64(&node.lastRespTime) < atomic.LoadInt64(&reqNode.lastRespTime)) {
-			reqNode = node
+		if strict || ctx.Err() != nil {
+			return nil, err
 		}
 	}
+
+	if strict {
+		return nil, ErrNoActiveConnections
+	}
+
+	// fallback if bounded node is not available
+	return c.queryWithSmartBalancer(ctx, req)
+}
+
+func (c *ConnectionPool) queryWithSmartBalancer(ctx context.Context, req *ADNLRequest) (*connection, error) {
+	c.nodesMx.RLock()
+	reqNode := c.selectNode(nil, GetRequestPriority(ctx))
 	c.nodesMx.RUnlock()
 
 	if reqNode == nil {
 		return nil, ErrNoActiveConnections
 	}
 
-	atomic.AddInt64(&reqNode.weight, -1)
-
-	_, err := reqNode.queryAdnl(req.QueryID, req.Data)
-	if err != nil {
+	if err := c.queryNode(ctx, reqNode, req); err != nil {
 		return nil, err
 	}
 	return reqNode, nil
 }
 
+// queryNode - waits for the node and global limits and sends request, both limiter slots should be released by caller on success.
+// Global slot is taken only after the node one, to not hold it while waiting for the limited node,
+// otherwise requests stuck on one node would block more important requests to other nodes.
+func (c *ConnectionPool) queryNode(ctx context.Context, node *connection, req *ADNLRequest) error {
+	priority := GetRequestPriority(ctx)
+	if err := node.limiter.acquire(ctx, priority); err != nil {
+		return fmt.Errorf("failed to wait for node %s request limit: %w", node.addr, err)
+	}
+
+	if err := c.limiter.acquire(ctx, priority); err != nil {
+		node.limiter.release()
+		return fmt.Errorf("failed to wait for request limit: %w", err)
+	}
+
+	atomic.AddInt64(&node.weight, -1)
+
+	_, err := node.queryAdnl(req.QueryID, req.Data)
+	if err != nil {
+		c.limiter.release()
+		node.limiter.release()
+		node.health.recordError()
+		return err
+	}
+	return nil
+}
+
+// SetObserver - sets observer of requests and reconnects, should be called before usage
+func (c *ConnectionPool) SetObserver(o observer.Observer) {
+	c.observer = o
+}
+
+// queryName - returns TL name of the request, liteserver queries are named by the wrapped request
+func queryName(request tl.Serializable) string {
+	if q, ok := request.(LiteServerQuery); ok {
+		return tl.TypeName(q.Data)
+	}
+	return tl.TypeName(request)
+}
+
 func (c *ConnectionPool) SetOnDisconnect(cb OnDisconnectCallback) {
 	c.reqMx.Lock()
 	c.onDisconnect = cb
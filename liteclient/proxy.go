@@ -0,0 +1,297 @@
+package liteclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+var ErrProxyUnauthorized = errors.New("client is not authorized")
+
+const (
+	// ProxyErrorCodeLimit - liteserver error code returned by proxy when client request limits are exceeded
+	ProxyErrorCodeLimit = -429
+	// ProxyErrorCodeUpstream - liteserver error code returned by proxy when upstream query failed,
+	// it is not an answer of liteserver, so query can be retried with another node
+	ProxyErrorCodeUpstream = -503
+)
+
+// liteServer.error is defined in ton package, proxy builds it manually to report upstream failures
+var _LSErrorID = tl.CRC("liteServer.error code:int message:string = liteServer.Error")
+
+// ProxyUpstream - client which is used by Proxy to forward queries, ConnectionPool implements it.
+// Immutable responses can be cached by wrapping the pool with ton package:
+//
+//	upstream := ton.NewAPIClient(pool).WithCache(ton.NewLRUCache(64 << 20)).Client()
+//	proxy := liteclient.NewProxy([]ed25519.PrivateKey{key}, upstream)
+type ProxyUpstream interface {
+	QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error
+}
+
+// ProxyClient - client which is allowed to use the proxy after TCP authorization with its key
+type ProxyClient struct {
+	Name string
+	Key  ed25519.PublicKey
+	// Limits - quota of the client, shared between all its connections
+	Limits RequestLimits
+	// Priority - priority of the client requests in the upstream pool
+	Priority RequestPriority
+}
+
+// Proxy - liteserver which terminates client connections and forwards liteServer.query
+// to the upstream, so many services can share a few upstream connections
+type Proxy struct {
+	server   *Server
+	upstream ProxyUpstream
+
+	queryTimeout    time.Duration
+	allowAnonymous  bool
+	anonymousLimits RequestLimits
+
+	clients  map[string]*proxyClient
+	sessions map[*ServerClient]*proxySession
+	mx       sync.RWMutex
+}
+
+type proxyClient struct {
+	info    ProxyClient
+	limiter requestLimiter
+}
+
+type proxySession struct {
+	nonce  []byte
+	client *proxyClient
+	// limiter - used when authorization is not required
+	limiter *requestLimiter
+}
+
+// NewProxy - creates proxy, keys are the server keys which clients are using to connect.
+// Only clients added with AddClient are served, use SetAllowAnonymous to accept connections without authorization.
+func NewProxy(keys []ed25519.PrivateKey, upstream ProxyUpstream) *Proxy {
+	p := &Proxy{
+		server:       NewServer(keys),
+		upstream:     upstream,
+		queryTimeout: 10 * time.Second,
+		clients:      map[string]*proxyClient{},
+		sessions:     map[*ServerClient]*proxySession{},
+	}
+	p.server.SetConnectionHook(p.onConnect)
+	p.server.SetDisconnectHook(p.onDisconnect)
+	p.server.SetMessageHandler(p.handleMessage)
+	return p
+}
+
+// AddClient - allows client with the key, replaces limits if the client is already added
+func (p *Proxy) AddClient(client ProxyClient) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	cl := p.clients[string(client.Key)]
+	if cl == nil {
+		cl = &proxyClient{}
+		p.clients[string(client.Key)] = cl
+	}
+	cl.info = client
+	cl.limiter.setLimits(client.Limits)
+}
+
+// RemoveClient - forbids client with the key and closes its active connections
+func (p *Proxy) RemoveClient(key ed25519.PublicKey) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	cl := p.clients[string(key)]
+	if cl == nil {
+		return
+	}
+	delete(p.clients, string(key))
+
+	for sc, s := range p.sessions {
+		if s.client == cl {
+			sc.Close()
+		}
+	}
+}
+
+// SetAllowAnonymous - allows queries from connections which are not authorized as one of the added clients,
+// they are limited by anonymous limits. Disabled by default, so proxy is not an open relay.
+func (p *Proxy) SetAllowAnonymous(allow bool) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.allowAnonymous = allow
+}
+
+// SetAnonymousLimits - sets limits of each anonymous connection, see SetAllowAnonymous
+func (p *Proxy) SetAnonymousLimits(limits RequestLimits) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.anonymousLimits = limits
+}
+
+// SetQueryTimeout - sets timeout of upstream query, including waiting for the client limits
+func (p *Proxy) SetQueryTimeout(timeout time.Duration) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.queryTimeout = timeout
+}
+
+// Listen - starts accepting connections, blocks until Close is called
+func (p *Proxy) Listen(addr string) error {
+	return p.server.Listen(addr)
+}
+
+func (p *Proxy) Close() error {
+	return p.server.Close()
+}
+
+func (p *Proxy) onConnect(sc *ServerClient) error {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	s := &proxySession{limiter: &requestLimiter{}}
+	s.limiter.setLimits(p.anonymousLimits)
+	p.sessions[sc] = s
+	return nil
+}
+
+func (p *Proxy) onDisconnect(sc *ServerClient) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	delete(p.sessions, sc)
+}
+
+func (p *Proxy) handleMessage(ctx context.Context, sc *ServerClient, msg tl.Serializable) error {
+	p.mx.RLock()
+	s := p.sessions[sc]
+	p.mx.RUnlock()
+
+	if s == nil {
+		return fmt.Errorf("session not found")
+	}
+
+	switch m := msg.(type) {
+	case TCPPing:
+		return sc.Send(TCPPong{RandomID: m.RandomID})
+	case TCPAuthenticate:
+		return p.authenticate(sc, s, m)
+	case TCPAuthenticationComplete:
+		return p.completeAuthentication(s, m)
+	case adnl.MessageQuery:
+		p.mx.RLock()
+		limiter := s.limiter
+		if s.client != nil {
+			limiter = &s.client.limiter
+		} else if !p.allowAnonymous {
+			limiter = nil
+		}
+		timeout := p.queryTimeout
+		p.mx.RUnlock()
+
+		if limiter == nil {
+			return ErrProxyUnauthorized
+		}
+
+		q, ok := m.Data.(LiteServerQuery)
+		if !ok {
+			return fmt.Errorf("unsupported query: %s", reflect.TypeOf(m.Data).String())
+		}
+
+		// server is reading messages sequentially, so query is processed separately to not block next ones
+		go func() {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			if err := sc.Send(adnl.MessageAnswer{ID: m.ID, Data: p.query(ctx, s, limiter, q.Data)}); err != nil {
+				Logger("["+sc.IP()+"]", "failed to send proxy answer:", err.Error())
+			}
+		}()
+		return nil
+	}
+
+	return fmt.Errorf("unexpected message: %s", reflect.TypeOf(msg).String())
+}
+
+func (p *Proxy) query(ctx context.Context, s *proxySession, limiter *requestLimiter, payload tl.Serializable) tl.Serializable {
+	p.mx.RLock()
+	if s.client != nil {
+		ctx = WithRequestPriority(ctx, s.client.info.Priority)
+	}
+	p.mx.RUnlock()
+
+	prio := GetRequestPriority(ctx)
+	if err := limiter.acquire(ctx, prio); err != nil {
+		return proxyError(ProxyErrorCodeLimit, "request limit: "+err.Error())
+	}
+	defer limiter.release()
+
+	var resp tl.Serializable
+	if err := p.upstream.QueryLiteserver(ctx, payload, &resp); err != nil {
+		return proxyError(ProxyErrorCodeUpstream, "upstream: "+err.Error())
+	}
+	return resp
+}
+
+func (p *Proxy) authenticate(sc *ServerClient, s *proxySession, m TCPAuthenticate) error {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	p.mx.Lock()
+	// client signs its nonce concatenated with ours
+	s.nonce = append(append([]byte{}, m.Nonce...), nonce...)
+	p.mx.Unlock()
+
+	return sc.Send(TCPAuthenticationNonce{Nonce: nonce})
+}
+
+func (p *Proxy) completeAuthentication(s *proxySession, m TCPAuthenticationComplete) error {
+	key, ok := m.PublicKey.(adnl.PublicKeyED25519)
+	if !ok || len(key.Key) != ed25519.PublicKeySize {
+		return fmt.Errorf("unsupported auth key")
+	}
+
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	if s.nonce == nil {
+		return fmt.Errorf("auth was not requested")
+	}
+
+	if !ed25519.Verify(key.Key, s.nonce, m.Signature) {
+		return fmt.Errorf("invalid auth signature")
+	}
+	s.nonce = nil
+
+	cl := p.clients[string(key.Key)]
+	if cl == nil {
+		if !p.allowAnonymous {
+			return ErrProxyUnauthorized
+		}
+		// unknown key, keep anonymous limits
+		return nil
+	}
+
+	s.client = cl
+	return nil
+}
+
+func proxyError(code int32, text string) tl.Serializable {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint32(buf, _LSErrorID)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(code))
+	return tl.Raw(append(buf, tl.ToBytes([]byte(text))...))
+}
@@ -0,0 +1,161 @@
+package liteclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+func init() {
+	tl.Register(proxyTestError{}, "liteServer.error code:int message:string = liteServer.Error")
+}
+
+// proxyTestError - same as ton.LSError, which cannot be imported here
+type proxyTestError struct {
+	Code int32  `tl:"int"`
+	Text string `tl:"string"`
+}
+
+type proxyTestUpstream struct {
+	calls int32
+	fail  bool
+}
+
+func (u *proxyTestUpstream) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	atomic.AddInt32(&u.calls, 1)
+	if _, ok := payload.(GetMasterchainInf); !ok {
+		return errors.New("unexpected payload")
+	}
+	if u.fail {
+		return errors.New("no nodes")
+	}
+	*result.(*tl.Serializable) = TCPPong{RandomID: 777}
+	return nil
+}
+
+func startTestProxy(t *testing.T, p *Proxy) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	_ = lis.Close()
+
+	go func() {
+		_ = p.Listen(addr)
+	}()
+	t.Cleanup(func() {
+		_ = p.Close()
+	})
+	time.Sleep(100 * time.Millisecond)
+	return addr
+}
+
+func TestProxy_Query(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	upstream := &proxyTestUpstream{}
+	p := NewProxy([]ed25519.PrivateKey{key}, upstream)
+	p.SetAllowAnonymous(true)
+	addr := startTestProxy(t, p)
+
+	client := NewConnectionPool()
+	if err := client.AddConnection(context.Background(), addr, base64.StdEncoding.EncodeToString(pub)); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Stop()
+
+	var resp tl.Serializable
+	if err := client.QueryLiteserver(context.Background(), GetMasterchainInf{}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if pong, ok := resp.(TCPPong); !ok || pong.RandomID != 777 {
+		t.Fatal("incorrect response", resp)
+	}
+
+	upstream.fail = true
+	if err := client.QueryLiteserver(context.Background(), GetMasterchainInf{}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if lsErr, ok := resp.(proxyTestError); !ok || lsErr.Code != ProxyErrorCodeUpstream {
+		t.Fatal("upstream error should be returned as liteserver error", resp)
+	}
+}
+
+func TestProxy_Auth(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	clientPub, clientKey, _ := ed25519.GenerateKey(nil)
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+
+	upstream := &proxyTestUpstream{}
+	p := NewProxy([]ed25519.PrivateKey{key}, upstream)
+	p.AddClient(ProxyClient{Name: "service", Key: clientPub, Limits: RequestLimits{MaxInFlight: 2}})
+	addr := startTestProxy(t, p)
+
+	client := NewConnectionPoolWithAuth(clientKey)
+	if err := client.AddConnection(context.Background(), addr, base64.StdEncoding.EncodeToString(pub)); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Stop()
+
+	var resp tl.Serializable
+	if err := client.QueryLiteserver(context.Background(), GetMasterchainInf{}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.(TCPPong); !ok {
+		t.Fatal("incorrect response", resp)
+	}
+
+	for _, k := range []ed25519.PrivateKey{otherKey, nil} {
+		var cl *ConnectionPool
+		if k == nil {
+			cl = NewConnectionPool()
+		} else {
+			cl = NewConnectionPoolWithAuth(k)
+		}
+		// server closes connection, reconnects are not needed
+		cl.SetOnDisconnect(nil)
+
+		// connection can be established before server checks the key, but queries should not be processed
+		_ = cl.AddConnection(context.Background(), addr, base64.StdEncoding.EncodeToString(pub))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		if err := cl.QueryLiteserver(ctx, GetMasterchainInf{}, &resp); err == nil {
+			t.Fatal("unauthorized client should be rejected")
+		}
+		cancel()
+		cl.Stop()
+	}
+
+	if calls := atomic.LoadInt32(&upstream.calls); calls != 1 {
+		t.Fatal("only authorized query should reach upstream, got", calls)
+	}
+}
+
+func TestProxy_AnonymousDisabled(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	upstream := &proxyTestUpstream{}
+	addr := startTestProxy(t, NewProxy([]ed25519.PrivateKey{key}, upstream))
+
+	client := NewConnectionPool()
+	client.SetOnDisconnect(nil)
+	_ = client.AddConnection(context.Background(), addr, base64.StdEncoding.EncodeToString(pub))
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	var resp tl.Serializable
+	if err := client.QueryLiteserver(ctx, GetMasterchainInf{}, &resp); err == nil {
+		t.Fatal("anonymous query should be rejected when not allowed")
+	}
+	if calls := atomic.LoadInt32(&upstream.calls); calls != 0 {
+		t.Fatal("query should not reach upstream, got", calls)
+	}
+}
@@ -0,0 +1,162 @@
+package observer
+
+import (
+	"context"
+	"time"
+)
+
+// Components which are reporting to observer
+const (
+	ComponentLiteClient = "liteclient"
+	ComponentAPI        = "ton"
+	ComponentADNL       = "adnl"
+	ComponentRLDP       = "rldp"
+)
+
+type EventKind string
+
+const (
+	// EventRetry - request is retried with another node
+	EventRetry EventKind = "retry"
+	// EventReconnect - reconnection attempt, Err is set when it was failed
+	EventReconnect EventKind = "reconnect"
+	// EventPacketLoss - Value is the number of packets detected as lost
+	EventPacketLoss EventKind = "packet_loss"
+	// EventTransferSent - RLDP transfer is sent, Value is the size of data in bytes
+	EventTransferSent EventKind = "transfer_sent"
+	// EventTransferReceived - RLDP transfer is received and decoded, Value is the size of data in bytes
+	EventTransferReceived EventKind = "transfer_received"
+)
+
+type observerCtxKey struct{}
+
+// Request - information about the observed request
+type Request struct {
+	Component string
+	// Method - TL name of the request, for example liteServer.getMasterchainInfo
+	Method string
+	// Peer - address of the remote side, when known
+	Peer      string
+	StartedAt time.Time
+}
+
+// Event - information about the event, which is not a request
+type Event struct {
+	Component string
+	Kind      EventKind
+	Method    string
+	Peer      string
+	// Value - amount of the event, for counters it is 1
+	Value int64
+	Err   error
+}
+
+// RequestTracker - reports the finish of the request to observer, nil tracker does nothing
+type RequestTracker struct {
+	ctx      context.Context
+	observer Observer
+	req      *Request
+}
+
+// Observer - receives requests and events of the library components, to collect metrics and traces.
+// Methods are called from different goroutines and should not block.
+type Observer interface {
+	// OnRequest - called before the request is sent, returned context is used for the request
+	// and passed to OnResponse, so it can carry the span or other request related data
+	OnRequest(ctx context.Context, req *Request) context.Context
+	// OnResponse - called when the request is finished, err is nil on success
+	OnResponse(ctx context.Context, req *Request, err error)
+	// OnEvent - called for the events which are not requests: retries, reconnects, packet loss, transfers
+	OnEvent(ctx context.Context, e *Event)
+}
+
+// Nop - observer which does nothing, can be embedded to implement only needed methods
+type Nop struct{}
+
+type multiObserver []Observer
+
+func (Nop) OnRequest(ctx context.Context, req *Request) context.Context {
+	return ctx
+}
+
+func (Nop) OnResponse(ctx context.Context, req *Request, err error) {}
+
+func (Nop) OnEvent(ctx context.Context, e *Event) {}
+
+// Multi - combines observers, they are called in the given order
+func Multi(observers ...Observer) Observer {
+	return multiObserver(observers)
+}
+
+func (m multiObserver) OnRequest(ctx context.Context, req *Request) context.Context {
+	for _, o := range m {
+		ctx = o.OnRequest(ctx, req)
+	}
+	return ctx
+}
+
+func (m multiObserver) OnResponse(ctx context.Context, req *Request, err error) {
+	for _, o := range m {
+		o.OnResponse(ctx, req, err)
+	}
+}
+
+func (m multiObserver) OnEvent(ctx context.Context, e *Event) {
+	for _, o := range m {
+		o.OnEvent(ctx, e)
+	}
+}
+
+// StartRequest - notifies observer about the request, and returns tracker to report when it is finished.
+// Observer can be nil, then nothing is done.
+func StartRequest(ctx context.Context, o Observer, component, method, peer string) (context.Context, *RequestTracker) {
+	if o == nil {
+		return ctx, nil
+	}
+
+	req := &Request{
+		Component: component,
+		Method:    method,
+		Peer:      peer,
+		StartedAt: time.Now(),
+	}
+	ctx = o.OnRequest(ctx, req)
+	return ctx, &RequestTracker{ctx: ctx, observer: o, req: req}
+}
+
+// SetPeer - sets peer of the request, when it is known only after sending
+func (t *RequestTracker) SetPeer(peer string) {
+	if t != nil {
+		t.req.Peer = peer
+	}
+}
+
+// Done - reports finish of the request, err is nil on success
+func (t *RequestTracker) Done(err error) {
+	if t != nil {
+		t.observer.OnResponse(t.ctx, t.req, err)
+	}
+}
+
+// NewContext - returns context which carries observer, it is used by the layers
+// which have no own observer, for example by API client wrappers
+func NewContext(ctx context.Context, o Observer) context.Context {
+	return context.WithValue(ctx, observerCtxKey{}, o)
+}
+
+// FromContext - returns observer from context, or nil
+func FromContext(ctx context.Context) Observer {
+	o, _ := ctx.Value(observerCtxKey{}).(Observer)
+	return o
+}
+
+// Notify - reports event to observer, if it is not nil
+func Notify(ctx context.Context, o Observer, e *Event) {
+	if o == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	o.OnEvent(ctx, e)
+}
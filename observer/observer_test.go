@@ -0,0 +1,75 @@
+package observer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testRecorder struct {
+	requests []string
+	failed   int
+	events   map[EventKind]int64
+}
+
+func (r *testRecorder) RecordRequest(component, method string, duration time.Duration, failed bool) {
+	r.requests = append(r.requests, component+" "+method)
+	if failed {
+		r.failed++
+	}
+}
+
+func (r *testRecorder) RecordEvent(component string, kind EventKind, value int64) {
+	r.events[kind] += value
+}
+
+func TestTracingObserver(t *testing.T) {
+	var started, ended []string
+	var endErr error
+	var events []string
+
+	start := func(ctx context.Context, name string, attrs []Attribute) (context.Context, func(err error)) {
+		started = append(started, name)
+		for _, a := range attrs {
+			if a.Key == "ton.tl.type" && a.Value != "liteServer.getMasterchainInfo" {
+				t.Fatal("incorrect type attribute")
+			}
+		}
+		return ctx, func(err error) {
+			ended = append(ended, name)
+			endErr = err
+		}
+	}
+	addEvent := func(ctx context.Context, name string, attrs []Attribute) {
+		events = append(events, name)
+	}
+
+	rec := &testRecorder{events: map[EventKind]int64{}}
+	o := Multi(NewTracingObserver(start, addEvent), NewMetricsObserver(rec))
+
+	ctx, tracker := StartRequest(context.Background(), o, ComponentLiteClient, "liteServer.getMasterchainInfo", "")
+	Notify(ctx, o, &Event{Component: ComponentAPI, Kind: EventRetry, Value: 1})
+	Notify(ctx, o, &Event{Component: ComponentADNL, Kind: EventPacketLoss, Value: 3})
+	tracker.SetPeer("1.2.3.4:5555")
+	tracker.Done(errors.New("timeout"))
+
+	if len(started) != 1 || started[0] != "liteclient liteServer.getMasterchainInfo" {
+		t.Fatal("incorrect span", started)
+	}
+	if len(ended) != 1 || endErr == nil {
+		t.Fatal("span should be ended with error")
+	}
+	if len(events) != 2 || events[0] != "retry" {
+		t.Fatal("incorrect span events", events)
+	}
+	if len(rec.requests) != 1 || rec.failed != 1 || rec.events[EventPacketLoss] != 3 {
+		t.Fatal("incorrect metrics")
+	}
+
+	// nil observer should be allowed
+	_, tracker = StartRequest(context.Background(), nil, ComponentADNL, "x", "")
+	tracker.SetPeer("y")
+	tracker.Done(nil)
+	Notify(context.Background(), nil, &Event{})
+}
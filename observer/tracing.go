@@ -0,0 +1,116 @@
+package observer
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+type spanCtxKey struct{}
+
+// Attribute - key value pair attached to the span, same as attribute.KeyValue in OpenTelemetry
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// SpanStarter - starts span with the given name and attributes, and returns function to end it.
+// It allows to use any tracing library without dependency on it, for example with OpenTelemetry:
+//
+//	tracer := otel.Tracer("tonutils-go")
+//	starter := func(ctx context.Context, name string, attrs []observer.Attribute) (context.Context, func(err error)) {
+//		kv := make([]attribute.KeyValue, 0, len(attrs))
+//		for _, a := range attrs {
+//			kv = append(kv, attribute.String(a.Key, a.Value))
+//		}
+//		ctx, span := tracer.Start(ctx, name, trace.WithAttributes(kv...))
+//		return ctx, func(err error) {
+//			if err != nil {
+//				span.RecordError(err)
+//				span.SetStatus(codes.Error, err.Error())
+//			}
+//			span.End()
+//		}
+//	}
+type SpanStarter func(ctx context.Context, name string, attrs []Attribute) (context.Context, func(err error))
+
+// SpanEventAdder - adds event to the current span of ctx, like trace.SpanFromContext(ctx).AddEvent in OpenTelemetry
+type SpanEventAdder func(ctx context.Context, name string, attrs []Attribute)
+
+// TracingObserver - creates span for each request, named as "component method", for example
+// "liteclient liteServer.getMasterchainInfo", and adds other events to the current span
+type TracingObserver struct {
+	start    SpanStarter
+	addEvent SpanEventAdder
+}
+
+// NewTracingObserver - creates observer for tracing, addEvent is optional
+func NewTracingObserver(start SpanStarter, addEvent SpanEventAdder) *TracingObserver {
+	return &TracingObserver{start: start, addEvent: addEvent}
+}
+
+func (t *TracingObserver) OnRequest(ctx context.Context, req *Request) context.Context {
+	attrs := []Attribute{
+		{Key: "ton.component", Value: req.Component},
+		{Key: "ton.tl.type", Value: req.Method},
+	}
+	if req.Peer != "" {
+		attrs = append(attrs, Attribute{Key: "net.peer.name", Value: req.Peer})
+	}
+
+	ctx, end := t.start(ctx, req.Component+" "+req.Method, attrs)
+	return context.WithValue(ctx, spanCtxKey{}, end)
+}
+
+func (t *TracingObserver) OnResponse(ctx context.Context, req *Request, err error) {
+	if end, ok := ctx.Value(spanCtxKey{}).(func(err error)); ok {
+		end(err)
+	}
+}
+
+func (t *TracingObserver) OnEvent(ctx context.Context, e *Event) {
+	if t.addEvent == nil {
+		return
+	}
+
+	attrs := []Attribute{
+		{Key: "ton.component", Value: e.Component},
+		{Key: "ton.event.value", Value: strconv.FormatInt(e.Value, 10)},
+	}
+	if e.Method != "" {
+		attrs = append(attrs, Attribute{Key: "ton.tl.type", Value: e.Method})
+	}
+	if e.Peer != "" {
+		attrs = append(attrs, Attribute{Key: "net.peer.name", Value: e.Peer})
+	}
+	if e.Err != nil {
+		attrs = append(attrs, Attribute{Key: "error", Value: e.Err.Error()})
+	}
+	t.addEvent(ctx, string(e.Kind), attrs)
+}
+
+// MetricsRecorder - receives measurements, can be implemented with Prometheus or OpenTelemetry metrics
+type MetricsRecorder interface {
+	// RecordRequest - request duration, failed is true when request returned error
+	RecordRequest(component, method string, duration time.Duration, failed bool)
+	// RecordEvent - event counter, value is added to the counter of the kind
+	RecordEvent(component string, kind EventKind, value int64)
+}
+
+// MetricsObserver - translates requests and events to the measurements of recorder
+type MetricsObserver struct {
+	Nop
+	recorder MetricsRecorder
+}
+
+func NewMetricsObserver(recorder MetricsRecorder) *MetricsObserver {
+	return &MetricsObserver{recorder: recorder}
+}
+
+func (m *MetricsObserver) OnResponse(ctx context.Context, req *Request, err error) {
+	m.recorder.RecordRequest(req.Component, req.Method, time.Since(req.StartedAt), err != nil)
+}
+
+func (m *MetricsObserver) OnEvent(ctx context.Context, e *Event) {
+	m.recorder.RecordEvent(e.Component, e.Kind, e.Value)
+}
@@ -24,6 +24,7 @@ type TL interface {
 var _SchemaIDByTypeName = map[string]uint32{}
 var _SchemaIDByName = map[string]uint32{}
 var _SchemaByID = map[uint32]reflect.Type{}
+var _SchemaNameByID = map[uint32]string{}
 
 var BoolTrue = CRC("boolTrue = Bool")
 var BoolFalse = CRC("boolFalse = Bool")
@@ -750,6 +751,7 @@ func Register(typ any, tl string) uint32 {
 	_SchemaByID[id] = t
 	_SchemaIDByTypeName[t.String()] = id
 	_SchemaIDByName[nameParts[0]] = id
+	_SchemaNameByID[id] = nameParts[0]
 
 	b := make([]byte, 4)
 	binary.LittleEndian.PutUint32(b, id)
@@ -758,6 +760,23 @@ func Register(typ any, tl string) uint32 {
 	return id
 }
 
+// TypeName - returns name of the registered TL constructor of v, or go type name if it is not registered
+func TypeName(v any) string {
+	if v == nil {
+		return "nil"
+	}
+
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if id, ok := _SchemaIDByTypeName[t.String()]; ok {
+		return _SchemaNameByID[id]
+	}
+	return t.String()
+}
+
 var ieeeTable = crc32.MakeTable(crc32.IEEE)
 
 func CRC(schema string) uint32 {
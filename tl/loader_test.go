@@ -85,3 +85,18 @@ func TestHash(t *testing.T) {
 		t.Fatal("incorrect hash " + hex.EncodeToString(hash))
 	}
 }
+
+func TestTypeName(t *testing.T) {
+	Register(TestInner{}, "test.inner double:long key:int256 = test.Inner")
+
+	if name := TypeName(TestInner{}); name != "test.inner" {
+		t.Fatal("incorrect name " + name)
+	}
+	if name := TypeName(&TestInner{}); name != "test.inner" {
+		t.Fatal("incorrect pointer name " + name)
+	}
+	type notRegistered struct{}
+	if name := TypeName(notRegistered{}); name != "tl.notRegistered" {
+		t.Fatal("incorrect not registered name " + name)
+	}
+}
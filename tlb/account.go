@@ -2,6 +2,7 @@ package tlb
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
 
@@ -162,6 +163,78 @@ func (a *AccountState) LoadFromCell(loader *cell.Slice) error {
 	return nil
 }
 
+func (a *AccountState) ToCell() (*cell.Cell, error) {
+	if !a.IsValid {
+		return cell.BeginCell().MustStoreBoolBit(false).EndCell(), nil
+	}
+
+	info, err := ToCell(a.StorageInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize storage info: %w", err)
+	}
+
+	storage, err := a.AccountStorage.ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize account storage: %w", err)
+	}
+
+	b := cell.BeginCell().MustStoreBoolBit(true)
+	if err = b.StoreAddr(a.Address); err != nil {
+		return nil, fmt.Errorf("failed to store address: %w", err)
+	}
+	if err = b.StoreBuilder(info.ToBuilder()); err != nil {
+		return nil, fmt.Errorf("failed to store storage info: %w", err)
+	}
+	if err = b.StoreBuilder(storage.ToBuilder()); err != nil {
+		return nil, fmt.Errorf("failed to store account storage: %w", err)
+	}
+	return b.EndCell(), nil
+}
+
+func (s *AccountStorage) ToCell() (*cell.Cell, error) {
+	b := cell.BeginCell().MustStoreUInt(s.LastTransactionLT, 64)
+	if err := b.StoreBigCoins(s.Balance.Nano()); err != nil {
+		return nil, fmt.Errorf("failed to store balance: %w", err)
+	}
+	if err := b.StoreDict(s.ExtraCurrencies); err != nil {
+		return nil, fmt.Errorf("failed to store extra currencies: %w", err)
+	}
+
+	switch s.Status {
+	case AccountStatusActive:
+		if s.StateInit == nil {
+			return nil, fmt.Errorf("state init is required for active account")
+		}
+		stInit, err := ToCell(s.StateInit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize state init: %w", err)
+		}
+		if err = b.StoreBoolBit(true); err != nil {
+			return nil, err
+		}
+		if err = b.StoreBuilder(stInit.ToBuilder()); err != nil {
+			return nil, fmt.Errorf("failed to store state init: %w", err)
+		}
+	case AccountStatusFrozen:
+		if len(s.StateHash) != 32 {
+			return nil, fmt.Errorf("invalid frozen state hash")
+		}
+		if err := b.StoreUInt(0b01, 2); err != nil {
+			return nil, err
+		}
+		if err := b.StoreSlice(s.StateHash, 256); err != nil {
+			return nil, err
+		}
+	case AccountStatusUninit:
+		if err := b.StoreUInt(0b00, 2); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unexpected account status %s", s.Status)
+	}
+	return b.EndCell(), nil
+}
+
 func (s *AccountStorage) LoadFromCell(loader *cell.Slice) error {
 	lastTransaction, err := loader.LoadUInt(64)
 	if err != nil {
@@ -263,3 +336,50 @@ func MethodNameHash(name string) uint64 {
 	// https://github.com/ton-blockchain/ton/blob/24dc184a2ea67f9c47042b4104bbb4d82289fac1/crypto/smc-envelope/SmartContract.h#L75
 	return uint64(crc16.Checksum([]byte(name), crc16.MakeTable(crc16.CRC16_XMODEM))) | 0x10000
 }
+
+// Add - returns sum of two collections, extra currencies with the same id are summed
+func (c CurrencyCollection) Add(other CurrencyCollection) (CurrencyCollection, error) {
+	res := CurrencyCollection{
+		Coins:           FromNanoTON(new(big.Int).Add(c.Coins.Nano(), other.Coins.Nano())),
+		ExtraCurrencies: cell.NewDict(32),
+	}
+	if c.ExtraCurrencies != nil {
+		res.ExtraCurrencies = c.ExtraCurrencies.Copy()
+	}
+
+	if other.ExtraCurrencies == nil || other.ExtraCurrencies.IsEmpty() {
+		return res, nil
+	}
+
+	list, err := other.ExtraCurrencies.LoadAll()
+	if err != nil {
+		return CurrencyCollection{}, fmt.Errorf("failed to load extra currencies: %w", err)
+	}
+
+	for _, kv := range list {
+		amount, err := kv.Value.LoadVarUInt(32)
+		if err != nil {
+			return CurrencyCollection{}, fmt.Errorf("failed to load extra currency amount: %w", err)
+		}
+
+		key := kv.Key.MustToCell()
+		if v, err := res.ExtraCurrencies.LoadValue(key); err == nil {
+			cur, err := v.LoadVarUInt(32)
+			if err != nil {
+				return CurrencyCollection{}, fmt.Errorf("failed to load extra currency amount: %w", err)
+			}
+			amount.Add(amount, cur)
+		} else if !errors.Is(err, cell.ErrNoSuchKeyInDict) {
+			return CurrencyCollection{}, fmt.Errorf("failed to find extra currency: %w", err)
+		}
+
+		b := cell.BeginCell()
+		if err = b.StoreBigVarUInt(amount, 32); err != nil {
+			return CurrencyCollection{}, fmt.Errorf("failed to store extra currency amount: %w", err)
+		}
+		if err = res.ExtraCurrencies.Set(key, b.EndCell()); err != nil {
+			return CurrencyCollection{}, fmt.Errorf("failed to set extra currency: %w", err)
+		}
+	}
+	return res, nil
+}
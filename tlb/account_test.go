@@ -1,6 +1,7 @@
 package tlb
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
@@ -49,6 +50,17 @@ func TestAccountState_LoadFromCell(t *testing.T) {
 		t.Fatal("LastTransactionLT incorrect", as.LastTransactionLT)
 		return
 	}
+
+	c, err := as.ToCell()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if !bytes.Equal(c.Hash(), acc.Hash()) {
+		t.Fatal("serialized account hash not eq")
+		return
+	}
 }
 
 func Test_MethodNameHash(t *testing.T) {
@@ -211,3 +223,49 @@ func TestAccountStorage_LoadFromCell(t *testing.T) {
 	})
 
 }
+
+func TestCurrencyCollection_Add(t *testing.T) {
+	extra := func(vals map[int64]int64) *cell.Dictionary {
+		d := cell.NewDict(32)
+		for k, v := range vals {
+			if err := d.SetIntKey(big.NewInt(k), cell.BeginCell().MustStoreBigVarUInt(big.NewInt(v), 32).EndCell()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return d
+	}
+
+	a := CurrencyCollection{Coins: MustFromTON("1.5"), ExtraCurrencies: extra(map[int64]int64{1: 100, 2: 5})}
+	b := CurrencyCollection{Coins: MustFromTON("0.5"), ExtraCurrencies: extra(map[int64]int64{2: 10, 7: 1})}
+
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Coins.String() != "2" {
+		t.Fatal("incorrect coins", sum.Coins.String())
+	}
+
+	for k, v := range map[int64]int64{1: 100, 2: 15, 7: 1} {
+		s, err := sum.ExtraCurrencies.LoadValueByIntKey(big.NewInt(k))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.MustLoadVarUInt(32).Int64() != v {
+			t.Fatal("incorrect extra currency", k)
+		}
+	}
+
+	// source should not be modified
+	if _, err = a.ExtraCurrencies.LoadValueByIntKey(big.NewInt(7)); err == nil {
+		t.Fatal("source collection was modified")
+	}
+
+	sum, err = CurrencyCollection{}.Add(CurrencyCollection{Coins: MustFromTON("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Coins.String() != "1" || !sum.ExtraCurrencies.IsEmpty() {
+		t.Fatal("incorrect sum of empty collections")
+	}
+}
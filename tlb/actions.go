@@ -0,0 +1,186 @@
+package tlb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// MaxOutActions - max number of actions in the OutList which can be processed by the action phase
+const MaxOutActions = 255
+
+var ErrTooManyActions = errors.New("too many actions in list")
+
+func init() {
+	Register(ActionSendMsg{})
+	Register(ActionSetCode{})
+	Register(ActionReserveCurrency{})
+	Register(ActionChangeLibrary{})
+	Register(LibRefHash{})
+	Register(LibRefCell{})
+
+	Register(ActionAddExtension{})
+	Register(ActionDeleteExtension{})
+	Register(ActionSetSignatureAuthAllowed{})
+}
+
+// OutAction - action of the compute phase result (c5 register)
+type OutAction struct {
+	Action any `tlb:"[ActionSendMsg,ActionSetCode,ActionReserveCurrency,ActionChangeLibrary]"`
+}
+
+type ActionSendMsg struct {
+	_    Magic    `tlb:"#0ec3c86d"`
+	Mode uint8    `tlb:"## 8"`
+	Msg  *Message `tlb:"^"`
+}
+
+type ActionSetCode struct {
+	_       Magic      `tlb:"#ad4de08e"`
+	NewCode *cell.Cell `tlb:"^"`
+}
+
+type ActionReserveCurrency struct {
+	_        Magic              `tlb:"#36e6b809"`
+	Mode     uint8              `tlb:"## 8"`
+	Currency CurrencyCollection `tlb:"."`
+}
+
+type ActionChangeLibrary struct {
+	_    Magic `tlb:"#26fa1dd4"`
+	Mode uint8 `tlb:"## 7"`
+	// LibRef - LibRefHash or LibRefCell
+	LibRef any `tlb:"[LibRefHash,LibRefCell]"`
+}
+
+type LibRefHash struct {
+	_    Magic  `tlb:"$0"`
+	Hash []byte `tlb:"bits 256"`
+}
+
+type LibRefCell struct {
+	_       Magic      `tlb:"$1"`
+	Library *cell.Cell `tlb:"^"`
+}
+
+// ExtendedAction - wallet v5 action which is processed by the contract itself, not by the action phase
+type ExtendedAction struct {
+	Action any `tlb:"[ActionAddExtension,ActionDeleteExtension,ActionSetSignatureAuthAllowed]"`
+}
+
+type ActionAddExtension struct {
+	_    Magic            `tlb:"#02"`
+	Addr *address.Address `tlb:"addr"`
+}
+
+type ActionDeleteExtension struct {
+	_    Magic            `tlb:"#03"`
+	Addr *address.Address `tlb:"addr"`
+}
+
+type ActionSetSignatureAuthAllowed struct {
+	_       Magic `tlb:"#04"`
+	Allowed bool  `tlb:"bool"`
+}
+
+// LoadOutList - parses OutList chain, actions are returned in the order of execution
+func LoadOutList(list *cell.Cell) ([]OutAction, error) {
+	// out_list_empty$_ = OutList 0;
+	// out_list$_ {n:#} prev:^(OutList n) action:OutAction = OutList (n + 1);
+	var res []OutAction
+	for c := list; c.BitsSize() > 0 || c.RefsNum() > 0; {
+		if len(res) >= MaxOutActions {
+			return nil, ErrTooManyActions
+		}
+
+		s := c.BeginParse()
+		prev, err := s.LoadRefCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load prev actions: %w", err)
+		}
+
+		var act OutAction
+		if err = LoadFromCell(&act, s); err != nil {
+			return nil, fmt.Errorf("failed to parse action %d from the end: %w", len(res), err)
+		}
+		res = append(res, act)
+		c = prev
+	}
+
+	// list is linked from the last action to the first
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res, nil
+}
+
+// OutListToCell - serializes actions to OutList, first action will be executed first
+func OutListToCell(actions []OutAction) (*cell.Cell, error) {
+	if len(actions) > MaxOutActions {
+		return nil, ErrTooManyActions
+	}
+
+	list := cell.BeginCell().EndCell()
+	for i, act := range actions {
+		c, err := ToCell(act)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize action %d: %w", i, err)
+		}
+
+		list = cell.BeginCell().MustStoreRef(list).MustStoreBuilder(c.ToBuilder()).EndCell()
+	}
+	return list, nil
+}
+
+// LoadExtendedActions - parses wallet v5 extended actions chain,
+// each action is followed by optional reference to the next one
+func LoadExtendedActions(list *cell.Cell) ([]ExtendedAction, error) {
+	var res []ExtendedAction
+	for c := list; c != nil; {
+		if len(res) >= MaxOutActions {
+			return nil, ErrTooManyActions
+		}
+
+		s := c.BeginParse()
+
+		var act ExtendedAction
+		if err := LoadFromCell(&act, s); err != nil {
+			return nil, fmt.Errorf("failed to parse extended action %d: %w", len(res), err)
+		}
+		res = append(res, act)
+
+		c = nil
+		if s.RefsNum() > 0 {
+			next, err := s.LoadRefCell()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load next extended action: %w", err)
+			}
+			c = next
+		}
+	}
+	return res, nil
+}
+
+// ExtendedActionsToCell - serializes wallet v5 extended actions chain
+func ExtendedActionsToCell(actions []ExtendedAction) (*cell.Cell, error) {
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("at least one action is required")
+	}
+
+	var next *cell.Cell
+	for i := len(actions) - 1; i >= 0; i-- {
+		c, err := ToCell(actions[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize extended action %d: %w", i, err)
+		}
+
+		b := c.ToBuilder()
+		if next != nil {
+			b.MustStoreRef(next)
+		}
+		next = b.EndCell()
+	}
+	return next, nil
+}
@@ -0,0 +1,124 @@
+package tlb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestLoadOutList(t *testing.T) {
+	addr := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+
+	msg, err := ToCell(&InternalMessage{
+		IHRDisabled: true,
+		Bounce:      true,
+		DstAddr:     addr,
+		Amount:      MustFromTON("1.5"),
+		Body:        cell.BeginCell().MustStoreUInt(0, 32).MustStoreStringSnake("test").EndCell(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := cell.BeginCell().MustStoreUInt(0xFF, 8).EndCell()
+
+	// built the same way as wallets do
+	list := cell.BeginCell().EndCell()
+	list = cell.BeginCell().MustStoreRef(list).
+		MustStoreUInt(0x0ec3c86d, 32).MustStoreUInt(3, 8).MustStoreRef(msg).EndCell()
+	list = cell.BeginCell().MustStoreRef(list).
+		MustStoreUInt(0x36e6b809, 32).MustStoreUInt(2, 8).MustStoreBigCoins(MustFromTON("0.1").Nano()).MustStoreDict(nil).EndCell()
+	list = cell.BeginCell().MustStoreRef(list).
+		MustStoreUInt(0xad4de08e, 32).MustStoreRef(code).EndCell()
+	list = cell.BeginCell().MustStoreRef(list).
+		MustStoreUInt(0x26fa1dd4, 32).MustStoreUInt(2, 7).MustStoreUInt(0, 1).MustStoreSlice(code.Hash(), 256).EndCell()
+
+	actions, err := LoadOutList(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 4 {
+		t.Fatal("incorrect actions count", len(actions))
+	}
+
+	send, ok := actions[0].Action.(ActionSendMsg)
+	if !ok || send.Mode != 3 || send.Msg.MsgType != MsgTypeInternal {
+		t.Fatal("incorrect send msg action")
+	}
+	if in := send.Msg.AsInternal(); !in.DstAddr.Equals(addr) || in.Amount.String() != "1.5" || in.Comment() != "test" {
+		t.Fatal("incorrect message of send msg action")
+	}
+
+	reserve, ok := actions[1].Action.(ActionReserveCurrency)
+	if !ok || reserve.Mode != 2 || reserve.Currency.Coins.String() != "0.1" {
+		t.Fatal("incorrect reserve action")
+	}
+
+	setCode, ok := actions[2].Action.(ActionSetCode)
+	if !ok || !bytes.Equal(setCode.NewCode.Hash(), code.Hash()) {
+		t.Fatal("incorrect set code action")
+	}
+
+	lib, ok := actions[3].Action.(ActionChangeLibrary)
+	if !ok || lib.Mode != 2 || !bytes.Equal(lib.LibRef.(LibRefHash).Hash, code.Hash()) {
+		t.Fatal("incorrect change library action")
+	}
+
+	list2, err := OutListToCell(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(list2.Hash(), list.Hash()) {
+		t.Fatal("incorrect serialization")
+	}
+
+	empty, err := LoadOutList(cell.BeginCell().EndCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty) != 0 {
+		t.Fatal("list should be empty")
+	}
+
+	_, err = LoadOutList(cell.BeginCell().MustStoreRef(cell.BeginCell().EndCell()).MustStoreUInt(0xDEADBEEF, 32).EndCell())
+	if err == nil {
+		t.Fatal("unknown action should not be parsed")
+	}
+}
+
+func TestLoadExtendedActions(t *testing.T) {
+	addr := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+
+	list := cell.BeginCell().MustStoreUInt(0x02, 8).MustStoreAddr(addr).
+		MustStoreRef(cell.BeginCell().MustStoreUInt(0x04, 8).MustStoreBoolBit(false).
+			MustStoreRef(cell.BeginCell().MustStoreUInt(0x03, 8).MustStoreAddr(addr).EndCell()).
+			EndCell()).
+		EndCell()
+
+	actions, err := LoadExtendedActions(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 3 {
+		t.Fatal("incorrect actions count", len(actions))
+	}
+
+	if a, ok := actions[0].Action.(ActionAddExtension); !ok || !a.Addr.Equals(addr) {
+		t.Fatal("incorrect add extension action")
+	}
+	if a, ok := actions[1].Action.(ActionSetSignatureAuthAllowed); !ok || a.Allowed {
+		t.Fatal("incorrect set signature allowed action")
+	}
+	if a, ok := actions[2].Action.(ActionDeleteExtension); !ok || !a.Addr.Equals(addr) {
+		t.Fatal("incorrect delete extension action")
+	}
+
+	list2, err := ExtendedActionsToCell(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(list2.Hash(), list.Hash()) {
+		t.Fatal("incorrect serialization")
+	}
+}
@@ -0,0 +1,140 @@
+package tlb
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// CurrencyCollectionExtra - extra of augmented dictionaries which is a sum of CurrencyCollection, used in OutMsgDescr
+type CurrencyCollectionExtra struct{}
+
+// ImportFeesExtra - extra of InMsgDescr
+type ImportFeesExtra struct{}
+
+// DepthBalanceExtra - extra of ShardAccounts
+type DepthBalanceExtra struct{}
+
+// MinLTExtra - extra of OutMsgQueue and DispatchQueue, minimal lt of the messages
+type MinLTExtra struct{}
+
+func (CurrencyCollectionExtra) SkipExtra(loader *cell.Slice) error {
+	var cc CurrencyCollection
+	return LoadFromCell(&cc, loader)
+}
+
+func (CurrencyCollectionExtra) AggregateExtra(left, right *cell.Slice) (*cell.Builder, error) {
+	var l, r CurrencyCollection
+	if err := LoadFromCell(&l, left); err != nil {
+		return nil, fmt.Errorf("failed to load left value: %w", err)
+	}
+	if err := LoadFromCell(&r, right); err != nil {
+		return nil, fmt.Errorf("failed to load right value: %w", err)
+	}
+
+	sum, err := l.Add(r)
+	if err != nil {
+		return nil, err
+	}
+	return toBuilder(sum)
+}
+
+func (CurrencyCollectionExtra) EmptyExtra() (*cell.Builder, error) {
+	return toBuilder(CurrencyCollection{})
+}
+
+func (ImportFeesExtra) SkipExtra(loader *cell.Slice) error {
+	var fees ImportFees
+	return LoadFromCell(&fees, loader)
+}
+
+func (ImportFeesExtra) AggregateExtra(left, right *cell.Slice) (*cell.Builder, error) {
+	var l, r ImportFees
+	if err := LoadFromCell(&l, left); err != nil {
+		return nil, fmt.Errorf("failed to load left fees: %w", err)
+	}
+	if err := LoadFromCell(&r, right); err != nil {
+		return nil, fmt.Errorf("failed to load right fees: %w", err)
+	}
+
+	value, err := l.ValueImported.Add(r.ValueImported)
+	if err != nil {
+		return nil, err
+	}
+
+	return toBuilder(ImportFees{
+		FeesCollected: FromNanoTON(new(big.Int).Add(l.FeesCollected.Nano(), r.FeesCollected.Nano())),
+		ValueImported: value,
+	})
+}
+
+func (ImportFeesExtra) EmptyExtra() (*cell.Builder, error) {
+	return toBuilder(ImportFees{})
+}
+
+func (DepthBalanceExtra) SkipExtra(loader *cell.Slice) error {
+	var info DepthBalanceInfo
+	return LoadFromCell(&info, loader)
+}
+
+func (DepthBalanceExtra) AggregateExtra(left, right *cell.Slice) (*cell.Builder, error) {
+	var l, r DepthBalanceInfo
+	if err := LoadFromCell(&l, left); err != nil {
+		return nil, fmt.Errorf("failed to load left balance: %w", err)
+	}
+	if err := LoadFromCell(&r, right); err != nil {
+		return nil, fmt.Errorf("failed to load right balance: %w", err)
+	}
+
+	balance, err := l.Currencies.Add(r.Currencies)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := l.Depth
+	if r.Depth > depth {
+		depth = r.Depth
+	}
+	return toBuilder(DepthBalanceInfo{
+		Depth:      depth,
+		Currencies: balance,
+	})
+}
+
+func (DepthBalanceExtra) EmptyExtra() (*cell.Builder, error) {
+	return toBuilder(DepthBalanceInfo{})
+}
+
+func (MinLTExtra) SkipExtra(loader *cell.Slice) error {
+	_, err := loader.LoadUInt(64)
+	return err
+}
+
+func (MinLTExtra) AggregateExtra(left, right *cell.Slice) (*cell.Builder, error) {
+	l, err := left.LoadUInt(64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load left lt: %w", err)
+	}
+	r, err := right.LoadUInt(64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load right lt: %w", err)
+	}
+
+	if r < l {
+		l = r
+	}
+	return cell.BeginCell().MustStoreUInt(l, 64), nil
+}
+
+func (MinLTExtra) EmptyExtra() (*cell.Builder, error) {
+	return cell.BeginCell().MustStoreUInt(0, 64), nil
+}
+
+func toBuilder(v any) (*cell.Builder, error) {
+	c, err := ToCell(v)
+	if err != nil {
+		return nil, err
+	}
+	return c.ToBuilder(), nil
+}
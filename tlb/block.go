@@ -3,6 +3,7 @@ package tlb
 import (
 	"bytes"
 	"fmt"
+	"sort"
 
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
@@ -59,11 +60,26 @@ type Block struct {
 	_           Magic       `tlb:"#11ef55aa"`
 	GlobalID    int32       `tlb:"## 32"`
 	BlockInfo   BlockHeader `tlb:"^"`
-	ValueFlow   *cell.Cell  `tlb:"^"`
+	ValueFlow   *ValueFlow  `tlb:"^"`
 	StateUpdate *cell.Cell  `tlb:"^"`
 	Extra       *BlockExtra `tlb:"^"`
 }
 
+// ValueFlow - values moved by the block, both value_flow and value_flow_v2 are unpacked into it
+type ValueFlow struct {
+	FromPrevBlock CurrencyCollection
+	ToNextBlock   CurrencyCollection
+	Imported      CurrencyCollection
+	Exported      CurrencyCollection
+	FeesCollected CurrencyCollection
+	// Burned - is set only for value_flow_v2
+	Burned       *CurrencyCollection
+	FeesImported CurrencyCollection
+	Recovered    CurrencyCollection
+	Created      CurrencyCollection
+	Minted       CurrencyCollection
+}
+
 type AllShardsInfo struct {
 	ShardHashes *cell.Dictionary `tlb:"dict 32"`
 }
@@ -118,6 +134,91 @@ type BlkPrevInfo struct {
 	Prev2 *ExtBlkRef
 }
 
+func (v *ValueFlow) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(32)
+	if err != nil {
+		return err
+	}
+	if tag != 0xb8e48dfb && tag != 0x3ebf98b7 {
+		return fmt.Errorf("unknown value flow tag %x", tag)
+	}
+
+	// parts of the value flow can be pruned in proofs, we keep them empty
+	loadPart := func(values ...*CurrencyCollection) error {
+		ref, err := loader.LoadRefCell()
+		if err != nil {
+			return err
+		}
+		if ref.GetType() == cell.PrunedCellType {
+			return nil
+		}
+
+		s := ref.BeginParse()
+		for _, cc := range values {
+			if err = LoadFromCell(cc, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err = loadPart(&v.FromPrevBlock, &v.ToNextBlock, &v.Imported, &v.Exported); err != nil {
+		return fmt.Errorf("failed to load first part: %w", err)
+	}
+	if err = LoadFromCell(&v.FeesCollected, loader); err != nil {
+		return fmt.Errorf("failed to load fees collected: %w", err)
+	}
+	if tag == 0x3ebf98b7 {
+		v.Burned = &CurrencyCollection{}
+		if err = LoadFromCell(v.Burned, loader); err != nil {
+			return fmt.Errorf("failed to load burned: %w", err)
+		}
+	}
+	if err = loadPart(&v.FeesImported, &v.Recovered, &v.Created, &v.Minted); err != nil {
+		return fmt.Errorf("failed to load second part: %w", err)
+	}
+	return nil
+}
+
+func (v *ValueFlow) ToCell() (*cell.Cell, error) {
+	storePart := func(b *cell.Builder, values ...CurrencyCollection) error {
+		for _, cc := range values {
+			c, err := ToCell(cc)
+			if err != nil {
+				return err
+			}
+			if err = b.StoreBuilder(c.ToBuilder()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	first, second := cell.BeginCell(), cell.BeginCell()
+	if err := storePart(first, v.FromPrevBlock, v.ToNextBlock, v.Imported, v.Exported); err != nil {
+		return nil, fmt.Errorf("failed to store first part: %w", err)
+	}
+	if err := storePart(second, v.FeesImported, v.Recovered, v.Created, v.Minted); err != nil {
+		return nil, fmt.Errorf("failed to store second part: %w", err)
+	}
+
+	b := cell.BeginCell()
+	values := []CurrencyCollection{v.FeesCollected}
+	if v.Burned != nil {
+		b.MustStoreUInt(0x3ebf98b7, 32)
+		values = append(values, *v.Burned)
+	} else {
+		b.MustStoreUInt(0xb8e48dfb, 32)
+	}
+	b.MustStoreRef(first.EndCell())
+
+	if err := storePart(b, values...); err != nil {
+		return nil, fmt.Errorf("failed to store fees: %w", err)
+	}
+	b.MustStoreRef(second.EndCell())
+	return b.EndCell(), nil
+}
+
 func (h *BlockInfo) Equals(h2 *BlockInfo) bool {
 	return h.Shard == h2.Shard && h.SeqNo == h2.SeqNo && h.Workchain == h2.Workchain &&
 		bytes.Equal(h.FileHash, h2.FileHash) && bytes.Equal(h.RootHash, h2.RootHash)
@@ -303,3 +404,105 @@ func (h *BlockHeader) GetParentBlocks() ([]*BlockInfo, error) {
 	})
 	return parents, nil
 }
+
+// LoadInMsgDescr - parses descriptions of the messages imported by the block
+func (b *Block) LoadInMsgDescr() (*InMsgDescr, error) {
+	if b.Extra == nil || b.Extra.InMsgDesc == nil {
+		return nil, fmt.Errorf("block has no extra with in msg descr")
+	}
+	if b.Extra.InMsgDesc.GetType() == cell.PrunedCellType {
+		return nil, fmt.Errorf("in msg descr is pruned")
+	}
+
+	var descr InMsgDescr
+	if err := LoadFromCell(&descr, b.Extra.InMsgDesc.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse in msg descr: %w", err)
+	}
+	return &descr, nil
+}
+
+// LoadOutMsgDescr - parses descriptions of the messages exported by the block
+func (b *Block) LoadOutMsgDescr() (*OutMsgDescr, error) {
+	if b.Extra == nil || b.Extra.OutMsgDesc == nil {
+		return nil, fmt.Errorf("block has no extra with out msg descr")
+	}
+	if b.Extra.OutMsgDesc.GetType() == cell.PrunedCellType {
+		return nil, fmt.Errorf("out msg descr is pruned")
+	}
+
+	var descr OutMsgDescr
+	if err := LoadFromCell(&descr, b.Extra.OutMsgDesc.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse out msg descr: %w", err)
+	}
+	return &descr, nil
+}
+
+// ForEachInMsg - iterates over messages imported by the block, stops on the first error returned by fn
+func (b *Block) ForEachInMsg(fn func(hash []byte, msg *InMsg, fees *ImportFees) error) error {
+	descr, err := b.LoadInMsgDescr()
+	if err != nil {
+		return err
+	}
+	return descr.ForEach(fn)
+}
+
+// ForEachOutMsg - iterates over messages exported by the block, stops on the first error returned by fn
+func (b *Block) ForEachOutMsg(fn func(hash []byte, msg *OutMsg, value *CurrencyCollection) error) error {
+	descr, err := b.LoadOutMsgDescr()
+	if err != nil {
+		return err
+	}
+	return descr.ForEach(fn)
+}
+
+// LoadTransactions - parses all transactions of the block, sorted by logical time
+func (b *Block) LoadTransactions() ([]*Transaction, error) {
+	if b.Extra == nil || b.Extra.ShardAccountBlocks == nil {
+		return nil, fmt.Errorf("block has no extra with account blocks")
+	}
+	if b.Extra.ShardAccountBlocks.GetType() == cell.PrunedCellType {
+		return nil, fmt.Errorf("account blocks are pruned")
+	}
+
+	shardAccounts, err := b.Extra.ShardAccountBlocks.BeginParse().LoadAugmentedDict(256, CurrencyCollectionExtra{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse account blocks: %w", err)
+	}
+
+	accounts, err := shardAccounts.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load account blocks: %w", err)
+	}
+
+	var list []*Transaction
+	for _, acc := range accounts {
+		var accBlock AccountBlock
+		if err = LoadFromCell(&accBlock, acc.Value); err != nil {
+			return nil, fmt.Errorf("failed to parse account block: %w", err)
+		}
+
+		txs, err := accBlock.Transactions.AsCell().AsAugmentedDict(64, CurrencyCollectionExtra{}).LoadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load account %x transactions: %w", accBlock.Addr, err)
+		}
+
+		for _, kv := range txs {
+			txCell, err := kv.Value.LoadRefCell()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load transaction ref: %w", err)
+			}
+
+			var tx Transaction
+			if err = LoadFromCell(&tx, txCell.BeginParse()); err != nil {
+				return nil, fmt.Errorf("failed to parse transaction of account %x: %w", accBlock.Addr, err)
+			}
+			tx.Hash = txCell.Hash()
+			list = append(list, &tx)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LT < list[j].LT
+	})
+	return list, nil
+}
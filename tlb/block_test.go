@@ -1,6 +1,7 @@
 package tlb
 
 import (
+	"bytes"
 	"encoding/hex"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 	"testing"
@@ -21,6 +22,39 @@ func TestBlockMaster(t *testing.T) {
 	}
 
 	println(len(parents))
+
+	if vf := block.ValueFlow; vf.Burned != nil || vf.FeesCollected.Coins.String() != "2.7" ||
+		vf.Created.Coins.String() != "1.7" || vf.Minted.Coins.Nano().Sign() != 0 {
+		t.Fatal("incorrect value flow")
+	}
+
+	vf, err := ToCell(block.ValueFlow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vfOrig, err := c.PeekRef(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(vf.Hash(), vfOrig.Hash()) {
+		t.Fatal("incorrect value flow serialization")
+	}
+
+	txs, err := block.LoadTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) == 0 {
+		t.Fatal("no transactions in block")
+	}
+	for i, tx := range txs {
+		if len(tx.Hash) != 32 {
+			t.Fatal("no tx hash")
+		}
+		if i > 0 && txs[i-1].LT > tx.LT {
+			t.Fatal("transactions are not sorted")
+		}
+	}
 }
 
 func TestBlockNotMaster(t *testing.T) {
@@ -39,3 +73,34 @@ func TestBlockNotMaster(t *testing.T) {
 
 	println(len(parents))
 }
+
+func TestValueFlow_V2(t *testing.T) {
+	cc := func(ton string) *cell.Builder {
+		return cell.BeginCell().MustStoreBigCoins(MustFromTON(ton).Nano()).MustStoreDict(nil)
+	}
+
+	c := cell.BeginCell().MustStoreUInt(0x3ebf98b7, 32).
+		MustStoreRef(cell.BeginCell().MustStoreBuilder(cc("100")).MustStoreBuilder(cc("101")).
+			MustStoreBuilder(cc("2")).MustStoreBuilder(cc("1")).EndCell()).
+		MustStoreBuilder(cc("0.5")).MustStoreBuilder(cc("0.25")).
+		MustStoreRef(cell.BeginCell().MustStoreBuilder(cc("0.1")).MustStoreBuilder(cc("0")).
+			MustStoreBuilder(cc("1.7")).MustStoreBuilder(cc("0")).EndCell()).
+		EndCell()
+
+	var vf ValueFlow
+	if err := LoadFromCell(&vf, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if vf.Burned == nil || vf.Burned.Coins.String() != "0.25" || vf.FeesCollected.Coins.String() != "0.5" ||
+		vf.ToNextBlock.Coins.String() != "101" || vf.FeesImported.Coins.String() != "0.1" || vf.Created.Coins.String() != "1.7" {
+		t.Fatal("incorrect value flow")
+	}
+
+	c2, err := ToCell(&vf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c2.Hash(), c.Hash()) {
+		t.Fatal("incorrect serialization")
+	}
+}
@@ -1,6 +1,13 @@
 package tlb
 
-import "github.com/xssnick/tonutils-go/tvm/cell"
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
 
 func init() {
 	Register(ValidatorSet{})
@@ -13,19 +20,31 @@ func init() {
 	Register(ConsensusConfigV2{})
 	Register(ConsensusConfigV3{})
 	Register(ConsensusConfigV4{})
+
+	Register(WorkchainDescrV1{})
+	Register(WorkchainDescrV2{})
+	Register(WorkchainFormatBasic{})
+	Register(WorkchainFormatExt{})
+
+	Register(BlockLimitsV1{})
+	Register(BlockLimitsV2{})
+
+	Register(JettonBridgeParamsV0{})
+	Register(JettonBridgeParamsV1{})
 }
 
 type ValidatorSetAny struct {
 	Validators any `tlb:"[ValidatorSet,ValidatorSetExt]"`
 }
 
+// ValidatorSet - list of validators is not empty Hashmap stored inline, unlike HashmapE of ValidatorSetExt
 type ValidatorSet struct {
 	_          Magic            `tlb:"#11"`
 	UTimeSince uint32           `tlb:"## 32"`
 	UTimeUntil uint32           `tlb:"## 32"`
 	Total      uint16           `tlb:"## 16"`
 	Main       uint16           `tlb:"## 16"`
-	List       *cell.Dictionary `tlb:"dict 16"`
+	List       *cell.Dictionary `tlb:"dict inline 16"`
 }
 
 type ValidatorSetExt struct {
@@ -138,3 +157,615 @@ type ConsensusConfigV4 struct {
 	ProtoVersion          uint16 `tlb:"## 16"`
 	CatchainMaxBlocksCoff uint32 `tlb:"## 32"`
 }
+
+// StoragePrices - config param 18 dictionary value, prices are in nanotons per 2^16 units per second
+type StoragePrices struct {
+	_             Magic  `tlb:"#cc"`
+	UTimeSince    uint32 `tlb:"## 32"`
+	BitPricePS    uint64 `tlb:"## 64"`
+	CellPricePS   uint64 `tlb:"## 64"`
+	McBitPricePS  uint64 `tlb:"## 64"`
+	McCellPricePS uint64 `tlb:"## 64"`
+}
+
+// GasLimitsPrices - config params 20 (masterchain) and 21 (basechain),
+// all scheme variants (gas_prices, gas_prices_ext, gas_flat_pfx) are unpacked into the one struct
+type GasLimitsPrices struct {
+	FlatGasLimit uint64
+	FlatGasPrice uint64
+	// GasPrice - price of gas unit in nanotons multiplied by 2^16
+	GasPrice        uint64
+	GasLimit        uint64
+	SpecialGasLimit uint64
+	GasCredit       uint64
+	BlockGasLimit   uint64
+	FreezeDueLimit  uint64
+	DeleteDueLimit  uint64
+}
+
+// MsgForwardPrices - config params 24 (masterchain) and 25 (basechain)
+type MsgForwardPrices struct {
+	_              Magic  `tlb:"#ea"`
+	LumpPrice      uint64 `tlb:"## 64"`
+	BitPrice       uint64 `tlb:"## 64"`
+	CellPrice      uint64 `tlb:"## 64"`
+	IHRPriceFactor uint32 `tlb:"## 32"`
+	FirstFrac      uint16 `tlb:"## 16"`
+	NextFrac       uint16 `tlb:"## 16"`
+}
+
+func (g *GasLimitsPrices) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(8)
+	if err != nil {
+		return err
+	}
+
+	if tag == 0xd1 {
+		if g.FlatGasLimit, err = loader.LoadUInt(64); err != nil {
+			return err
+		}
+		if g.FlatGasPrice, err = loader.LoadUInt(64); err != nil {
+			return err
+		}
+		if tag, err = loader.LoadUInt(8); err != nil {
+			return err
+		}
+	}
+
+	var fields []*uint64
+	switch tag {
+	case 0xdd:
+		fields = []*uint64{&g.GasPrice, &g.GasLimit, &g.GasCredit, &g.BlockGasLimit, &g.FreezeDueLimit, &g.DeleteDueLimit}
+	case 0xde:
+		fields = []*uint64{&g.GasPrice, &g.GasLimit, &g.SpecialGasLimit, &g.GasCredit, &g.BlockGasLimit, &g.FreezeDueLimit, &g.DeleteDueLimit}
+	default:
+		return fmt.Errorf("unknown gas limits and prices tag %x", tag)
+	}
+
+	for _, f := range fields {
+		if *f, err = loader.LoadUInt(64); err != nil {
+			return err
+		}
+	}
+
+	if tag == 0xdd {
+		g.SpecialGasLimit = g.GasLimit
+	}
+	return nil
+}
+
+func (g GasLimitsPrices) ToCell() (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if g.FlatGasLimit > 0 || g.FlatGasPrice > 0 {
+		b.MustStoreUInt(0xd1, 8).MustStoreUInt(g.FlatGasLimit, 64).MustStoreUInt(g.FlatGasPrice, 64)
+	}
+
+	b.MustStoreUInt(0xde, 8)
+	for _, f := range []uint64{g.GasPrice, g.GasLimit, g.SpecialGasLimit, g.GasCredit, g.BlockGasLimit, g.FreezeDueLimit, g.DeleteDueLimit} {
+		b.MustStoreUInt(f, 64)
+	}
+	return b.EndCell(), nil
+}
+
+// CalcGasFee - price of the gas amount in nanotons
+func (g *GasLimitsPrices) CalcGasFee(gas uint64) *big.Int {
+	if gas <= g.FlatGasLimit {
+		return new(big.Int).SetUint64(g.FlatGasPrice)
+	}
+
+	res := new(big.Int).SetUint64(gas - g.FlatGasLimit)
+	res.Mul(res, new(big.Int).SetUint64(g.GasPrice))
+	res = divCeil16(res)
+	return res.Add(res, new(big.Int).SetUint64(g.FlatGasPrice))
+}
+
+// CalcGasBought - amount of gas which can be bought for the given amount of nanotons, limited by GasLimit
+func (g *GasLimitsPrices) CalcGasBought(amount *big.Int) uint64 {
+	if amount.Sign() <= 0 || amount.Cmp(new(big.Int).SetUint64(g.FlatGasPrice)) < 0 {
+		return 0
+	}
+	if g.GasPrice == 0 {
+		return g.GasLimit
+	}
+
+	res := new(big.Int).Sub(amount, new(big.Int).SetUint64(g.FlatGasPrice))
+	res.Lsh(res, 16)
+	res.Div(res, new(big.Int).SetUint64(g.GasPrice))
+	res.Add(res, new(big.Int).SetUint64(g.FlatGasLimit))
+	if !res.IsUint64() || res.Uint64() > g.GasLimit {
+		return g.GasLimit
+	}
+	return res.Uint64()
+}
+
+// CalcFwdFee - forward fee for the message, cells and bits should be counted without the root cell
+func (m *MsgForwardPrices) CalcFwdFee(cells, bits uint64) *big.Int {
+	res := new(big.Int).Mul(new(big.Int).SetUint64(m.BitPrice), new(big.Int).SetUint64(bits))
+	res.Add(res, new(big.Int).Mul(new(big.Int).SetUint64(m.CellPrice), new(big.Int).SetUint64(cells)))
+	res = divCeil16(res)
+	return res.Add(res, new(big.Int).SetUint64(m.LumpPrice))
+}
+
+// CalcIHRFee - fee for the instant hypercube routing, charged only when ihr is not disabled in message
+func (m *MsgForwardPrices) CalcIHRFee(fwdFee *big.Int) *big.Int {
+	res := new(big.Int).Mul(fwdFee, new(big.Int).SetUint64(uint64(m.IHRPriceFactor)))
+	return res.Rsh(res, 16)
+}
+
+// CalcFirstFrac - part of the forward fee which is collected by the validators of the source shard
+func (m *MsgForwardPrices) CalcFirstFrac(fwdFee *big.Int) *big.Int {
+	res := new(big.Int).Mul(fwdFee, new(big.Int).SetUint64(uint64(m.FirstFrac)))
+	return res.Rsh(res, 16)
+}
+
+// CalcStorageFee - storage fee for the period from lastPaid to now, prices should be sorted by UTimeSince
+func CalcStorageFee(prices []StoragePrices, masterchain bool, cells, bits uint64, lastPaid, now uint32) *big.Int {
+	total := new(big.Int)
+	if now <= lastPaid {
+		return total
+	}
+
+	for i, p := range prices {
+		from, to := p.UTimeSince, now
+		if i+1 < len(prices) && prices[i+1].UTimeSince < to {
+			to = prices[i+1].UTimeSince
+		}
+		if from < lastPaid {
+			from = lastPaid
+		}
+		if to <= from {
+			continue
+		}
+
+		bitPrice, cellPrice := p.BitPricePS, p.CellPricePS
+		if masterchain {
+			bitPrice, cellPrice = p.McBitPricePS, p.McCellPricePS
+		}
+
+		v := new(big.Int).Mul(new(big.Int).SetUint64(bits), new(big.Int).SetUint64(bitPrice))
+		v.Add(v, new(big.Int).Mul(new(big.Int).SetUint64(cells), new(big.Int).SetUint64(cellPrice)))
+		v.Mul(v, new(big.Int).SetUint64(uint64(to-from)))
+		total.Add(total, v)
+	}
+	return divCeil16(total)
+}
+
+func divCeil16(x *big.Int) *big.Int {
+	res := new(big.Int).Add(x, big.NewInt(1<<16-1))
+	return res.Rsh(res, 16)
+}
+
+// ConfigAddress - config params 0-4, addresses of config, elector, minter, fee collector
+// and dns root smart contracts in masterchain
+type ConfigAddress struct {
+	Addr []byte `tlb:"bits 256"`
+}
+
+// BurningConfig - config param 5
+type BurningConfig struct {
+	_ Magic `tlb:"#01"`
+	// BlackholeAddr - masterchain address which burns all incoming value, nil if not set
+	BlackholeAddr []byte `tlb:"maybe bits 256"`
+	FeeBurnNum    uint32 `tlb:"## 32"`
+	FeeBurnDenom  uint32 `tlb:"## 32"`
+}
+
+// MintPrices - config param 6
+type MintPrices struct {
+	MintNewPrice Coins `tlb:"."`
+	MintAddPrice Coins `tlb:"."`
+}
+
+// ToMint - config param 7, extra currencies to mint
+type ToMint struct {
+	Currencies map[string]*big.Int `tlb:"dict 32 -> var uint 32"`
+}
+
+// ConfigParamsList - config params 9 and 10, lists of mandatory and critical params
+type ConfigParamsList struct {
+	Params []int32
+}
+
+// ConfigVotingSetup - config param 11
+type ConfigVotingSetup struct {
+	_              Magic                `tlb:"#91"`
+	NormalParams   *ConfigProposalSetup `tlb:"^"`
+	CriticalParams *ConfigProposalSetup `tlb:"^"`
+}
+
+type ConfigProposalSetup struct {
+	_            Magic  `tlb:"#36"`
+	MinTotRounds uint8  `tlb:"## 8"`
+	MaxTotRounds uint8  `tlb:"## 8"`
+	MinWins      uint8  `tlb:"## 8"`
+	MaxLosses    uint8  `tlb:"## 8"`
+	MinStoreSec  uint32 `tlb:"## 32"`
+	MaxStoreSec  uint32 `tlb:"## 32"`
+	BitPrice     uint32 `tlb:"## 32"`
+	CellPrice    uint32 `tlb:"## 32"`
+}
+
+// Workchains - config param 12, key is a workchain id
+type Workchains struct {
+	List map[string]WorkchainDescr `tlb:"dict 32 -> ."`
+}
+
+type WorkchainDescr struct {
+	Descr any `tlb:"[WorkchainDescrV1,WorkchainDescrV2]"`
+}
+
+type WorkchainDescrV1 struct {
+	_                 Magic  `tlb:"#a6"`
+	EnabledSince      uint32 `tlb:"## 32"`
+	ActualMinSplit    uint8  `tlb:"## 8"`
+	MinSplit          uint8  `tlb:"## 8"`
+	MaxSplit          uint8  `tlb:"## 8"`
+	Basic             bool   `tlb:"bool"`
+	Active            bool   `tlb:"bool"`
+	AcceptMsgs        bool   `tlb:"bool"`
+	Flags             uint16 `tlb:"## 13"`
+	ZeroStateRootHash []byte `tlb:"bits 256"`
+	ZeroStateFileHash []byte `tlb:"bits 256"`
+	Version           uint32 `tlb:"## 32"`
+	Format            any    `tlb:"[WorkchainFormatBasic,WorkchainFormatExt]"`
+}
+
+type WorkchainDescrV2 struct {
+	_                 Magic                      `tlb:"#a7"`
+	EnabledSince      uint32                     `tlb:"## 32"`
+	MonitorMinSplit   uint8                      `tlb:"## 8"`
+	MinSplit          uint8                      `tlb:"## 8"`
+	MaxSplit          uint8                      `tlb:"## 8"`
+	Basic             bool                       `tlb:"bool"`
+	Active            bool                       `tlb:"bool"`
+	AcceptMsgs        bool                       `tlb:"bool"`
+	Flags             uint16                     `tlb:"## 13"`
+	ZeroStateRootHash []byte                     `tlb:"bits 256"`
+	ZeroStateFileHash []byte                     `tlb:"bits 256"`
+	Version           uint32                     `tlb:"## 32"`
+	Format            any                        `tlb:"[WorkchainFormatBasic,WorkchainFormatExt]"`
+	SplitMergeTimings WorkchainSplitMergeTimings `tlb:"."`
+	// PersistentStateSplitDepth - depth of shards, persistent state of the workchain is split into, 63 max
+	PersistentStateSplitDepth uint8 `tlb:"## 8"`
+}
+
+type WorkchainFormatBasic struct {
+	_         Magic  `tlb:"#1"`
+	VMVersion int32  `tlb:"## 32"`
+	VMMode    uint64 `tlb:"## 64"`
+}
+
+type WorkchainFormatExt struct {
+	_               Magic  `tlb:"#0"`
+	MinAddrLen      uint16 `tlb:"## 12"`
+	MaxAddrLen      uint16 `tlb:"## 12"`
+	AddrLenStep     uint16 `tlb:"## 12"`
+	WorkchainTypeID uint32 `tlb:"## 32"`
+}
+
+type WorkchainSplitMergeTimings struct {
+	_                     Magic  `tlb:"$0000"`
+	SplitMergeDelay       uint32 `tlb:"## 32"`
+	SplitMergeInterval    uint32 `tlb:"## 32"`
+	MinSplitMergeInterval uint32 `tlb:"## 32"`
+	MaxSplitMergeDelay    uint32 `tlb:"## 32"`
+}
+
+// ComplaintPricing - config param 13
+type ComplaintPricing struct {
+	_         Magic `tlb:"#1a"`
+	Deposit   Coins `tlb:"."`
+	BitPrice  Coins `tlb:"."`
+	CellPrice Coins `tlb:"."`
+}
+
+// BlockCreateFees - config param 14
+type BlockCreateFees struct {
+	_                   Magic `tlb:"#6b"`
+	MasterchainBlockFee Coins `tlb:"."`
+	BasechainBlockFee   Coins `tlb:"."`
+}
+
+// ElectionsTimings - config param 15
+type ElectionsTimings struct {
+	ValidatorsElectedFor uint32 `tlb:"## 32"`
+	ElectionsStartBefore uint32 `tlb:"## 32"`
+	ElectionsEndBefore   uint32 `tlb:"## 32"`
+	StakeHeldFor         uint32 `tlb:"## 32"`
+}
+
+// ValidatorsCount - config param 16
+type ValidatorsCount struct {
+	MaxValidators     uint16 `tlb:"## 16"`
+	MaxMainValidators uint16 `tlb:"## 16"`
+	MinValidators     uint16 `tlb:"## 16"`
+}
+
+// StakeLimits - config param 17
+type StakeLimits struct {
+	MinStake       Coins  `tlb:"."`
+	MaxStake       Coins  `tlb:"."`
+	MinTotalStake  Coins  `tlb:"."`
+	MaxStakeFactor uint32 `tlb:"## 32"`
+}
+
+// StoragePricesList - config param 18, key is a utime since which prices are applied
+type StoragePricesList struct {
+	List map[string]StoragePrices `tlb:"dict inline 32 -> ."`
+}
+
+// GlobalID - config param 19
+type GlobalID struct {
+	GlobalID int32 `tlb:"## 32"`
+}
+
+// BlockLimits - config params 22 (masterchain) and 23 (basechain)
+type BlockLimits struct {
+	Limits any `tlb:"[BlockLimitsV1,BlockLimitsV2]"`
+}
+
+type BlockLimitsV1 struct {
+	_       Magic       `tlb:"#5d"`
+	Bytes   ParamLimits `tlb:"."`
+	Gas     ParamLimits `tlb:"."`
+	LTDelta ParamLimits `tlb:"."`
+}
+
+type BlockLimitsV2 struct {
+	_                Magic                  `tlb:"#5e"`
+	Bytes            ParamLimits            `tlb:"."`
+	Gas              ParamLimits            `tlb:"."`
+	LTDelta          ParamLimits            `tlb:"."`
+	CollatedData     ParamLimits            `tlb:"."`
+	ImportedMsgQueue ImportedMsgQueueLimits `tlb:"."`
+}
+
+type ParamLimits struct {
+	_         Magic  `tlb:"#c3"`
+	Underload uint32 `tlb:"## 32"`
+	SoftLimit uint32 `tlb:"## 32"`
+	HardLimit uint32 `tlb:"## 32"`
+}
+
+type ImportedMsgQueueLimits struct {
+	_        Magic  `tlb:"#d3"`
+	MaxBytes uint32 `tlb:"## 32"`
+	MaxMsgs  uint32 `tlb:"## 32"`
+}
+
+// FundamentalSmcAddresses - config param 31, masterchain addresses of contracts which are not charged for gas
+type FundamentalSmcAddresses struct {
+	Addresses [][]byte
+}
+
+// ValidatorSignedTempKeys - config param 39
+type ValidatorSignedTempKeys struct {
+	Keys *cell.Dictionary `tlb:"dict 256"`
+}
+
+// MisbehaviourPunishmentConfig - config param 40
+type MisbehaviourPunishmentConfig struct {
+	_                        Magic  `tlb:"#01"`
+	DefaultFlatFine          Coins  `tlb:"."`
+	DefaultProportionalFine  uint32 `tlb:"## 32"`
+	SeverityFlatMult         uint16 `tlb:"## 16"`
+	SeverityProportionalMult uint16 `tlb:"## 16"`
+	UnpunishableInterval     uint16 `tlb:"## 16"`
+	LongInterval             uint16 `tlb:"## 16"`
+	LongFlatMult             uint16 `tlb:"## 16"`
+	LongProportionalMult     uint16 `tlb:"## 16"`
+	MediumInterval           uint16 `tlb:"## 16"`
+	MediumFlatMult           uint16 `tlb:"## 16"`
+	MediumProportionalMult   uint16 `tlb:"## 16"`
+}
+
+// SizeLimitsConfig - config param 43, fields which are not present in the loaded version are zero
+type SizeLimitsConfig struct {
+	Version                 uint8
+	MaxMsgBits              uint32
+	MaxMsgCells             uint32
+	MaxLibraryCells         uint32
+	MaxVMDataDepth          uint16
+	MaxExtMsgSize           uint32
+	MaxExtMsgDepth          uint16
+	MaxAccStateCells        uint32
+	MaxAccStateBits         uint32
+	MaxAccPublicLibraries   uint32
+	DeferOutQueueSizeLimit  uint32
+	MaxMsgExtraCurrencies   uint32
+	MaxAccFixedPrefixLength uint8
+}
+
+// SuspendedAddressList - config param 44
+type SuspendedAddressList struct {
+	Addresses      []*address.Address
+	SuspendedUntil uint32
+}
+
+// PrecompiledContractsConfig - config param 45, key is a code hash
+type PrecompiledContractsConfig struct {
+	_    Magic                     `tlb:"#c0"`
+	List map[string]PrecompiledSmc `tlb:"dict 256 -> ."`
+}
+
+type PrecompiledSmc struct {
+	_        Magic  `tlb:"#b0"`
+	GasUsage uint64 `tlb:"## 64"`
+}
+
+// OracleBridgeParams - config params 71-73, bridges of ETH, BNB and Polygon
+type OracleBridgeParams struct {
+	BridgeAddress         []byte              `tlb:"bits 256"`
+	OracleMultisigAddress []byte              `tlb:"bits 256"`
+	Oracles               map[string]*big.Int `tlb:"dict 256 -> ## 256"`
+	ExternalChainAddress  []byte              `tlb:"bits 256"`
+}
+
+// JettonBridgeParams - config params 79, 81 and 82, jetton bridges of ETH, BNB and Polygon
+type JettonBridgeParams struct {
+	Params any `tlb:"[JettonBridgeParamsV0,JettonBridgeParamsV1]"`
+}
+
+type JettonBridgeParamsV0 struct {
+	_              Magic               `tlb:"#00"`
+	BridgeAddress  []byte              `tlb:"bits 256"`
+	OraclesAddress []byte              `tlb:"bits 256"`
+	Oracles        map[string]*big.Int `tlb:"dict 256 -> ## 256"`
+	StateFlags     uint8               `tlb:"## 8"`
+	BurnBridgeFee  Coins               `tlb:"."`
+}
+
+type JettonBridgeParamsV1 struct {
+	_                    Magic               `tlb:"#01"`
+	BridgeAddress        []byte              `tlb:"bits 256"`
+	OraclesAddress       []byte              `tlb:"bits 256"`
+	Oracles              map[string]*big.Int `tlb:"dict 256 -> ## 256"`
+	StateFlags           uint8               `tlb:"## 8"`
+	Prices               *JettonBridgePrices `tlb:"^"`
+	ExternalChainAddress []byte              `tlb:"bits 256"`
+}
+
+type JettonBridgePrices struct {
+	BridgeBurnFee           Coins `tlb:"."`
+	BridgeMintFee           Coins `tlb:"."`
+	WalletMinTonsForStorage Coins `tlb:"."`
+	WalletGasConsumption    Coins `tlb:"."`
+	MinterMinTonsForStorage Coins `tlb:"."`
+	DiscoverGasConsumption  Coins `tlb:"."`
+}
+
+// Address - masterchain address of contract
+func (c *ConfigAddress) Address() *address.Address {
+	return address.NewAddress(0, 255, c.Addr)
+}
+
+func (c *ConfigParamsList) LoadFromCell(loader *cell.Slice) error {
+	dict, err := loader.ToDict(32)
+	if err != nil {
+		return err
+	}
+
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return err
+	}
+
+	c.Params = make([]int32, 0, len(kvs))
+	for _, kv := range kvs {
+		id, err := kv.Key.LoadInt(32)
+		if err != nil {
+			return err
+		}
+		c.Params = append(c.Params, int32(id))
+	}
+	sort.Slice(c.Params, func(i, j int) bool {
+		return c.Params[i] < c.Params[j]
+	})
+	return nil
+}
+
+func (f *FundamentalSmcAddresses) LoadFromCell(loader *cell.Slice) error {
+	dict, err := loader.LoadDict(256)
+	if err != nil {
+		return err
+	}
+
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return err
+	}
+
+	f.Addresses = make([][]byte, 0, len(kvs))
+	for _, kv := range kvs {
+		addr, err := kv.Key.LoadSlice(256)
+		if err != nil {
+			return err
+		}
+		f.Addresses = append(f.Addresses, addr)
+	}
+	return nil
+}
+
+func (s *SizeLimitsConfig) LoadFromCell(loader *cell.Slice) error {
+	ver, err := loader.LoadUInt(8)
+	if err != nil {
+		return err
+	}
+	if ver != 0x01 && ver != 0x02 {
+		return fmt.Errorf("unknown size limits config tag %x", ver)
+	}
+	s.Version = uint8(ver)
+
+	type field struct {
+		sz  uint
+		set func(v uint64)
+	}
+	fields := []field{
+		{32, func(v uint64) { s.MaxMsgBits = uint32(v) }},
+		{32, func(v uint64) { s.MaxMsgCells = uint32(v) }},
+		{32, func(v uint64) { s.MaxLibraryCells = uint32(v) }},
+		{16, func(v uint64) { s.MaxVMDataDepth = uint16(v) }},
+		{32, func(v uint64) { s.MaxExtMsgSize = uint32(v) }},
+		{16, func(v uint64) { s.MaxExtMsgDepth = uint16(v) }},
+	}
+	if ver == 0x02 {
+		fields = append(fields,
+			field{32, func(v uint64) { s.MaxAccStateCells = uint32(v) }},
+			field{32, func(v uint64) { s.MaxAccStateBits = uint32(v) }},
+			field{32, func(v uint64) { s.MaxAccPublicLibraries = uint32(v) }},
+			field{32, func(v uint64) { s.DeferOutQueueSizeLimit = uint32(v) }},
+			field{32, func(v uint64) { s.MaxMsgExtraCurrencies = uint32(v) }},
+			field{8, func(v uint64) { s.MaxAccFixedPrefixLength = uint8(v) }},
+		)
+	}
+
+	for i, f := range fields {
+		if i >= 6 && loader.BitsLeft() < f.sz {
+			// older versions of v2 have fewer fields
+			break
+		}
+
+		v, err := loader.LoadUInt(f.sz)
+		if err != nil {
+			return err
+		}
+		f.set(v)
+	}
+	return nil
+}
+
+func (s *SuspendedAddressList) LoadFromCell(loader *cell.Slice) error {
+	if tag, err := loader.LoadUInt(8); err != nil || tag != 0x00 {
+		return fmt.Errorf("invalid suspended address list tag")
+	}
+
+	dict, err := loader.LoadDict(288)
+	if err != nil {
+		return err
+	}
+
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return err
+	}
+
+	s.Addresses = make([]*address.Address, 0, len(kvs))
+	for _, kv := range kvs {
+		wc, err := kv.Key.LoadInt(32)
+		if err != nil {
+			return err
+		}
+		data, err := kv.Key.LoadSlice(256)
+		if err != nil {
+			return err
+		}
+		s.Addresses = append(s.Addresses, address.NewAddress(0, byte(wc), data))
+	}
+
+	until, err := loader.LoadUInt(32)
+	if err != nil {
+		return err
+	}
+	s.SuspendedUntil = uint32(until)
+	return nil
+}
@@ -0,0 +1,114 @@
+package tlb
+
+import (
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestGasLimitsPrices_LoadFromCell(t *testing.T) {
+	// basechain gas prices of mainnet
+	c := cell.BeginCell().
+		MustStoreUInt(0xd1, 8).MustStoreUInt(100, 64).MustStoreUInt(40000, 64).
+		MustStoreUInt(0xde, 8).
+		MustStoreUInt(26214400, 64).
+		MustStoreUInt(1000000, 64).
+		MustStoreUInt(1000000, 64).
+		MustStoreUInt(10000, 64).
+		MustStoreUInt(10000000, 64).
+		MustStoreUInt(100000000, 64).
+		MustStoreUInt(1000000000, 64).
+		EndCell()
+
+	var g GasLimitsPrices
+	if err := LoadFromCell(&g, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	if g.FlatGasLimit != 100 || g.FlatGasPrice != 40000 || g.GasPrice != 26214400 || g.GasLimit != 1000000 ||
+		g.SpecialGasLimit != 1000000 || g.GasCredit != 10000 || g.BlockGasLimit != 10000000 ||
+		g.FreezeDueLimit != 100000000 || g.DeleteDueLimit != 1000000000 {
+		t.Fatal("incorrect gas prices", g)
+	}
+
+	c2, err := ToCell(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(c2.Hash()) != string(c.Hash()) {
+		t.Fatal("incorrect serialization")
+	}
+
+	if fee := g.CalcGasFee(50); fee.Uint64() != 40000 {
+		t.Fatal("incorrect flat gas fee", fee.String())
+	}
+	if fee := g.CalcGasFee(3308); fee.Uint64() != 1323200 {
+		t.Fatal("incorrect gas fee", fee.String())
+	}
+	if gas := g.CalcGasBought(g.CalcGasFee(3308)); gas != 3308 {
+		t.Fatal("incorrect gas bought", gas)
+	}
+	if gas := g.CalcGasBought(FromNanoTONU(1000000000000).Nano()); gas != g.GasLimit {
+		t.Fatal("gas bought should be limited", gas)
+	}
+
+	var old GasLimitsPrices
+	if err = LoadFromCell(&old, cell.BeginCell().MustStoreUInt(0xdd, 8).
+		MustStoreUInt(1000, 64).MustStoreUInt(2000, 64).MustStoreUInt(3000, 64).
+		MustStoreUInt(4000, 64).MustStoreUInt(5000, 64).MustStoreUInt(6000, 64).
+		EndCell().BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if old.GasLimit != 2000 || old.SpecialGasLimit != 2000 || old.DeleteDueLimit != 6000 {
+		t.Fatal("incorrect old gas prices", old)
+	}
+}
+
+func TestMsgForwardPrices_Calc(t *testing.T) {
+	c := cell.BeginCell().
+		MustStoreUInt(0xea, 8).
+		MustStoreUInt(400000, 64).
+		MustStoreUInt(26214400, 64).
+		MustStoreUInt(2621440000, 64).
+		MustStoreUInt(98304, 32).
+		MustStoreUInt(21845, 16).
+		MustStoreUInt(21845, 16).
+		EndCell()
+
+	var m MsgForwardPrices
+	if err := LoadFromCell(&m, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	fee := m.CalcFwdFee(1, 100)
+	if fee.Uint64() != 480000 {
+		t.Fatal("incorrect fwd fee", fee.String())
+	}
+	if first := m.CalcFirstFrac(fee); first.Uint64() != 159997 {
+		t.Fatal("incorrect first frac", first.String())
+	}
+	if ihr := m.CalcIHRFee(fee); ihr.Uint64() != 720000 {
+		t.Fatal("incorrect ihr fee", ihr.String())
+	}
+}
+
+func TestCalcStorageFee(t *testing.T) {
+	prices := []StoragePrices{
+		{UTimeSince: 0, BitPricePS: 1, CellPricePS: 500, McBitPricePS: 1000, McCellPricePS: 500000},
+		{UTimeSince: 1 << 17, BitPricePS: 2, CellPricePS: 1000, McBitPricePS: 2000, McCellPricePS: 1000000},
+	}
+
+	if fee := CalcStorageFee(prices, false, 3, 1000, 1<<16, 1<<17); fee.Uint64() != 2500 {
+		t.Fatal("incorrect storage fee", fee.String())
+	}
+	// half of period is paid with the new prices
+	if fee := CalcStorageFee(prices, false, 3, 1000, 1<<16, 3<<16); fee.Uint64() != 7500 {
+		t.Fatal("incorrect storage fee for two periods", fee.String())
+	}
+	if fee := CalcStorageFee(prices, true, 1, 0, 0, 1<<16); fee.Uint64() != 500000 {
+		t.Fatal("incorrect masterchain storage fee", fee.String())
+	}
+	if fee := CalcStorageFee(prices, false, 3, 1000, 100, 50); fee.Sign() != 0 {
+		t.Fatal("storage fee should be zero", fee.String())
+	}
+}
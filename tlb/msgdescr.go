@@ -0,0 +1,428 @@
+package tlb
+
+import (
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	Register(IntermediateAddressRegular{})
+	Register(IntermediateAddressSimple{})
+	Register(IntermediateAddressExt{})
+	Register(MsgMetadata{})
+
+	Register(InMsgImportExt{})
+	Register(InMsgImportIHR{})
+	Register(InMsgImportImm{})
+	Register(InMsgImportFin{})
+	Register(InMsgImportTr{})
+	Register(InMsgDiscardFin{})
+	Register(InMsgDiscardTr{})
+	Register(InMsgImportDeferredFin{})
+	Register(InMsgImportDeferredTr{})
+
+	Register(OutMsgExportExt{})
+	Register(OutMsgExportImm{})
+	Register(OutMsgExportNew{})
+	Register(OutMsgExportTr{})
+	Register(OutMsgExportDeq{})
+	Register(OutMsgExportDeqShort{})
+	Register(OutMsgExportTrReq{})
+	Register(OutMsgExportDeqImm{})
+	Register(OutMsgExportNewDefer{})
+	Register(OutMsgExportDeferredTr{})
+}
+
+type IntermediateAddress struct {
+	Addr any `tlb:"[IntermediateAddressRegular,IntermediateAddressSimple,IntermediateAddressExt]"`
+}
+
+type IntermediateAddressRegular struct {
+	_           Magic `tlb:"$0"`
+	UseDestBits uint8 `tlb:"## 7"`
+}
+
+type IntermediateAddressSimple struct {
+	_          Magic  `tlb:"$10"`
+	Workchain  int8   `tlb:"## 8"`
+	AddrPrefix uint64 `tlb:"## 64"`
+}
+
+type IntermediateAddressExt struct {
+	_          Magic  `tlb:"$11"`
+	Workchain  int32  `tlb:"## 32"`
+	AddrPrefix uint64 `tlb:"## 64"`
+}
+
+type MsgMetadata struct {
+	_             Magic            `tlb:"#0"`
+	Depth         uint32           `tlb:"## 32"`
+	InitiatorAddr *address.Address `tlb:"addr"`
+	InitiatorLT   uint64           `tlb:"## 64"`
+}
+
+// MsgEnvelope - message with its routing info, both msg_envelope and msg_envelope_v2 are unpacked into it
+type MsgEnvelope struct {
+	CurAddr         IntermediateAddress
+	NextAddr        IntermediateAddress
+	FwdFeeRemaining Coins
+	Msg             *Message
+
+	// EmittedLT and Metadata can be set only in msg_envelope_v2
+	EmittedLT *uint64
+	Metadata  *MsgMetadata
+}
+
+type ImportFees struct {
+	FeesCollected Coins              `tlb:"."`
+	ValueImported CurrencyCollection `tlb:"."`
+}
+
+// InMsg - description of the message imported by the block
+type InMsg struct {
+	Msg any `tlb:"[InMsgImportExt,InMsgImportIHR,InMsgImportImm,InMsgImportFin,InMsgImportTr,InMsgDiscardFin,InMsgDiscardTr,InMsgImportDeferredFin,InMsgImportDeferredTr]"`
+}
+
+type InMsgImportExt struct {
+	_           Magic      `tlb:"$000"`
+	Msg         *Message   `tlb:"^"`
+	Transaction *cell.Cell `tlb:"^"`
+}
+
+type InMsgImportIHR struct {
+	_            Magic      `tlb:"$010"`
+	Msg          *Message   `tlb:"^"`
+	Transaction  *cell.Cell `tlb:"^"`
+	IHRFee       Coins      `tlb:"."`
+	ProofCreated *cell.Cell `tlb:"^"`
+}
+
+type InMsgImportImm struct {
+	_           Magic        `tlb:"$011"`
+	InMsg       *MsgEnvelope `tlb:"^"`
+	Transaction *cell.Cell   `tlb:"^"`
+	FwdFee      Coins        `tlb:"."`
+}
+
+type InMsgImportFin struct {
+	_           Magic        `tlb:"$100"`
+	InMsg       *MsgEnvelope `tlb:"^"`
+	Transaction *cell.Cell   `tlb:"^"`
+	FwdFee      Coins        `tlb:"."`
+}
+
+type InMsgImportTr struct {
+	_          Magic        `tlb:"$101"`
+	InMsg      *MsgEnvelope `tlb:"^"`
+	OutMsg     *MsgEnvelope `tlb:"^"`
+	TransitFee Coins        `tlb:"."`
+}
+
+type InMsgDiscardFin struct {
+	_             Magic        `tlb:"$110"`
+	InMsg         *MsgEnvelope `tlb:"^"`
+	TransactionID uint64       `tlb:"## 64"`
+	FwdFee        Coins        `tlb:"."`
+}
+
+type InMsgDiscardTr struct {
+	_              Magic        `tlb:"$111"`
+	InMsg          *MsgEnvelope `tlb:"^"`
+	TransactionID  uint64       `tlb:"## 64"`
+	FwdFee         Coins        `tlb:"."`
+	ProofDelivered *cell.Cell   `tlb:"^"`
+}
+
+type InMsgImportDeferredFin struct {
+	_           Magic        `tlb:"$00100"`
+	InMsg       *MsgEnvelope `tlb:"^"`
+	Transaction *cell.Cell   `tlb:"^"`
+	FwdFee      Coins        `tlb:"."`
+}
+
+type InMsgImportDeferredTr struct {
+	_      Magic        `tlb:"$00101"`
+	InMsg  *MsgEnvelope `tlb:"^"`
+	OutMsg *MsgEnvelope `tlb:"^"`
+}
+
+// OutMsg - description of the message exported by the block
+type OutMsg struct {
+	Msg any `tlb:"[OutMsgExportExt,OutMsgExportImm,OutMsgExportNew,OutMsgExportTr,OutMsgExportDeq,OutMsgExportDeqShort,OutMsgExportTrReq,OutMsgExportDeqImm,OutMsgExportNewDefer,OutMsgExportDeferredTr]"`
+}
+
+type OutMsgExportExt struct {
+	_           Magic      `tlb:"$000"`
+	Msg         *Message   `tlb:"^"`
+	Transaction *cell.Cell `tlb:"^"`
+}
+
+type OutMsgExportImm struct {
+	_           Magic        `tlb:"$010"`
+	OutMsg      *MsgEnvelope `tlb:"^"`
+	Transaction *cell.Cell   `tlb:"^"`
+	Reimport    *InMsg       `tlb:"^"`
+}
+
+type OutMsgExportNew struct {
+	_           Magic        `tlb:"$001"`
+	OutMsg      *MsgEnvelope `tlb:"^"`
+	Transaction *cell.Cell   `tlb:"^"`
+}
+
+type OutMsgExportTr struct {
+	_        Magic        `tlb:"$011"`
+	OutMsg   *MsgEnvelope `tlb:"^"`
+	Imported *InMsg       `tlb:"^"`
+}
+
+type OutMsgExportDeq struct {
+	_             Magic        `tlb:"$1100"`
+	OutMsg        *MsgEnvelope `tlb:"^"`
+	ImportBlockLT uint64       `tlb:"## 63"`
+}
+
+type OutMsgExportDeqShort struct {
+	_             Magic  `tlb:"$1101"`
+	MsgEnvHash    []byte `tlb:"bits 256"`
+	NextWorkchain int32  `tlb:"## 32"`
+	NextAddrPfx   uint64 `tlb:"## 64"`
+	ImportBlockLT uint64 `tlb:"## 64"`
+}
+
+type OutMsgExportTrReq struct {
+	_        Magic        `tlb:"$111"`
+	OutMsg   *MsgEnvelope `tlb:"^"`
+	Imported *InMsg       `tlb:"^"`
+}
+
+type OutMsgExportDeqImm struct {
+	_        Magic        `tlb:"$100"`
+	OutMsg   *MsgEnvelope `tlb:"^"`
+	Reimport *InMsg       `tlb:"^"`
+}
+
+type OutMsgExportNewDefer struct {
+	_           Magic        `tlb:"$10100"`
+	OutMsg      *MsgEnvelope `tlb:"^"`
+	Transaction *cell.Cell   `tlb:"^"`
+}
+
+type OutMsgExportDeferredTr struct {
+	_        Magic        `tlb:"$10101"`
+	OutMsg   *MsgEnvelope `tlb:"^"`
+	Imported *InMsg       `tlb:"^"`
+}
+
+// InMsgDescr - messages imported by the block, keyed by message hash and augmented with ImportFees
+type InMsgDescr struct {
+	Messages *cell.AugmentedDictionary
+	Total    ImportFees
+}
+
+// OutMsgDescr - messages exported by the block, keyed by message hash and augmented with exported value
+type OutMsgDescr struct {
+	Messages *cell.AugmentedDictionary
+	Total    CurrencyCollection
+}
+
+func (m *MsgEnvelope) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(4)
+	if err != nil {
+		return err
+	}
+	if tag != 4 && tag != 5 {
+		return fmt.Errorf("unknown msg envelope tag %x", tag)
+	}
+
+	if err = LoadFromCell(&m.CurAddr, loader); err != nil {
+		return fmt.Errorf("failed to load cur addr: %w", err)
+	}
+	if err = LoadFromCell(&m.NextAddr, loader); err != nil {
+		return fmt.Errorf("failed to load next addr: %w", err)
+	}
+	if err = LoadFromCell(&m.FwdFeeRemaining, loader); err != nil {
+		return fmt.Errorf("failed to load fwd fee remaining: %w", err)
+	}
+
+	msg, err := loader.LoadRef()
+	if err != nil {
+		return fmt.Errorf("failed to load msg ref: %w", err)
+	}
+	m.Msg = &Message{}
+	if err = LoadFromCell(m.Msg, msg); err != nil {
+		return fmt.Errorf("failed to load msg: %w", err)
+	}
+
+	if tag == 5 {
+		var v2 struct {
+			EmittedLT *uint64      `tlb:"maybe ## 64"`
+			Metadata  *MsgMetadata `tlb:"maybe ."`
+		}
+		if err = LoadFromCell(&v2, loader); err != nil {
+			return fmt.Errorf("failed to load v2 fields: %w", err)
+		}
+		m.EmittedLT, m.Metadata = v2.EmittedLT, v2.Metadata
+	}
+	return nil
+}
+
+func (m *MsgEnvelope) ToCell() (*cell.Cell, error) {
+	isV2 := m.EmittedLT != nil || m.Metadata != nil
+
+	b := cell.BeginCell()
+	if isV2 {
+		b.MustStoreUInt(5, 4)
+	} else {
+		b.MustStoreUInt(4, 4)
+	}
+
+	for _, v := range []any{m.CurAddr, m.NextAddr, m.FwdFeeRemaining} {
+		c, err := ToCell(v)
+		if err != nil {
+			return nil, err
+		}
+		if err = b.StoreBuilder(c.ToBuilder()); err != nil {
+			return nil, err
+		}
+	}
+
+	msg, err := ToCell(m.Msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize msg: %w", err)
+	}
+	b.MustStoreRef(msg)
+
+	if isV2 {
+		b.MustStoreBoolBit(m.EmittedLT != nil)
+		if m.EmittedLT != nil {
+			b.MustStoreUInt(*m.EmittedLT, 64)
+		}
+
+		b.MustStoreBoolBit(m.Metadata != nil)
+		if m.Metadata != nil {
+			c, err := ToCell(m.Metadata)
+			if err != nil {
+				return nil, fmt.Errorf("failed to serialize metadata: %w", err)
+			}
+			if err = b.StoreBuilder(c.ToBuilder()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b.EndCell(), nil
+}
+
+func (d *InMsgDescr) LoadFromCell(loader *cell.Slice) error {
+	dict, err := loader.LoadAugmentedDict(256, ImportFeesExtra{})
+	if err != nil {
+		return err
+	}
+
+	total, err := dict.Extra()
+	if err != nil {
+		return fmt.Errorf("failed to load total extra: %w", err)
+	}
+	if err = LoadFromCell(&d.Total, total); err != nil {
+		return fmt.Errorf("failed to parse total import fees: %w", err)
+	}
+	d.Messages = dict
+	return nil
+}
+
+func (d *InMsgDescr) ToCell() (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := b.StoreAugmentedDict(d.Messages); err != nil {
+		return nil, err
+	}
+	return b.EndCell(), nil
+}
+
+func (d *OutMsgDescr) LoadFromCell(loader *cell.Slice) error {
+	dict, err := loader.LoadAugmentedDict(256, CurrencyCollectionExtra{})
+	if err != nil {
+		return err
+	}
+
+	total, err := dict.Extra()
+	if err != nil {
+		return fmt.Errorf("failed to load total extra: %w", err)
+	}
+	if err = LoadFromCell(&d.Total, total); err != nil {
+		return fmt.Errorf("failed to parse total exported value: %w", err)
+	}
+	d.Messages = dict
+	return nil
+}
+
+func (d *OutMsgDescr) ToCell() (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := b.StoreAugmentedDict(d.Messages); err != nil {
+		return nil, err
+	}
+	return b.EndCell(), nil
+}
+
+// ForEach - calls fn for each imported message with its hash and import fees,
+// iteration stops on the first error returned by fn
+func (d *InMsgDescr) ForEach(fn func(hash []byte, msg *InMsg, fees *ImportFees) error) error {
+	kvs, err := d.Messages.LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load in msg descr: %w", err)
+	}
+
+	for _, kv := range kvs {
+		hash, err := kv.Key.LoadSlice(256)
+		if err != nil {
+			return fmt.Errorf("failed to load msg hash: %w", err)
+		}
+
+		var fees ImportFees
+		if err = LoadFromCell(&fees, kv.Extra); err != nil {
+			return fmt.Errorf("failed to load import fees of msg %x: %w", hash, err)
+		}
+
+		var msg InMsg
+		if err = LoadFromCell(&msg, kv.Value); err != nil {
+			return fmt.Errorf("failed to load in msg %x: %w", hash, err)
+		}
+
+		if err = fn(hash, &msg, &fees); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForEach - calls fn for each exported message with its hash and exported value,
+// iteration stops on the first error returned by fn
+func (d *OutMsgDescr) ForEach(fn func(hash []byte, msg *OutMsg, value *CurrencyCollection) error) error {
+	kvs, err := d.Messages.LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load out msg descr: %w", err)
+	}
+
+	for _, kv := range kvs {
+		hash, err := kv.Key.LoadSlice(256)
+		if err != nil {
+			return fmt.Errorf("failed to load msg hash: %w", err)
+		}
+
+		var value CurrencyCollection
+		if err = LoadFromCell(&value, kv.Extra); err != nil {
+			return fmt.Errorf("failed to load value of msg %x: %w", hash, err)
+		}
+
+		var msg OutMsg
+		if err = LoadFromCell(&msg, kv.Value); err != nil {
+			return fmt.Errorf("failed to load out msg %x: %w", hash, err)
+		}
+
+		if err = fn(hash, &msg, &value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
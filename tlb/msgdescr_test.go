@@ -0,0 +1,164 @@
+package tlb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestBlock_ForEachMsg(t *testing.T) {
+	addr := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	src := address.MustParseAddr("Ef8zMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzM0vF")
+
+	intMsg, err := ToCell(&InternalMessage{
+		IHRDisabled: true,
+		SrcAddr:     src,
+		DstAddr:     addr,
+		Amount:      MustFromTON("2"),
+		CreatedLT:   1000,
+		Body:        cell.BeginCell().EndCell(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extMsg, err := ToCell(&ExternalMessage{
+		SrcAddr: address.NewAddressNone(),
+		DstAddr: addr,
+		Body:    cell.BeginCell().MustStoreUInt(777, 32).EndCell(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := cell.BeginCell().MustStoreUInt(0b0111, 4).EndCell()
+
+	env := cell.BeginCell().MustStoreUInt(4, 4).
+		MustStoreUInt(0, 1).MustStoreUInt(96, 7).
+		MustStoreUInt(0b10, 2).MustStoreInt(-1, 8).MustStoreUInt(0x8000000000000000, 64).
+		MustStoreBigCoins(MustFromTON("0.01").Nano()).
+		MustStoreRef(intMsg).EndCell()
+
+	envV2 := cell.BeginCell().MustStoreUInt(5, 4).
+		MustStoreUInt(0, 1).MustStoreUInt(0, 7).
+		MustStoreUInt(0, 1).MustStoreUInt(96, 7).
+		MustStoreBigCoins(MustFromTON("0.02").Nano()).
+		MustStoreRef(intMsg).
+		MustStoreBoolBit(true).MustStoreUInt(1001, 64).
+		MustStoreBoolBit(true).MustStoreUInt(0, 4).MustStoreUInt(2, 32).MustStoreAddr(src).MustStoreUInt(900, 64).
+		EndCell()
+
+	fees := func(collected, imported string) *cell.Cell {
+		c, err := ToCell(ImportFees{FeesCollected: MustFromTON(collected), ValueImported: CurrencyCollection{Coins: MustFromTON(imported)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	inDict := cell.NewAugmentedDict(256, ImportFeesExtra{})
+	if err = inDict.Set(cell.BeginCell().MustStoreSlice(intMsg.Hash(), 256).EndCell(),
+		cell.BeginCell().MustStoreUInt(0b100, 3).MustStoreRef(env).MustStoreRef(tx).MustStoreBigCoins(MustFromTON("0.005").Nano()).EndCell(),
+		fees("0.005", "2")); err != nil {
+		t.Fatal(err)
+	}
+	if err = inDict.Set(cell.BeginCell().MustStoreSlice(extMsg.Hash(), 256).EndCell(),
+		cell.BeginCell().MustStoreUInt(0b000, 3).MustStoreRef(extMsg).MustStoreRef(tx).EndCell(),
+		fees("0.001", "0")); err != nil {
+		t.Fatal(err)
+	}
+
+	outDict := cell.NewAugmentedDict(256, CurrencyCollectionExtra{})
+	if err = outDict.Set(cell.BeginCell().MustStoreSlice(intMsg.Hash(), 256).EndCell(),
+		cell.BeginCell().MustStoreUInt(0b001, 3).MustStoreRef(envV2).MustStoreRef(tx).EndCell(),
+		cell.BeginCell().MustStoreBigCoins(MustFromTON("2").Nano()).MustStoreDict(nil).EndCell()); err != nil {
+		t.Fatal(err)
+	}
+
+	block := &Block{
+		Extra: &BlockExtra{
+			InMsgDesc:  cell.BeginCell().MustStoreAugmentedDict(inDict).EndCell(),
+			OutMsgDesc: cell.BeginCell().MustStoreAugmentedDict(outDict).EndCell(),
+		},
+	}
+
+	inDescr, err := block.LoadInMsgDescr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inDescr.Total.FeesCollected.String() != "0.006" || inDescr.Total.ValueImported.Coins.String() != "2" {
+		t.Fatal("incorrect in msg descr total")
+	}
+
+	var found int
+	err = block.ForEachInMsg(func(hash []byte, msg *InMsg, fees *ImportFees) error {
+		switch m := msg.Msg.(type) {
+		case InMsgImportFin:
+			if !bytes.Equal(hash, intMsg.Hash()) || fees.FeesCollected.String() != "0.005" || m.FwdFee.String() != "0.005" {
+				t.Fatal("incorrect import fin")
+			}
+			if m.InMsg.FwdFeeRemaining.String() != "0.01" || m.InMsg.EmittedLT != nil ||
+				m.InMsg.NextAddr.Addr.(IntermediateAddressSimple).Workchain != -1 {
+				t.Fatal("incorrect envelope")
+			}
+			if in := m.InMsg.Msg.AsInternal(); !in.DstAddr.Equals(addr) || in.CreatedLT != 1000 {
+				t.Fatal("incorrect enveloped message")
+			}
+			if !bytes.Equal(m.Transaction.Hash(), tx.Hash()) {
+				t.Fatal("incorrect transaction")
+			}
+
+			c, err := ToCell(m.InMsg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(c.Hash(), env.Hash()) {
+				t.Fatal("incorrect envelope serialization")
+			}
+		case InMsgImportExt:
+			if !bytes.Equal(hash, extMsg.Hash()) || m.Msg.MsgType != MsgTypeExternalIn || fees.FeesCollected.String() != "0.001" {
+				t.Fatal("incorrect import ext")
+			}
+		default:
+			t.Fatal("unexpected in msg type")
+		}
+		found++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != 2 {
+		t.Fatal("incorrect in msgs count", found)
+	}
+
+	found = 0
+	err = block.ForEachOutMsg(func(hash []byte, msg *OutMsg, value *CurrencyCollection) error {
+		m, ok := msg.Msg.(OutMsgExportNew)
+		if !ok || value.Coins.String() != "2" {
+			t.Fatal("incorrect export new")
+		}
+		if m.OutMsg.EmittedLT == nil || *m.OutMsg.EmittedLT != 1001 ||
+			m.OutMsg.Metadata == nil || m.OutMsg.Metadata.Depth != 2 || !m.OutMsg.Metadata.InitiatorAddr.Equals(src) {
+			t.Fatal("incorrect v2 envelope")
+		}
+
+		c, err := ToCell(m.OutMsg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(c.Hash(), envV2.Hash()) {
+			t.Fatal("incorrect v2 envelope serialization")
+		}
+		found++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != 1 {
+		t.Fatal("incorrect out msgs count", found)
+	}
+}
@@ -0,0 +1,138 @@
+package tlb
+
+import (
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// OutMsgQueueInfo - outbound message queue of the shard state, part of ShardStateUnsplit
+type OutMsgQueueInfo struct {
+	OutQueue      *cell.AugmentedDictionary
+	ProcessedInfo *cell.Dictionary
+	Extra         *OutMsgQueueExtra
+}
+
+// OutMsgQueueExtra - dispatch queue and out queue size, present in states of new versions
+type OutMsgQueueExtra struct {
+	DispatchQueue *cell.AugmentedDictionary
+	OutQueueSize  *uint64
+}
+
+// AccountDispatchQueue - deferred messages of the account, value of DispatchQueue
+type AccountDispatchQueue struct {
+	Messages *cell.Dictionary `tlb:"dict 64"`
+	Count    uint64           `tlb:"## 48"`
+}
+
+func (o *OutMsgQueueInfo) LoadFromCell(loader *cell.Slice) error {
+	queue, err := loader.LoadAugmentedDict(352, MinLTExtra{})
+	if err != nil {
+		return fmt.Errorf("failed to load out queue: %w", err)
+	}
+
+	processed, err := loader.LoadDict(96)
+	if err != nil {
+		return fmt.Errorf("failed to load processed info: %w", err)
+	}
+
+	hasExtra, err := loader.LoadBoolBit()
+	if err != nil {
+		return fmt.Errorf("failed to load extra flag: %w", err)
+	}
+
+	var extra *OutMsgQueueExtra
+	if hasExtra {
+		extra = &OutMsgQueueExtra{}
+		if err = extra.LoadFromCell(loader); err != nil {
+			return fmt.Errorf("failed to load extra: %w", err)
+		}
+	}
+
+	*o = OutMsgQueueInfo{
+		OutQueue:      queue,
+		ProcessedInfo: processed,
+		Extra:         extra,
+	}
+	return nil
+}
+
+func (o *OutMsgQueueInfo) ToCell() (*cell.Cell, error) {
+	queue := o.OutQueue
+	if queue == nil {
+		queue = cell.NewAugmentedDict(352, MinLTExtra{})
+	}
+
+	b := cell.BeginCell()
+	if err := b.StoreAugmentedDict(queue); err != nil {
+		return nil, fmt.Errorf("failed to store out queue: %w", err)
+	}
+	if err := b.StoreDict(o.ProcessedInfo); err != nil {
+		return nil, fmt.Errorf("failed to store processed info: %w", err)
+	}
+
+	if o.Extra == nil {
+		return b.MustStoreBoolBit(false).EndCell(), nil
+	}
+
+	extra, err := o.Extra.ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize extra: %w", err)
+	}
+	if err = b.MustStoreBoolBit(true).StoreBuilder(extra.ToBuilder()); err != nil {
+		return nil, fmt.Errorf("failed to store extra: %w", err)
+	}
+	return b.EndCell(), nil
+}
+
+func (o *OutMsgQueueExtra) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(4)
+	if err != nil {
+		return fmt.Errorf("failed to load tag: %w", err)
+	}
+	if tag != 0 {
+		return fmt.Errorf("unknown out msg queue extra tag %d", tag)
+	}
+
+	dispatch, err := loader.LoadAugmentedDict(256, MinLTExtra{})
+	if err != nil {
+		return fmt.Errorf("failed to load dispatch queue: %w", err)
+	}
+
+	hasSize, err := loader.LoadBoolBit()
+	if err != nil {
+		return fmt.Errorf("failed to load size flag: %w", err)
+	}
+
+	var size *uint64
+	if hasSize {
+		sz, err := loader.LoadUInt(48)
+		if err != nil {
+			return fmt.Errorf("failed to load size: %w", err)
+		}
+		size = &sz
+	}
+
+	*o = OutMsgQueueExtra{
+		DispatchQueue: dispatch,
+		OutQueueSize:  size,
+	}
+	return nil
+}
+
+func (o *OutMsgQueueExtra) ToCell() (*cell.Cell, error) {
+	dispatch := o.DispatchQueue
+	if dispatch == nil {
+		dispatch = cell.NewAugmentedDict(256, MinLTExtra{})
+	}
+
+	b := cell.BeginCell().MustStoreUInt(0, 4)
+	if err := b.StoreAugmentedDict(dispatch); err != nil {
+		return nil, fmt.Errorf("failed to store dispatch queue: %w", err)
+	}
+
+	if o.OutQueueSize == nil {
+		return b.MustStoreBoolBit(false).EndCell(), nil
+	}
+	return b.MustStoreBoolBit(true).MustStoreUInt(*o.OutQueueSize, 48).EndCell(), nil
+}
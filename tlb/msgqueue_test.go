@@ -0,0 +1,79 @@
+package tlb
+
+import (
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestOutMsgQueueInfo_LoadFromCell(t *testing.T) {
+	queue := &AccountDispatchQueue{
+		Messages: cell.NewDict(64),
+		Count:    2,
+	}
+	qc, err := ToCell(queue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dispatch := cell.NewAugmentedDict(256, MinLTExtra{})
+	addr := make([]byte, 32)
+	addr[0] = 0xAA
+	err = dispatch.Set(cell.BeginCell().MustStoreSlice(addr, 256).EndCell(), qc,
+		cell.BeginCell().MustStoreUInt(777, 64).EndCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := uint64(15)
+	info := &OutMsgQueueInfo{
+		ProcessedInfo: cell.NewDict(96),
+		Extra: &OutMsgQueueExtra{
+			DispatchQueue: dispatch,
+			OutQueueSize:  &size,
+		},
+	}
+
+	c, err := info.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded OutMsgQueueInfo
+	if err = loaded.LoadFromCell(c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Extra == nil || loaded.Extra.OutQueueSize == nil || *loaded.Extra.OutQueueSize != 15 {
+		t.Fatal("incorrect out queue size")
+	}
+
+	val, extra, err := loaded.Extra.DispatchQueue.LoadValueWithExtra(cell.BeginCell().MustStoreSlice(addr, 256).EndCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var q AccountDispatchQueue
+	if err = LoadFromCell(&q, val); err != nil {
+		t.Fatal(err)
+	}
+	if q.Count != 2 {
+		t.Fatal("incorrect queue count")
+	}
+
+	if minLT := extra.MustLoadUInt(64); minLT != 777 {
+		t.Fatal("incorrect min lt", minLT)
+	}
+
+	info.Extra = nil
+	c, err = info.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = loaded.LoadFromCell(c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Extra != nil {
+		t.Fatal("extra should be nil")
+	}
+}
@@ -2,6 +2,7 @@ package tlb
 
 import (
 	"encoding/binary"
+	"fmt"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
@@ -18,21 +19,25 @@ func init() {
 type ShardID uint64
 
 type ShardStateUnsplit struct {
-	_               Magic      `tlb:"#9023afe2"`
-	GlobalID        int32      `tlb:"## 32"`
-	ShardIdent      ShardIdent `tlb:"."`
-	Seqno           uint32     `tlb:"## 32"`
-	VertSeqno       uint32     `tlb:"## 32"`
-	GenUTime        uint32     `tlb:"## 32"`
-	GenLT           uint64     `tlb:"## 64"`
-	MinRefMCSeqno   uint32     `tlb:"## 32"`
-	OutMsgQueueInfo *cell.Cell `tlb:"^"`
-	BeforeSplit     bool       `tlb:"bool"`
-	Accounts        struct {
-		ShardAccounts *cell.Dictionary `tlb:"dict 256"`
-	} `tlb:"^"`
-	Stats        *cell.Cell `tlb:"^"`
-	McStateExtra *cell.Cell `tlb:"maybe ^"`
+	_               Magic         `tlb:"#9023afe2"`
+	GlobalID        int32         `tlb:"## 32"`
+	ShardIdent      ShardIdent    `tlb:"."`
+	Seqno           uint32        `tlb:"## 32"`
+	VertSeqno       uint32        `tlb:"## 32"`
+	GenUTime        uint32        `tlb:"## 32"`
+	GenLT           uint64        `tlb:"## 64"`
+	MinRefMCSeqno   uint32        `tlb:"## 32"`
+	OutMsgQueueInfo *cell.Cell    `tlb:"^"`
+	BeforeSplit     bool          `tlb:"bool"`
+	Accounts        ShardAccounts `tlb:"^"`
+	Stats           *cell.Cell    `tlb:"^"`
+	McStateExtra    *cell.Cell    `tlb:"maybe ^"`
+}
+
+// ShardAccounts - accounts of the shard, keyed by address and augmented with DepthBalanceInfo
+type ShardAccounts struct {
+	ShardAccounts *cell.AugmentedDictionary
+	Total         DepthBalanceInfo
 }
 
 type McStateExtra struct {
@@ -63,10 +68,133 @@ type McStateExtraBlockInfo struct {
 	Flags            uint16           `tlb:"## 16"`
 	ValidatorInfo    ValidatorInfo    `tlb:"."`
 	PrevBlocks       *cell.Dictionary `tlb:"dict 32"`
+	PrevBlocksMaxLT  KeyMaxLt         `tlb:"."`
+	AfterKeyBlock    bool             `tlb:"bool"`
 	LastKeyBlock     *ExtBlkRef       `tlb:"maybe ."`
 	BlockCreateStats *cell.Cell       `tlb:"."`
 }
 
+// LoadBlockCreateStats - parses block creation stats of validators, they are present when flags bit 0 is set
+func (i *McStateExtraBlockInfo) LoadBlockCreateStats() (*BlockCreateStats, error) {
+	if i.Flags&1 == 0 || i.BlockCreateStats == nil {
+		return nil, fmt.Errorf("no block create stats")
+	}
+
+	var stats BlockCreateStats
+	if err := stats.LoadFromCell(i.BlockCreateStats.BeginParse()); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+type Counters struct {
+	LastUpdated uint32 `tlb:"## 32"`
+	Total       uint64 `tlb:"## 64"`
+	Cnt2048     uint64 `tlb:"## 64"`
+	Cnt65536    uint64 `tlb:"## 64"`
+}
+
+type CreatorStats struct {
+	_                 Magic    `tlb:"#4"`
+	MasterchainBlocks Counters `tlb:"."`
+	ShardBlocks       Counters `tlb:"."`
+}
+
+// BlockCreateStats - counters of created blocks by validator public keys
+type BlockCreateStats struct {
+	// Counters - dictionary with 256 bit keys, when Extended, values are prefixed with 32 bit augmentation
+	Counters *cell.Dictionary
+	Extended bool
+}
+
+// ValidatorCreateStats - block creation stats of the validator with the given public key
+type ValidatorCreateStats struct {
+	PublicKey []byte
+	Stats     CreatorStats
+}
+
+func (s *BlockCreateStats) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(8)
+	if err != nil {
+		return fmt.Errorf("failed to load tag: %w", err)
+	}
+
+	var extended bool
+	switch tag {
+	case 0x17:
+	case 0x34:
+		extended = true
+	default:
+		return fmt.Errorf("unknown block create stats tag %x", tag)
+	}
+
+	dict, err := loader.LoadDict(256)
+	if err != nil {
+		return fmt.Errorf("failed to load counters: %w", err)
+	}
+
+	if extended {
+		// total of augmented dict
+		if _, err = loader.LoadUInt(32); err != nil {
+			return fmt.Errorf("failed to load counters extra: %w", err)
+		}
+	}
+
+	*s = BlockCreateStats{
+		Counters: dict,
+		Extended: extended,
+	}
+	return nil
+}
+
+// LoadAll - parses stats of all validators, when skipPruned is true,
+// pruned branches are skipped, so it can be used to load stats from proof
+func (s *BlockCreateStats) LoadAll(skipPruned bool) ([]ValidatorCreateStats, error) {
+	kvs, err := s.Counters.LoadAll(skipPruned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load counters dict: %w", err)
+	}
+	return s.parseCounters(kvs)
+}
+
+// LoadAllFromProof - parses stats of validators present in proof, and returns key prefixes of pruned branches,
+// keys under them are hidden by the proof
+func (s *BlockCreateStats) LoadAllFromProof() ([]ValidatorCreateStats, []*cell.Slice, error) {
+	kvs, pruned, err := s.Counters.LoadAllWithPruned()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load counters dict: %w", err)
+	}
+
+	res, err := s.parseCounters(kvs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, pruned, nil
+}
+
+func (s *BlockCreateStats) parseCounters(kvs []cell.DictKV) ([]ValidatorCreateStats, error) {
+	var err error
+	res := make([]ValidatorCreateStats, 0, len(kvs))
+	for _, kv := range kvs {
+		if s.Extended {
+			if _, err = kv.Value.LoadUInt(32); err != nil {
+				return nil, fmt.Errorf("failed to load counters extra: %w", err)
+			}
+		}
+
+		var stats CreatorStats
+		if err = LoadFromCell(&stats, kv.Value); err != nil {
+			return nil, fmt.Errorf("failed to parse creator stats: %w", err)
+		}
+
+		res = append(res, ValidatorCreateStats{
+			PublicKey: kv.Key.MustLoadSlice(256),
+			Stats:     stats,
+		})
+	}
+	return res, nil
+}
+
 type ConfigParams struct {
 	ConfigAddr []byte `tlb:"bits 256"`
 	Config     struct {
@@ -199,3 +327,34 @@ func (s ShardIdent) IsParent(of ShardIdent) bool {
 func (s ShardIdent) GetShardID() ShardID {
 	return ShardID(s.ShardPrefix)
 }
+
+func (a *ShardAccounts) LoadFromCell(loader *cell.Slice) error {
+	// total is read from the root extra, because root node can be pruned in proofs
+	root := loader.Copy()
+	if _, err := root.LoadMaybeRef(); err != nil {
+		return fmt.Errorf("failed to load accounts ref: %w", err)
+	}
+	if err := LoadFromCell(&a.Total, root); err != nil {
+		return fmt.Errorf("failed to parse total balance: %w", err)
+	}
+
+	dict, err := loader.LoadAugmentedDict(256, DepthBalanceExtra{})
+	if err != nil {
+		return err
+	}
+	a.ShardAccounts = dict
+	return nil
+}
+
+func (a *ShardAccounts) ToCell() (*cell.Cell, error) {
+	dict := a.ShardAccounts
+	if dict == nil {
+		dict = cell.NewAugmentedDict(256, DepthBalanceExtra{})
+	}
+
+	b := cell.BeginCell()
+	if err := b.StoreAugmentedDict(dict); err != nil {
+		return nil, err
+	}
+	return b.EndCell(), nil
+}
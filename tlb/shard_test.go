@@ -423,3 +423,117 @@ func TestShardID_ContainsAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestBlockCreateStats_LoadAll(t *testing.T) {
+	for _, extended := range []bool{false, true} {
+		dict := cell.NewDict(256)
+		for i := 0; i < 5; i++ {
+			st, err := ToCell(CreatorStats{
+				MasterchainBlocks: Counters{LastUpdated: 100, Total: uint64(i)},
+				ShardBlocks:       Counters{LastUpdated: 200, Total: uint64(i * 10)},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			val := cell.BeginCell()
+			if extended {
+				val.MustStoreUInt(uint64(i), 32)
+			}
+			val.MustStoreBuilder(st.ToBuilder())
+
+			key := make([]byte, 32)
+			key[31] = byte(i)
+			if err = dict.Set(cell.BeginCell().MustStoreSlice(key, 256).EndCell(), val.EndCell()); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		b := cell.BeginCell()
+		if extended {
+			b.MustStoreUInt(0x34, 8).MustStoreDict(dict).MustStoreUInt(10, 32)
+		} else {
+			b.MustStoreUInt(0x17, 8).MustStoreDict(dict)
+		}
+
+		info := McStateExtraBlockInfo{Flags: 1, BlockCreateStats: b.EndCell()}
+		stats, err := info.LoadBlockCreateStats()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Extended != extended {
+			t.Fatal("incorrect type")
+		}
+
+		all, err := stats.LoadAll(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 5 {
+			t.Fatal("incorrect len", len(all))
+		}
+		for _, v := range all {
+			i := uint64(v.PublicKey[31])
+			if v.Stats.MasterchainBlocks.Total != i || v.Stats.ShardBlocks.Total != i*10 || v.Stats.ShardBlocks.LastUpdated != 200 {
+				t.Fatal("incorrect stats", v.Stats)
+			}
+		}
+	}
+
+	if _, err := (&McStateExtraBlockInfo{}).LoadBlockCreateStats(); err == nil {
+		t.Fatal("stats should not be loaded without flag")
+	}
+}
+
+func TestShardAccounts_LoadFromCell(t *testing.T) {
+	dict := cell.NewAugmentedDict(256, DepthBalanceExtra{})
+	for i, amt := range []uint64{100, 250} {
+		extra, err := ToCell(DepthBalanceInfo{Depth: uint32(i), Currencies: CurrencyCollection{Coins: FromNanoTONU(amt)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key := cell.BeginCell().MustStoreUInt(uint64(i), 256).EndCell()
+		val := cell.BeginCell().MustStoreUInt(uint64(i), 8).EndCell()
+		if err = dict.Set(key, val, extra); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := ToCell(&ShardAccounts{ShardAccounts: dict})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accounts ShardAccounts
+	if err = LoadFromCell(&accounts, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if accounts.Total.Currencies.Coins.Nano().Uint64() != 350 {
+		t.Fatal("incorrect total balance", accounts.Total.Currencies.Coins.String())
+	}
+
+	val, extra, err := accounts.ShardAccounts.LoadValueWithExtra(cell.BeginCell().MustStoreUInt(1, 256).EndCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info DepthBalanceInfo
+	if err = LoadFromCell(&info, extra); err != nil {
+		t.Fatal(err)
+	}
+	if val.MustLoadUInt(8) != 1 || info.Currencies.Coins.Nano().Uint64() != 250 {
+		t.Fatal("incorrect account value or extra")
+	}
+
+	var empty ShardAccounts
+	if c, err = ToCell(&empty); err != nil {
+		t.Fatal(err)
+	}
+	if err = LoadFromCell(&empty, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if !empty.ShardAccounts.IsEmpty() || empty.Total.Currencies.Coins.Nano().Sign() != 0 {
+		t.Fatal("accounts should be empty")
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/observer"
 	"github.com/xssnick/tonutils-go/tl"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
@@ -78,16 +79,51 @@ type APIClientWrapped interface {
 	FindLastTransactionByOutMsgHash(ctx context.Context, addr *address.Address, msgHash []byte, maxTxNumToScan ...int) (*tlb.Transaction, error)
 }
 
+// APIClientExtended - methods of *APIClient which are not part of APIClientWrapped,
+// to not break its custom implementations. Clients returned by With* and WaitForBlock methods implement it,
+// so it can be accessed with type assertion:
+//
+//	ext, ok := api.WithRetry().(ton.APIClientExtended)
+type APIClientExtended interface {
+	APIClientWrapped
+	GetLibrariesWithProof(ctx context.Context, master *BlockIDExt, list ...[]byte) ([]*cell.Cell, error)
+	LookupBlockWithProof(ctx context.Context, master *BlockIDExt, workchain int32, shard int64, seqno uint32) (*BlockIDExt, error)
+	GetBlockHeader(ctx context.Context, block *BlockIDExt) (*tlb.BlockHeader, error)
+	GetState(ctx context.Context, block *BlockIDExt) (*cell.Cell, error)
+	GetShardBlockProof(ctx context.Context, block *BlockIDExt) (*ShardBlockProof, error)
+	GetMasterchainInfoExt(ctx context.Context) (*MasterchainInfoExt, error)
+	GetOutMsgQueueSizes(ctx context.Context) (*OutMsgQueueSizes, error)
+	GetBlockOutMsgQueueSize(ctx context.Context, block *BlockIDExt) (uint64, error)
+	GetDispatchQueueInfo(ctx context.Context, block *BlockIDExt, afterAddr []byte, maxAccounts int32) ([]AccountDispatchQueueInfo, bool, error)
+	GetValidatorStats(ctx context.Context, master *BlockIDExt, limit int32, startAfter []byte, modifiedAfter uint32) ([]tlb.ValidatorCreateStats, bool, error)
+	GetNonfinalValidatorGroups(ctx context.Context) ([]NonfinalValidatorGroupInfo, error)
+	GetNonfinalCandidate(ctx context.Context, id *NonfinalCandidateID) (*tlb.Block, error)
+	SubscribeOnBlocks(workerCtx context.Context, lastProcessedMaster *BlockIDExt, channel chan<- *BlockEvent)
+	FindOldestMasterBlock(ctx context.Context) (uint32, error)
+	WithQuorum(nodes, required int) APIClientWrapped
+	WithCache(cache ResponseCache) APIClientWrapped
+	WithObserver(o observer.Observer) APIClientWrapped
+	SetTrustedBlockStore(ctx context.Context, store TrustedBlockStore) error
+	FlushTrustedBlock(ctx context.Context) error
+	SetVerifiedGetMethods(enabled bool)
+}
+
 type APIClient struct {
 	client LiteClient
 	parent *APIClient
 
 	trustedBlock     *BlockIDExt
+	trustedStore     TrustedBlockStore
 	curMasters       map[uint32]*masterInfo
 	curMastersLock   sync.RWMutex
 	proofCheckPolicy ProofCheckPolicy
+	verifyGetMethods bool
 
 	trustedLock sync.RWMutex
+
+	trustedSavedAt    time.Time
+	trustedSavedSeqno uint32
+	trustedSaveMx     sync.Mutex
 }
 
 type masterInfo struct {
@@ -159,6 +195,49 @@ func (c *APIClient) WithTimeout(timeout time.Duration) APIClientWrapped {
 	}
 }
 
+// WithQuorum - sends each request to the given number of distinct liteservers,
+// and requires at least required matching answers to return the result.
+// Nodes marked as out of sync by the pool health checks are not asked. Each request is bound to its node strictly,
+// and when the same node still answers for several requests, it is counted once.
+// Answers are compared by the block ids and the hashes of returned data, proofs are not compared,
+// for other responses full serialized data is compared. When quorum is not reached, *QuorumError is returned.
+//
+// Requests which are not bound to a block, like GetMasterchainInfo, CurrentMasterchainInfo and GetTime,
+// reach quorum only when nodes are at the same last block at the moment of request, WaitForBlock does not change it,
+// because it sets only the minimal block. For reliable comparison get the block once and use methods which accept it.
+func (c *APIClient) WithQuorum(nodes, required int) APIClientWrapped {
+	return &APIClient{
+		parent:           c,
+		client:           &quorumClient{original: c.client, nodes: nodes, required: required},
+		proofCheckPolicy: c.proofCheckPolicy,
+	}
+}
+
+// WithCache - caches responses for immutable data, bound to a concrete block:
+// block data and headers, transactions, shards info, config at a block, lookup of block by seqno and libraries.
+// Concurrent equal requests are sent to liteserver only once.
+func (c *APIClient) WithCache(cache ResponseCache) APIClientWrapped {
+	return &APIClient{
+		parent: c,
+		client: &cacheClient{
+			original:   c.client,
+			cache:      cache,
+			inProgress: map[string]*cacheCall{},
+		},
+		proofCheckPolicy: c.proofCheckPolicy,
+	}
+}
+
+// WithObserver - reports each request to observer, liteserver errors are reported as errors.
+// It should be the outermost wrapper, to let other wrappers, like WithRetry, report their events too.
+func (c *APIClient) WithObserver(o observer.Observer) APIClientWrapped {
+	return &APIClient{
+		parent:           c,
+		client:           &observedClient{original: c.client, observer: o},
+		proofCheckPolicy: c.proofCheckPolicy,
+	}
+}
+
 func (c *APIClient) root() *APIClient {
 	if c.parent != nil {
 		return c.parent.root()
@@ -3,6 +3,7 @@ package ton
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -72,14 +73,14 @@ type Version struct {
 }
 
 type GetState struct {
-	ID       *BlockIDExt `tl:"struct"`
-	RootHash []byte      `tl:"int256"`
-	FileHash []byte      `tl:"int256"`
-	Data     *cell.Cell  `tl:"cell"`
+	ID *BlockIDExt `tl:"struct"`
 }
 
 type BlockState struct {
-	ID *BlockIDExt `tl:"struct"`
+	ID       *BlockIDExt `tl:"struct"`
+	RootHash []byte      `tl:"int256"`
+	FileHash []byte      `tl:"int256"`
+	Data     []byte      `tl:"bytes"`
 }
 
 type GetShardBlockProof struct {
@@ -350,27 +351,8 @@ func (c *APIClient) GetMasterchainInfo(ctx context.Context) (*BlockIDExt, error)
 
 	switch t := resp.(type) {
 	case MasterchainInfo:
-		if c.proofCheckPolicy == ProofCheckPolicySecure {
-			root := c.root()
-			root.trustedLock.Lock()
-			defer root.trustedLock.Unlock()
-
-			if root.trustedBlock == nil {
-				if root.trustedBlock == nil {
-					// we have no block to trust, so trust first block we get
-					root.trustedBlock = t.Last.Copy()
-					log.Println("[WARNING] trusted block was not set on initialization, so first block we got was considered as trusted. " +
-						"For better security you should use SetTrustedBlock(block) method and pass there init block from config on start")
-				}
-			} else {
-				if err := c.VerifyProofChain(ctx, root.trustedBlock, t.Last); err != nil {
-					return nil, fmt.Errorf("failed to verify proof chain: %w", err)
-				}
-
-				if t.Last.SeqNo > root.trustedBlock.SeqNo {
-					root.trustedBlock = t.Last.Copy()
-				}
-			}
+		if err = c.checkLastMasterBlock(ctx, t.Last); err != nil {
+			return nil, err
 		}
 		return t.Last, nil
 	case LSError:
@@ -379,6 +361,53 @@ func (c *APIClient) GetMasterchainInfo(ctx context.Context) (*BlockIDExt, error)
 	return nil, errUnexpectedResponse(resp)
 }
 
+// GetMasterchainInfoExt - gets the latest state of master chain together with liteserver version and time
+func (c *APIClient) GetMasterchainInfoExt(ctx context.Context) (*MasterchainInfoExt, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetMasterchainInfoExt{Mode: 0}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case MasterchainInfoExt:
+		if err = c.checkLastMasterBlock(ctx, t.Last); err != nil {
+			return nil, err
+		}
+		return &t, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+func (c *APIClient) checkLastMasterBlock(ctx context.Context, last *BlockIDExt) error {
+	if c.proofCheckPolicy != ProofCheckPolicySecure {
+		return nil
+	}
+
+	root := c.root()
+	root.trustedLock.Lock()
+	defer root.trustedLock.Unlock()
+
+	if root.trustedBlock == nil {
+		// we have no block to trust, so trust first block we get
+		root.trustedBlock = last.Copy()
+		log.Println("[WARNING] trusted block was not set on initialization, so first block we got was considered as trusted. " +
+			"For better security you should use SetTrustedBlock(block) method and pass there init block from config on start")
+		return nil
+	}
+
+	if err := c.VerifyProofChain(ctx, root.trustedBlock, last); err != nil {
+		return fmt.Errorf("failed to verify proof chain: %w", err)
+	}
+
+	if last.SeqNo > root.trustedBlock.SeqNo {
+		c.updateTrustedBlock(last)
+	}
+	return nil
+}
+
 // LookupBlock - find block information by seqno, shard and chain
 func (c *APIClient) LookupBlock(ctx context.Context, workchain int32, shard int64, seqno uint32) (*BlockIDExt, error) {
 	var resp tl.Serializable
@@ -437,6 +466,45 @@ func (c *APIClient) GetBlockData(ctx context.Context, block *BlockIDExt) (*tlb.B
 	return nil, errUnexpectedResponse(resp)
 }
 
+// GetState - gets full state of the block, liteserver allows it only for zero state and some small states.
+// Root hash of the state is verified, and for the zero state it is also checked to be a block hash.
+func (c *APIClient) GetState(ctx context.Context, block *BlockIDExt) (*cell.Cell, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetState{ID: block}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case BlockState:
+		if !t.ID.Equals(block) {
+			return nil, fmt.Errorf("incorrect block in response")
+		}
+
+		fileHash := sha256.Sum256(t.Data)
+		if !bytes.Equal(fileHash[:], t.FileHash) {
+			return nil, fmt.Errorf("incorrect state file hash")
+		}
+
+		state, err := cell.FromBOC(t.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse state: %w", err)
+		}
+
+		if !bytes.Equal(state.Hash(), t.RootHash) {
+			return nil, fmt.Errorf("incorrect state root hash")
+		}
+
+		if block.SeqNo == 0 && (!bytes.Equal(block.RootHash, t.RootHash) || !bytes.Equal(block.FileHash, t.FileHash)) {
+			return nil, fmt.Errorf("state is not matches zero state block")
+		}
+		return state, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
 // GetBlockTransactionsV2 - list of block transactions
 func (c *APIClient) GetBlockTransactionsV2(ctx context.Context, block *BlockIDExt, count uint32, after ...*TransactionID3) ([]TransactionShortInfo, bool, error) {
 	withAfter := uint32(0)
@@ -0,0 +1,321 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	tl.Register(LookupBlockWithProof{}, "liteServer.lookupBlockWithProof mode:# id:tonNode.blockId mc_block_id:tonNode.blockIdExt lt:mode.1?long utime:mode.2?int = liteServer.LookupBlockResult")
+	tl.Register(LookupBlockResult{}, "liteServer.lookupBlockResult id:tonNode.blockIdExt mode:# mc_block_id:tonNode.blockIdExt client_mc_state_proof:bytes mc_block_proof:bytes shard_links:(vector liteServer.shardBlockLink) header:bytes prev_header:bytes = liteServer.LookupBlockResult")
+}
+
+type LookupBlockWithProof struct {
+	Mode      uint32          `tl:"flags"`
+	ID        *BlockInfoShort `tl:"struct"`
+	MCBlockID *BlockIDExt     `tl:"struct"`
+	LT        uint64          `tl:"?1 long"`
+	UTime     uint32          `tl:"?2 int"`
+}
+
+type LookupBlockResult struct {
+	ID                 *BlockIDExt      `tl:"struct"`
+	Mode               uint32           `tl:"flags"`
+	MCBlockID          *BlockIDExt      `tl:"struct"`
+	ClientMCStateProof []*cell.Cell     `tl:"cell optional"`
+	MCBlockProof       *cell.Cell       `tl:"cell optional"`
+	ShardLinks         []ShardBlockLink `tl:"vector struct"`
+	Header             *cell.Cell       `tl:"cell"`
+	PrevHeader         *cell.Cell       `tl:"cell optional"`
+}
+
+// GetShardBlockProof - gets proof that block is a part of the masterchain block returned in result,
+// with policy other than unsafe, links of the proof are verified. Masterchain block should be checked by the caller.
+func (c *APIClient) GetShardBlockProof(ctx context.Context, block *BlockIDExt) (*ShardBlockProof, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetShardBlockProof{ID: block}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case ShardBlockProof:
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			if err = CheckShardBlockProof(block, &t); err != nil {
+				return nil, fmt.Errorf("failed to check shard block proof: %w", err)
+			}
+		}
+		return &t, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// GetBlockHeader - gets block header, header is checked by the proof
+func (c *APIClient) GetBlockHeader(ctx context.Context, block *BlockIDExt) (*tlb.BlockHeader, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetBlockHeader{ID: block, Mode: 0}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case BlockHeader:
+		if !t.ID.Equals(block) {
+			return nil, fmt.Errorf("incorrect block in response")
+		}
+
+		proof, err := cell.FromBOC(t.HeaderProof)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse header proof: %w", err)
+		}
+
+		blk, err := CheckBlockProof(proof, block.RootHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check header proof: %w", err)
+		}
+
+		if blk.BlockInfo.SeqNo != block.SeqNo {
+			return nil, fmt.Errorf("incorrect block seqno in header")
+		}
+		return &blk.BlockInfo, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// LookupBlockWithProof - find block information by seqno, shard and chain,
+// result is proven to be a part of the master block, which is known by client.
+func (c *APIClient) LookupBlockWithProof(ctx context.Context, master *BlockIDExt, workchain int32, shard int64, seqno uint32) (*BlockIDExt, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, LookupBlockWithProof{
+		Mode: 1,
+		ID: &BlockInfoShort{
+			Workchain: workchain,
+			Shard:     shard,
+			Seqno:     int32(seqno),
+		},
+		MCBlockID: master,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case LookupBlockResult:
+		if t.ID == nil || t.ID.Workchain != workchain || t.ID.SeqNo != seqno {
+			return nil, fmt.Errorf("incorrect block in response")
+		}
+
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			if err = CheckLookupBlockProof(master, &t); err != nil {
+				return nil, fmt.Errorf("failed to check lookup block proof: %w", err)
+			}
+		}
+		return t.ID, nil
+	case LSError:
+		// 651 = block not found code
+		if t.Code == 651 {
+			return nil, ErrBlockNotFound
+		}
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// CheckShardBlockProof - verifies links of the proof from masterchain block to the target block
+func CheckShardBlockProof(target *BlockIDExt, proof *ShardBlockProof) error {
+	if proof.MasterchainID == nil || proof.MasterchainID.Workchain != address.MasterchainID {
+		return fmt.Errorf("incorrect masterchain block")
+	}
+
+	cur := proof.MasterchainID
+	for i, link := range proof.Links {
+		if link.ID == nil {
+			return fmt.Errorf("link %d has no block id", i)
+		}
+
+		prf, err := cell.FromBOC(link.Proof)
+		if err != nil {
+			return fmt.Errorf("failed to parse link %d proof: %w", i, err)
+		}
+
+		blk, err := CheckBlockProof(prf, cur.RootHash)
+		if err != nil {
+			return fmt.Errorf("failed to check link %d proof: %w", i, err)
+		}
+
+		if err = checkBlockRefersTo(blk, cur.Workchain == address.MasterchainID, link.ID); err != nil {
+			return fmt.Errorf("incorrect link %d: %w", i, err)
+		}
+		cur = link.ID
+	}
+
+	if !cur.Equals(target) {
+		return fmt.Errorf("proof is not for the target block")
+	}
+	return nil
+}
+
+// CheckLookupBlockProof - verifies that block from lookup result is a part of the known master block
+func CheckLookupBlockProof(master *BlockIDExt, res *LookupBlockResult) error {
+	if res.MCBlockID == nil || !res.MCBlockID.Equals(master) {
+		return fmt.Errorf("incorrect masterchain block in response")
+	}
+
+	if res.Header == nil {
+		return fmt.Errorf("no header proof")
+	}
+
+	header, err := CheckBlockProof(res.Header, res.ID.RootHash)
+	if err != nil {
+		return fmt.Errorf("failed to check header proof: %w", err)
+	}
+
+	if header.BlockInfo.SeqNo != res.ID.SeqNo {
+		return fmt.Errorf("incorrect block seqno in header proof")
+	}
+
+	checkInMaster := func(seqno uint32, rootHash []byte) error {
+		if seqno == master.SeqNo {
+			if !bytes.Equal(rootHash, master.RootHash) {
+				return fmt.Errorf("incorrect masterchain block hash")
+			}
+			return nil
+		}
+
+		if seqno > master.SeqNo {
+			return fmt.Errorf("masterchain block is newer than known")
+		}
+		return CheckMasterBlockInStateProof(master, res.ClientMCStateProof, seqno, rootHash)
+	}
+
+	if res.ID.Workchain == address.MasterchainID {
+		return checkInMaster(res.ID.SeqNo, res.ID.RootHash)
+	}
+
+	if res.MCBlockProof == nil {
+		return fmt.Errorf("no masterchain block proof")
+	}
+
+	mcRoot, err := res.MCBlockProof.PeekRef(0)
+	if err != nil {
+		return fmt.Errorf("incorrect masterchain block proof: %w", err)
+	}
+	mcHash := mcRoot.Hash(0)
+
+	mcBlock, err := CheckBlockProof(res.MCBlockProof, mcHash)
+	if err != nil {
+		return fmt.Errorf("failed to check masterchain block proof: %w", err)
+	}
+
+	if mcBlock.BlockInfo.NotMaster {
+		return fmt.Errorf("masterchain block proof is not for a masterchain block")
+	}
+
+	if err = checkInMaster(mcBlock.BlockInfo.SeqNo, mcHash); err != nil {
+		return err
+	}
+
+	// masterchain block contains the top shard block, which is linked to the target one
+	blk, isMaster := mcBlock, true
+	for i, link := range res.ShardLinks {
+		if link.ID == nil {
+			return fmt.Errorf("link %d has no block id", i)
+		}
+
+		if err = checkBlockRefersTo(blk, isMaster, link.ID); err != nil {
+			return fmt.Errorf("incorrect link %d: %w", i, err)
+		}
+
+		prf, err := cell.FromBOC(link.Proof)
+		if err != nil {
+			return fmt.Errorf("failed to parse link %d proof: %w", i, err)
+		}
+
+		blk, err = CheckBlockProof(prf, link.ID.RootHash)
+		if err != nil {
+			return fmt.Errorf("failed to check link %d proof: %w", i, err)
+		}
+		isMaster = false
+	}
+
+	if err = checkBlockRefersTo(blk, isMaster, res.ID); err != nil {
+		return fmt.Errorf("target block is not linked: %w", err)
+	}
+	return nil
+}
+
+// CheckMasterBlockInStateProof - verifies that masterchain block with given seqno and hash
+// is in the previous blocks list of the master block state
+func CheckMasterBlockInStateProof(master *BlockIDExt, stateProof []*cell.Cell, seqno uint32, rootHash []byte) error {
+	stateExtra, err := CheckShardMcStateExtraProof(master, stateProof)
+	if err != nil {
+		return fmt.Errorf("failed to check proof for mc state extra: %w", err)
+	}
+
+	var info tlb.McStateExtraBlockInfo
+	if err = tlb.LoadFromCellAsProof(&info, stateExtra.Info.BeginParse()); err != nil {
+		return fmt.Errorf("failed to load mc state extra info: %w", err)
+	}
+
+	blkInfo := info.PrevBlocks.GetByIntKey(big.NewInt(int64(seqno)))
+	if blkInfo == nil {
+		return fmt.Errorf("block not found in state proof")
+	}
+
+	slc := blkInfo.BeginParse()
+	if err = tlb.LoadFromCellAsProof(new(tlb.KeyMaxLt), slc); err != nil {
+		return fmt.Errorf("failed to load block KeyMaxLt proof cell: %w", err)
+	}
+
+	var blk tlb.KeyExtBlkRef
+	if err = tlb.LoadFromCellAsProof(&blk, slc); err != nil {
+		return fmt.Errorf("failed to load block KeyExtBlkRef proof cell: %w", err)
+	}
+
+	if blk.BlkRef.SeqNo != seqno || !bytes.Equal(blk.BlkRef.RootHash, rootHash) {
+		return fmt.Errorf("incorrect block hash in state proof")
+	}
+	return nil
+}
+
+// checkBlockRefersTo - checks that masterchain block contains next in shard hashes,
+// or that shard block has it as a parent
+func checkBlockRefersTo(blk *tlb.Block, isMaster bool, next *BlockIDExt) error {
+	var refs []*BlockIDExt
+	if isMaster {
+		if blk.Extra == nil || blk.Extra.Custom == nil {
+			return fmt.Errorf("no shard hashes in masterchain block proof")
+		}
+
+		shards, err := LoadShardsFromHashes(blk.Extra.Custom.ShardHashes, true)
+		if err != nil {
+			return fmt.Errorf("failed to load shard hashes: %w", err)
+		}
+		refs = shards
+	} else {
+		parents, err := blk.BlockInfo.GetParentBlocks()
+		if err != nil {
+			return fmt.Errorf("failed to get parent blocks: %w", err)
+		}
+		refs = parents
+	}
+
+	for _, ref := range refs {
+		if ref.Workchain == next.Workchain && ref.SeqNo == next.SeqNo &&
+			bytes.Equal(ref.RootHash, next.RootHash) && bytes.Equal(ref.FileHash, next.FileHash) {
+			return nil
+		}
+	}
+	return fmt.Errorf("block %d:%x:%d is not referenced", next.Workchain, uint64(next.Shard), next.SeqNo)
+}
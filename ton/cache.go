@@ -0,0 +1,298 @@
+package ton
+
+import (
+	"container/list"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// ResponseCache - storage of serialized liteserver responses, keyed by request hash.
+// Cache is best effort, so implementations should not fail, and just skip data on errors.
+type ResponseCache interface {
+	Get(key []byte) ([]byte, bool)
+	Set(key []byte, data []byte)
+}
+
+// LRUCache - in-memory cache with size limit, least recently used responses are evicted first.
+// When next cache is set, it is used on miss, and all new responses are saved to it too.
+type LRUCache struct {
+	maxSize int
+	size    int
+	next    ResponseCache
+
+	items map[string]*list.Element
+	order *list.List
+
+	mx sync.Mutex
+}
+
+type lruItem struct {
+	key  string
+	data []byte
+}
+
+// DiskCache - stores each response in a separate file in the directory, it is not limited by size
+type DiskCache struct {
+	dir string
+}
+
+type cacheClient struct {
+	original LiteClient
+	cache    ResponseCache
+
+	inProgress map[string]*cacheCall
+	mx         sync.Mutex
+}
+
+type cacheCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// NewLRUCache - creates in-memory cache limited by maxSize bytes of responses data, next cache is optional
+func NewLRUCache(maxSize int, next ...ResponseCache) *LRUCache {
+	c := &LRUCache{
+		maxSize: maxSize,
+		items:   map[string]*list.Element{},
+		order:   list.New(),
+	}
+	if len(next) > 0 {
+		c.next = next[0]
+	}
+	return c
+}
+
+func (c *LRUCache) Get(key []byte) ([]byte, bool) {
+	c.mx.Lock()
+	if el, ok := c.items[string(key)]; ok {
+		c.order.MoveToFront(el)
+		c.mx.Unlock()
+		return el.Value.(*lruItem).data, true
+	}
+	c.mx.Unlock()
+
+	if c.next == nil {
+		return nil, false
+	}
+
+	data, ok := c.next.Get(key)
+	if ok {
+		c.put(key, data)
+	}
+	return data, ok
+}
+
+func (c *LRUCache) Set(key []byte, data []byte) {
+	c.put(key, data)
+	if c.next != nil {
+		c.next.Set(key, data)
+	}
+}
+
+func (c *LRUCache) put(key []byte, data []byte) {
+	if len(data) > c.maxSize {
+		return
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if el, ok := c.items[string(key)]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[string(key)] = c.order.PushFront(&lruItem{key: string(key), data: data})
+	c.size += len(data)
+
+	for c.size > c.maxSize {
+		el := c.order.Back()
+		item := el.Value.(*lruItem)
+		c.order.Remove(el)
+		delete(c.items, item.key)
+		c.size -= len(item.data)
+	}
+}
+
+// NewDiskCache - creates cache in the directory, it will be created if not exists
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+func (c *DiskCache) Get(key []byte) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (c *DiskCache) Set(key []byte, data []byte) {
+	tmp, err := os.CreateTemp(c.dir, "tmp*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if cErr := tmp.Close(); err != nil || cErr != nil {
+		return
+	}
+	// rename is atomic, so readers will never see partially written response
+	_ = os.Rename(tmp.Name(), c.path(key))
+}
+
+func (c *DiskCache) path(key []byte) string {
+	return filepath.Join(c.dir, hex.EncodeToString(key))
+}
+
+// isCacheableRequest - checks that response to request is immutable
+func isCacheableRequest(payload tl.Serializable) bool {
+	switch t := payload.(type) {
+	case GetBlockData:
+		return t.ID != nil
+	case GetBlockHeader:
+		return t.ID != nil
+	case GetOneTransaction:
+		return t.ID != nil
+	case GetAllShardsInfo:
+		return t.ID != nil
+	case GetConfigAll:
+		return t.BlockID != nil
+	case GetConfigParams:
+		return t.BlockID != nil
+	case LookupBlock:
+		// only by seqno, because lookup by lt or time can give different blocks until shard is finalized
+		return t.Mode == 1 && t.ID != nil
+	case GetLibraries:
+		// libraries are identified by hash of their content
+		return true
+	}
+	return false
+}
+
+// isCacheableResponse - checks that response has the data, and not an error
+func isCacheableResponse(payload, resp tl.Serializable) bool {
+	switch t := resp.(type) {
+	case LSError:
+		return false
+	case LibraryResult:
+		// library can be not known by node yet, cache only full result
+		return len(t.Result) == len(payload.(GetLibraries).LibraryList)
+	}
+	return true
+}
+
+func (c *cacheClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	if !isCacheableRequest(payload) {
+		return c.original.QueryLiteserver(ctx, payload, result)
+	}
+
+	key, err := tl.Hash(payload)
+	if err != nil {
+		return fmt.Errorf("failed to calc request hash: %w", err)
+	}
+
+	var resp tl.Serializable
+	data, ok := c.cache.Get(key)
+	if !ok {
+		data, resp, err = c.load(ctx, key, payload)
+		if err != nil {
+			return err
+		}
+
+		if resp == nil && data == nil {
+			// concurrent request was not successful, do it ourselves
+			return c.original.QueryLiteserver(ctx, payload, result)
+		}
+	}
+
+	if resp == nil {
+		if _, err = tl.Parse(&resp, data, true); err != nil {
+			return fmt.Errorf("failed to parse cached response: %w", err)
+		}
+	}
+	reflect.ValueOf(result).Elem().Set(reflect.ValueOf(resp))
+	return nil
+}
+
+// load - requests data from liteserver, concurrent requests with the same key are waiting for the first one.
+// Response is returned only to the first request, others are getting serialized data,
+// or nothing, when request was failed or response is not cacheable.
+func (c *cacheClient) load(ctx context.Context, key []byte, payload tl.Serializable) ([]byte, tl.Serializable, error) {
+	c.mx.Lock()
+	if call, ok := c.inProgress[string(key)]; ok {
+		c.mx.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-call.done:
+		}
+
+		if call.err != nil {
+			// error can be related to the context of another request
+			return nil, nil, nil
+		}
+		return call.data, nil, nil
+	}
+
+	call := &cacheCall{done: make(chan struct{})}
+	c.inProgress[string(key)] = call
+	c.mx.Unlock()
+
+	defer func() {
+		c.mx.Lock()
+		delete(c.inProgress, string(key))
+		c.mx.Unlock()
+		close(call.done)
+	}()
+
+	var resp tl.Serializable
+	if call.err = c.original.QueryLiteserver(ctx, payload, &resp); call.err != nil {
+		return nil, nil, call.err
+	}
+
+	if !isCacheableResponse(payload, resp) {
+		call.err = fmt.Errorf("response is not cacheable")
+		return nil, resp, nil
+	}
+
+	if call.data, call.err = tl.Serialize(resp, true); call.err != nil {
+		// still can be used by the caller
+		return nil, resp, nil
+	}
+	c.cache.Set(key, call.data)
+	return call.data, resp, nil
+}
+
+func (c *cacheClient) unwrap() LiteClient {
+	return c.original
+}
+
+func (c *cacheClient) StickyContext(ctx context.Context) context.Context {
+	return c.original.StickyContext(ctx)
+}
+
+func (c *cacheClient) StickyNodeID(ctx context.Context) uint32 {
+	return c.original.StickyNodeID(ctx)
+}
+
+func (c *cacheClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return c.original.StickyContextNextNode(ctx)
+}
+
+func (c *cacheClient) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return c.original.StickyContextNextNodeBalanced(ctx)
+}
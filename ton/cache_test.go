@@ -0,0 +1,119 @@
+package ton
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+type countingClient struct {
+	calls int32
+}
+
+func (m *countingClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	atomic.AddInt32(&m.calls, 1)
+
+	req := payload.(LookupBlock)
+	if req.ID.Seqno > 1000 {
+		*result.(*tl.Serializable) = LSError{Code: 651, Text: "not found"}
+		return nil
+	}
+
+	*result.(*tl.Serializable) = BlockHeader{
+		ID: &BlockIDExt{Workchain: req.ID.Workchain, Shard: req.ID.Shard, SeqNo: uint32(req.ID.Seqno),
+			RootHash: make([]byte, 32), FileHash: make([]byte, 32)},
+		HeaderProof: []byte{1, 2, 3},
+	}
+	return nil
+}
+
+func (m *countingClient) StickyContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (m *countingClient) StickyNodeID(ctx context.Context) uint32 {
+	return 0
+}
+
+func (m *countingClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *countingClient) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func TestAPIClient_WithCache(t *testing.T) {
+	client := &countingClient{}
+	disk, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewAPIClient(client, ProofCheckPolicyUnsafe).WithCache(NewLRUCache(1<<20, disk))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			block, err := api.LookupBlock(context.Background(), -1, -0x8000000000000000, 100)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if block.SeqNo != 100 {
+				t.Error("incorrect block")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+		t.Fatal("request should be sent once, sent", calls)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err = api.LookupBlock(context.Background(), -1, -0x8000000000000000, 2000); err != ErrBlockNotFound {
+			t.Fatal("block should not be found, got", err)
+		}
+	}
+	if calls := atomic.LoadInt32(&client.calls); calls != 3 {
+		t.Fatal("errors should not be cached, calls", calls)
+	}
+
+	// new memory cache, data should be taken from disk
+	api = NewAPIClient(client, ProofCheckPolicyUnsafe).WithCache(NewLRUCache(1<<20, disk))
+	block, err := api.LookupBlock(context.Background(), -1, -0x8000000000000000, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.SeqNo != 100 || atomic.LoadInt32(&client.calls) != 3 {
+		t.Fatal("response should be loaded from disk")
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(10)
+	c.Set([]byte("a"), make([]byte, 4))
+	c.Set([]byte("b"), make([]byte, 4))
+	c.Get([]byte("a"))
+	c.Set([]byte("c"), make([]byte, 4))
+
+	if _, ok := c.Get([]byte("b")); ok {
+		t.Fatal("least recently used should be evicted")
+	}
+	if _, ok := c.Get([]byte("a")); !ok {
+		t.Fatal("recently used should be kept")
+	}
+	if _, ok := c.Get([]byte("c")); !ok {
+		t.Fatal("new item should be kept")
+	}
+
+	c.Set([]byte("d"), make([]byte, 11))
+	if _, ok := c.Get([]byte("d")); ok {
+		t.Fatal("too big item should not be cached")
+	}
+}
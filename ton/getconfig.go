@@ -3,10 +3,15 @@ package ton
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"sort"
+	"strconv"
 
 	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
@@ -18,6 +23,8 @@ func init() {
 	tl.Register(GetLibraries{}, "liteServer.getLibraries library_list:(vector int256) = liteServer.LibraryResult")
 	tl.Register(LibraryEntry{}, "liteServer.libraryEntry hash:int256 data:bytes = liteServer.LibraryEntry")
 	tl.Register(LibraryResult{}, "liteServer.libraryResult result:(vector liteServer.libraryEntry) = liteServer.LibraryResult")
+	tl.Register(GetLibrariesWithProof{}, "liteServer.getLibrariesWithProof id:tonNode.blockIdExt mode:# library_list:(vector int256) = liteServer.LibraryResultWithProof")
+	tl.Register(LibraryResultWithProof{}, "liteServer.libraryResultWithProof id:tonNode.blockIdExt mode:# result:(vector liteServer.libraryEntry) state_proof:bytes data_proof:bytes = liteServer.LibraryResultWithProof")
 }
 
 type GetLibraries struct {
@@ -33,6 +40,20 @@ type LibraryResult struct {
 	Result []*LibraryEntry `tl:"vector struct"`
 }
 
+type GetLibrariesWithProof struct {
+	ID          *BlockIDExt `tl:"struct"`
+	Mode        uint32      `tl:"flags"`
+	LibraryList [][]byte    `tl:"vector int256"`
+}
+
+type LibraryResultWithProof struct {
+	ID         *BlockIDExt     `tl:"struct"`
+	Mode       uint32          `tl:"flags"`
+	Result     []*LibraryEntry `tl:"vector struct"`
+	StateProof *cell.Cell      `tl:"cell"`
+	DataProof  *cell.Cell      `tl:"cell"`
+}
+
 type ConfigAll struct {
 	Mode        int         `tl:"int"`
 	ID          *BlockIDExt `tl:"struct"`
@@ -87,6 +108,108 @@ func (c *APIClient) GetLibraries(ctx context.Context, hashes ...[]byte) ([]*cell
 	return nil, errUnexpectedResponse(resp)
 }
 
+// GetLibrariesWithProof - gets libraries from the masterchain block state, existence of each library is checked by the proof.
+// Result has the same order as requested hashes, nil is returned for libraries which are not exist.
+func (c *APIClient) GetLibrariesWithProof(ctx context.Context, master *BlockIDExt, hashes ...[]byte) ([]*cell.Cell, error) {
+	var resp tl.Serializable
+	if err := c.client.QueryLiteserver(ctx, GetLibrariesWithProof{
+		ID:          master,
+		Mode:        0,
+		LibraryList: hashes,
+	}, &resp); err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case LibraryResultWithProof:
+		if !t.ID.Equals(master) {
+			return nil, fmt.Errorf("incorrect block in response")
+		}
+
+		state, err := CheckBlockShardStateProof([]*cell.Cell{t.StateProof, t.DataProof}, master.RootHash)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect proof: %w", err)
+		}
+
+		libs, err := loadLibrariesFromStateProof(state)
+		if err != nil {
+			return nil, err
+		}
+
+		libList := make([]*cell.Cell, len(hashes))
+		for i, hash := range hashes {
+			descr, err := libs.LoadValue(cell.BeginCell().MustStoreSlice(hash, 256).EndCell())
+			if err != nil {
+				if errors.Is(err, cell.ErrNoSuchKeyInDict) {
+					// proven that library is not exists
+					continue
+				}
+				return nil, fmt.Errorf("failed to find library %x in proof: %w", hash, err)
+			}
+
+			if tag, err := descr.LoadUInt(2); err != nil || tag != 0 {
+				return nil, fmt.Errorf("incorrect library %x descr", hash)
+			}
+
+			lib, err := descr.LoadRefCell()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load library %x from descr: %w", hash, err)
+			}
+
+			if !bytes.Equal(lib.Hash(0), hash) {
+				return nil, fmt.Errorf("incorrect library %x hash in proof", hash)
+			}
+
+			if lib.GetType() != cell.PrunedCellType {
+				libList[i] = lib
+				continue
+			}
+
+			for _, e := range t.Result {
+				if e.Data != nil && bytes.Equal(hash, e.Data.Hash()) {
+					libList[i] = e.Data
+					break
+				}
+			}
+
+			if libList[i] == nil {
+				return nil, fmt.Errorf("library %x exists in proof but not returned", hash)
+			}
+		}
+		return libList, nil
+	case LSError:
+		return nil, t
+	}
+
+	return nil, errUnexpectedResponse(resp)
+}
+
+func loadLibrariesFromStateProof(state *tlb.ShardStateUnsplit) (*cell.Dictionary, error) {
+	if state.Stats == nil || state.Stats.GetType() == cell.PrunedCellType {
+		return nil, fmt.Errorf("no libraries in state proof")
+	}
+
+	loader := state.Stats.BeginParse()
+	// overload and underload history
+	if _, err := loader.LoadSlice(128); err != nil {
+		return nil, fmt.Errorf("failed to skip history: %w", err)
+	}
+
+	var balance, fees tlb.CurrencyCollection
+	if err := tlb.LoadFromCellAsProof(&balance, loader); err != nil {
+		return nil, fmt.Errorf("failed to load total balance: %w", err)
+	}
+	if err := tlb.LoadFromCellAsProof(&fees, loader); err != nil {
+		return nil, fmt.Errorf("failed to load total validator fees: %w", err)
+	}
+
+	libs, err := loader.LoadDict(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load libraries dict: %w", err)
+	}
+	return libs, nil
+}
+
 func (c *APIClient) GetBlockchainConfig(ctx context.Context, block *BlockIDExt, onlyParams ...int32) (*BlockchainConfig, error) {
 	var resp tl.Serializable
 	var err error
@@ -156,7 +279,10 @@ func (c *APIClient) GetBlockchainConfig(ctx context.Context, block *BlockIDExt,
 	return nil, errUnexpectedResponse(resp)
 }
 
-// TODO: add methods to BlockchainConfig to easily get gas price and etc
+// NewBlockchainConfig - creates config from the params map, can be used when params are fetched from other source
+func NewBlockchainConfig(params map[int32]*cell.Cell) *BlockchainConfig {
+	return &BlockchainConfig{data: params}
+}
 
 func (b *BlockchainConfig) Get(id int32) *cell.Cell {
 	return b.data[id]
@@ -165,3 +291,159 @@ func (b *BlockchainConfig) Get(id int32) *cell.Cell {
 func (b *BlockchainConfig) All() map[int32]*cell.Cell {
 	return b.data
 }
+
+// GetStoragePrices - parses config param 18, result is sorted by UTimeSince
+func (b *BlockchainConfig) GetStoragePrices() ([]tlb.StoragePrices, error) {
+	c := b.data[18]
+	if c == nil {
+		return nil, fmt.Errorf("config param 18 not found")
+	}
+
+	kvs, err := c.AsDict(32).LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load storage prices dict: %w", err)
+	}
+
+	res := make([]tlb.StoragePrices, 0, len(kvs))
+	for _, kv := range kvs {
+		var p tlb.StoragePrices
+		if err = tlb.LoadFromCell(&p, kv.Value); err != nil {
+			return nil, fmt.Errorf("failed to parse storage prices: %w", err)
+		}
+		res = append(res, p)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].UTimeSince < res[j].UTimeSince
+	})
+	return res, nil
+}
+
+// GetGasLimitsPrices - parses config param 20 for masterchain or 21 for basechain
+func (b *BlockchainConfig) GetGasLimitsPrices(masterchain bool) (*tlb.GasLimitsPrices, error) {
+	id := int32(21)
+	if masterchain {
+		id = 20
+	}
+
+	c := b.data[id]
+	if c == nil {
+		return nil, fmt.Errorf("config param %d not found", id)
+	}
+
+	var p tlb.GasLimitsPrices
+	if err := tlb.LoadFromCell(&p, c.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse config param %d: %w", id, err)
+	}
+	return &p, nil
+}
+
+// GetMsgForwardPrices - parses config param 24 for masterchain or 25 for basechain
+func (b *BlockchainConfig) GetMsgForwardPrices(masterchain bool) (*tlb.MsgForwardPrices, error) {
+	id := int32(25)
+	if masterchain {
+		id = 24
+	}
+
+	c := b.data[id]
+	if c == nil {
+		return nil, fmt.Errorf("config param %d not found", id)
+	}
+
+	var p tlb.MsgForwardPrices
+	if err := tlb.LoadFromCell(&p, c.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse config param %d: %w", id, err)
+	}
+	return &p, nil
+}
+
+// Param - decodes config param into its typed tlb struct, for example *tlb.GasLimitsPrices for param 21.
+// Unknown params are returned as a raw *cell.Cell.
+func (b *BlockchainConfig) Param(id int32) (any, error) {
+	c := b.data[id]
+	if c == nil {
+		return nil, fmt.Errorf("config param %d not found", id)
+	}
+
+	var v any
+	switch id {
+	case 0, 1, 2, 3, 4:
+		v = &tlb.ConfigAddress{}
+	case 5:
+		v = &tlb.BurningConfig{}
+	case 6:
+		v = &tlb.MintPrices{}
+	case 7:
+		v = &tlb.ToMint{}
+	case 8:
+		v = &tlb.GlobalVersion{}
+	case 9, 10:
+		v = &tlb.ConfigParamsList{}
+	case 11:
+		v = &tlb.ConfigVotingSetup{}
+	case 12:
+		v = &tlb.Workchains{}
+	case 13:
+		v = &tlb.ComplaintPricing{}
+	case 14:
+		v = &tlb.BlockCreateFees{}
+	case 15:
+		v = &tlb.ElectionsTimings{}
+	case 16:
+		v = &tlb.ValidatorsCount{}
+	case 17:
+		v = &tlb.StakeLimits{}
+	case 18:
+		v = &tlb.StoragePricesList{}
+	case 19:
+		v = &tlb.GlobalID{}
+	case 20, 21:
+		v = &tlb.GasLimitsPrices{}
+	case 22, 23:
+		v = &tlb.BlockLimits{}
+	case 24, 25:
+		v = &tlb.MsgForwardPrices{}
+	case 28:
+		v = &tlb.CatchainConfig{}
+	case 29:
+		v = &tlb.ConsensusConfig{}
+	case 31:
+		v = &tlb.FundamentalSmcAddresses{}
+	case 32, 33, 34, 35, 36, 37:
+		v = &tlb.ValidatorSetAny{}
+	case 39:
+		v = &tlb.ValidatorSignedTempKeys{}
+	case 40:
+		v = &tlb.MisbehaviourPunishmentConfig{}
+	case 43:
+		v = &tlb.SizeLimitsConfig{}
+	case 44:
+		v = &tlb.SuspendedAddressList{}
+	case 45:
+		v = &tlb.PrecompiledContractsConfig{}
+	case 71, 72, 73:
+		v = &tlb.OracleBridgeParams{}
+	case 79, 81, 82:
+		v = &tlb.JettonBridgeParams{}
+	default:
+		return c, nil
+	}
+
+	if err := tlb.LoadFromCell(v, c.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse config param %d: %w", id, err)
+	}
+	return v, nil
+}
+
+// MarshalJSON - all params as object with param id keys, params which cannot be decoded are represented as boc
+func (b *BlockchainConfig) MarshalJSON() ([]byte, error) {
+	res := make(map[string]any, len(b.data))
+	for id, c := range b.data {
+		v, err := b.Param(id)
+		if err != nil {
+			v = c
+		}
+		res[strconv.Itoa(int(id))] = v
+	}
+	return json.Marshal(res)
+}
@@ -0,0 +1,297 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestBlockchainConfig_Param(t *testing.T) {
+	mandatory := cell.NewDict(32)
+	for _, id := range []int64{20, 0, 18, 1} {
+		if err := mandatory.SetIntKey(big.NewInt(id), cell.BeginCell().EndCell()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workchains := cell.NewDict(32)
+	if err := workchains.SetIntKey(big.NewInt(0), cell.BeginCell().
+		MustStoreUInt(0xa6, 8).MustStoreUInt(1573821854, 32).
+		MustStoreUInt(0, 8).MustStoreUInt(2, 8).MustStoreUInt(8, 8).
+		MustStoreBoolBit(true).MustStoreBoolBit(true).MustStoreBoolBit(true).MustStoreUInt(0, 13).
+		MustStoreSlice(make([]byte, 32), 256).MustStoreSlice(make([]byte, 32), 256).
+		MustStoreUInt(0, 32).
+		// wfmt_basic#1 vm_version:int32 vm_mode:uint64, tag is 4 bits as in block.tlb
+		MustStoreUInt(0x1, 4).MustStoreInt(-1, 32).MustStoreUInt(0, 64).
+		EndCell()); err != nil {
+		t.Fatal(err)
+	}
+	if err := workchains.SetIntKey(big.NewInt(1), cell.BeginCell().
+		MustStoreUInt(0xa6, 8).MustStoreUInt(1573821854, 32).
+		MustStoreUInt(0, 8).MustStoreUInt(0, 8).MustStoreUInt(4, 8).
+		MustStoreBoolBit(false).MustStoreBoolBit(false).MustStoreBoolBit(false).MustStoreUInt(0, 13).
+		MustStoreSlice(make([]byte, 32), 256).MustStoreSlice(make([]byte, 32), 256).
+		MustStoreUInt(0, 32).
+		// wfmt_ext#0 min_addr_len:(## 12) max_addr_len:(## 12) addr_len_step:(## 12) workchain_type_id:(## 32)
+		MustStoreUInt(0x0, 4).MustStoreUInt(64, 12).MustStoreUInt(1023, 12).MustStoreUInt(8, 12).MustStoreUInt(1, 32).
+		EndCell()); err != nil {
+		t.Fatal(err)
+	}
+	if err := workchains.SetIntKey(big.NewInt(2), cell.BeginCell().
+		MustStoreUInt(0xa7, 8).MustStoreUInt(1573821854, 32).
+		MustStoreUInt(0, 8).MustStoreUInt(2, 8).MustStoreUInt(8, 8).
+		MustStoreBoolBit(true).MustStoreBoolBit(true).MustStoreBoolBit(true).MustStoreUInt(0, 13).
+		MustStoreSlice(make([]byte, 32), 256).MustStoreSlice(make([]byte, 32), 256).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(0x1, 4).MustStoreInt(-1, 32).MustStoreUInt(0, 64).
+		// wc_split_merge_timings#0 then persistent_state_split_depth:(## 8)
+		MustStoreUInt(0x0, 4).MustStoreUInt(10, 32).MustStoreUInt(20, 32).MustStoreUInt(30, 32).MustStoreUInt(40, 32).
+		MustStoreUInt(4, 8).
+		EndCell()); err != nil {
+		t.Fatal(err)
+	}
+
+	suspended := cell.NewDict(288)
+	if err := suspended.Set(cell.BeginCell().MustStoreInt(0, 32).MustStoreSlice(bytes.Repeat([]byte{0xAA}, 32), 256).EndCell(),
+		cell.BeginCell().EndCell()); err != nil {
+		t.Fatal(err)
+	}
+
+	validators := cell.NewDict(16)
+	validator, err := tlb.ToCell(tlb.Validator{PublicKey: tlb.SigPubKeyED25519{Key: make([]byte, 32)}, Weight: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = validators.SetIntKey(big.NewInt(0), validator); err != nil {
+		t.Fatal(err)
+	}
+	// validators#11 utime_since:uint32 utime_until:uint32 total:(## 16) main:(## 16) list:(Hashmap 16 ValidatorDescr)
+	validatorSet := cell.BeginCell().
+		MustStoreUInt(0x11, 8).MustStoreUInt(100, 32).MustStoreUInt(200, 32).MustStoreUInt(1, 16).MustStoreUInt(1, 16).
+		MustStoreBuilder(validators.AsCell().ToBuilder()).
+		EndCell()
+
+	paramLimits := func(u, s, h uint64) *cell.Builder {
+		return cell.BeginCell().MustStoreUInt(0xc3, 8).MustStoreUInt(u, 32).MustStoreUInt(s, 32).MustStoreUInt(h, 32)
+	}
+
+	cfg := NewBlockchainConfig(map[int32]*cell.Cell{
+		1:  cell.BeginCell().MustStoreSlice(bytes.Repeat([]byte{0x33}, 32), 256).EndCell(),
+		5:  cell.BeginCell().MustStoreUInt(0x01, 8).MustStoreBoolBit(true).MustStoreSlice(make([]byte, 32), 256).MustStoreUInt(1, 32).MustStoreUInt(2, 32).EndCell(),
+		8:  cell.BeginCell().MustStoreUInt(0xc4, 8).MustStoreUInt(9, 32).MustStoreUInt(494, 64).EndCell(),
+		9:  mandatory.AsCell(),
+		12: cell.BeginCell().MustStoreDict(workchains).EndCell(),
+		15: cell.BeginCell().MustStoreUInt(65536, 32).MustStoreUInt(32768, 32).MustStoreUInt(8192, 32).MustStoreUInt(32768, 32).EndCell(),
+		17: cell.BeginCell().MustStoreBigCoins(big.NewInt(10)).MustStoreBigCoins(big.NewInt(20)).MustStoreBigCoins(big.NewInt(30)).MustStoreUInt(196608, 32).EndCell(),
+		22: cell.BeginCell().MustStoreUInt(0x5e, 8).
+			MustStoreBuilder(paramLimits(131072, 524288, 1048576)).
+			MustStoreBuilder(paramLimits(2000000, 10000000, 20000000)).
+			MustStoreBuilder(paramLimits(1000, 5000, 10000)).
+			MustStoreBuilder(paramLimits(2000000, 4000000, 8000000)).
+			MustStoreUInt(0xd3, 8).MustStoreUInt(2097152, 32).MustStoreUInt(8192, 32).
+			EndCell(),
+		34: validatorSet,
+		43: cell.BeginCell().MustStoreUInt(0x02, 8).
+			MustStoreUInt(2097152, 32).MustStoreUInt(8192, 32).MustStoreUInt(1000, 32).MustStoreUInt(512, 16).
+			MustStoreUInt(65535, 32).MustStoreUInt(512, 16).MustStoreUInt(65536, 32).MustStoreUInt(67108864, 32).
+			EndCell(),
+		44: cell.BeginCell().MustStoreUInt(0, 8).MustStoreDict(suspended).MustStoreUInt(1735693200, 32).EndCell(),
+		99: cell.BeginCell().MustStoreUInt(7, 8).EndCell(),
+	})
+
+	v, err := cfg.Param(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := v.(*tlb.ConfigAddress).Address(); addr.Workchain() != -1 || !bytes.Equal(addr.Data(), bytes.Repeat([]byte{0x33}, 32)) {
+		t.Fatal("incorrect elector address")
+	}
+
+	v, err = cfg.Param(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := v.(*tlb.BurningConfig); len(b.BlackholeAddr) != 32 || b.FeeBurnNum != 1 || b.FeeBurnDenom != 2 {
+		t.Fatal("incorrect burning config")
+	}
+
+	v, err = cfg.Param(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gv := v.(*tlb.GlobalVersion); gv.Version != 9 || gv.Capabilities != 494 {
+		t.Fatal("incorrect global version")
+	}
+
+	v, err = cfg.Param(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := v.(*tlb.ConfigParamsList).Params; len(list) != 4 || list[0] != 0 || list[3] != 20 {
+		t.Fatal("incorrect mandatory params", list)
+	}
+
+	v, err = cfg.Param(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wc, ok := v.(*tlb.Workchains).List["0"].Descr.(tlb.WorkchainDescrV1)
+	if !ok || wc.MaxSplit != 8 || !wc.AcceptMsgs || wc.Format.(tlb.WorkchainFormatBasic).VMVersion != -1 {
+		t.Fatal("incorrect workchains")
+	}
+	wcExt, ok := v.(*tlb.Workchains).List["1"].Descr.(tlb.WorkchainDescrV1)
+	if f, isExt := wcExt.Format.(tlb.WorkchainFormatExt); !ok || !isExt || f.MaxAddrLen != 1023 || f.WorkchainTypeID != 1 {
+		t.Fatal("incorrect ext workchain")
+	}
+	wcV2, ok := v.(*tlb.Workchains).List["2"].Descr.(tlb.WorkchainDescrV2)
+	if !ok || wcV2.SplitMergeTimings.MaxSplitMergeDelay != 40 || wcV2.PersistentStateSplitDepth != 4 {
+		t.Fatal("incorrect workchain v2")
+	}
+
+	v, err = cfg.Param(22)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, ok := v.(*tlb.BlockLimits).Limits.(tlb.BlockLimitsV2); !ok || l.Gas.HardLimit != 20000000 || l.CollatedData.SoftLimit != 4000000 || l.ImportedMsgQueue.MaxMsgs != 8192 {
+		t.Fatal("incorrect block limits")
+	}
+
+	v, err = cfg.Param(34)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs, ok := v.(*tlb.ValidatorSetAny).Validators.(tlb.ValidatorSet)
+	if !ok || vs.UTimeUntil != 200 || vs.List.GetByIntKey(big.NewInt(0)) == nil {
+		t.Fatal("incorrect validator set")
+	}
+
+	v, err = cfg.Param(43)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sl := v.(*tlb.SizeLimitsConfig); sl.MaxMsgCells != 8192 || sl.MaxAccStateBits != 67108864 || sl.DeferOutQueueSizeLimit != 0 {
+		t.Fatal("incorrect size limits")
+	}
+
+	v, err = cfg.Param(44)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sa := v.(*tlb.SuspendedAddressList); len(sa.Addresses) != 1 || sa.SuspendedUntil != 1735693200 || sa.Addresses[0].Workchain() != 0 {
+		t.Fatal("incorrect suspended addresses")
+	}
+
+	v, err = cfg.Param(99)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok = v.(*cell.Cell); !ok {
+		t.Fatal("unknown param should be returned as cell")
+	}
+
+	if _, err = cfg.Param(100); err == nil {
+		t.Fatal("missing param should return error")
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res map[string]json.RawMessage
+	if err = json.Unmarshal(data, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 12 {
+		t.Fatal("incorrect params number in json", len(res))
+	}
+
+	var stake struct {
+		MinStake       string
+		MaxStakeFactor uint32
+	}
+	if err = json.Unmarshal(res["17"], &stake); err != nil {
+		t.Fatal(err)
+	}
+	if stake.MinStake != "10" || stake.MaxStakeFactor != 196608 {
+		t.Fatal("incorrect json of param 17", string(res["17"]))
+	}
+}
+
+func TestAPIClient_GetLibrariesWithProof(t *testing.T) {
+	// library with refs is pruned in proof, so it should be returned in result
+	bigLib := cell.BeginCell().MustStoreUInt(1, 8).MustStoreRef(cell.BeginCell().MustStoreUInt(2, 8).EndCell()).EndCell()
+	smallLib := cell.BeginCell().MustStoreUInt(3, 8).EndCell()
+	missing := cell.BeginCell().MustStoreUInt(4, 8).EndCell()
+
+	libs := cell.NewDict(256)
+	for _, lib := range []*cell.Cell{bigLib, smallLib} {
+		if err := libs.Set(cell.BeginCell().MustStoreSlice(lib.Hash(), 256).EndCell(), cell.BeginCell().MustStoreUInt(0, 2).MustStoreRef(lib).EndCell()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := cell.BeginCell().
+		MustStoreUInt(0, 64).MustStoreUInt(0, 64).
+		MustStoreCoins(0).MustStoreDict(nil).
+		MustStoreCoins(0).MustStoreDict(nil).
+		MustStoreDict(libs).
+		MustStoreBoolBit(false).
+		EndCell()
+	block := newTestBlock(t, -1, 10, testBlockParts{stateStats: stats})
+
+	proof := func(block *testBlock, keys ...*cell.Cell) []*cell.Cell {
+		sk := cell.CreateProofSkeleton()
+		libsSk := sk.ProofRef(2).ProofRef(0)
+		for _, k := range keys {
+			_, _, err := libs.LoadValueWithProof(cell.BeginCell().MustStoreSlice(k.Hash(), 256).EndCell(), libsSk)
+			if err != nil && !errors.Is(err, cell.ErrNoSuchKeyInDict) {
+				t.Fatal(err)
+			}
+		}
+		return block.stateProof(t, sk)
+	}
+
+	request := func(prf []*cell.Cell, result ...*cell.Cell) ([]*cell.Cell, error) {
+		api := NewAPIClient(&proofClient{answer: func(payload tl.Serializable) tl.Serializable {
+			res := LibraryResultWithProof{ID: block.id, StateProof: prf[0], DataProof: prf[1]}
+			for _, c := range result {
+				res.Result = append(res.Result, &LibraryEntry{Hash: c.Hash(), Data: c})
+			}
+			return res
+		}})
+		return api.GetLibrariesWithProof(context.Background(), block.id, bigLib.Hash(), smallLib.Hash(), missing.Hash())
+	}
+
+	list, err := request(proof(block, bigLib, smallLib, missing), bigLib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || !bytes.Equal(list[0].Hash(), bigLib.Hash()) || !bytes.Equal(list[1].Hash(), smallLib.Hash()) || list[2] != nil {
+		t.Fatal("incorrect libraries")
+	}
+
+	if _, err = request(proof(block, bigLib, smallLib, missing)); err == nil {
+		t.Fatal("library which exists in proof should be returned")
+	}
+
+	if _, err = request(proof(block, bigLib, smallLib, missing), smallLib); err == nil {
+		t.Fatal("another library should not be accepted")
+	}
+
+	if _, err = request(proof(block, bigLib), bigLib); err == nil {
+		t.Fatal("library hidden in pruned branch should be rejected")
+	}
+
+	other := newTestBlock(t, -1, 11, testBlockParts{stateStats: stats})
+	if _, err = request(proof(other, bigLib, smallLib, missing), bigLib); err == nil {
+		t.Fatal("proof of another block should be rejected")
+	}
+}
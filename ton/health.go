@@ -0,0 +1,80 @@
+package ton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// NewHealthProbe - creates probe for liteclient.ConnectionPool health checks, it requests
+// masterchain info with version and capabilities of the node. When checkArchiveDepth is true,
+// the oldest available masterchain block is also searched, it costs about 30 additional requests per check.
+func NewHealthProbe(checkArchiveDepth bool) liteclient.HealthProbe {
+	return func(ctx context.Context, pool *liteclient.ConnectionPool) (*liteclient.NodeInfo, error) {
+		var resp tl.Serializable
+		if err := pool.QueryLiteserver(ctx, GetMasterchainInfoExt{Mode: 0}, &resp); err != nil {
+			return nil, err
+		}
+
+		var info *liteclient.NodeInfo
+		switch t := resp.(type) {
+		case MasterchainInfoExt:
+			if t.Last == nil {
+				return nil, fmt.Errorf("no last block in response")
+			}
+
+			info = &liteclient.NodeInfo{
+				MasterSeqno:  t.Last.SeqNo,
+				Version:      uint32(t.Version),
+				Capabilities: uint64(t.Capabilities),
+			}
+		case LSError:
+			return nil, t
+		default:
+			return nil, errUnexpectedResponse(resp)
+		}
+
+		if checkArchiveDepth {
+			oldest, err := findOldestMasterBlock(ctx, NewAPIClient(pool, ProofCheckPolicyUnsafe), info.MasterSeqno)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find oldest block: %w", err)
+			}
+			info.OldestMasterSeqno = oldest
+		}
+		return info, nil
+	}
+}
+
+// findOldestMasterBlock - binary search of the oldest masterchain block known by node
+func findOldestMasterBlock(ctx context.Context, api *APIClient, last uint32) (uint32, error) {
+	low, high := uint32(1), last
+	for low < high {
+		mid := low + (high-low)/2
+
+		_, err := api.LookupBlock(ctx, address.MasterchainID, -0x8000000000000000, mid)
+		if err != nil {
+			if !isBlockMissing(err) {
+				return 0, err
+			}
+			low = mid + 1
+			continue
+		}
+		high = mid
+	}
+	return low, nil
+}
+
+// isBlockMissing - node reports block which is not in its db with 651 (ErrBlockNotFound) or -400,
+// other errors are not related to the block availability
+func isBlockMissing(err error) bool {
+	if errors.Is(err, ErrBlockNotFound) {
+		return true
+	}
+
+	var lsErr LSError
+	return errors.As(err, &lsErr) && lsErr.Code == -400
+}
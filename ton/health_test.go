@@ -0,0 +1,47 @@
+package ton
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+type blockLookupClient struct {
+	txHistoryClient
+	oldest     uint32
+	missedCode int32
+	// failSeqno - seqno for which node responds with error not related to block availability
+	failSeqno uint32
+}
+
+func (m *blockLookupClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	seqno := uint32(payload.(LookupBlock).ID.Seqno)
+	switch {
+	case seqno == m.failSeqno:
+		*result.(*tl.Serializable) = LSError{Code: 602, Text: "failure"}
+	case seqno < m.oldest:
+		*result.(*tl.Serializable) = LSError{Code: m.missedCode, Text: "not in db"}
+	default:
+		*result.(*tl.Serializable) = BlockHeader{ID: &BlockIDExt{Workchain: -1, Shard: -0x8000000000000000, SeqNo: seqno}}
+	}
+	return nil
+}
+
+func TestFindOldestMasterBlock(t *testing.T) {
+	for _, code := range []int32{651, -400} {
+		api := NewAPIClient(&blockLookupClient{oldest: 12345, missedCode: code}, ProofCheckPolicyUnsafe)
+		oldest, err := findOldestMasterBlock(context.Background(), api, 100000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if oldest != 12345 {
+			t.Fatal("incorrect oldest block", oldest, "for code", code)
+		}
+	}
+
+	api := NewAPIClient(&blockLookupClient{oldest: 12345, missedCode: 651, failSeqno: 50000}, ProofCheckPolicyUnsafe)
+	if _, err := findOldestMasterBlock(context.Background(), api, 100000); err == nil {
+		t.Fatal("error not related to block availability should be returned")
+	}
+}
@@ -0,0 +1,162 @@
+package ton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+// TransactionHistoryIterator - walks account transactions backwards, from the given one to the first one.
+// When liteserver has no old data anymore, requests are rerouted to other nodes,
+// nodes with the deepest archive are tried first when it is known (see NewHealthProbe).
+type TransactionHistoryIterator struct {
+	// PageSize - number of transactions requested at once, 16 by default
+	PageSize uint32
+
+	api  APIClientWrapped
+	addr *address.Address
+	lt   uint64
+	hash []byte
+
+	// node - id of the last node which had the data
+	node uint32
+}
+
+// nodesHealthClient - implemented by liteclient.ConnectionPool
+type nodesHealthClient interface {
+	NodesHealth() []liteclient.NodeHealth
+	StickyContextWithNodeIDStrict(ctx context.Context, nodeId uint32) context.Context
+}
+
+// wrappedClient - implemented by clients which are wrapping another one, like retry or cache clients
+type wrappedClient interface {
+	unwrap() LiteClient
+}
+
+// findNodesHealthClient - unwraps client till the pool, sticky contexts of the pool are passed through wrappers
+func findNodesHealthClient(client LiteClient) (nodesHealthClient, bool) {
+	for client != nil {
+		if pool, ok := client.(nodesHealthClient); ok {
+			return pool, true
+		}
+
+		w, ok := client.(wrappedClient)
+		if !ok {
+			break
+		}
+		client = w.unwrap()
+	}
+	return nil, false
+}
+
+// NewTransactionHistoryIterator - creates iterator starting from the given transaction (including it),
+// usually it is the last transaction of account, from account state
+func NewTransactionHistoryIterator(api APIClientWrapped, addr *address.Address, lt uint64, txHash []byte) *TransactionHistoryIterator {
+	return &TransactionHistoryIterator{
+		PageSize: 16,
+		api:      api,
+		addr:     addr,
+		lt:       lt,
+		hash:     txHash,
+	}
+}
+
+// Next - returns next page of older transactions, transactions in page are sorted from old to new,
+// like in ListTransactions. Linkage of the pages by previous transaction hash is verified.
+// ErrNoTransactionsWereFound is returned when the first transaction of account is reached.
+// Requests are made with liteclient.PriorityBackground, if other priority is not set in ctx.
+func (it *TransactionHistoryIterator) Next(ctx context.Context) ([]*tlb.Transaction, error) {
+	if it.lt == 0 {
+		return nil, ErrNoTransactionsWereFound
+	}
+
+	ctx = liteclient.WithDefaultRequestPriority(ctx, liteclient.PriorityBackground)
+	client := it.api.Client()
+
+	var tried []uint32
+	nodeCtx := client.StickyContext(ctx)
+	if pool, ok := findNodesHealthClient(client); ok && it.node != 0 {
+		nodeCtx = pool.StickyContextWithNodeIDStrict(ctx, it.node)
+	}
+
+	for {
+		list, err := it.api.ListTransactions(nodeCtx, it.addr, it.PageSize, it.lt, it.hash)
+		if err != nil {
+			var lsErr LSError
+			if (errors.As(err, &lsErr) && (lsErr.Code == -400 || lsErr.Code == 651)) ||
+				errors.Is(err, liteclient.ErrNoActiveConnections) {
+				// node has no data for this lt or is not connected anymore, try another one
+				tried = append(tried, client.StickyNodeID(nodeCtx))
+				if nodeCtx, err = it.nextNode(ctx, nodeCtx, tried); err != nil {
+					return nil, fmt.Errorf("%w: no node has history before lt %d: %v", ErrLTNotInDB, it.lt, err)
+				}
+				continue
+			}
+			return nil, fmt.Errorf("failed to list transactions: %w", err)
+		}
+
+		// hashes are already verified by ListTransactions, starting from the requested one
+		if newest := list[len(list)-1]; newest.LT != it.lt {
+			return nil, fmt.Errorf("incorrect transaction lt %d, want %d", newest.LT, it.lt)
+		}
+
+		it.node = client.StickyNodeID(nodeCtx)
+		it.lt, it.hash = list[0].PrevTxLT, list[0].PrevTxHash
+		return list, nil
+	}
+}
+
+// nextNode - picks node which was not tried yet, preferring nodes with the oldest known blocks
+func (it *TransactionHistoryIterator) nextNode(ctx, nodeCtx context.Context, tried []uint32) (context.Context, error) {
+	client := it.api.Client()
+
+	pool, ok := findNodesHealthClient(client)
+	if !ok {
+		return client.StickyContextNextNode(nodeCtx)
+	}
+
+	var candidates []liteclient.NodeHealth
+iter:
+	for _, h := range pool.NodesHealth() {
+		for _, id := range tried {
+			if id == h.ID {
+				continue iter
+			}
+		}
+		candidates = append(candidates, h)
+	}
+
+	if len(candidates) == 0 {
+		return nil, liteclient.ErrNoNodesLeft
+	}
+
+	oldest := func(h liteclient.NodeHealth) uint32 {
+		if h.Info == nil || h.Info.OldestMasterSeqno == 0 {
+			// unknown depth, try after known archive nodes
+			return 0xFFFFFFFF
+		}
+		return h.Info.OldestMasterSeqno
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return oldest(candidates[i]) < oldest(candidates[j])
+	})
+
+	return pool.StickyContextWithNodeIDStrict(ctx, candidates[0].ID), nil
+}
+
+// FindOldestMasterBlock - finds seqno of the oldest masterchain block available on the node,
+// it shows how deep the node archive is. Use sticky context to check the concrete node.
+func (c *APIClient) FindOldestMasterBlock(ctx context.Context) (uint32, error) {
+	ctx = c.client.StickyContext(ctx)
+
+	master, err := c.GetMasterchainInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return findOldestMasterBlock(ctx, c, master.SeqNo)
+}
@@ -0,0 +1,130 @@
+package ton
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+type archiveClient struct {
+	// history - per node clients, keyed by node id
+	history map[uint32]*txHistoryClient
+	oldest  map[uint32]uint32
+	used    []uint32
+	// notStrict - number of rerouted requests which are allowed to fall back to another node
+	notStrict int
+}
+
+type archiveStrictKey struct{}
+
+func (m *archiveClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	id := m.StickyNodeID(ctx)
+	if strict, _ := ctx.Value(archiveStrictKey{}).(bool); id != 1 && !strict {
+		m.notStrict++
+	}
+	m.used = append(m.used, id)
+	return m.history[id].QueryLiteserver(ctx, payload, result)
+}
+
+func (m *archiveClient) StickyContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quorumNodeKey{}, uint32(1))
+}
+
+func (m *archiveClient) StickyNodeID(ctx context.Context) uint32 {
+	id, _ := ctx.Value(quorumNodeKey{}).(uint32)
+	return id
+}
+
+func (m *archiveClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return nil, errors.New("should not be used")
+}
+
+func (m *archiveClient) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return nil, errors.New("should not be used")
+}
+
+func (m *archiveClient) StickyContextWithNodeIDStrict(ctx context.Context, nodeId uint32) context.Context {
+	return context.WithValue(context.WithValue(ctx, quorumNodeKey{}, nodeId), archiveStrictKey{}, true)
+}
+
+func (m *archiveClient) NodesHealth() []liteclient.NodeHealth {
+	var list []liteclient.NodeHealth
+	for id := uint32(1); id <= uint32(len(m.history)); id++ {
+		list = append(list, liteclient.NodeHealth{ID: id, Info: &liteclient.NodeInfo{OldestMasterSeqno: m.oldest[id]}})
+	}
+	return list
+}
+
+func TestTransactionHistoryIterator(t *testing.T) {
+	txs := makeTxHistory(t, 40)
+	client := &archiveClient{
+		history: map[uint32]*txHistoryClient{
+			1: {txs: txs, notInDBLT: 300},
+			2: {txs: txs, notInDBLT: 100},
+			3: {txs: txs},
+		},
+		oldest: map[uint32]uint32{1: 900, 2: 500, 3: 10},
+	}
+
+	// pool should be found under wrappers
+	api := NewAPIClient(client, ProofCheckPolicyUnsafe).WithTimeout(time.Second).WithRetry(1)
+	addr := address.NewAddress(0, 0, make([]byte, 32))
+	last := txs[len(txs)-1]
+
+	it := NewTransactionHistoryIterator(api, addr, 400, last.Hash())
+	it.PageSize = 15
+
+	var all int
+	expectLT := uint64(400)
+	for {
+		list, err := it.Next(context.Background())
+		if errors.Is(err, ErrNoTransactionsWereFound) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := len(list) - 1; i >= 0; i-- {
+			if list[i].LT != expectLT {
+				t.Fatal("incorrect history order", list[i].LT, expectLT)
+			}
+			expectLT -= 10
+		}
+		all += len(list)
+	}
+
+	if all != 40 {
+		t.Fatal("incorrect history length", all)
+	}
+
+	// node 3 has the deepest archive, so node 2 should be skipped
+	for _, id := range client.used {
+		if id == 2 {
+			t.Fatal("node with the oldest blocks should be preferred")
+		}
+	}
+	if client.used[len(client.used)-1] != 3 {
+		t.Fatal("archive node should be used at the end")
+	}
+	if client.notStrict > 0 {
+		t.Fatal("rerouted requests should be bound to the node strictly")
+	}
+
+	client.history[3].notInDBLT = 100
+	it = NewTransactionHistoryIterator(api, addr, 400, last.Hash())
+	for {
+		_, err := it.Next(context.Background())
+		if err != nil {
+			if !errors.Is(err, ErrLTNotInDB) {
+				t.Fatal("missing history should be reported, got", err)
+			}
+			break
+		}
+	}
+}
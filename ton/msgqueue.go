@@ -0,0 +1,227 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	tl.Register(GetOutMsgQueueSizes{}, "liteServer.getOutMsgQueueSizes mode:# wc:mode.0?int shard:mode.0?long = liteServer.OutMsgQueueSizes")
+	tl.Register(OutMsgQueueSize{}, "liteServer.outMsgQueueSize id:tonNode.blockIdExt size:int = liteServer.OutMsgQueueSize")
+	tl.Register(OutMsgQueueSizes{}, "liteServer.outMsgQueueSizes shards:(vector liteServer.outMsgQueueSize) ext_msg_queue_size_limit:int = liteServer.OutMsgQueueSizes")
+	tl.Register(GetBlockOutMsgQueueSize{}, "liteServer.getBlockOutMsgQueueSize mode:# id:tonNode.blockIdExt want_proof:mode.0?true = liteServer.BlockOutMsgQueueSize")
+	tl.Register(BlockOutMsgQueueSize{}, "liteServer.blockOutMsgQueueSize mode:# id:tonNode.blockIdExt size:long proof:mode.0?bytes = liteServer.BlockOutMsgQueueSize")
+	tl.Register(GetDispatchQueueInfo{}, "liteServer.getDispatchQueueInfo mode:# id:tonNode.blockIdExt after_addr:mode.1?int256 max_accounts:int want_proof:mode.0?true = liteServer.DispatchQueueInfo")
+	tl.Register(AccountDispatchQueueInfo{}, "liteServer.accountDispatchQueueInfo addr:int256 size:long min_lt:long max_lt:long = liteServer.AccountDispatchQueueInfo")
+	tl.Register(DispatchQueueInfo{}, "liteServer.dispatchQueueInfo mode:# id:tonNode.blockIdExt account_dispatch_queues:(vector liteServer.accountDispatchQueueInfo) complete:Bool proof:mode.0?bytes = liteServer.DispatchQueueInfo")
+}
+
+type GetOutMsgQueueSizes struct {
+	Mode      uint32 `tl:"flags"`
+	Workchain int32  `tl:"?0 int"`
+	Shard     int64  `tl:"?0 long"`
+}
+
+type OutMsgQueueSize struct {
+	ID   *BlockIDExt `tl:"struct"`
+	Size int32       `tl:"int"`
+}
+
+type OutMsgQueueSizes struct {
+	Shards               []OutMsgQueueSize `tl:"vector struct"`
+	ExtMsgQueueSizeLimit int32             `tl:"int"`
+}
+
+type GetBlockOutMsgQueueSize struct {
+	Mode      uint32      `tl:"flags"`
+	ID        *BlockIDExt `tl:"struct"`
+	WantProof *True       `tl:"?0 struct"`
+}
+
+type BlockOutMsgQueueSize struct {
+	Mode  uint32       `tl:"flags"`
+	ID    *BlockIDExt  `tl:"struct"`
+	Size  uint64       `tl:"long"`
+	Proof []*cell.Cell `tl:"?0 cell optional"`
+}
+
+type GetDispatchQueueInfo struct {
+	Mode        uint32      `tl:"flags"`
+	ID          *BlockIDExt `tl:"struct"`
+	AfterAddr   []byte      `tl:"?1 int256"`
+	MaxAccounts int32       `tl:"int"`
+	WantProof   *True       `tl:"?0 struct"`
+}
+
+type AccountDispatchQueueInfo struct {
+	Addr  []byte `tl:"int256"`
+	Size  uint64 `tl:"long"`
+	MinLT uint64 `tl:"long"`
+	MaxLT uint64 `tl:"long"`
+}
+
+type DispatchQueueInfo struct {
+	Mode                  uint32                     `tl:"flags"`
+	ID                    *BlockIDExt                `tl:"struct"`
+	AccountDispatchQueues []AccountDispatchQueueInfo `tl:"vector struct"`
+	Complete              bool                       `tl:"bool"`
+	Proof                 []*cell.Cell               `tl:"?0 cell optional"`
+}
+
+// GetOutMsgQueueSizes - gets out message queue sizes of the latest masterchain block and its shards,
+// can be used to monitor congestion. Liteserver provides no proof for this method.
+func (c *APIClient) GetOutMsgQueueSizes(ctx context.Context) (*OutMsgQueueSizes, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetOutMsgQueueSizes{Mode: 0}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case OutMsgQueueSizes:
+		return &t, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// GetBlockOutMsgQueueSize - gets out message queue size of the block, size is checked by the state proof
+func (c *APIClient) GetBlockOutMsgQueueSize(ctx context.Context, block *BlockIDExt) (uint64, error) {
+	req := GetBlockOutMsgQueueSize{ID: block}
+	if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+		req.Mode = 1
+		req.WantProof = &True{}
+	}
+
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, req, &resp)
+	if err != nil {
+		return 0, err
+	}
+
+	switch t := resp.(type) {
+	case BlockOutMsgQueueSize:
+		if !t.ID.Equals(block) {
+			return 0, fmt.Errorf("incorrect block in response")
+		}
+
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			info, err := checkOutMsgQueueInfoProof(block, t.Proof)
+			if err != nil {
+				return 0, err
+			}
+
+			if info.Extra == nil || info.Extra.OutQueueSize == nil {
+				return 0, fmt.Errorf("no out queue size in state proof")
+			}
+
+			if *info.Extra.OutQueueSize != t.Size {
+				return 0, fmt.Errorf("out queue size not matches proof")
+			}
+		}
+		return t.Size, nil
+	case LSError:
+		return 0, t
+	}
+	return 0, errUnexpectedResponse(resp)
+}
+
+// GetDispatchQueueInfo - gets sizes of accounts dispatch queues (deferred messages) of the block,
+// starting after the given address (can be nil), up to maxAccounts. Returns list and incomplete flag, true when more entries can be requested.
+func (c *APIClient) GetDispatchQueueInfo(ctx context.Context, block *BlockIDExt, afterAddr []byte, maxAccounts int32) ([]AccountDispatchQueueInfo, bool, error) {
+	req := GetDispatchQueueInfo{ID: block, MaxAccounts: maxAccounts}
+	if afterAddr != nil {
+		if len(afterAddr) != 32 {
+			return nil, false, fmt.Errorf("incorrect address len")
+		}
+		req.Mode |= 1 << 1
+		req.AfterAddr = afterAddr
+	}
+	if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+		req.Mode |= 1
+		req.WantProof = &True{}
+	}
+
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, req, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch t := resp.(type) {
+	case DispatchQueueInfo:
+		if !t.ID.Equals(block) {
+			return nil, false, fmt.Errorf("incorrect block in response")
+		}
+
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			info, err := checkOutMsgQueueInfoProof(block, t.Proof)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if info.Extra == nil {
+				if len(t.AccountDispatchQueues) > 0 {
+					return nil, false, fmt.Errorf("no dispatch queue in state proof")
+				}
+				return t.AccountDispatchQueues, !t.Complete, nil
+			}
+
+			for _, q := range t.AccountDispatchQueues {
+				if afterAddr != nil && bytes.Compare(q.Addr, afterAddr) <= 0 {
+					return nil, false, fmt.Errorf("account %x is not after requested", q.Addr)
+				}
+
+				val, extra, err := info.Extra.DispatchQueue.LoadValueWithExtra(cell.BeginCell().MustStoreSlice(q.Addr, 256).EndCell())
+				if err != nil {
+					return nil, false, fmt.Errorf("failed to find account %x queue in proof: %w", q.Addr, err)
+				}
+
+				var queue tlb.AccountDispatchQueue
+				if err = tlb.LoadFromCellAsProof(&queue, val); err != nil {
+					return nil, false, fmt.Errorf("failed to parse account %x queue: %w", q.Addr, err)
+				}
+
+				minLT, err := extra.LoadUInt(64)
+				if err != nil {
+					return nil, false, fmt.Errorf("failed to load account %x queue min lt: %w", q.Addr, err)
+				}
+
+				if queue.Count != q.Size || minLT != q.MinLT {
+					return nil, false, fmt.Errorf("account %x queue not matches proof", q.Addr)
+				}
+			}
+		}
+		return t.AccountDispatchQueues, !t.Complete, nil
+	case LSError:
+		return nil, false, t
+	}
+	return nil, false, errUnexpectedResponse(resp)
+}
+
+func checkOutMsgQueueInfoProof(block *BlockIDExt, proof []*cell.Cell) (*tlb.OutMsgQueueInfo, error) {
+	if len(proof) == 0 {
+		return nil, fmt.Errorf("no proof passed by ls")
+	}
+
+	state, err := CheckBlockShardStateProof(proof, block.RootHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check state proof: %w", err)
+	}
+
+	if state.OutMsgQueueInfo == nil || state.OutMsgQueueInfo.GetType() == cell.PrunedCellType {
+		return nil, fmt.Errorf("no out msg queue info in state proof")
+	}
+
+	var info tlb.OutMsgQueueInfo
+	if err = info.LoadFromCell(state.OutMsgQueueInfo.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse out msg queue info: %w", err)
+	}
+	return &info, nil
+}
@@ -0,0 +1,116 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func testMsgQueueBlock(t *testing.T) *testBlock {
+	dispatch := cell.NewAugmentedDict(256, tlb.MinLTExtra{})
+	for i, addr := range [][]byte{bytes.Repeat([]byte{0x11}, 32), bytes.Repeat([]byte{0x22}, 32)} {
+		queue := cell.BeginCell().MustStoreDict(nil).MustStoreUInt(uint64(i+1)*10, 48).EndCell()
+		minLT := cell.BeginCell().MustStoreUInt(uint64(i+1)*1000, 64).EndCell()
+		if err := dispatch.Set(cell.BeginCell().MustStoreSlice(addr, 256).EndCell(), queue, minLT); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size := uint64(30)
+	info, err := (&tlb.OutMsgQueueInfo{Extra: &tlb.OutMsgQueueExtra{DispatchQueue: dispatch, OutQueueSize: &size}}).ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newTestBlock(t, 0, 20, testBlockParts{outMsgQueueInfo: info})
+}
+
+// msgQueueProof - returns state proof with out msg queue info, or with it pruned
+func msgQueueProof(t *testing.T, block *testBlock, withQueue bool) []*cell.Cell {
+	sk := cell.CreateProofSkeleton()
+	if withQueue {
+		sk.ProofRef(0).SetRecursive()
+	}
+	return block.stateProof(t, sk)
+}
+
+func TestCheckOutMsgQueueInfoProof(t *testing.T) {
+	block := testMsgQueueBlock(t)
+
+	info, err := checkOutMsgQueueInfoProof(block.id, msgQueueProof(t, block, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Extra == nil || info.Extra.OutQueueSize == nil || *info.Extra.OutQueueSize != 30 {
+		t.Fatal("incorrect queue info")
+	}
+
+	if _, err = checkOutMsgQueueInfoProof(block.id, msgQueueProof(t, block, false)); err == nil {
+		t.Fatal("pruned queue info should be rejected")
+	}
+
+	other := newTestBlock(t, 0, 20, testBlockParts{})
+	if _, err = checkOutMsgQueueInfoProof(block.id, msgQueueProof(t, other, true)); err == nil {
+		t.Fatal("proof of another block should be rejected")
+	}
+
+	size := uint64(30)
+	api := NewAPIClient(&proofClient{answer: func(payload tl.Serializable) tl.Serializable {
+		return BlockOutMsgQueueSize{Mode: 1, ID: block.id, Size: size, Proof: msgQueueProof(t, block, true)}
+	}})
+	if sz, err := api.GetBlockOutMsgQueueSize(context.Background(), block.id); err != nil || sz != 30 {
+		t.Fatal("incorrect queue size", sz, err)
+	}
+
+	size = 31
+	if _, err = api.GetBlockOutMsgQueueSize(context.Background(), block.id); err == nil {
+		t.Fatal("size which not matches proof should be rejected")
+	}
+}
+
+func TestAPIClient_GetDispatchQueueInfo(t *testing.T) {
+	block := testMsgQueueBlock(t)
+	first := AccountDispatchQueueInfo{Addr: bytes.Repeat([]byte{0x11}, 32), Size: 10, MinLT: 1000, MaxLT: 1500}
+	second := AccountDispatchQueueInfo{Addr: bytes.Repeat([]byte{0x22}, 32), Size: 20, MinLT: 2000, MaxLT: 2500}
+
+	tests := []struct {
+		name      string
+		queues    []AccountDispatchQueueInfo
+		afterAddr []byte
+		withQueue bool
+		fail      bool
+	}{
+		{name: "valid", queues: []AccountDispatchQueueInfo{first, second}, withQueue: true},
+		{name: "after address", queues: []AccountDispatchQueueInfo{second}, afterAddr: first.Addr, withQueue: true},
+		{name: "not after address", queues: []AccountDispatchQueueInfo{first, second}, afterAddr: first.Addr, withQueue: true, fail: true},
+		{name: "incorrect size", queues: []AccountDispatchQueueInfo{{Addr: first.Addr, Size: 11, MinLT: 1000}}, withQueue: true, fail: true},
+		{name: "incorrect min lt", queues: []AccountDispatchQueueInfo{{Addr: first.Addr, Size: 10, MinLT: 999}}, withQueue: true, fail: true},
+		{name: "unknown account", queues: []AccountDispatchQueueInfo{{Addr: bytes.Repeat([]byte{0x33}, 32), Size: 1}}, withQueue: true, fail: true},
+		{name: "pruned queue", queues: []AccountDispatchQueueInfo{first}, fail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := NewAPIClient(&proofClient{answer: func(payload tl.Serializable) tl.Serializable {
+				return DispatchQueueInfo{Mode: 1, ID: block.id, AccountDispatchQueues: tt.queues, Complete: true, Proof: msgQueueProof(t, block, tt.withQueue)}
+			}})
+
+			list, incomplete, err := api.GetDispatchQueueInfo(context.Background(), block.id, tt.afterAddr, 10)
+			if tt.fail {
+				if err == nil {
+					t.Fatal("tampered response should be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if incomplete || len(list) != len(tt.queues) {
+				t.Fatal("incorrect result", incomplete, len(list))
+			}
+		})
+	}
+}
@@ -0,0 +1,510 @@
+package multisig
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Client for https://github.com/ton-blockchain/multisig-contract-v2
+
+type TonApi interface {
+	WaitForBlock(seqno uint32) ton.APIClientWrapped
+	CurrentMasterchainInfo(ctx context.Context) (_ *ton.BlockIDExt, err error)
+	RunGetMethod(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...any) (*ton.ExecutionResult, error)
+}
+
+const (
+	OpNewOrder        = 0xf718510f
+	OpExecute         = 0x75097f5d
+	OpExecuteInternal = 0xa32c59bf
+
+	OpOrderInit            = 0x9c73fba2
+	OpOrderApprove         = 0xa762230f
+	OpOrderApproveAccepted = 0x82609bf6
+	OpOrderApproveRejected = 0xafaf283e
+)
+
+// MaxSigners - signers num is stored as uint8, approvals mask has 256 bits, one per signer index
+const MaxSigners = 255
+
+// maxOrderSeqno - when multisig creates orders sequentially, contract replaces it with the next order seqno
+var maxOrderSeqno = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+var (
+	ErrNotSigner             = errors.New("address is not a signer of multisig")
+	ErrNotProposer           = errors.New("address is neither signer nor proposer of multisig")
+	ErrOrderNotFound         = errors.New("order is not deployed")
+	ErrArbitraryOrderSeqno   = errors.New("multisig allows arbitrary order seqno, orders cannot be listed sequentially")
+	ErrInvalidOrderSeqno     = errors.New("invalid order seqno")
+	ErrMultisigNotDeployed   = errors.New("multisig is not deployed")
+	ErrInvalidMultisigConfig = errors.New("invalid multisig config")
+)
+
+// Config - parameters of multisig, threshold is the number of signer approvals required to execute order
+type Config struct {
+	Threshold uint8
+	Signers   []*address.Address
+	Proposers []*address.Address
+	// AllowArbitraryOrderSeqno - when true, orders can be created with any seqno, otherwise sequentially
+	AllowArbitraryOrderSeqno bool
+}
+
+// Data - parsed persistent data of multisig
+type Data struct {
+	Config
+	// NextOrderSeqno - seqno of the next order, meaningful only when arbitrary seqno is not allowed
+	NextOrderSeqno *big.Int
+}
+
+type storage struct {
+	NextOrderSeqno           *big.Int         `tlb:"## 256"`
+	Threshold                uint8            `tlb:"## 8"`
+	Signers                  *cell.Dictionary `tlb:"^ dict inline 8"`
+	SignersNum               uint8            `tlb:"## 8"`
+	Proposers                *cell.Dictionary `tlb:"dict 8"`
+	AllowArbitraryOrderSeqno bool             `tlb:"bool"`
+}
+
+type NewOrderPayload struct {
+	_              tlb.Magic  `tlb:"#f718510f"`
+	QueryID        uint64     `tlb:"## 64"`
+	OrderSeqno     *big.Int   `tlb:"## 256"`
+	IsSigner       bool       `tlb:"bool"`
+	Index          uint8      `tlb:"## 8"`
+	ExpirationDate uint64     `tlb:"## 48"`
+	Order          *cell.Cell `tlb:"^"`
+}
+
+type ApprovePayload struct {
+	_           tlb.Magic `tlb:"#a762230f"`
+	QueryID     uint64    `tlb:"## 64"`
+	SignerIndex uint8     `tlb:"## 8"`
+}
+
+type Client struct {
+	addr *address.Address
+	api  TonApi
+}
+
+func NewClient(api TonApi, multisigAddr *address.Address) *Client {
+	return &Client{
+		addr: multisigAddr,
+		api:  api,
+	}
+}
+
+func (c *Client) Address() *address.Address {
+	return c.addr
+}
+
+// BuildDeployData - builds initial data of multisig contract
+func BuildDeployData(cfg Config) (*cell.Cell, error) {
+	if len(cfg.Signers) == 0 || len(cfg.Signers) > MaxSigners || len(cfg.Proposers) > MaxSigners {
+		return nil, fmt.Errorf("%w: signers num should be from 1 to %d, proposers up to %d", ErrInvalidMultisigConfig, MaxSigners, MaxSigners)
+	}
+	if cfg.Threshold == 0 || int(cfg.Threshold) > len(cfg.Signers) {
+		return nil, fmt.Errorf("%w: threshold should be from 1 to signers num", ErrInvalidMultisigConfig)
+	}
+
+	signers, err := addressesToDict(cfg.Signers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build signers dict: %w", err)
+	}
+	proposers, err := addressesToDict(cfg.Proposers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build proposers dict: %w", err)
+	}
+
+	return tlb.ToCell(storage{
+		NextOrderSeqno:           big.NewInt(0),
+		Threshold:                cfg.Threshold,
+		Signers:                  signers,
+		SignersNum:               uint8(len(cfg.Signers)),
+		Proposers:                proposers,
+		AllowArbitraryOrderSeqno: cfg.AllowArbitraryOrderSeqno,
+	})
+}
+
+// Deploy - deploys multisig from the wallet and waits for the transaction, code is compiled multisig-contract-v2 code.
+// Amount is the initial balance of multisig.
+func Deploy(ctx context.Context, api TonApi, w *wallet.Wallet, code *cell.Cell, cfg Config, amount tlb.Coins) (*Client, error) {
+	data, err := BuildDeployData(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// op 0 is accepted as a top up
+	body := cell.BeginCell().MustStoreUInt(0, 32).MustStoreUInt(0, 64).EndCell()
+
+	addr, _, _, err := w.DeployContractWaitTransaction(ctx, amount, body, code, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy multisig: %w", err)
+	}
+	return NewClient(api, addr), nil
+}
+
+// ParseData - parses data cell of multisig account
+func ParseData(data *cell.Cell) (*Data, error) {
+	if data == nil {
+		return nil, fmt.Errorf("data is nil")
+	}
+
+	var st storage
+	if err := tlb.LoadFromCell(&st, data.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse multisig data: %w", err)
+	}
+
+	signers, err := dictToAddresses(st.Signers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signers: %w", err)
+	}
+	proposers, err := dictToAddresses(st.Proposers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proposers: %w", err)
+	}
+
+	return &Data{
+		Config: Config{
+			Threshold:                st.Threshold,
+			Signers:                  signers,
+			Proposers:                proposers,
+			AllowArbitraryOrderSeqno: st.AllowArbitraryOrderSeqno,
+		},
+		NextOrderSeqno: st.NextOrderSeqno,
+	}, nil
+}
+
+func (c *Client) GetData(ctx context.Context) (*Data, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetDataAtBlock(ctx, b)
+}
+
+func (c *Client) GetDataAtBlock(ctx context.Context, b *ton.BlockIDExt) (*Data, error) {
+	acc, err := c.api.WaitForBlock(b.SeqNo).GetAccount(ctx, b, c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if !acc.IsActive || acc.State.Status != tlb.AccountStatusActive {
+		return nil, ErrMultisigNotDeployed
+	}
+	return ParseData(acc.Data)
+}
+
+func (c *Client) GetOrderAddress(ctx context.Context, orderSeqno *big.Int) (*address.Address, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetOrderAddressAtBlock(ctx, orderSeqno, b)
+}
+
+func (c *Client) GetOrderAddressAtBlock(ctx context.Context, orderSeqno *big.Int, b *ton.BlockIDExt) (*address.Address, error) {
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_order_address", orderSeqno)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_order_address method: %w", err)
+	}
+
+	x, err := res.Slice(0)
+	if err != nil {
+		return nil, fmt.Errorf("result get err: %w", err)
+	}
+
+	addr, err := x.LoadAddr()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load address from result slice: %w", err)
+	}
+	return addr, nil
+}
+
+func (c *Client) GetOrder(ctx context.Context, orderSeqno *big.Int) (*Order, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetOrderAtBlock(ctx, orderSeqno, b)
+}
+
+func (c *Client) GetOrderAtBlock(ctx context.Context, orderSeqno *big.Int, b *ton.BlockIDExt) (*Order, error) {
+	addr, err := c.GetOrderAddressAtBlock(ctx, orderSeqno, b)
+	if err != nil {
+		return nil, err
+	}
+
+	acc, err := c.api.WaitForBlock(b.SeqNo).GetAccount(ctx, b, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order account: %w", err)
+	}
+
+	if !acc.IsActive || acc.State.Status != tlb.AccountStatusActive {
+		return nil, ErrOrderNotFound
+	}
+
+	order, err := ParseOrderData(acc.Data)
+	if err != nil {
+		return nil, err
+	}
+	order.Address = addr
+	return order, nil
+}
+
+// ListPendingOrders - returns initialized orders which are not executed and not expired,
+// orders are checked sequentially starting from fromSeqno up to the next order seqno.
+// Not available for multisig with arbitrary order seqno.
+func (c *Client) ListPendingOrders(ctx context.Context, fromSeqno *big.Int) ([]*Order, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	data, err := c.GetDataAtBlock(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.AllowArbitraryOrderSeqno {
+		return nil, ErrArbitraryOrderSeqno
+	}
+
+	if fromSeqno == nil {
+		fromSeqno = big.NewInt(0)
+	}
+
+	now := time.Now()
+	var list []*Order
+	for seqno := new(big.Int).Set(fromSeqno); seqno.Cmp(data.NextOrderSeqno) < 0; seqno = new(big.Int).Add(seqno, big.NewInt(1)) {
+		order, err := c.GetOrderAtBlock(ctx, seqno, b)
+		if err != nil {
+			if errors.Is(err, ErrOrderNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get order %s: %w", seqno.String(), err)
+		}
+
+		if order.IsPending(now) {
+			list = append(list, order)
+		}
+	}
+	return list, nil
+}
+
+// BuildNewOrderPayload - builds body of the message which creates order, it should be sent to multisig.
+// Index is the position of sender in signers list when isSigner is true, or in proposers list otherwise.
+// Signer's order is approved by the sender on creation.
+func BuildNewOrderPayload(orderSeqno *big.Int, isSigner bool, index uint8, expiration time.Time, actions []Action) (*cell.Cell, error) {
+	order, err := PackOrder(actions)
+	if err != nil {
+		return nil, err
+	}
+
+	return tlb.ToCell(NewOrderPayload{
+		QueryID:        randomQueryID(),
+		OrderSeqno:     orderSeqno,
+		IsSigner:       isSigner,
+		Index:          index,
+		ExpirationDate: uint64(expiration.Unix()),
+		Order:          order,
+	})
+}
+
+// BuildNewOrderMessage - builds message from signer or proposer wallet which creates order,
+// amount should cover order deployment and execution fees, excess is kept by multisig. Returns seqno of the order.
+//
+// When multisig creates orders sequentially, orderSeqno should be nil, the next seqno is used, so when another order
+// is created first, message is rejected by contract instead of creating order with an unexpected seqno.
+// When arbitrary seqno is allowed, orderSeqno is used as is, or random seqno is generated when it is nil.
+func (c *Client) BuildNewOrderMessage(ctx context.Context, sender *address.Address, orderSeqno *big.Int, expiration time.Time, actions []Action, amount tlb.Coins) (*wallet.Message, *big.Int, error) {
+	data, err := c.GetData(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isSigner := true
+	index, ok := findAddress(data.Signers, sender)
+	if !ok {
+		isSigner = false
+		if index, ok = findAddress(data.Proposers, sender); !ok {
+			return nil, nil, ErrNotProposer
+		}
+	}
+
+	seqno, err := newOrderSeqno(data, orderSeqno)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := BuildNewOrderPayload(seqno, isSigner, index, expiration, actions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.buildMessage(c.addr, amount, body), seqno, nil
+}
+
+func newOrderSeqno(data *Data, orderSeqno *big.Int) (*big.Int, error) {
+	if !data.AllowArbitraryOrderSeqno {
+		if orderSeqno != nil && orderSeqno.Cmp(data.NextOrderSeqno) != 0 {
+			return nil, fmt.Errorf("%w: multisig creates orders sequentially, next seqno is %s", ErrInvalidOrderSeqno, data.NextOrderSeqno.String())
+		}
+		// maxOrderSeqno could be used to let contract take the next seqno,
+		// but then the returned seqno may differ from the created order when another order is created first
+		return new(big.Int).Set(data.NextOrderSeqno), nil
+	}
+
+	// in arbitrary mode maxOrderSeqno has no special meaning, it is a regular seqno
+	if orderSeqno != nil {
+		if orderSeqno.Sign() < 0 || orderSeqno.Cmp(maxOrderSeqno) > 0 {
+			return nil, fmt.Errorf("%w: it should fit in 256 bits", ErrInvalidOrderSeqno)
+		}
+		return new(big.Int).Set(orderSeqno), nil
+	}
+
+	seqno, err := rand.Int(rand.Reader, maxOrderSeqno)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate order seqno: %w", err)
+	}
+	return seqno, nil
+}
+
+// CreateOrder - creates order from signer or proposer wallet and waits for the transaction, returns seqno of created order.
+// See BuildNewOrderMessage for orderSeqno usage.
+func (c *Client) CreateOrder(ctx context.Context, w *wallet.Wallet, orderSeqno *big.Int, expiration time.Time, actions []Action, amount tlb.Coins) (*big.Int, error) {
+	msg, seqno, err := c.BuildNewOrderMessage(ctx, w.WalletAddress(), orderSeqno, expiration, actions, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err = w.SendWaitTransaction(ctx, msg); err != nil {
+		return nil, fmt.Errorf("failed to send new order message: %w", err)
+	}
+	return seqno, nil
+}
+
+// BuildApprovePayload - builds body of the approve message, it should be sent to order from signer's address
+func BuildApprovePayload(signerIndex uint8) (*cell.Cell, error) {
+	return tlb.ToCell(ApprovePayload{
+		QueryID:     randomQueryID(),
+		SignerIndex: signerIndex,
+	})
+}
+
+// BuildApproveCommentPayload - builds text comment "approve", order finds signer index by sender address itself,
+// it can be used when signer's wallet application can send only comments
+func BuildApproveCommentPayload() *cell.Cell {
+	return cell.BeginCell().MustStoreUInt(0, 32).MustStoreStringSnake("approve").EndCell()
+}
+
+// BuildApproveMessage - builds internal message which approves order from signer's address.
+// Order contract accepts only internal messages, so it should be sent by signer's wallet or other contract.
+func (c *Client) BuildApproveMessage(ctx context.Context, signer *address.Address, orderSeqno *big.Int, amount tlb.Coins) (*wallet.Message, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	data, err := c.GetDataAtBlock(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	index, ok := findAddress(data.Signers, signer)
+	if !ok {
+		return nil, ErrNotSigner
+	}
+
+	orderAddr, err := c.GetOrderAddressAtBlock(ctx, orderSeqno, b)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := BuildApprovePayload(index)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildMessage(orderAddr, amount, body), nil
+}
+
+// Approve - approves order by external message to signer's wallet and waits for the transaction.
+// Amount should cover order processing fees, excess is returned to signer.
+func (c *Client) Approve(ctx context.Context, w *wallet.Wallet, orderSeqno *big.Int, amount tlb.Coins) error {
+	msg, err := c.BuildApproveMessage(ctx, w.WalletAddress(), orderSeqno, amount)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err = w.SendWaitTransaction(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send approve message: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) buildMessage(to *address.Address, amount tlb.Coins, body *cell.Cell) *wallet.Message {
+	return &wallet.Message{
+		Mode: wallet.PayGasSeparately + wallet.IgnoreErrors,
+		InternalMessage: &tlb.InternalMessage{
+			IHRDisabled: true,
+			Bounce:      true,
+			DstAddr:     to,
+			Amount:      amount,
+			Body:        body,
+		},
+	}
+}
+
+func findAddress(list []*address.Address, addr *address.Address) (uint8, bool) {
+	for i, a := range list {
+		if a.Equals(addr) {
+			return uint8(i), true
+		}
+	}
+	return 0, false
+}
+
+func addressesToDict(list []*address.Address) (*cell.Dictionary, error) {
+	dict := cell.NewDict(8)
+	for i, addr := range list {
+		if err := dict.SetIntKey(big.NewInt(int64(i)), cell.BeginCell().MustStoreAddr(addr).EndCell()); err != nil {
+			return nil, err
+		}
+	}
+	return dict, nil
+}
+
+func dictToAddresses(dict *cell.Dictionary) ([]*address.Address, error) {
+	if dict == nil {
+		return nil, nil
+	}
+
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dict: %w", err)
+	}
+
+	list := make([]*address.Address, len(kvs))
+	for _, kv := range kvs {
+		idx, err := kv.Key.LoadUInt(8)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load index: %w", err)
+		}
+		if idx >= uint64(len(kvs)) {
+			return nil, fmt.Errorf("indexes are not sequential")
+		}
+
+		if list[idx], err = kv.Value.LoadAddr(); err != nil {
+			return nil, fmt.Errorf("failed to load address: %w", err)
+		}
+	}
+	return list, nil
+}
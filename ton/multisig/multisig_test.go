@@ -0,0 +1,272 @@
+package multisig
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var testAddrs = []*address.Address{
+	address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"),
+	address.NewAddress(0, 0, make([]byte, 32)),
+	address.NewAddress(0, 0, append(make([]byte, 31), 1)),
+	address.NewAddress(0, 0, append(make([]byte, 31), 2)),
+	address.NewAddress(0, 0, append(make([]byte, 31), 3)),
+}
+
+func TestBuildDeployData(t *testing.T) {
+	data, err := BuildDeployData(Config{
+		Threshold: 3,
+		Signers:   testAddrs,
+		Proposers: testAddrs[:1],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ParseData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Threshold != 3 || d.NextOrderSeqno.Sign() != 0 || d.AllowArbitraryOrderSeqno {
+		t.Fatal("incorrect data")
+	}
+	if len(d.Signers) != len(testAddrs) || len(d.Proposers) != 1 {
+		t.Fatal("incorrect signers or proposers num")
+	}
+	for i, a := range testAddrs {
+		if !d.Signers[i].Equals(a) {
+			t.Fatal("incorrect signer", i)
+		}
+	}
+
+	if _, err = BuildDeployData(Config{Threshold: 6, Signers: testAddrs}); err == nil {
+		t.Fatal("threshold above signers num should be rejected")
+	}
+	if _, err = BuildDeployData(Config{Threshold: 1}); err == nil {
+		t.Fatal("empty signers should be rejected")
+	}
+}
+
+func TestBuildNewOrderPayload(t *testing.T) {
+	expire := time.Unix(2000000000, 0)
+	actions := []Action{
+		SendMessageAction{
+			Mode: 3,
+			Message: &tlb.InternalMessage{
+				Bounce:  true,
+				DstAddr: testAddrs[0],
+				Amount:  tlb.MustFromTON("1.5"),
+			},
+		},
+		UpdateParamsAction{
+			Threshold: 2,
+			Signers:   testAddrs[:3],
+		},
+	}
+
+	body, err := BuildNewOrderPayload(big.NewInt(7), true, 2, expire, actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var p NewOrderPayload
+	if err = tlb.LoadFromCell(&p, body.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if p.OrderSeqno.Uint64() != 7 || !p.IsSigner || p.Index != 2 || p.ExpirationDate != uint64(expire.Unix()) {
+		t.Fatal("incorrect payload")
+	}
+
+	parsed, err := UnpackOrder(p.Order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 2 {
+		t.Fatal("incorrect actions num", len(parsed))
+	}
+
+	send, ok := parsed[0].(SendMessageAction)
+	if !ok || send.Mode != 3 || !send.Message.DstAddr.Equals(testAddrs[0]) || send.Message.Amount.String() != "1.5" {
+		t.Fatal("incorrect send message action")
+	}
+
+	upd, ok := parsed[1].(UpdateParamsAction)
+	if !ok || upd.Threshold != 2 || len(upd.Signers) != 3 || len(upd.Proposers) != 0 || !upd.Signers[2].Equals(testAddrs[2]) {
+		t.Fatal("incorrect update params action")
+	}
+
+	if _, err = BuildNewOrderPayload(big.NewInt(0), true, 0, expire, nil); err == nil {
+		t.Fatal("empty order should be rejected")
+	}
+}
+
+func TestParseOrderData(t *testing.T) {
+	order, err := PackOrder([]Action{SendMessageAction{
+		Mode:    3,
+		Message: &tlb.InternalMessage{DstAddr: testAddrs[1], Amount: tlb.MustFromTON("0.1")},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signers, err := addressesToDict(testAddrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head := cell.BeginCell().MustStoreAddr(testAddrs[0]).MustStoreBigUInt(big.NewInt(5), 256)
+
+	o, err := ParseOrderData(head.EndCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Initialized || o.Seqno.Uint64() != 5 || !o.MultisigAddress.Equals(testAddrs[0]) {
+		t.Fatal("incorrect not initialized order")
+	}
+
+	// approvals mask has MASK_SIZE = 1 << INDEX_SIZE = 256 bits in the contract, big endian
+	mask := make([]byte, 32)
+	mask[0] = 1 << 7
+	mask[31] = 1<<1 | 1<<4
+
+	data := head.
+		MustStoreUInt(3, 8).
+		MustStoreBoolBit(false).
+		MustStoreRef(signers.AsCell()).
+		MustStoreSlice(mask, 256).
+		MustStoreUInt(2, 8).
+		MustStoreUInt(2000000000, 48).
+		MustStoreRef(order).
+		EndCell()
+
+	o, err = ParseOrderData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.Initialized || o.Executed || o.Threshold != 3 || o.ApprovalsNum != 2 || len(o.Signers) != 5 || len(o.Actions) != 1 {
+		t.Fatal("incorrect order")
+	}
+	if !o.IsApprovedBy(1) || !o.IsApprovedBy(4) || !o.IsApprovedBy(255) || o.IsApprovedBy(0) || o.IsApprovedBy(254) {
+		t.Fatal("incorrect approvals")
+	}
+	if !o.IsPending(time.Unix(1999999999, 0)) || o.IsPending(time.Unix(2000000000, 0)) {
+		t.Fatal("incorrect pending flag")
+	}
+}
+
+func TestBuildApprovePayload(t *testing.T) {
+	body, err := BuildApprovePayload(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := body.BeginParse()
+	if s.MustLoadUInt(32) != OpOrderApprove {
+		t.Fatal("incorrect op")
+	}
+	s.MustLoadUInt(64)
+	if s.MustLoadUInt(8) != 4 || s.BitsLeft() != 0 {
+		t.Fatal("incorrect signer index")
+	}
+
+	s = BuildApproveCommentPayload().BeginParse()
+	if s.MustLoadUInt(32) != 0 || s.MustLoadStringSnake() != "approve" {
+		t.Fatal("incorrect comment")
+	}
+}
+
+type testMultisigAPI struct {
+	ton.APIClientWrapped
+	data *cell.Cell
+}
+
+func (a *testMultisigAPI) WaitForBlock(seqno uint32) ton.APIClientWrapped {
+	return a
+}
+
+func (a *testMultisigAPI) CurrentMasterchainInfo(ctx context.Context) (*ton.BlockIDExt, error) {
+	return &ton.BlockIDExt{}, nil
+}
+
+func (a *testMultisigAPI) GetAccount(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+	return &tlb.Account{
+		IsActive: true,
+		State: &tlb.AccountState{
+			IsValid:        true,
+			AccountStorage: tlb.AccountStorage{Status: tlb.AccountStatusActive},
+		},
+		Data: a.data,
+	}, nil
+}
+
+func TestClient_BuildNewOrderMessage(t *testing.T) {
+	build := func(arbitrary bool, orderSeqno *big.Int) (*big.Int, *big.Int, error) {
+		t.Helper()
+
+		data, err := BuildDeployData(Config{Threshold: 2, Signers: testAddrs, AllowArbitraryOrderSeqno: arbitrary})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := NewClient(&testMultisigAPI{data: data}, testAddrs[0])
+		actions := []Action{UpdateParamsAction{Threshold: 1, Signers: testAddrs[:1]}}
+		msg, seqno, err := c.BuildNewOrderMessage(context.Background(), testAddrs[1], orderSeqno, time.Unix(2000000000, 0), actions, tlb.MustFromTON("0.2"))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// order seqno is uint256, ## tag loads it as signed, so it is loaded manually
+		sl := msg.InternalMessage.Body.BeginParse()
+		if _, err = sl.LoadSlice(32 + 64); err != nil {
+			t.Fatal(err)
+		}
+		sent, err := sl.LoadBigUInt(256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sent, seqno, nil
+	}
+
+	// sequential mode uses the next seqno explicitly, to not create order with unexpected seqno
+	sent, seqno, err := build(false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.Sign() != 0 || seqno.Sign() != 0 {
+		t.Fatal("next seqno should be used", sent, seqno)
+	}
+	if _, _, err = build(false, big.NewInt(5)); !errors.Is(err, ErrInvalidOrderSeqno) {
+		t.Fatal("seqno other than next should be rejected in sequential mode", err)
+	}
+
+	// arbitrary mode uses the given seqno literally, max seqno has no special meaning there
+	for _, want := range []*big.Int{big.NewInt(5), maxOrderSeqno} {
+		sent, seqno, err = build(true, want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sent.Cmp(want) != 0 || seqno.Cmp(want) != 0 {
+			t.Fatal("given seqno should be used", sent, seqno)
+		}
+	}
+
+	sent, seqno, err = build(true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := build(true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.Cmp(seqno) != 0 || sent.Cmp(other) == 0 {
+		t.Fatal("random seqno should be generated and returned", sent, seqno, other)
+	}
+}
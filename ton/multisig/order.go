@@ -0,0 +1,258 @@
+package multisig
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	ActionSendMessage  = 0xf1381e5b
+	ActionUpdateParams = 0x1d0cfbd3
+)
+
+// Action - action executed by multisig when order is approved, SendMessageAction or UpdateParamsAction
+type Action interface {
+	ToCell() (*cell.Cell, error)
+}
+
+// SendMessageAction - sends message from multisig with specified mode
+type SendMessageAction struct {
+	Mode    uint8
+	Message *tlb.InternalMessage
+}
+
+// UpdateParamsAction - replaces threshold, signers and proposers of multisig
+type UpdateParamsAction struct {
+	Threshold uint8
+	Signers   []*address.Address
+	Proposers []*address.Address
+}
+
+// Order - parsed state of order contract
+type Order struct {
+	Address         *address.Address
+	MultisigAddress *address.Address
+	Seqno           *big.Int
+
+	// Initialized - false when order is deployed but not initialized by multisig yet, other fields are empty then
+	Initialized bool
+	Threshold   uint8
+	// Executed - threshold was reached and order was sent to multisig for execution
+	Executed      bool
+	Signers       []*address.Address
+	ApprovalsMask *big.Int
+	ApprovalsNum  uint8
+	ExpiresAt     time.Time
+	Actions       []Action
+}
+
+func (a SendMessageAction) ToCell() (*cell.Cell, error) {
+	if a.Message == nil {
+		return nil, fmt.Errorf("message cannot be nil")
+	}
+
+	msg, err := tlb.ToCell(a.Message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(ActionSendMessage, 32).
+		MustStoreUInt(uint64(a.Mode), 8).
+		MustStoreRef(msg).
+		EndCell(), nil
+}
+
+func (a UpdateParamsAction) ToCell() (*cell.Cell, error) {
+	signers, err := addressesToDict(a.Signers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build signers dict: %w", err)
+	}
+	proposers, err := addressesToDict(a.Proposers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build proposers dict: %w", err)
+	}
+
+	signersCell := signers.AsCell()
+	if signersCell == nil {
+		return nil, fmt.Errorf("signers cannot be empty")
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(ActionUpdateParams, 32).
+		MustStoreUInt(uint64(a.Threshold), 8).
+		MustStoreRef(signersCell).
+		MustStoreDict(proposers).
+		EndCell(), nil
+}
+
+// PackOrder - serializes actions to order cell, it is a dictionary with action index key and action in ref
+func PackOrder(actions []Action) (*cell.Cell, error) {
+	if len(actions) == 0 || len(actions) > 255 {
+		return nil, fmt.Errorf("actions num should be from 1 to 255")
+	}
+
+	dict := cell.NewDict(8)
+	for i, a := range actions {
+		c, err := a.ToCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize action %d: %w", i, err)
+		}
+
+		if err = dict.SetIntKey(big.NewInt(int64(i)), cell.BeginCell().MustStoreRef(c).EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to store action %d: %w", i, err)
+		}
+	}
+	return dict.AsCell(), nil
+}
+
+// UnpackOrder - parses actions from order cell
+func UnpackOrder(order *cell.Cell) ([]Action, error) {
+	kvs, err := order.AsDict(8).LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order dict: %w", err)
+	}
+
+	actions := make([]Action, 0, len(kvs))
+	for _, kv := range kvs {
+		ref, err := kv.Value.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load action ref: %w", err)
+		}
+
+		op, err := ref.LoadUInt(32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load action op: %w", err)
+		}
+
+		switch op {
+		case ActionSendMessage:
+			mode, err := ref.LoadUInt(8)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load mode: %w", err)
+			}
+
+			msgSlice, err := ref.LoadRef()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load message ref: %w", err)
+			}
+
+			var msg tlb.InternalMessage
+			if err = tlb.LoadFromCell(&msg, msgSlice); err != nil {
+				return nil, fmt.Errorf("failed to parse message: %w", err)
+			}
+			actions = append(actions, SendMessageAction{Mode: uint8(mode), Message: &msg})
+		case ActionUpdateParams:
+			threshold, err := ref.LoadUInt(8)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load threshold: %w", err)
+			}
+
+			signersCell, err := ref.LoadRefCell()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load signers: %w", err)
+			}
+			signers, err := dictToAddresses(signersCell.AsDict(8))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse signers: %w", err)
+			}
+
+			proposersDict, err := ref.LoadDict(8)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load proposers: %w", err)
+			}
+			proposers, err := dictToAddresses(proposersDict)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse proposers: %w", err)
+			}
+
+			actions = append(actions, UpdateParamsAction{Threshold: uint8(threshold), Signers: signers, Proposers: proposers})
+		default:
+			return nil, fmt.Errorf("unknown action op %x", op)
+		}
+	}
+	return actions, nil
+}
+
+// ParseOrderData - parses data cell of order account
+func ParseOrderData(data *cell.Cell) (*Order, error) {
+	if data == nil {
+		return nil, fmt.Errorf("data is nil")
+	}
+
+	s := data.BeginParse()
+
+	multisigAddr, err := s.LoadAddr()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load multisig address: %w", err)
+	}
+
+	seqno, err := s.LoadBigUInt(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order seqno: %w", err)
+	}
+
+	order := &Order{
+		MultisigAddress: multisigAddr,
+		Seqno:           seqno,
+	}
+
+	if s.BitsLeft() == 0 {
+		// deployed but not initialized yet
+		return order, nil
+	}
+
+	var st struct {
+		Threshold      uint8            `tlb:"## 8"`
+		Executed       bool             `tlb:"bool"`
+		Signers        *cell.Dictionary `tlb:"^ dict inline 8"`
+		ApprovalsMask  *big.Int         `tlb:"## 256"`
+		ApprovalsNum   uint8            `tlb:"## 8"`
+		ExpirationDate uint64           `tlb:"## 48"`
+		Order          *cell.Cell       `tlb:"^"`
+	}
+	if err = tlb.LoadFromCell(&st, s); err != nil {
+		return nil, fmt.Errorf("failed to parse order data: %w", err)
+	}
+
+	if order.Signers, err = dictToAddresses(st.Signers); err != nil {
+		return nil, fmt.Errorf("failed to parse signers: %w", err)
+	}
+	if order.Actions, err = UnpackOrder(st.Order); err != nil {
+		return nil, fmt.Errorf("failed to parse order actions: %w", err)
+	}
+
+	order.Initialized = true
+	order.Threshold = st.Threshold
+	order.Executed = st.Executed
+	order.ApprovalsMask = st.ApprovalsMask
+	order.ApprovalsNum = st.ApprovalsNum
+	order.ExpiresAt = time.Unix(int64(st.ExpirationDate), 0)
+	return order, nil
+}
+
+// IsApprovedBy - checks approval of signer with the index in the order signers list
+func (o *Order) IsApprovedBy(signerIndex uint8) bool {
+	return o.ApprovalsMask != nil && o.ApprovalsMask.Bit(int(signerIndex)) == 1
+}
+
+// IsPending - order is waiting for approvals
+func (o *Order) IsPending(now time.Time) bool {
+	return o.Initialized && !o.Executed && now.Before(o.ExpiresAt)
+}
+
+func randomQueryID() uint64 {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		// should never happen, query id is not required to be random
+		return uint64(time.Now().UnixNano())
+	}
+	return binary.LittleEndian.Uint64(buf)
+}
@@ -0,0 +1,125 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	tl.Register(NonfinalCandidateID{}, "liteServer.nonfinal.candidateId block_id:tonNode.blockIdExt creator:int256 collated_data_hash:int256 = liteServer.nonfinal.CandidateId")
+	tl.Register(NonfinalCandidate{}, "liteServer.nonfinal.candidate id:liteServer.nonfinal.candidateId data:bytes collated_data:bytes = liteServer.nonfinal.Candidate")
+	tl.Register(NonfinalCandidateInfo{}, "liteServer.nonfinal.candidateInfo id:liteServer.nonfinal.candidateId available:Bool approved_weight:long signed_weight:long total_weight:long = liteServer.nonfinal.CandidateInfo")
+	tl.Register(NonfinalValidatorGroupInfo{}, "liteServer.nonfinal.validatorGroupInfo next_block_id:tonNode.blockId cc_seqno:int prev:(vector tonNode.blockIdExt) candidates:(vector liteServer.nonfinal.candidateInfo) = liteServer.nonfinal.ValidatorGroupInfo")
+	tl.Register(NonfinalValidatorGroups{}, "liteServer.nonfinal.validatorGroups groups:(vector liteServer.nonfinal.validatorGroupInfo) = liteServer.nonfinal.ValidatorGroups")
+	tl.Register(NonfinalGetValidatorGroups{}, "liteServer.nonfinal.getValidatorGroups mode:# wc:mode.0?int shard:mode.1?long = liteServer.nonfinal.ValidatorGroups")
+	tl.Register(NonfinalGetCandidate{}, "liteServer.nonfinal.getCandidate id:liteServer.nonfinal.candidateId = liteServer.nonfinal.Candidate")
+}
+
+type NonfinalCandidateID struct {
+	BlockID          *BlockIDExt `tl:"struct"`
+	Creator          []byte      `tl:"int256"`
+	CollatedDataHash []byte      `tl:"int256"`
+}
+
+type NonfinalCandidate struct {
+	ID           *NonfinalCandidateID `tl:"struct"`
+	Data         []byte               `tl:"bytes"`
+	CollatedData []byte               `tl:"bytes"`
+}
+
+type NonfinalCandidateInfo struct {
+	ID             *NonfinalCandidateID `tl:"struct"`
+	Available      bool                 `tl:"bool"`
+	ApprovedWeight int64                `tl:"long"`
+	SignedWeight   int64                `tl:"long"`
+	TotalWeight    int64                `tl:"long"`
+}
+
+type NonfinalValidatorGroupInfo struct {
+	NextBlockID   *BlockInfoShort         `tl:"struct"`
+	CatchainSeqno int32                   `tl:"int"`
+	Prev          []*BlockIDExt           `tl:"vector struct"`
+	Candidates    []NonfinalCandidateInfo `tl:"vector struct"`
+}
+
+type NonfinalValidatorGroups struct {
+	Groups []NonfinalValidatorGroupInfo `tl:"vector struct"`
+}
+
+type NonfinalGetValidatorGroups struct {
+	Mode      uint32 `tl:"flags"`
+	Workchain int32  `tl:"?0 int"`
+	Shard     int64  `tl:"?1 long"`
+}
+
+type NonfinalGetCandidate struct {
+	ID *NonfinalCandidateID `tl:"struct"`
+}
+
+// GetNonfinalValidatorGroups - gets groups of validators with block candidates which are not yet finalized (signed),
+// liteserver should be run together with validator to support it. Such data cannot be proven.
+func (c *APIClient) GetNonfinalValidatorGroups(ctx context.Context) ([]NonfinalValidatorGroupInfo, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, NonfinalGetValidatorGroups{Mode: 0}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case NonfinalValidatorGroups:
+		return t.Groups, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// GetNonfinalCandidate - gets not yet finalized block candidate, its data is checked to match candidate id hashes
+func (c *APIClient) GetNonfinalCandidate(ctx context.Context, id *NonfinalCandidateID) (*tlb.Block, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, NonfinalGetCandidate{ID: id}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case NonfinalCandidate:
+		if t.ID == nil || t.ID.BlockID == nil || !t.ID.BlockID.Equals(id.BlockID) {
+			return nil, fmt.Errorf("incorrect candidate in response")
+		}
+
+		fileHash := sha256.Sum256(t.Data)
+		if !bytes.Equal(fileHash[:], id.BlockID.FileHash) {
+			return nil, fmt.Errorf("incorrect candidate file hash")
+		}
+
+		collatedHash := sha256.Sum256(t.CollatedData)
+		if !bytes.Equal(collatedHash[:], id.CollatedDataHash) {
+			return nil, fmt.Errorf("incorrect candidate collated data hash")
+		}
+
+		pl, err := cell.FromBOC(t.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(pl.Hash(), id.BlockID.RootHash) {
+			return nil, fmt.Errorf("incorrect candidate root hash")
+		}
+
+		var bData tlb.Block
+		if err = tlb.LoadFromCell(&bData, pl.BeginParse()); err != nil {
+			return nil, fmt.Errorf("failed to parse block data: %w", err)
+		}
+		return &bData, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
@@ -0,0 +1,54 @@
+package ton
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+func TestAPIClient_GetNonfinalCandidate(t *testing.T) {
+	block := newTestBlock(t, 0, 20, testBlockParts{})
+	data := block.block.ToBOC()
+	collated := []byte("collated data")
+
+	fileHash := sha256.Sum256(data)
+	collatedHash := sha256.Sum256(collated)
+	id := &NonfinalCandidateID{
+		BlockID:          &BlockIDExt{Workchain: 0, Shard: block.id.Shard, SeqNo: 20, RootHash: block.id.RootHash, FileHash: fileHash[:]},
+		Creator:          make([]byte, 32),
+		CollatedDataHash: collatedHash[:],
+	}
+
+	candidate := func(data, collated []byte) *APIClient {
+		return NewAPIClient(&proofClient{answer: func(payload tl.Serializable) tl.Serializable {
+			return NonfinalCandidate{ID: payload.(NonfinalGetCandidate).ID, Data: data, CollatedData: collated}
+		}})
+	}
+
+	blk, err := candidate(data, collated).GetNonfinalCandidate(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blk.BlockInfo.SeqNo != 20 || !blk.BlockInfo.NotMaster {
+		t.Fatal("incorrect block", blk.BlockInfo.SeqNo)
+	}
+
+	other := newTestBlock(t, 0, 21, testBlockParts{}).block.ToBOC()
+	if _, err = candidate(other, collated).GetNonfinalCandidate(context.Background(), id); err == nil {
+		t.Fatal("data of another block should be rejected")
+	}
+
+	if _, err = candidate(data, []byte("other")).GetNonfinalCandidate(context.Background(), id); err == nil {
+		t.Fatal("another collated data should be rejected")
+	}
+
+	// data matches file hash, but root is different
+	otherFileHash := sha256.Sum256(other)
+	tampered := *id
+	tampered.BlockID = &BlockIDExt{Workchain: 0, Shard: block.id.Shard, SeqNo: 20, RootHash: block.id.RootHash, FileHash: otherFileHash[:]}
+	if _, err = candidate(other, collated).GetNonfinalCandidate(context.Background(), &tampered); err == nil {
+		t.Fatal("data with another root should be rejected")
+	}
+}
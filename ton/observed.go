@@ -0,0 +1,54 @@
+package ton
+
+import (
+	"context"
+
+	"github.com/xssnick/tonutils-go/observer"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+type observedClient struct {
+	original LiteClient
+	observer observer.Observer
+}
+
+func (o *observedClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) (err error) {
+	// inner wrappers, like retrier, are reporting their events using observer from context
+	ctx = observer.NewContext(ctx, o.observer)
+
+	ctx, tracker := observer.StartRequest(ctx, o.observer, observer.ComponentAPI, tl.TypeName(payload), "")
+	defer func() {
+		if err == nil {
+			// liteserver errors are returned as response, but reported as errors
+			if tmp, ok := result.(*tl.Serializable); ok && tmp != nil {
+				if lsErr, ok := (*tmp).(LSError); ok {
+					tracker.Done(lsErr)
+					return
+				}
+			}
+		}
+		tracker.Done(err)
+	}()
+
+	return o.original.QueryLiteserver(ctx, payload, result)
+}
+
+func (o *observedClient) unwrap() LiteClient {
+	return o.original
+}
+
+func (o *observedClient) StickyContext(ctx context.Context) context.Context {
+	return o.original.StickyContext(ctx)
+}
+
+func (o *observedClient) StickyNodeID(ctx context.Context) uint32 {
+	return o.original.StickyNodeID(ctx)
+}
+
+func (o *observedClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return o.original.StickyContextNextNode(ctx)
+}
+
+func (o *observedClient) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return o.original.StickyContextNextNodeBalanced(ctx)
+}
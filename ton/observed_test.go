@@ -0,0 +1,66 @@
+package ton
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/observer"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+type notAppliedClient struct {
+	multiNodeClient
+}
+
+func (m *notAppliedClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	if m.StickyNodeID(ctx) == 0 {
+		*result.(*tl.Serializable) = LSError{Code: 651, Text: "block not applied"}
+		return nil
+	}
+	return m.multiNodeClient.QueryLiteserver(ctx, payload, result)
+}
+
+type testObserver struct {
+	observer.Nop
+	requests []string
+	errors   int
+	retries  int
+}
+
+func (o *testObserver) OnResponse(ctx context.Context, req *observer.Request, err error) {
+	o.requests = append(o.requests, req.Method)
+	if err != nil {
+		o.errors++
+	}
+}
+
+func (o *testObserver) OnEvent(ctx context.Context, e *observer.Event) {
+	if e.Kind == observer.EventRetry {
+		o.retries++
+	}
+}
+
+func TestAPIClient_WithObserver(t *testing.T) {
+	client := &notAppliedClient{multiNodeClient{answers: []uint32{100, 100}}}
+	obs := &testObserver{}
+
+	api := NewAPIClient(client, ProofCheckPolicyUnsafe).WithRetry().(APIClientExtended).WithObserver(obs)
+	if _, err := api.GetMasterchainInfo(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(obs.requests) != 1 || obs.requests[0] != "liteServer.getMasterchainInfo" || obs.errors != 0 {
+		t.Fatal("incorrect observed requests", obs.requests)
+	}
+	if obs.retries != 1 {
+		t.Fatal("retry should be reported")
+	}
+
+	api = NewAPIClient(client, ProofCheckPolicyUnsafe).WithObserver(obs)
+	if _, err := api.GetMasterchainInfo(client.StickyContext(context.Background())); err == nil {
+		t.Fatal("should be liteserver error")
+	}
+	if obs.errors != 1 {
+		t.Fatal("liteserver error should be reported")
+	}
+}
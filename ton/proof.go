@@ -104,8 +104,14 @@ func CheckBlockProof(proof *cell.Cell, blockRootHash []byte) (*tlb.Block, error)
 }
 
 func CheckAccountStateProof(addr *address.Address, block *BlockIDExt, stateProof []*cell.Cell, shardProof []*cell.Cell, shardHash []byte, skipBlockCheck bool) (*tlb.ShardAccount, *tlb.DepthBalanceInfo, error) {
+	acc, balance, _, err := checkAccountStateProof(addr, block, stateProof, shardProof, shardHash, skipBlockCheck)
+	return acc, balance, err
+}
+
+// checkAccountStateProof - same as CheckAccountStateProof, but also returns shard state of the account from proof
+func checkAccountStateProof(addr *address.Address, block *BlockIDExt, stateProof []*cell.Cell, shardProof []*cell.Cell, shardHash []byte, skipBlockCheck bool) (*tlb.ShardAccount, *tlb.DepthBalanceInfo, *tlb.ShardStateUnsplit, error) {
 	if len(stateProof) != 2 {
-		return nil, nil, fmt.Errorf("proof should have 2 roots")
+		return nil, nil, nil, fmt.Errorf("proof should have 2 roots")
 	}
 
 	var shardState *tlb.ShardStateUnsplit
@@ -115,7 +121,7 @@ func CheckAccountStateProof(addr *address.Address, block *BlockIDExt, stateProof
 		// we need shard proof only for not masterchain
 		if len(shardHash) > 0 {
 			if err := CheckShardInMasterProof(block, shardProof, addr.Workchain(), shardHash); err != nil {
-				return nil, nil, fmt.Errorf("shard proof is incorrect: %w", err)
+				return nil, nil, nil, fmt.Errorf("shard proof is incorrect: %w", err)
 			}
 			blockHash = shardHash
 		}
@@ -123,46 +129,47 @@ func CheckAccountStateProof(addr *address.Address, block *BlockIDExt, stateProof
 		var err error
 		shardState, err = CheckBlockShardStateProof(stateProof, blockHash)
 		if err != nil {
-			return nil, nil, fmt.Errorf("incorrect block proof: %w", err)
+			return nil, nil, nil, fmt.Errorf("incorrect block proof: %w", err)
 		}
 	} else {
 		shardStateProofData, err := stateProof[1].BeginParse().LoadRef()
 		if err != nil {
-			return nil, nil, fmt.Errorf("shard state proof should have ref: %w", err)
+			return nil, nil, nil, fmt.Errorf("shard state proof should have ref: %w", err)
 		}
 
 		var state tlb.ShardStateUnsplit
 		if err = tlb.LoadFromCellAsProof(&state, shardStateProofData, false); err != nil {
-			return nil, nil, fmt.Errorf("failed to parse ShardStateUnsplit: %w", err)
+			return nil, nil, nil, fmt.Errorf("failed to parse ShardStateUnsplit: %w", err)
 		}
 		shardState = &state
 	}
 
-	if shardState.Accounts.ShardAccounts == nil {
-		return nil, nil, errors.New("no shard accounts in proof")
+	if shardState.Accounts.ShardAccounts.IsEmpty() {
+		return nil, nil, nil, errors.New("no shard accounts in proof")
 	}
 
 	addrKey := cell.BeginCell().MustStoreSlice(addr.Data(), 256).EndCell()
-	val := shardState.Accounts.ShardAccounts.Get(addrKey)
-	if val == nil {
-		return nil, nil, errors.New("no addr info in proof hashmap")
+	loadVal, extra, err := shardState.Accounts.ShardAccounts.LoadValueWithExtra(addrKey)
+	if err != nil {
+		if errors.Is(err, cell.ErrNoSuchKeyInDict) {
+			return nil, nil, nil, errors.New("no addr info in proof hashmap")
+		}
+		return nil, nil, nil, fmt.Errorf("failed to load addr info from proof hashmap: %w", err)
 	}
 
-	loadVal := val.BeginParse()
-
 	var balanceInfo tlb.DepthBalanceInfo
-	err := tlb.LoadFromCell(&balanceInfo, loadVal)
+	err = tlb.LoadFromCell(&balanceInfo, extra)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load DepthBalanceInfo: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to load DepthBalanceInfo: %w", err)
 	}
 
 	var accInfo tlb.ShardAccount
 	err = tlb.LoadFromCell(&accInfo, loadVal)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load ShardAccount: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to load ShardAccount: %w", err)
 	}
 
-	return &accInfo, &balanceInfo, nil
+	return &accInfo, &balanceInfo, shardState, nil
 }
 
 func CheckTransactionProof(txHash []byte, txLT uint64, txAccount []byte, shardAccounts *tlb.ShardAccountBlocks) error {
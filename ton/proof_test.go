@@ -0,0 +1,459 @@
+package ton
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// proofClient - answers liteserver requests with the prepared responses
+type proofClient struct {
+	answer func(payload tl.Serializable) tl.Serializable
+}
+
+func (m *proofClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	*result.(*tl.Serializable) = m.answer(payload)
+	return nil
+}
+
+func (m *proofClient) StickyContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (m *proofClient) StickyNodeID(ctx context.Context) uint32 {
+	return 0
+}
+
+func (m *proofClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *proofClient) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+// testBlockParts - parts of the synthetic block, not set ones are filled with dummy cells
+type testBlockParts struct {
+	// prev - parent block, referenced by header
+	prev *BlockIDExt
+	// master - masterchain block, referenced by header of shard block
+	master *BlockIDExt
+	// shards - top shard blocks, referenced by masterchain block extra
+	shards []*BlockIDExt
+	// shard - shard of the block, root shard when zero
+	shard int64
+	// prev2 - second parent block, set after merge
+	prev2      *BlockIDExt
+	afterSplit bool
+
+	outMsgQueueInfo *cell.Cell
+	stateStats      *cell.Cell
+	// mcStateExtraInfo - info of masterchain state extra, with prev blocks and block create stats
+	mcStateExtraInfo *cell.Cell
+}
+
+// testBlock - synthetic block with its state, parts of which are pruned in proofs
+type testBlock struct {
+	id    *BlockIDExt
+	block *cell.Cell
+	state *cell.Cell
+	// shardHashes - shards of masterchain block
+	shardHashes *cell.Dictionary
+}
+
+// testDummyCell - returns cell with ref, so it is pruned in proof when not included
+func testDummyCell(tag uint64) *cell.Cell {
+	return cell.BeginCell().MustStoreUInt(tag, 32).MustStoreRef(cell.BeginCell().EndCell()).EndCell()
+}
+
+func testExtBlkRef(id *BlockIDExt) *cell.Builder {
+	return cell.BeginCell().
+		MustStoreUInt(uint64(id.SeqNo)*1000000+999, 64).
+		MustStoreUInt(uint64(id.SeqNo), 32).
+		MustStoreSlice(id.RootHash, 256).
+		MustStoreSlice(id.FileHash, 256)
+}
+
+func testShardIdent(workchain int32, shard int64) *cell.Builder {
+	bits := 0
+	for x := uint64(shard); x&1 == 0; x >>= 1 {
+		bits++
+	}
+	lowBit := uint64(1) << bits
+	return cell.BeginCell().MustStoreUInt(0, 2).MustStoreUInt(uint64(63-bits), 6).MustStoreInt(int64(workchain), 32).MustStoreUInt(uint64(shard)&^lowBit, 64)
+}
+
+// testShardBinTree - builds BinTree of descriptions of the workchain shards, starting from the given shard
+func testShardBinTree(t *testing.T, mcSeqno uint32, shards []*BlockIDExt, shard uint64) *cell.Cell {
+	for _, s := range shards {
+		if uint64(s.Shard) != shard {
+			continue
+		}
+		return cell.BeginCell().
+			MustStoreUInt(0, 1). // bt_leaf
+			MustStoreUInt(0xb, 4).
+			MustStoreUInt(uint64(s.SeqNo), 32).
+			MustStoreUInt(uint64(mcSeqno), 32).
+			MustStoreUInt(0, 64).MustStoreUInt(0, 64).
+			MustStoreSlice(s.RootHash, 256).
+			MustStoreSlice(s.FileHash, 256).
+			MustStoreUInt(0, 5).MustStoreUInt(0, 3).
+			MustStoreUInt(0, 32).
+			MustStoreInt(s.Shard, 64).
+			MustStoreUInt(0, 32).MustStoreUInt(0, 32).
+			MustStoreUInt(0, 1). // fsm_none
+			MustStoreCoins(0).MustStoreDict(nil).
+			MustStoreCoins(0).MustStoreDict(nil).
+			EndCell()
+	}
+
+	half := (shard & -shard) >> 1
+	if half == 0 {
+		t.Fatal("no shard", shard, "in shards list")
+	}
+	return cell.BeginCell().
+		MustStoreUInt(1, 1). // bt_fork
+		MustStoreRef(testShardBinTree(t, mcSeqno, shards, shard-half)).
+		MustStoreRef(testShardBinTree(t, mcSeqno, shards, shard+half)).
+		EndCell()
+}
+
+// testShardHashes - builds dictionary of shard hashes of the masterchain block
+func testShardHashes(t *testing.T, mcSeqno uint32, shards []*BlockIDExt) *cell.Dictionary {
+	byWorkchain := map[int32][]*BlockIDExt{}
+	for _, shard := range shards {
+		byWorkchain[shard.Workchain] = append(byWorkchain[shard.Workchain], shard)
+	}
+
+	shardHashes := cell.NewDict(32)
+	for workchain, list := range byWorkchain {
+		tree := testShardBinTree(t, mcSeqno, list, 1<<63)
+		if err := shardHashes.SetIntKey(big.NewInt(int64(workchain)), cell.BeginCell().MustStoreRef(tree).EndCell()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return shardHashes
+}
+
+func newTestBlock(t *testing.T, workchain int32, seqno uint32, parts testBlockParts) *testBlock {
+	isMaster := workchain == -1
+	prev := parts.prev
+	if prev == nil {
+		prev = &BlockIDExt{Workchain: workchain, SeqNo: seqno - 1, RootHash: make([]byte, 32), FileHash: make([]byte, 32)}
+	}
+	shard := parts.shard
+	if shard == 0 {
+		shard = -0x8000000000000000
+	}
+
+	header := cell.BeginCell().
+		MustStoreUInt(0x9bc7a987, 32).
+		MustStoreUInt(0, 32).
+		MustStoreBoolBit(!isMaster).
+		MustStoreBoolBit(parts.prev2 != nil).
+		MustStoreBoolBit(false).
+		MustStoreBoolBit(parts.afterSplit).
+		MustStoreUInt(0, 4).
+		MustStoreUInt(0, 8).
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreUInt(0, 32).
+		MustStoreBuilder(testShardIdent(workchain, shard)).
+		MustStoreUInt(1700000000+uint64(seqno), 32).
+		MustStoreUInt(uint64(seqno)*1000000, 64).
+		MustStoreUInt(uint64(seqno)*1000000+999, 64).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(0, 32)
+	if !isMaster {
+		master := parts.master
+		if master == nil {
+			master = &BlockIDExt{Workchain: -1, RootHash: make([]byte, 32), FileHash: make([]byte, 32)}
+		}
+		header.MustStoreRef(testExtBlkRef(master).EndCell())
+	}
+	if parts.prev2 != nil {
+		header.MustStoreRef(cell.BeginCell().
+			MustStoreRef(testExtBlkRef(prev).EndCell()).
+			MustStoreRef(testExtBlkRef(parts.prev2).EndCell()).
+			EndCell())
+	} else {
+		header.MustStoreRef(testExtBlkRef(prev).EndCell())
+	}
+
+	var custom *cell.Cell
+	var mcStateExtra *cell.Cell
+	var shardHashes *cell.Dictionary
+	if isMaster {
+		shardHashes = testShardHashes(t, seqno, parts.shards)
+
+		custom = cell.BeginCell().
+			MustStoreUInt(0xcca5, 16).
+			MustStoreBoolBit(false).
+			MustStoreDict(shardHashes).
+			MustStoreDict(nil).
+			MustStoreRef(cell.BeginCell().MustStoreDict(nil).MustStoreMaybeRef(nil).MustStoreMaybeRef(nil).EndCell()).
+			EndCell()
+
+		info := parts.mcStateExtraInfo
+		if info == nil {
+			info = testDummyCell(1)
+		}
+		mcStateExtra = cell.BeginCell().
+			MustStoreUInt(0xcc26, 16).
+			MustStoreDict(nil).
+			MustStoreSlice(make([]byte, 32), 256).MustStoreRef(testDummyCell(2)).
+			MustStoreRef(info).
+			MustStoreCoins(0).MustStoreDict(nil).
+			EndCell()
+	}
+
+	extra := cell.BeginCell().
+		MustStoreUInt(0x4a33f6fd, 32).
+		MustStoreRef(testDummyCell(3)).
+		MustStoreRef(testDummyCell(4)).
+		MustStoreRef(cell.BeginCell().MustStoreBoolBit(false).MustStoreCoins(0).MustStoreDict(nil).EndCell()). // no account blocks
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreMaybeRef(custom).
+		EndCell()
+
+	outMsgQueueInfo, stateStats := parts.outMsgQueueInfo, parts.stateStats
+	if outMsgQueueInfo == nil {
+		outMsgQueueInfo = testDummyCell(6)
+	}
+	if stateStats == nil {
+		stateStats = testDummyCell(7)
+	}
+
+	state := cell.BeginCell().
+		MustStoreUInt(0x9023afe2, 32).
+		MustStoreInt(-239, 32).
+		MustStoreBuilder(testShardIdent(workchain, shard)).
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(1700000000+uint64(seqno), 32).
+		MustStoreUInt(uint64(seqno)*1000000, 64).
+		MustStoreUInt(0, 32).
+		MustStoreRef(outMsgQueueInfo).
+		MustStoreBoolBit(false).
+		MustStoreRef(testDummyCell(8)).
+		MustStoreRef(stateStats).
+		MustStoreMaybeRef(mcStateExtra).
+		EndCell()
+
+	valueFlow, err := (&tlb.ValueFlow{}).ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := cell.BeginCell().
+		MustStoreUInt(0x11ef55aa, 32).
+		MustStoreInt(-239, 32).
+		MustStoreRef(header.EndCell()).
+		MustStoreRef(valueFlow).
+		MustStoreRef(cell.BeginCell().MustStoreRef(testDummyCell(9)).MustStoreRef(state).EndCell()).
+		MustStoreRef(extra).
+		EndCell()
+
+	return &testBlock{
+		id: &BlockIDExt{
+			Workchain: workchain,
+			Shard:     shard,
+			SeqNo:     seqno,
+			RootHash:  block.Hash(),
+			FileHash:  testFileHash(seqno),
+		},
+		block:       block,
+		state:       state,
+		shardHashes: shardHashes,
+	}
+}
+
+func testFileHash(seqno uint32) []byte {
+	h := make([]byte, 32)
+	h[0], h[31] = 0xf1, byte(seqno)
+	return h
+}
+
+// blockProof - returns proof of the block header, and of the shard hashes for masterchain block
+func (b *testBlock) blockProof(t *testing.T) *cell.Cell {
+	sk := cell.CreateProofSkeleton()
+	sk.ProofRef(0)
+	if b.id.Workchain == -1 {
+		sk.ProofRef(3).ProofRef(3).SetRecursive()
+	}
+
+	proof, err := b.block.CreateProof(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+// stateProof - returns block and state proofs, state proof includes cells marked by stateSk
+func (b *testBlock) stateProof(t *testing.T, stateSk *cell.ProofSkeleton) []*cell.Cell {
+	blockSk := cell.CreateProofSkeleton()
+	blockSk.ProofRef(2)
+
+	blockProof, err := b.block.CreateProof(blockSk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stateProof, err := b.state.CreateProof(stateSk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []*cell.Cell{blockProof, stateProof}
+}
+
+// mcExtraInfoSkeleton - returns state skeleton and its part for the McStateExtra info cell
+func mcExtraInfoSkeleton() (state, info *cell.ProofSkeleton) {
+	state = cell.CreateProofSkeleton()
+	return state, state.ProofRef(3).ProofRef(1)
+}
+
+func TestCheckShardBlockProof(t *testing.T) {
+	shard0 := newTestBlock(t, 0, 20, testBlockParts{})
+	shard1 := newTestBlock(t, 0, 21, testBlockParts{prev: shard0.id})
+	master := newTestBlock(t, -1, 10, testBlockParts{shards: []*BlockIDExt{shard1.id}})
+
+	proof := &ShardBlockProof{
+		MasterchainID: master.id,
+		Links: []ShardBlockLink{
+			{ID: shard1.id, Proof: master.blockProof(t).ToBOC()},
+			{ID: shard0.id, Proof: shard1.blockProof(t).ToBOC()},
+		},
+	}
+	if err := CheckShardBlockProof(shard0.id, proof); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckShardBlockProof(shard1.id, proof); err == nil {
+		t.Fatal("proof for another block should be rejected")
+	}
+
+	// link to the block which is not referenced by masterchain
+	other := newTestBlock(t, 0, 21, testBlockParts{prev: shard0.id, master: master.id})
+	tampered := &ShardBlockProof{
+		MasterchainID: master.id,
+		Links: []ShardBlockLink{
+			{ID: other.id, Proof: master.blockProof(t).ToBOC()},
+			{ID: shard0.id, Proof: other.blockProof(t).ToBOC()},
+		},
+	}
+	if err := CheckShardBlockProof(shard0.id, tampered); err == nil {
+		t.Fatal("not referenced link should be rejected")
+	}
+
+	// proof of another block is passed for the link
+	tampered = &ShardBlockProof{
+		MasterchainID: master.id,
+		Links: []ShardBlockLink{
+			{ID: shard1.id, Proof: master.blockProof(t).ToBOC()},
+			{ID: shard0.id, Proof: other.blockProof(t).ToBOC()},
+		},
+	}
+	if err := CheckShardBlockProof(shard0.id, tampered); err == nil {
+		t.Fatal("proof of another block should be rejected")
+	}
+}
+
+func TestCheckLookupBlockProof(t *testing.T) {
+	shard0 := newTestBlock(t, 0, 20, testBlockParts{})
+	shard1 := newTestBlock(t, 0, 21, testBlockParts{prev: shard0.id})
+	prevMaster := newTestBlock(t, -1, 9, testBlockParts{})
+
+	prevBlocks := cell.NewDict(32)
+	if err := prevBlocks.SetIntKey(big.NewInt(9), cell.BeginCell().
+		MustStoreBoolBit(false).MustStoreUInt(9000999, 64).
+		MustStoreBoolBit(false).MustStoreBuilder(testExtBlkRef(prevMaster.id)).
+		EndCell()); err != nil {
+		t.Fatal(err)
+	}
+
+	info := cell.BeginCell().
+		MustStoreUInt(0, 16).
+		MustStoreUInt(0, 32).MustStoreUInt(0, 32).MustStoreBoolBit(false).
+		MustStoreDict(prevBlocks).MustStoreBoolBit(false).MustStoreUInt(9000999, 64).
+		MustStoreBoolBit(false).
+		MustStoreBoolBit(false).
+		EndCell()
+	master := newTestBlock(t, -1, 10, testBlockParts{shards: []*BlockIDExt{shard1.id}, mcStateExtraInfo: info, prev: prevMaster.id})
+
+	t.Run("shard block", func(t *testing.T) {
+		res := &LookupBlockResult{
+			ID:           shard0.id,
+			MCBlockID:    master.id,
+			MCBlockProof: master.blockProof(t),
+			ShardLinks:   []ShardBlockLink{{ID: shard1.id, Proof: shard1.blockProof(t).ToBOC()}},
+			Header:       shard0.blockProof(t),
+		}
+		if err := CheckLookupBlockProof(master.id, res); err != nil {
+			t.Fatal(err)
+		}
+
+		tampered := *res
+		tampered.MCBlockID = prevMaster.id
+		if err := CheckLookupBlockProof(master.id, &tampered); err == nil {
+			t.Fatal("another masterchain block should be rejected")
+		}
+
+		tampered = *res
+		tampered.ShardLinks = nil
+		if err := CheckLookupBlockProof(master.id, &tampered); err == nil {
+			t.Fatal("not linked block should be rejected")
+		}
+
+		// masterchain block with the same seqno and shards, but other hash
+		otherMaster := newTestBlock(t, -1, 10, testBlockParts{shards: []*BlockIDExt{shard1.id}})
+		tampered = *res
+		tampered.MCBlockProof = otherMaster.blockProof(t)
+		if err := CheckLookupBlockProof(master.id, &tampered); err == nil {
+			t.Fatal("proof of not known masterchain block should be rejected")
+		}
+
+		tampered = *res
+		tampered.Header = shard1.blockProof(t)
+		if err := CheckLookupBlockProof(master.id, &tampered); err == nil {
+			t.Fatal("header of another block should be rejected")
+		}
+	})
+
+	t.Run("previous masterchain block", func(t *testing.T) {
+		stateSk, infoSk := mcExtraInfoSkeleton()
+		if _, _, err := prevBlocks.LoadValueWithProof(cell.BeginCell().MustStoreUInt(9, 32).EndCell(), infoSk.ProofRef(0)); err != nil {
+			t.Fatal(err)
+		}
+
+		res := &LookupBlockResult{
+			ID:                 prevMaster.id,
+			MCBlockID:          master.id,
+			ClientMCStateProof: master.stateProof(t, stateSk),
+			Header:             prevMaster.blockProof(t),
+		}
+		if err := CheckLookupBlockProof(master.id, res); err != nil {
+			t.Fatal(err)
+		}
+
+		// block with the same seqno, but other hash
+		other := newTestBlock(t, -1, 9, testBlockParts{shards: []*BlockIDExt{shard0.id}})
+		tampered := *res
+		tampered.ID = other.id
+		tampered.Header = other.blockProof(t)
+		if err := CheckLookupBlockProof(master.id, &tampered); err == nil {
+			t.Fatal("block which is not in state should be rejected")
+		}
+
+		tampered = *res
+		tampered.ClientMCStateProof = prevMaster.stateProof(t, cell.CreateProofSkeleton())
+		if err := CheckLookupBlockProof(master.id, &tampered); err == nil {
+			t.Fatal("state proof of another block should be rejected")
+		}
+	})
+}
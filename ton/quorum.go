@@ -0,0 +1,222 @@
+package ton
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+var ErrQuorumNotReached = errors.New("quorum of liteservers is not reached")
+
+// QuorumAnswer - answer of the single liteserver in the quorum request
+type QuorumAnswer struct {
+	NodeID uint32
+	// Hash - hash of the meaningful part of response, equal for matching answers
+	Hash []byte
+	Err  error
+}
+
+// QuorumError - returned when not enough liteservers gave the same answer,
+// errors.Is(err, ErrQuorumNotReached) can be used to check it
+type QuorumError struct {
+	Required int
+	// Agreed - size of the biggest group of matching answers
+	Agreed  int
+	Answers []QuorumAnswer
+}
+
+type quorumClient struct {
+	original LiteClient
+	nodes    int
+	required int
+}
+
+func (e *QuorumError) Error() string {
+	var failed, groups int
+	seen := map[string]bool{}
+	for _, a := range e.Answers {
+		if a.Err != nil {
+			failed++
+			continue
+		}
+		if !seen[string(a.Hash)] {
+			seen[string(a.Hash)] = true
+			groups++
+		}
+	}
+	return fmt.Sprintf("%s: %d matching answers of %d required, %d different answers, %d failed requests",
+		ErrQuorumNotReached.Error(), e.Agreed, e.Required, groups, failed)
+}
+
+func (e *QuorumError) Is(err error) bool {
+	return err == ErrQuorumNotReached
+}
+
+func (q *quorumClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	if q.required <= 0 || q.required > q.nodes {
+		return fmt.Errorf("incorrect quorum %d of %d", q.required, q.nodes)
+	}
+
+	resType := reflect.TypeOf(result)
+	if resType.Kind() != reflect.Pointer {
+		return fmt.Errorf("result should be a pointer")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// nodes which are behind are not asked, their answers would not match
+	outOfSync := map[uint32]bool{}
+	pool, hasPool := findNodesHealthClient(q.original)
+	if hasPool {
+		for _, h := range pool.NodesHealth() {
+			outOfSync[h.ID] = h.OutOfSync
+		}
+	}
+
+	// select distinct nodes
+	var contexts []context.Context
+	nodeCtx := q.original.StickyContext(ctx)
+	for {
+		if !outOfSync[q.original.StickyNodeID(nodeCtx)] {
+			contexts = append(contexts, nodeCtx)
+		}
+		if len(contexts) >= q.nodes {
+			break
+		}
+
+		next, err := q.original.StickyContextNextNode(nodeCtx)
+		if err != nil {
+			// no more nodes
+			break
+		}
+		nodeCtx = next
+	}
+
+	type answer struct {
+		QuorumAnswer
+		result reflect.Value
+	}
+
+	answers := make(chan answer, len(contexts))
+	for _, nCtx := range contexts {
+		go func(nCtx context.Context) {
+			res := reflect.New(resType.Elem())
+			a := answer{result: res}
+			a.NodeID = q.original.StickyNodeID(nCtx)
+
+			if hasPool {
+				// request should not be routed to another node when the bound one fails,
+				// otherwise the same node could answer for several members
+				nCtx = pool.StickyContextWithNodeIDStrict(nCtx, a.NodeID)
+			}
+
+			var responded uint32
+			nCtx = liteclient.WithRespondedNode(nCtx, &responded)
+
+			if err := q.original.QueryLiteserver(nCtx, payload, res.Interface().(tl.Serializable)); err != nil {
+				a.Err = err
+			} else {
+				a.Hash, a.Err = quorumResponseHash(res.Elem().Interface())
+			}
+
+			// wrappers, like retry, may still switch the node, so the node which really answered is used
+			if responded != 0 {
+				a.NodeID = responded
+			}
+			answers <- a
+		}(nCtx)
+	}
+
+	qErr := &QuorumError{Required: q.required}
+	groups := map[string]int{}
+	answered := map[uint32]bool{}
+	for range contexts {
+		a := <-answers
+		if a.Err == nil && answered[a.NodeID] {
+			a.Err = fmt.Errorf("node %d already answered for another member", a.NodeID)
+		}
+		qErr.Answers = append(qErr.Answers, a.QuorumAnswer)
+		if a.Err != nil {
+			continue
+		}
+		answered[a.NodeID] = true
+
+		groups[string(a.Hash)]++
+		if n := groups[string(a.Hash)]; n > qErr.Agreed {
+			qErr.Agreed = n
+		}
+
+		if qErr.Agreed >= q.required {
+			reflect.ValueOf(result).Elem().Set(a.result.Elem())
+			return nil
+		}
+	}
+	return qErr
+}
+
+// quorumResponseHash - calculates hash of the response data, which should match between liteservers
+func quorumResponseHash(resp any) ([]byte, error) {
+	if tmp, ok := resp.(tl.Serializable); ok {
+		resp = tmp
+	}
+
+	h := sha256.New()
+	writeBlock := func(b *BlockIDExt) {
+		if b == nil {
+			h.Write([]byte{0})
+			return
+		}
+		h.Write([]byte(fmt.Sprintf("%d:%d:%d:", b.Workchain, b.Shard, b.SeqNo)))
+		h.Write(b.RootHash)
+		h.Write(b.FileHash)
+	}
+
+	switch t := resp.(type) {
+	case RunMethodResult:
+		writeBlock(t.ID)
+		writeBlock(t.ShardBlock)
+		h.Write([]byte(fmt.Sprintf("exit:%d:", t.ExitCode)))
+		if t.Result != nil {
+			h.Write(t.Result.Hash())
+		}
+	case AccountState:
+		writeBlock(t.ID)
+		writeBlock(t.Shard)
+		if t.State != nil {
+			h.Write(t.State.Hash())
+		}
+	default:
+		data, err := tl.Serialize(resp, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize response: %w", err)
+		}
+		h.Write(data)
+	}
+	return h.Sum(nil), nil
+}
+
+func (q *quorumClient) unwrap() LiteClient {
+	return q.original
+}
+
+func (q *quorumClient) StickyContext(ctx context.Context) context.Context {
+	return q.original.StickyContext(ctx)
+}
+
+func (q *quorumClient) StickyNodeID(ctx context.Context) uint32 {
+	return q.original.StickyNodeID(ctx)
+}
+
+func (q *quorumClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return q.original.StickyContextNextNode(ctx)
+}
+
+func (q *quorumClient) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return q.original.StickyContextNextNodeBalanced(ctx)
+}
@@ -0,0 +1,144 @@
+package ton
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+type quorumNodeKey struct{}
+type quorumStrictKey struct{}
+
+type multiNodeClient struct {
+	// answers - masterchain seqno returned by each node, 0 means failure
+	answers   []uint32
+	outOfSync map[uint32]bool
+	asked     []uint32
+	mx        sync.Mutex
+	// sendFails - nodes to which request cannot be sent, not strict requests are routed to node 1 then, like in pool
+	sendFails map[uint32]bool
+	// rerouteStrict - reroutes even strict requests, like wrapper which switches node
+	rerouteStrict bool
+}
+
+func (m *multiNodeClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	id := m.StickyNodeID(ctx)
+	m.mx.Lock()
+	m.asked = append(m.asked, id)
+	m.mx.Unlock()
+
+	if m.sendFails[id] {
+		if strict, _ := ctx.Value(quorumStrictKey{}).(bool); strict && !m.rerouteStrict {
+			return liteclient.ErrNoActiveConnections
+		}
+		id = 1
+	}
+	liteclient.ReportRespondedNode(ctx, id)
+
+	seqno := m.answers[id]
+	if seqno == 0 {
+		return errors.New("node failed")
+	}
+
+	*result.(*tl.Serializable) = MasterchainInfo{
+		Last:          &BlockIDExt{Workchain: -1, Shard: -0x8000000000000000, SeqNo: seqno, RootHash: make([]byte, 32), FileHash: make([]byte, 32)},
+		StateRootHash: make([]byte, 32),
+		Init:          &ZeroStateIDExt{RootHash: make([]byte, 32), FileHash: make([]byte, 32)},
+	}
+	return nil
+}
+
+func (m *multiNodeClient) StickyContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quorumNodeKey{}, uint32(0))
+}
+
+func (m *multiNodeClient) StickyNodeID(ctx context.Context) uint32 {
+	id, _ := ctx.Value(quorumNodeKey{}).(uint32)
+	return id
+}
+
+func (m *multiNodeClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	next := m.StickyNodeID(ctx) + 1
+	if int(next) >= len(m.answers) {
+		return nil, errors.New("no more active nodes left")
+	}
+	return context.WithValue(ctx, quorumNodeKey{}, next), nil
+}
+
+func (m *multiNodeClient) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return m.StickyContextNextNode(ctx)
+}
+
+func (m *multiNodeClient) NodesHealth() []liteclient.NodeHealth {
+	var list []liteclient.NodeHealth
+	for id := range m.answers {
+		list = append(list, liteclient.NodeHealth{ID: uint32(id), OutOfSync: m.outOfSync[uint32(id)]})
+	}
+	return list
+}
+
+func (m *multiNodeClient) StickyContextWithNodeIDStrict(ctx context.Context, nodeId uint32) context.Context {
+	return context.WithValue(context.WithValue(ctx, quorumNodeKey{}, nodeId), quorumStrictKey{}, true)
+}
+
+func TestAPIClient_WithQuorum(t *testing.T) {
+	client := &multiNodeClient{answers: []uint32{100, 0, 100, 99}}
+	api := NewAPIClient(client, ProofCheckPolicyUnsafe)
+
+	info, err := api.WithQuorum(4, 2).GetMasterchainInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.SeqNo != 100 {
+		t.Fatal("incorrect agreed answer", info.SeqNo)
+	}
+
+	_, err = api.WithQuorum(4, 3).GetMasterchainInfo(context.Background())
+	if !errors.Is(err, ErrQuorumNotReached) {
+		t.Fatal("quorum should not be reached, got", err)
+	}
+
+	var qErr *QuorumError
+	if !errors.As(err, &qErr) {
+		t.Fatal("error should be typed")
+	}
+	if qErr.Agreed != 2 || len(qErr.Answers) != 4 {
+		t.Fatal("incorrect quorum error details", qErr.Agreed, len(qErr.Answers))
+	}
+
+	// only 4 nodes are available
+	_, err = api.WithQuorum(10, 5).GetMasterchainInfo(context.Background())
+	if !errors.Is(err, ErrQuorumNotReached) {
+		t.Fatal("quorum should not be reached, got", err)
+	}
+
+	// lagging node should be skipped, so 3 nodes in sync are enough
+	client = &multiNodeClient{answers: []uint32{100, 100, 99, 100}, outOfSync: map[uint32]bool{2: true}}
+	info, err = NewAPIClient(client, ProofCheckPolicyUnsafe).WithTimeout(time.Second).(APIClientExtended).WithQuorum(3, 3).GetMasterchainInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.SeqNo != 100 {
+		t.Fatal("incorrect agreed answer", info.SeqNo)
+	}
+	for _, id := range client.asked {
+		if id == 2 {
+			t.Fatal("out of sync node should not be asked")
+		}
+	}
+
+	// node which cannot be reached should not be replaced by another one, which already answered
+	for _, reroute := range []bool{false, true} {
+		client = &multiNodeClient{answers: []uint32{100, 100, 100, 100}, sendFails: map[uint32]bool{2: true}, rerouteStrict: reroute}
+		_, err = NewAPIClient(client, ProofCheckPolicyUnsafe).WithQuorum(3, 3).GetMasterchainInfo(context.Background())
+		var qErr *QuorumError
+		if !errors.As(err, &qErr) || qErr.Agreed != 2 {
+			t.Fatal("one node should not be counted twice, got", err)
+		}
+	}
+}
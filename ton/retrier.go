@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/observer"
 	"github.com/xssnick/tonutils-go/tl"
 )
 
@@ -29,18 +30,19 @@ func (w *retryClient) QueryLiteserver(ctx context.Context, payload tl.Serializab
 				return err
 			}
 
-			err := ctx.Err()
-			if err != nil {
-				return err
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
 			}
 
 			// try next node
-			ctx, err = w.original.StickyContextNextNode(ctx)
-			if err != nil {
+			next, nextErr := w.original.StickyContextNextNode(ctx)
+			if nextErr != nil {
 				return fmt.Errorf("timeout error received, but failed to try with next node, "+
-					"looks like all active nodes was already tried, original error: %w", err)
+					"looks like all active nodes was already tried, original error: %w", nextErr)
 			}
+			ctx = next
 
+			w.notifyRetry(ctx, payload, err)
 			continue
 		}
 
@@ -54,6 +56,7 @@ func (w *retryClient) QueryLiteserver(ctx context.Context, payload tl.Serializab
 					// no more nodes left, return as it is
 					return nil
 				}
+				w.notifyRetry(ctx, payload, lsErr)
 				continue
 			}
 		}
@@ -61,6 +64,20 @@ func (w *retryClient) QueryLiteserver(ctx context.Context, payload tl.Serializab
 	}
 }
 
+func (w *retryClient) notifyRetry(ctx context.Context, payload tl.Serializable, reason error) {
+	observer.Notify(ctx, observer.FromContext(ctx), &observer.Event{
+		Component: observer.ComponentAPI,
+		Kind:      observer.EventRetry,
+		Method:    tl.TypeName(payload),
+		Value:     1,
+		Err:       reason,
+	})
+}
+
+func (w *retryClient) unwrap() LiteClient {
+	return w.original
+}
+
 func (w *retryClient) StickyContext(ctx context.Context) context.Context {
 	return w.original.StickyContext(ctx)
 }
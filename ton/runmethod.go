@@ -20,7 +20,9 @@ func init() {
 }
 
 type ExecutionResult struct {
-	result []any
+	result   []any
+	exitCode int32
+	gasUsed  int64
 }
 
 type RunSmcMethod struct {
@@ -45,7 +47,7 @@ type RunMethodResult struct {
 }
 
 func NewExecutionResult(data []any) *ExecutionResult {
-	return &ExecutionResult{data}
+	return &ExecutionResult{result: data}
 }
 
 func (c *APIClient) RunGetMethod(ctx context.Context, blockInfo *BlockIDExt, addr *address.Address, method string, params ...any) (*ExecutionResult, error) {
@@ -60,10 +62,16 @@ func (c *APIClient) RunGetMethod(ctx context.Context, blockInfo *BlockIDExt, add
 		return nil, fmt.Errorf("build stack err: %w", err)
 	}
 
+	verify := c.root().verifyGetMethods
+
 	mode := uint32(1 << 2)
-	if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+	if c.proofCheckPolicy != ProofCheckPolicyUnsafe || verify {
 		mode |= (1 << 1) | (1 << 0)
 	}
+	if verify {
+		// init c7 and libraries are needed to repeat execution locally
+		mode |= (1 << 3) | (1 << 4)
+	}
 
 	var resp tl.Serializable
 	err = c.client.QueryLiteserver(ctx, &RunSmcMethod{
@@ -82,20 +90,22 @@ func (c *APIClient) RunGetMethod(ctx context.Context, blockInfo *BlockIDExt, add
 
 	switch t := resp.(type) {
 	case RunMethodResult:
-		if t.ExitCode != 0 && t.ExitCode != 1 {
+		if !verify && t.ExitCode != 0 && t.ExitCode != 1 {
 			return nil, ContractExecError{
 				t.ExitCode,
 			}
 		}
 
-		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+		var accState *cell.Cell
+		var shardState *tlb.ShardStateUnsplit
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe || verify {
 			if t.StateProof == nil {
 				return nil, fmt.Errorf("liteserver has no state proof for this account in a given block, request newer block or disable proof checks")
 			}
 
 			var shardProof []*cell.Cell
 			var shardHash []byte
-			if c.proofCheckPolicy != ProofCheckPolicyUnsafe && addr.Workchain() != address.MasterchainID {
+			if addr.Workchain() != address.MasterchainID {
 				if len(t.ShardProof) == 0 {
 					return nil, fmt.Errorf("liteserver has no proof for this account in a given block, request newer block or disable proof checks")
 				}
@@ -109,44 +119,60 @@ func (c *APIClient) RunGetMethod(ctx context.Context, blockInfo *BlockIDExt, add
 				shardHash = t.ShardBlock.RootHash
 			}
 
-			shardAcc, _, err := CheckAccountStateProof(addr, blockInfo, t.Proof, shardProof, shardHash, c.proofCheckPolicy == ProofCheckPolicyUnsafe)
+			var shardAcc *tlb.ShardAccount
+			shardAcc, _, shardState, err = checkAccountStateProof(addr, blockInfo, t.Proof, shardProof, shardHash, c.proofCheckPolicy == ProofCheckPolicyUnsafe && !verify)
 			if err != nil {
 				return nil, fmt.Errorf("failed to check acc state proof: %w", err)
 			}
 
-			_, err = cell.UnwrapProof(t.StateProof, shardAcc.Account.Hash(0))
+			accState, err = cell.UnwrapProof(t.StateProof, shardAcc.Account.Hash(0))
 			if err != nil {
 				return nil, fmt.Errorf("failed to match state proof to state hash: %w", err)
 			}
 		}
 
-		if t.Result == nil {
-			return NewExecutionResult([]any{}), nil
-		}
+		result := []any{}
+		if t.Result != nil {
+			var resStack tlb.Stack
+			err = resStack.LoadFromCell(t.Result.BeginParse())
+			if err != nil {
+				return nil, err
+			}
 
-		var resStack tlb.Stack
-		err = resStack.LoadFromCell(t.Result.BeginParse())
-		if err != nil {
-			return nil, err
+			for resStack.Depth() > 0 {
+				v, err := resStack.Pop()
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, v)
+			}
 		}
 
-		var result []any
-
-		for resStack.Depth() > 0 {
-			v, err := resStack.Pop()
+		if verify {
+			configHash, err := c.getConfigRootHash(ctx, blockInfo)
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("failed to get config proof: %w", err)
 			}
-			result = append(result, v)
+			return verifyGetMethodResult(accState, addr, shardState.GenUTime, configHash, &t, method, params, result)
 		}
 
-		return NewExecutionResult(result), nil
+		return &ExecutionResult{result: result, exitCode: t.ExitCode}, nil
 	case LSError:
 		return nil, t
 	}
 	return nil, errUnexpectedResponse(resp)
 }
 
+// ExitCode - exit code of the contract execution, 0 or 1
+func (r ExecutionResult) ExitCode() int32 {
+	return r.exitCode
+}
+
+// GasUsed - gas consumed by execution, known only for local execution
+func (r ExecutionResult) GasUsed() int64 {
+	return r.gasUsed
+}
+
 func (r ExecutionResult) AsTuple() []any {
 	return r.result
 }
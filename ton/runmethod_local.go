@@ -0,0 +1,98 @@
+package ton
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"github.com/xssnick/tonutils-go/tvm/vm"
+)
+
+var ErrNoCode = errors.New("account has no code")
+
+// LocalRunOptions - optional environment for local get method execution
+type LocalRunOptions struct {
+	// Now - unix time available to contract, current time is used when zero
+	Now uint32
+	// Config - root cell of blockchain config dictionary, can be nil
+	Config *cell.Cell
+	// Libraries - library cells which may be used by contract code
+	Libraries []*cell.Cell
+	// GasLimit - default liteserver limit is used when zero
+	GasLimit int64
+	// C7 - custom c7 value, when set, other environment values are ignored
+	C7 []any
+}
+
+// RunGetMethodLocal - executes get method of account locally, without requests to liteserver.
+// Account can be fetched once using GetAccount and then used for any number of calls.
+func RunGetMethodLocal(acc *tlb.Account, opts *LocalRunOptions, method string, params ...any) (*ExecutionResult, error) {
+	if acc == nil || !acc.IsActive || acc.State == nil || acc.State.Status != tlb.AccountStatusActive {
+		return nil, ContractExecError{ErrCodeContractNotInitialized}
+	}
+	if acc.Code == nil {
+		return nil, ErrNoCode
+	}
+
+	if opts == nil {
+		opts = &LocalRunOptions{}
+	}
+
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		gasLimit = vm.DefaultGetMethodGasLimit
+	}
+
+	c7 := opts.C7
+	if c7 == nil {
+		now := opts.Now
+		if now == 0 {
+			now = uint32(time.Now().Unix())
+		}
+
+		var err error
+		c7, err = (&vm.ContractInfo{
+			Now:      now,
+			LT:       acc.LastTxLT,
+			Balance:  acc.State.Balance.Nano(),
+			Address:  acc.State.Address,
+			Config:   opts.Config,
+			Code:     acc.Code,
+			RandSeed: make([]byte, 32),
+		}).ToC7()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build c7: %w", err)
+		}
+	}
+
+	t := vm.NewTVM()
+	t.AddLibraries(opts.Libraries...)
+
+	res, err := t.RunGetMethod(acc.Code, acc.Data, c7, vm.GasWithLimit(gasLimit), method, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute get method: %w", err)
+	}
+
+	if res.ExitCode != 0 && res.ExitCode != 1 {
+		return nil, ContractExecError{
+			res.ExitCode,
+		}
+	}
+
+	var result []any
+	for res.Stack.Depth() > 0 {
+		v, err := res.Stack.Pop()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+
+	return &ExecutionResult{
+		result:   result,
+		exitCode: res.ExitCode,
+		gasUsed:  res.GasUsed,
+	}, nil
+}
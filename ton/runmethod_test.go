@@ -1,8 +1,13 @@
 package ton
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
+	"github.com/xssnick/tonutils-go/tvm/vm"
 	"math/big"
 	"reflect"
 	"testing"
@@ -101,3 +106,173 @@ func TestExecutionResult(t *testing.T) {
 		t.Fatal("as tuple wrong")
 	}
 }
+
+func TestRunGetMethodLocal(t *testing.T) {
+	boc, _ := hex.DecodeString("B5EE9C724101010100710000DEFF0020DD2082014C97BA218201339CBAB19F71B0ED44D0D31FD31F31D70BFFE304E0A4F2608308D71820D31FD31FD31FF82313BBF263ED44D0D31FD31FD3FFD15132BAF2A15144BAF2A204F901541055F910F2A3F8009320D74A96D307D402FB00E8D101A4C8CB1FCB1FCBFFC9ED5410BD6DAD")
+	code, err := cell.FromBOC(boc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acc := &tlb.Account{
+		IsActive: true,
+		State: &tlb.AccountState{
+			IsValid: true,
+			Address: address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"),
+			AccountStorage: tlb.AccountStorage{
+				Status:  tlb.AccountStatusActive,
+				Balance: tlb.MustFromTON("1"),
+			},
+		},
+		Code: code,
+		Data: cell.BeginCell().MustStoreUInt(5, 32).MustStoreUInt(698983191, 32).MustStoreUInt(0, 256).EndCell(),
+	}
+
+	res, err := RunGetMethodLocal(acc, nil, "seqno")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.MustInt(0).Uint64() != 5 {
+		t.Fatal("incorrect seqno")
+	}
+	if res.ExitCode() != 0 || res.GasUsed() == 0 {
+		t.Fatal("incorrect execution info", res.ExitCode(), res.GasUsed())
+	}
+
+	_, err = RunGetMethodLocal(acc, nil, "unknown")
+	if err == nil || err.(ContractExecError).Code != 32 {
+		t.Fatal("should fail with exit code 32", err)
+	}
+
+	acc.State.Status = tlb.AccountStatusUninit
+	_, err = RunGetMethodLocal(acc, nil, "seqno")
+	if err == nil || err.(ContractExecError).Code != ErrCodeContractNotInitialized {
+		t.Fatal("should fail for not active contract", err)
+	}
+}
+
+func TestVerifyGetMethodResult(t *testing.T) {
+	boc, _ := hex.DecodeString("B5EE9C724101010100710000DEFF0020DD2082014C97BA218201339CBAB19F71B0ED44D0D31FD31F31D70BFFE304E0A4F2608308D71820D31FD31FD31FF82313BBF263ED44D0D31FD31FD3FFD15132BAF2A15144BAF2A204F901541055F910F2A3F8009320D74A96D307D402FB00E8D101A4C8CB1FCB1FCBFFC9ED5410BD6DAD")
+	code, err := cell.FromBOC(boc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	st := &tlb.AccountState{
+		IsValid: true,
+		Address: addr,
+		StorageInfo: tlb.StorageInfo{
+			StorageUsed: tlb.StorageUsed{
+				CellsUsed:       big.NewInt(2),
+				BitsUsed:        big.NewInt(1000),
+				PublicCellsUsed: big.NewInt(0),
+			},
+		},
+		AccountStorage: tlb.AccountStorage{
+			Status:  tlb.AccountStatusActive,
+			Balance: tlb.MustFromTON("1"),
+			StateInit: &tlb.StateInit{
+				Code: code,
+				Data: cell.BeginCell().MustStoreUInt(5, 32).MustStoreUInt(698983191, 32).MustStoreUInt(0, 256).EndCell(),
+			},
+		},
+	}
+	stCell, err := st.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := cell.NewDict(32)
+	if err = config.SetIntKey(big.NewInt(0), cell.BeginCell().MustStoreRef(cell.BeginCell().MustStoreSlice(make([]byte, 32), 256).EndCell()).EndCell()); err != nil {
+		t.Fatal(err)
+	}
+	configHash := config.AsCell().Hash()
+
+	initC7 := func(balance *big.Int) *cell.Cell {
+		c7, err := (&vm.ContractInfo{
+			Now:      1700000000,
+			Balance:  balance,
+			Address:  addr,
+			Config:   config.AsCell(),
+			Code:     code,
+			RandSeed: make([]byte, 32),
+		}).ToC7()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b := cell.BeginCell()
+		if err = tlb.SerializeStackValue(b, c7); err != nil {
+			t.Fatal(err)
+		}
+		return b.EndCell()
+	}
+
+	res := &RunMethodResult{
+		InitC7:   initC7(tlb.MustFromTON("1").Nano()),
+		ExitCode: 0,
+	}
+
+	exec, err := verifyGetMethodResult(stCell, addr, 1700000000, configHash, res, "seqno", nil, []any{big.NewInt(5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exec.MustInt(0).Uint64() != 5 || exec.GasUsed() == 0 {
+		t.Fatal("incorrect result")
+	}
+
+	_, err = verifyGetMethodResult(stCell, addr, 1700000000, configHash, res, "seqno", nil, []any{big.NewInt(6)})
+	if !errors.Is(err, ErrGetMethodResultMismatch) {
+		t.Fatal("result mismatch should be detected", err)
+	}
+
+	res.ExitCode = 32
+	_, err = verifyGetMethodResult(stCell, addr, 1700000000, configHash, res, "unknown", nil, nil)
+	if err == nil || err.(ContractExecError).Code != 32 {
+		t.Fatal("should fail with exit code 32", err)
+	}
+
+	res.ExitCode = 0
+	_, err = verifyGetMethodResult(stCell, addr, 1700000000, configHash, res, "unknown", nil, nil)
+	if !errors.Is(err, ErrGetMethodResultMismatch) {
+		t.Fatal("exit code mismatch should be detected", err)
+	}
+
+	res.InitC7 = initC7(tlb.MustFromTON("2").Nano())
+	if _, err = verifyGetMethodResult(stCell, addr, 1700000000, configHash, res, "seqno", nil, []any{big.NewInt(5)}); err == nil {
+		t.Fatal("c7 with wrong balance should not be accepted")
+	}
+
+	res.InitC7 = initC7(tlb.MustFromTON("1").Nano())
+	if _, err = verifyGetMethodResult(stCell, addr, 1700000001, configHash, res, "seqno", nil, []any{big.NewInt(5)}); err == nil {
+		t.Fatal("c7 with wrong unixtime should not be accepted")
+	}
+	if _, err = verifyGetMethodResult(stCell, addr, 1700000000, make([]byte, 32), res, "seqno", nil, []any{big.NewInt(5)}); err == nil {
+		t.Fatal("c7 with wrong config should not be accepted")
+	}
+}
+
+func TestCheckConfigRootProof(t *testing.T) {
+	master := newTestBlock(t, -1, 10, testBlockParts{})
+
+	sk := cell.CreateProofSkeleton()
+	sk.ProofRef(3)
+	hash, err := checkConfigRootProof(master.id, master.stateProof(t, sk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hash, testDummyCell(2).Hash()) {
+		t.Fatal("incorrect config hash")
+	}
+
+	other := newTestBlock(t, -1, 11, testBlockParts{})
+	if _, err = checkConfigRootProof(master.id, other.stateProof(t, sk)); err == nil {
+		t.Fatal("proof of another block should be rejected")
+	}
+
+	shard := newTestBlock(t, 0, 10, testBlockParts{})
+	if _, err = checkConfigRootProof(shard.id, shard.stateProof(t, cell.CreateProofSkeleton())); err == nil {
+		t.Fatal("shard block should be rejected")
+	}
+}
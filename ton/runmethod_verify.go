@@ -0,0 +1,256 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"github.com/xssnick/tonutils-go/tvm/vm"
+)
+
+var ErrGetMethodResultMismatch = errors.New("get method result not match local execution")
+
+// SetVerifiedGetMethods - when enabled, RunGetMethod requests proofs, init c7 and libraries from liteserver,
+// checks account state against masterchain block and re-executes method locally, to compare results.
+// Balance, address, code, unixtime and config of init c7 are checked against proofs, config proof is requested separately.
+// Block and transaction lt, random seed and other fields are not proven, and are trusted to liteserver,
+// so contracts which depend on them can return different results on another node.
+// Applied to the root client, so all wrappers (WithRetry, WithTimeout, etc.) share it.
+func (c *APIClient) SetVerifiedGetMethods(enabled bool) {
+	c.root().verifyGetMethods = enabled
+}
+
+func verifyGetMethodResult(accState *cell.Cell, addr *address.Address, utime uint32, configHash []byte, res *RunMethodResult, method string, params []any, result []any) (*ExecutionResult, error) {
+	if res.InitC7 == nil {
+		return nil, fmt.Errorf("liteserver has not returned init c7")
+	}
+
+	var st tlb.AccountState
+	if err := st.LoadFromCell(accState.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to load account state: %w", err)
+	}
+
+	if !st.IsValid || st.Status != tlb.AccountStatusActive || st.StateInit == nil {
+		if res.ExitCode == ErrCodeContractNotInitialized {
+			return nil, ContractExecError{res.ExitCode}
+		}
+		return nil, fmt.Errorf("%w: account is not active, but exit code is %d", ErrGetMethodResultMismatch, res.ExitCode)
+	}
+
+	if st.StateInit.Code == nil {
+		return nil, ErrNoCode
+	}
+
+	c7v, err := tlb.ParseStackValue(res.InitC7.BeginParse())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse init c7: %w", err)
+	}
+
+	c7, ok := c7v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("init c7 is not a tuple")
+	}
+
+	if err = validateInitC7(c7, &st, utime, configHash); err != nil {
+		return nil, fmt.Errorf("invalid init c7: %w", err)
+	}
+
+	var libs []*cell.Cell
+	if res.LibExtras != nil {
+		libs, err = loadLibExtras(res.LibExtras)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load libraries: %w", err)
+		}
+	}
+
+	t := vm.NewTVM()
+	t.AddLibraries(libs...)
+
+	local, err := t.RunGetMethod(st.StateInit.Code, st.StateInit.Data, c7, vm.GasWithLimit(vm.DefaultGetMethodGasLimit), method, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute get method locally: %w", err)
+	}
+
+	if local.ExitCode != res.ExitCode {
+		return nil, fmt.Errorf("%w: exit code %d, expected %d", ErrGetMethodResultMismatch, res.ExitCode, local.ExitCode)
+	}
+
+	if res.ExitCode != 0 && res.ExitCode != 1 {
+		return nil, ContractExecError{
+			res.ExitCode,
+		}
+	}
+
+	var localResult []any
+	for local.Stack.Depth() > 0 {
+		v, err := local.Stack.Pop()
+		if err != nil {
+			return nil, err
+		}
+		localResult = append(localResult, v)
+	}
+
+	if err = compareStackValues(result, localResult); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGetMethodResultMismatch, err)
+	}
+
+	return &ExecutionResult{
+		result:   result,
+		exitCode: res.ExitCode,
+		gasUsed:  local.GasUsed,
+	}, nil
+}
+
+// validateInitC7 - checks that SmartContractInfo in c7 matches the proven account state, its gen utime and config
+func validateInitC7(c7 []any, st *tlb.AccountState, utime uint32, configHash []byte) error {
+	if len(c7) == 0 {
+		return fmt.Errorf("empty c7")
+	}
+
+	info, ok := c7[0].([]any)
+	if !ok || len(info) < 11 {
+		return fmt.Errorf("incorrect smart contract info")
+	}
+
+	magic, ok := info[0].(*big.Int)
+	if !ok || magic.Cmp(big.NewInt(0x076ef1ea)) != 0 {
+		return fmt.Errorf("incorrect smart contract info magic")
+	}
+
+	if now, ok := info[3].(*big.Int); !ok || !now.IsUint64() || now.Uint64() != uint64(utime) {
+		return fmt.Errorf("unixtime not match state gen utime")
+	}
+
+	balance, ok := info[7].([]any)
+	if !ok || len(balance) == 0 {
+		return fmt.Errorf("incorrect balance")
+	}
+	if b, ok := balance[0].(*big.Int); !ok || b.Cmp(st.Balance.Nano()) != 0 {
+		return fmt.Errorf("balance not match account state")
+	}
+
+	addrSlice, ok := info[8].(*cell.Slice)
+	if !ok {
+		return fmt.Errorf("incorrect address")
+	}
+	addr, err := addrSlice.Copy().LoadAddr()
+	if err != nil {
+		return fmt.Errorf("failed to load address: %w", err)
+	}
+	if !addr.Equals(st.Address) {
+		return fmt.Errorf("address not match account state")
+	}
+
+	if cfg, ok := info[9].(*cell.Cell); !ok || !bytes.Equal(cfg.Hash(), configHash) {
+		return fmt.Errorf("config not match proven config")
+	}
+
+	switch code := info[10].(type) {
+	case nil:
+	case *cell.Cell:
+		if st.StateInit == nil || st.StateInit.Code == nil || !bytes.Equal(code.Hash(), st.StateInit.Code.Hash()) {
+			return fmt.Errorf("code not match account state")
+		}
+	default:
+		return fmt.Errorf("incorrect code")
+	}
+
+	return nil
+}
+
+// getConfigRootHash - returns hash of the config dictionary of the masterchain block, checked by proof.
+// Only one small param is requested, dictionary is pruned in proof, but its root hash is kept.
+func (c *APIClient) getConfigRootHash(ctx context.Context, master *BlockIDExt) ([]byte, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetConfigParams{
+		Mode:    0,
+		BlockID: master,
+		Params:  []int32{0},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case ConfigAll:
+		return checkConfigRootProof(master, []*cell.Cell{t.StateProof, t.ConfigProof})
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// checkConfigRootProof - checks masterchain state proof and returns hash of its config dictionary
+func checkConfigRootProof(master *BlockIDExt, proof []*cell.Cell) ([]byte, error) {
+	state, err := CheckBlockShardStateProof(proof, master.RootHash)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect proof: %w", err)
+	}
+
+	if state.McStateExtra == nil {
+		return nil, fmt.Errorf("not a masterchain block")
+	}
+
+	s := state.McStateExtra.BeginParse()
+	if magic, err := s.LoadUInt(16); err != nil || magic != 0xcc26 {
+		return nil, fmt.Errorf("incorrect masterchain state extra")
+	}
+	if _, err = s.LoadMaybeRef(); err != nil {
+		return nil, fmt.Errorf("failed to load shard hashes: %w", err)
+	}
+	if _, err = s.LoadSlice(256); err != nil {
+		return nil, fmt.Errorf("failed to load config address: %w", err)
+	}
+
+	root, err := s.LoadRefCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return root.Hash(0), nil
+}
+
+// loadLibExtras - loads library cells from dictionary returned by liteserver
+func loadLibExtras(root *cell.Cell) ([]*cell.Cell, error) {
+	all, err := root.AsDict(256).LoadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	libs := make([]*cell.Cell, 0, len(all))
+	for _, kv := range all {
+		lib, err := kv.Value.LoadRefCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load library cell: %w", err)
+		}
+		libs = append(libs, lib)
+	}
+	return libs, nil
+}
+
+// compareStackValues - compares stack values by hashes of their serialized form
+func compareStackValues(a, b []any) error {
+	if len(a) != len(b) {
+		return fmt.Errorf("stack depth %d, expected %d", len(a), len(b))
+	}
+
+	for i := range a {
+		ab, bb := cell.BeginCell(), cell.BeginCell()
+		if err := tlb.SerializeStackValue(ab, a[i]); err != nil {
+			return fmt.Errorf("failed to serialize value %d: %w", i, err)
+		}
+		if err := tlb.SerializeStackValue(bb, b[i]); err != nil {
+			return fmt.Errorf("failed to serialize value %d: %w", i, err)
+		}
+
+		if !bytes.Equal(ab.EndCell().Hash(), bb.EndCell().Hash()) {
+			return fmt.Errorf("value %d is different", i)
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/tl"
 	"github.com/xssnick/tonutils-go/tlb"
 )
@@ -31,6 +32,9 @@ func (c *APIClient) SendExternalMessage(ctx context.Context, msg *tlb.ExternalMe
 		return fmt.Errorf("failed to serialize external message, err: %w", err)
 	}
 
+	// messages should not wait behind background requests, if priority is not set explicitly
+	ctx = liteclient.WithDefaultRequestPriority(ctx, liteclient.PriorityHigh)
+
 	var resp tl.Serializable
 	err = c.client.QueryLiteserver(ctx, SendMessage{Body: req.ToBOCWithFlags(false)}, &resp)
 	if err != nil {
@@ -0,0 +1,220 @@
+package ton
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+// BlockEvent - transactions of the new block, sorted by logical time
+type BlockEvent struct {
+	// MasterBlock - masterchain block which has committed the block
+	MasterBlock  *BlockIDExt
+	Block        *BlockIDExt
+	Transactions []*tlb.Transaction
+}
+
+// BlockMessage - message of the block transaction
+type BlockMessage struct {
+	Transaction *tlb.Transaction
+	Message     *tlb.Message
+	// Outgoing - message is created by the transaction, otherwise it is the inbound one
+	Outgoing bool
+}
+
+// Messages - returns messages of all block transactions in order,
+// inbound message of each transaction goes first, then its outgoing messages
+func (e *BlockEvent) Messages() ([]BlockMessage, error) {
+	var list []BlockMessage
+	for _, tx := range e.Transactions {
+		if tx.IO.In != nil {
+			list = append(list, BlockMessage{Transaction: tx, Message: tx.IO.In})
+		}
+
+		if tx.IO.Out == nil {
+			continue
+		}
+
+		out, err := tx.IO.Out.ToSlice()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load out messages of tx %x: %w", tx.Hash, err)
+		}
+
+		for i := range out {
+			list = append(list, BlockMessage{Transaction: tx, Message: &out[i], Outgoing: true})
+		}
+	}
+	return list, nil
+}
+
+// SubscribeOnBlocks - follows new masterchain blocks and sends events with transactions of every new block
+// of the network to the channel. For each masterchain block, its new shard blocks are emitted first
+// (including ones not referenced directly, parents go before children), and the masterchain block itself is the last.
+// Processing starts after lastProcessedMaster, when it is nil, starts after the current masterchain block.
+// Channel is closed when workerCtx is done.
+func (c *APIClient) SubscribeOnBlocks(workerCtx context.Context, lastProcessedMaster *BlockIDExt, channel chan<- *BlockEvent) {
+	defer func() {
+		close(channel)
+	}()
+
+	ctx := c.client.StickyContext(workerCtx)
+	master := lastProcessedMaster
+
+	// storage for last seen shard seqno
+	var shardLastSeqno map[string]uint32
+
+	nextNode := func() {
+		if nCtx, err := c.client.StickyContextNextNode(ctx); err == nil {
+			ctx = nCtx
+		}
+	}
+
+	wait := 0 * time.Second
+	for {
+		select {
+		case <-workerCtx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = 3 * time.Second
+
+		if master == nil {
+			reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			m, err := c.CurrentMasterchainInfo(reqCtx)
+			cancel()
+			if err != nil {
+				continue
+			}
+			master = m
+		}
+
+		if shardLastSeqno == nil {
+			reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			shards, err := c.GetBlockShardsInfo(reqCtx, master)
+			cancel()
+			if err != nil {
+				nextNode()
+				continue
+			}
+
+			shardLastSeqno = map[string]uint32{}
+			for _, shard := range shards {
+				shardLastSeqno[getShardKey(shard)] = shard.SeqNo
+			}
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		next, err := c.WaitForBlock(master.SeqNo+1).LookupBlock(reqCtx, master.Workchain, master.Shard, master.SeqNo+1)
+		cancel()
+		if err != nil {
+			nextNode()
+			continue
+		}
+
+		reqCtx, cancel = context.WithTimeout(ctx, 60*time.Second)
+		events, lastSeqno, err := c.collectBlockEvents(reqCtx, next, shardLastSeqno)
+		cancel()
+		if err != nil {
+			nextNode()
+			continue
+		}
+
+		for _, e := range events {
+			select {
+			case <-workerCtx.Done():
+				return
+			case channel <- e:
+			}
+		}
+
+		shardLastSeqno = lastSeqno
+		master = next
+		wait = 0 * time.Second
+	}
+}
+
+// collectBlockEvents - loads master block and its shard blocks which were not seen yet,
+// returns events in the order of processing and updated last seen shard seqno
+func (c *APIClient) collectBlockEvents(ctx context.Context, master *BlockIDExt, shardLastSeqno map[string]uint32) ([]*BlockEvent, map[string]uint32, error) {
+	shards, err := c.GetBlockShardsInfo(ctx, master)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get shards: %w", err)
+	}
+
+	var events []*BlockEvent
+	addEvent := func(block *BlockIDExt, data *tlb.Block) error {
+		txs, err := data.LoadTransactions()
+		if err != nil {
+			return fmt.Errorf("failed to load block %d:%x:%d transactions: %w", block.Workchain, uint64(block.Shard), block.SeqNo, err)
+		}
+
+		events = append(events, &BlockEvent{
+			MasterBlock:  master,
+			Block:        block,
+			Transactions: txs,
+		})
+		return nil
+	}
+
+	// shards in master block may have holes, e.g. shard seqno 2756461, then 2756463, and no 2756462 in master chain,
+	// also after split or merge, parents are not referenced, so we go back by parents till last seen
+	visited := map[string]bool{}
+	var collect func(shard *BlockIDExt) error
+	collect = func(shard *BlockIDExt) error {
+		if no, ok := shardLastSeqno[getShardKey(shard)]; ok && shard.SeqNo <= no {
+			return nil
+		}
+
+		key := fmt.Sprintf("%s|%d", getShardKey(shard), shard.SeqNo)
+		if visited[key] {
+			return nil
+		}
+		visited[key] = true
+
+		data, err := c.GetBlockData(ctx, shard)
+		if err != nil {
+			return fmt.Errorf("failed to get block %d:%x:%d data: %w", shard.Workchain, uint64(shard.Shard), shard.SeqNo, err)
+		}
+
+		parents, err := data.BlockInfo.GetParentBlocks()
+		if err != nil {
+			return fmt.Errorf("failed to get parents of block %d:%x:%d: %w", shard.Workchain, uint64(shard.Shard), shard.SeqNo, err)
+		}
+
+		for _, parent := range parents {
+			if err = collect(parent); err != nil {
+				return err
+			}
+		}
+
+		return addEvent(shard, data)
+	}
+
+	lastSeqno := make(map[string]uint32, len(shards))
+	for k, v := range shardLastSeqno {
+		lastSeqno[k] = v
+	}
+
+	for _, shard := range shards {
+		if err = collect(shard); err != nil {
+			return nil, nil, err
+		}
+		lastSeqno[getShardKey(shard)] = shard.SeqNo
+	}
+
+	data, err := c.GetBlockData(ctx, master)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get master block data: %w", err)
+	}
+
+	if err = addEvent(master, data); err != nil {
+		return nil, nil, err
+	}
+	return events, lastSeqno, nil
+}
+
+func getShardKey(shard *BlockIDExt) string {
+	return fmt.Sprintf("%d|%d", shard.Workchain, shard.Shard)
+}
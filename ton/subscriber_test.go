@@ -0,0 +1,164 @@
+package ton
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// chainClient - answers block requests from the synthetic chain, masterchain blocks after the last one are not found
+type chainClient struct {
+	blocks  map[string]*testBlock
+	masters []*testBlock
+}
+
+func (m *chainClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	if raw, ok := payload.(tl.Raw); ok {
+		// request wrapped by WaitForBlock
+		var wait WaitMasterchainSeqno
+		rest, err := tl.Parse(&wait, raw, true)
+		if err != nil {
+			return err
+		}
+
+		var lookup LookupBlock
+		if _, err = tl.Parse(&lookup, rest, true); err != nil {
+			return err
+		}
+		payload = lookup
+	}
+
+	var resp tl.Serializable
+	switch req := payload.(type) {
+	case LookupBlock:
+		resp = LSError{Code: 651, Text: "not found"}
+		for _, b := range m.masters {
+			if b.id.SeqNo == uint32(req.ID.Seqno) {
+				resp = BlockHeader{ID: b.id}
+			}
+		}
+	case GetBlockData:
+		b, ok := m.blocks[string(req.ID.RootHash)]
+		if !ok {
+			return fmt.Errorf("unknown block %d", req.ID.SeqNo)
+		}
+		resp = BlockData{ID: b.id, Payload: b.block.ToBOC()}
+	case GetAllShardsInfo:
+		b, ok := m.blocks[string(req.ID.RootHash)]
+		if !ok {
+			return fmt.Errorf("unknown block %d", req.ID.SeqNo)
+		}
+		resp = AllShardsInfo{ID: b.id, Data: cell.BeginCell().MustStoreDict(b.shardHashes).EndCell()}
+	default:
+		return fmt.Errorf("unexpected request %T", payload)
+	}
+
+	*result.(*tl.Serializable) = resp
+	return nil
+}
+
+func (m *chainClient) StickyContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (m *chainClient) StickyNodeID(ctx context.Context) uint32 {
+	return 0
+}
+
+func (m *chainClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *chainClient) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *chainClient) add(list ...*testBlock) {
+	for _, b := range list {
+		m.blocks[string(b.id.RootHash)] = b
+		if b.id.Workchain == -1 {
+			m.masters = append(m.masters, b)
+		}
+	}
+}
+
+func TestAPIClient_SubscribeOnBlocks(t *testing.T) {
+	const left, right = int64(0x4000000000000000), -0x4000000000000000
+
+	// root shard is split after master 1, and merged back after master 2,
+	// some shard blocks are not referenced by masterchain directly
+	root10 := newTestBlock(t, 0, 10, testBlockParts{})
+	left11 := newTestBlock(t, 0, 11, testBlockParts{shard: left, prev: root10.id, afterSplit: true})
+	left12 := newTestBlock(t, 0, 12, testBlockParts{shard: left, prev: left11.id})
+	right11 := newTestBlock(t, 0, 11, testBlockParts{shard: right, prev: root10.id, afterSplit: true})
+	right12 := newTestBlock(t, 0, 12, testBlockParts{shard: right, prev: right11.id})
+	merged13 := newTestBlock(t, 0, 13, testBlockParts{prev: left12.id, prev2: right12.id})
+
+	master1 := newTestBlock(t, -1, 1, testBlockParts{shards: []*BlockIDExt{root10.id}})
+	master2 := newTestBlock(t, -1, 2, testBlockParts{prev: master1.id, shards: []*BlockIDExt{left12.id, right11.id}})
+	master3 := newTestBlock(t, -1, 3, testBlockParts{prev: master2.id, shards: []*BlockIDExt{merged13.id}})
+
+	client := &chainClient{blocks: map[string]*testBlock{}}
+	client.add(root10, left11, left12, right11, right12, merged13, master1, master2, master3)
+	api := NewAPIClient(client, ProofCheckPolicyUnsafe)
+
+	type event struct {
+		master *testBlock
+		block  *testBlock
+	}
+
+	tests := []struct {
+		name   string
+		from   *testBlock
+		events []event
+	}{
+		{
+			name: "split and merge",
+			from: master1,
+			events: []event{
+				{master2, left11}, {master2, left12}, {master2, right11}, {master2, master2},
+				{master3, right12}, {master3, merged13}, {master3, master3},
+			},
+		},
+		{
+			name:   "resume after processed master",
+			from:   master2,
+			events: []event{{master3, right12}, {master3, merged13}, {master3, master3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ch := make(chan *BlockEvent)
+			go api.SubscribeOnBlocks(ctx, tt.from.id, ch)
+
+			for i, exp := range tt.events {
+				select {
+				case e := <-ch:
+					if !e.Block.Equals(exp.block.id) || !e.MasterBlock.Equals(exp.master.id) {
+						t.Fatalf("incorrect event %d: block %d:%x:%d of master %d", i, e.Block.Workchain, uint64(e.Block.Shard), e.Block.SeqNo, e.MasterBlock.SeqNo)
+					}
+				case <-time.After(3 * time.Second):
+					t.Fatal("no event", i)
+				}
+			}
+
+			select {
+			case e := <-ch:
+				t.Fatalf("unexpected event: block %d:%x:%d", e.Block.Workchain, uint64(e.Block.Shard), e.Block.SeqNo)
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			cancel()
+			for range ch {
+			}
+		})
+	}
+}
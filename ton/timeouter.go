@@ -19,6 +19,10 @@ func (c *timeoutClient) QueryLiteserver(ctx context.Context, payload tl.Serializ
 	return c.original.QueryLiteserver(tCtx, payload, result)
 }
 
+func (c *timeoutClient) unwrap() LiteClient {
+	return c.original
+}
+
 func (c *timeoutClient) StickyContext(ctx context.Context) context.Context {
 	return c.original.StickyContext(ctx)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/xssnick/tonutils-go/tl"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
+	"log"
 	"strings"
 	"time"
 )
@@ -22,6 +23,10 @@ func init() {
 
 var ErrTxWasNotFound = errors.New("requested transaction is not found")
 
+// ErrArchiveNodeNeeded - transactions are older than the oldest block of the node, so search cannot be completed,
+// it wraps ErrTxWasNotFound for compatibility
+var ErrArchiveNodeNeeded = fmt.Errorf("archive node is needed: %w", ErrTxWasNotFound)
+
 type TransactionInfo struct {
 	ID          *BlockIDExt `tl:"struct"`
 	Proof       []byte      `tl:"bytes"`
@@ -175,6 +180,9 @@ func (c *APIClient) GetTransaction(ctx context.Context, block *BlockIDExt, addr
 	return nil, errUnexpectedResponse(resp)
 }
 
+// SubscribeOnTransactions - sends new transactions of the address to channel, starting after lastProcessedLT,
+// channel is closed when workerCtx is done. When liteserver has no history till lastProcessedLT anymore,
+// warning with ErrLTNotInDB is logged and channel is closed too, use TransactionWatcher to handle such errors.
 func (c *APIClient) SubscribeOnTransactions(workerCtx context.Context, addr *address.Address, lastProcessedLT uint64, channel chan<- *tlb.Transaction) {
 	defer func() {
 		close(channel)
@@ -212,66 +220,78 @@ func (c *APIClient) SubscribeOnTransactions(workerCtx context.Context, addr *add
 			continue
 		}
 
-		var transactions []*tlb.Transaction
-		lastHash, lastLT := acc.LastTxHash, acc.LastTxLT
-
-		waitList := 0 * time.Second
-	list:
-		for {
-			select {
-			case <-workerCtx.Done():
+		ctx, cancel = context.WithTimeout(workerCtx, 60*time.Second)
+		transactions, err := listTransactionsSince(ctx, c, addr, acc.LastTxLT, acc.LastTxHash, lastProcessedLT, nil)
+		cancel()
+		if err != nil {
+			if errors.Is(err, ErrLTNotInDB) {
+				log.Println("[WARNING] transactions subscription of", addr.String(), "is stopped:", err.Error())
 				return
-			case <-time.After(waitList):
-			}
-
-			ctx, cancel = context.WithTimeout(workerCtx, 10*time.Second)
-			res, err := c.ListTransactions(ctx, addr, 10, lastLT, lastHash)
-			cancel()
-			if err != nil {
-				if lsErr, ok := err.(LSError); ok && lsErr.Code == -400 {
-					// lt not in db error
-					return
-				}
-				waitList = 3 * time.Second
-				continue
 			}
+			continue
+		}
 
-			if len(res) == 0 {
-				break
-			}
+		if len(transactions) > 0 {
+			lastProcessedLT = transactions[len(transactions)-1].LT // mark last transaction as known to not trigger twice
 
-			// reverse slice
-			for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
-				res[i], res[j] = res[j], res[i]
+			for _, tx := range transactions {
+				channel <- tx
 			}
 
-			for i, tx := range res {
-				if tx.LT <= lastProcessedLT {
-					transactions = append(transactions, res[:i]...)
-					break list
-				}
-			}
+			wait = 0 * time.Second
+		}
+	}
+}
 
-			lastLT, lastHash = res[len(res)-1].PrevTxLT, res[len(res)-1].PrevTxHash
-			transactions = append(transactions, res...)
-			waitList = 0 * time.Second
+// listTransactionsSince - lists account transactions back from the last one (lastLT, lastHash) till the transaction
+// with sinceLT (exclusive), result is ordered from old to new. When sinceHash is passed,
+// it is checked that the transaction with sinceLT is present in the history and has this hash.
+func listTransactionsSince(ctx context.Context, api APIClientWrapped, addr *address.Address, lastLT uint64, lastHash []byte, sinceLT uint64, sinceHash []byte) ([]*tlb.Transaction, error) {
+	if lastLT <= sinceLT {
+		if sinceHash != nil && lastLT == sinceLT && !bytes.Equal(lastHash, sinceHash) {
+			return nil, ErrCursorNotInHistory
 		}
+		// nothing new, or state is older than the cursor
+		return nil, nil
+	}
 
-		if len(transactions) > 0 {
-			lastProcessedLT = transactions[0].LT // mark last transaction as known to not trigger twice
+	var transactions []*tlb.Transaction
 
-			// reverse slice to send in correct time order (from old to new)
-			for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
-				transactions[i], transactions[j] = transactions[j], transactions[i]
+	found := false
+	for !found && lastLT > 0 {
+		res, err := api.ListTransactions(ctx, addr, 10, lastLT, lastHash)
+		if err != nil {
+			if lsErr, ok := err.(LSError); ok && lsErr.Code == -400 {
+				return nil, fmt.Errorf("%w: %s", ErrLTNotInDB, lsErr.Error())
 			}
+			return nil, err
+		}
 
-			for _, tx := range transactions {
-				channel <- tx
+		// from new to old
+		for i := len(res) - 1; i >= 0; i-- {
+			tx := res[i]
+			if tx.LT <= sinceLT {
+				if sinceHash != nil && (tx.LT != sinceLT || !bytes.Equal(tx.Hash, sinceHash)) {
+					return nil, ErrCursorNotInHistory
+				}
+				found = true
+				break
 			}
-
-			wait = 0 * time.Second
+			transactions = append(transactions, tx)
+			lastLT, lastHash = tx.PrevTxLT, tx.PrevTxHash
 		}
 	}
+
+	if !found && sinceHash != nil {
+		// history is ended, but cursor transaction was not met
+		return nil, ErrCursorNotInHistory
+	}
+
+	// reverse slice to get correct time order (from old to new)
+	for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+		transactions[i], transactions[j] = transactions[j], transactions[i]
+	}
+	return transactions, nil
 }
 
 // FindLastTransactionByInMsgHash returns last transaction in account where incoming message (payload) hash equal to msgHash.
@@ -312,7 +332,7 @@ func (c *APIClient) findLastTransactionByHash(ctx context.Context, addr *address
 		txList, err := c.ListTransactions(ctx, addr, 15, lastLt, lastHash)
 		if err != nil {
 			if strings.Contains(err.Error(), "cannot compute block with specified transaction: lt not in db") {
-				return nil, fmt.Errorf("archive node is needed: %w", ErrTxWasNotFound)
+				return nil, ErrArchiveNodeNeeded
 			}
 			return nil, fmt.Errorf("cannot list transactions: %w", err)
 		}
@@ -355,7 +375,7 @@ func (c *APIClient) findLastTransactionByHash(ctx context.Context, addr *address
 		scanned += 15
 
 		if scanned >= limit {
-			return nil, fmt.Errorf("scan limit of %d transactions was reached, %d transactions was checked and hash was not found", limit, scanned)
+			return nil, fmt.Errorf("scan limit of %d transactions was reached, %d transactions was checked and hash was not found: %w", limit, scanned, ErrTxWasNotFound)
 		}
 	}
 }
@@ -0,0 +1,184 @@
+package ton
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+)
+
+// TrustedBlockStore - persistent storage of the latest verified masterchain block,
+// used as a starting point of proof chain verification after restart.
+// Validator sets are proven by config proofs of the key blocks on each step of the chain,
+// so only block id is needed to continue verification.
+type TrustedBlockStore interface {
+	// LoadTrustedBlock - returns stored block, or nil when nothing is stored yet
+	LoadTrustedBlock(ctx context.Context) (*BlockIDExt, error)
+	SaveTrustedBlock(ctx context.Context, block *BlockIDExt) error
+}
+
+// FileTrustedBlockStore - keeps trusted block in json file, in the same format as init block in global config
+type FileTrustedBlockStore struct {
+	path string
+	mx   sync.Mutex
+}
+
+// NewFileTrustedBlockStore - creates store in the given file, it should not be shared between different networks
+func NewFileTrustedBlockStore(path string) *FileTrustedBlockStore {
+	return &FileTrustedBlockStore{path: path}
+}
+
+func (s *FileTrustedBlockStore) LoadTrustedBlock(ctx context.Context) (*BlockIDExt, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var block liteclient.ConfigBlock
+	if err = json.Unmarshal(data, &block); err != nil {
+		return nil, fmt.Errorf("failed to parse trusted block: %w", err)
+	}
+
+	if len(block.RootHash) != 32 || len(block.FileHash) != 32 {
+		return nil, fmt.Errorf("incorrect trusted block hashes")
+	}
+
+	b := BlockIDExt(block)
+	return &b, nil
+}
+
+func (s *FileTrustedBlockStore) SaveTrustedBlock(ctx context.Context, block *BlockIDExt) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	data, err := json.Marshal(liteclient.ConfigBlock(*block))
+	if err != nil {
+		return fmt.Errorf("failed to serialize trusted block: %w", err)
+	}
+
+	// write to temp file and rename, to not corrupt stored block in case of crash
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	return nil
+}
+
+// _TrustedBlockSaveInterval - min interval between trusted block saves, any verified block is a valid
+// starting point, so it is not needed to persist each one, only the chain from it is verified again after restart
+const _TrustedBlockSaveInterval = time.Minute
+
+// SetTrustedBlockStore - loads trusted block from the store, if it is newer than the current one,
+// and saves newer blocks verified by proof chain to the store, in background and at most once per minute.
+// Useful with ProofCheckPolicySecure to not verify the whole chain from init block on each start.
+// Short-lived tools should call FlushTrustedBlock before exit, otherwise the latest block may be not saved.
+func (c *APIClient) SetTrustedBlockStore(ctx context.Context, store TrustedBlockStore) error {
+	block, err := store.LoadTrustedBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load trusted block: %w", err)
+	}
+
+	root := c.root()
+	root.trustedLock.Lock()
+	defer root.trustedLock.Unlock()
+
+	root.trustedStore = store
+	if block != nil {
+		root.trustedSaveMx.Lock()
+		root.trustedSavedSeqno = block.SeqNo
+		root.trustedSaveMx.Unlock()
+
+		if root.trustedBlock == nil || block.SeqNo > root.trustedBlock.SeqNo {
+			root.trustedBlock = block.Copy()
+		}
+	}
+	return nil
+}
+
+// updateTrustedBlock - sets new trusted block and schedules its saving, trustedLock should be locked by caller
+func (c *APIClient) updateTrustedBlock(block *BlockIDExt) {
+	root := c.root()
+	root.trustedBlock = block.Copy()
+
+	if root.trustedStore == nil || time.Since(root.trustedSavedAt) < _TrustedBlockSaveInterval {
+		return
+	}
+	root.trustedSavedAt = time.Now()
+
+	// store can be slow, so it is called outside of the lock to not block requests
+	go func(store TrustedBlockStore, block *BlockIDExt) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := root.saveTrustedBlock(ctx, store, block); err != nil {
+			log.Println("[WARNING] failed to save trusted block:", err.Error())
+		}
+	}(root.trustedStore, root.trustedBlock.Copy())
+}
+
+// FlushTrustedBlock - saves the current trusted block to the store, if it was not saved yet,
+// waiting for the background save in progress. Saves are throttled, so it should be called before exit.
+func (c *APIClient) FlushTrustedBlock(ctx context.Context) error {
+	root := c.root()
+	root.trustedLock.RLock()
+	store, block := root.trustedStore, root.trustedBlock
+	if block != nil {
+		block = block.Copy()
+	}
+	root.trustedLock.RUnlock()
+
+	if store == nil || block == nil {
+		return nil
+	}
+
+	if err := root.saveTrustedBlock(ctx, store, block); err != nil {
+		return fmt.Errorf("failed to save trusted block: %w", err)
+	}
+	return nil
+}
+
+func (c *APIClient) saveTrustedBlock(ctx context.Context, store TrustedBlockStore, block *BlockIDExt) error {
+	c.trustedSaveMx.Lock()
+	defer c.trustedSaveMx.Unlock()
+
+	// previous save could take longer than interval, so newer block may be already saved
+	if block.SeqNo <= c.trustedSavedSeqno {
+		return nil
+	}
+
+	if err := store.SaveTrustedBlock(ctx, block); err != nil {
+		return err
+	}
+	c.trustedSavedSeqno = block.SeqNo
+	return nil
+}
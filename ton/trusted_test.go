@@ -0,0 +1,98 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileTrustedBlockStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trusted.json")
+	store := NewFileTrustedBlockStore(path)
+
+	block, err := store.LoadTrustedBlock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block != nil {
+		t.Fatal("should be no block")
+	}
+
+	saved := &BlockIDExt{Workchain: -1, Shard: -0x8000000000000000, SeqNo: 777, RootHash: bytes.Repeat([]byte{1}, 32), FileHash: bytes.Repeat([]byte{2}, 32)}
+	if err = store.SaveTrustedBlock(context.Background(), saved); err != nil {
+		t.Fatal(err)
+	}
+
+	block, err = store.LoadTrustedBlock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !block.Equals(saved) {
+		t.Fatal("incorrect loaded block")
+	}
+
+	api := NewAPIClient(nil, ProofCheckPolicySecure)
+	api.SetTrustedBlock(&BlockIDExt{Workchain: -1, Shard: -0x8000000000000000, SeqNo: 100, RootHash: make([]byte, 32), FileHash: make([]byte, 32)})
+	if err = api.SetTrustedBlockStore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	if api.trustedBlock.SeqNo != 777 {
+		t.Fatal("newer block from store should be used")
+	}
+
+	update := func(seqno uint32) {
+		api.trustedLock.Lock()
+		api.updateTrustedBlock(&BlockIDExt{Workchain: -1, Shard: -0x8000000000000000, SeqNo: seqno, RootHash: make([]byte, 32), FileHash: make([]byte, 32)})
+		api.trustedLock.Unlock()
+	}
+	waitSaved := func(seqno uint32) {
+		t.Helper()
+		for i := 0; i < 100; i++ {
+			api.trustedSaveMx.Lock()
+			saved := api.trustedSavedSeqno
+			api.trustedSaveMx.Unlock()
+			if saved == seqno {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("block", seqno, "should be saved")
+	}
+
+	update(900)
+	waitSaved(900)
+
+	// saves are throttled, block is only kept in memory
+	update(901)
+	time.Sleep(50 * time.Millisecond)
+	if block, err = store.LoadTrustedBlock(context.Background()); err != nil || block.SeqNo != 900 {
+		t.Fatal("trusted block should not be persisted before interval", err)
+	}
+	if api.trustedBlock.SeqNo != 901 {
+		t.Fatal("trusted block should be updated in memory")
+	}
+
+	if err = api.FlushTrustedBlock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if block, err = store.LoadTrustedBlock(context.Background()); err != nil || block.SeqNo != 901 {
+		t.Fatal("trusted block should be persisted after flush", err)
+	}
+
+	api.trustedSavedAt = time.Time{}
+	update(902)
+	waitSaved(902)
+	if block, err = store.LoadTrustedBlock(context.Background()); err != nil || block.SeqNo != 902 {
+		t.Fatal("new trusted block should be persisted", err)
+	}
+
+	if err = os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.LoadTrustedBlock(context.Background()); err == nil {
+		t.Fatal("incorrect block should not be loaded")
+	}
+}
@@ -0,0 +1,174 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	tl.Register(GetValidatorStats{}, "liteServer.getValidatorStats#091a58bc mode:# id:tonNode.blockIdExt limit:int start_after:mode.0?int256 modified_after:mode.2?int = liteServer.ValidatorStats")
+	tl.Register(ValidatorStats{}, "liteServer.validatorStats mode:# id:tonNode.blockIdExt count:int complete:Bool state_proof:bytes data_proof:bytes = liteServer.ValidatorStats")
+}
+
+type GetValidatorStats struct {
+	Mode          uint32      `tl:"flags"`
+	ID            *BlockIDExt `tl:"struct"`
+	Limit         int32       `tl:"int"`
+	StartAfter    []byte      `tl:"?0 int256"`
+	ModifiedAfter uint32      `tl:"?2 int"`
+}
+
+type ValidatorStats struct {
+	Mode       uint32      `tl:"flags"`
+	ID         *BlockIDExt `tl:"struct"`
+	Count      int32       `tl:"int"`
+	Complete   bool        `tl:"bool"`
+	StateProof *cell.Cell  `tl:"cell"`
+	DataProof  *cell.Cell  `tl:"cell"`
+}
+
+// GetValidatorStats - gets block creation stats of validators from the masterchain block state,
+// sorted by public key, starting after startAfter key (can be nil). When modifiedAfter is not zero,
+// only stats updated after this time are returned. Returns list and incomplete flag, true when more entries can be requested.
+// Proof should contain every entry of the returned range (and every next one, when list is complete),
+// and at least one entry after it, when list is incomplete.
+func (c *APIClient) GetValidatorStats(ctx context.Context, master *BlockIDExt, limit int32, startAfter []byte, modifiedAfter uint32) ([]tlb.ValidatorCreateStats, bool, error) {
+	if master.Workchain != address.MasterchainID {
+		return nil, false, fmt.Errorf("block should be from masterchain")
+	}
+
+	req := GetValidatorStats{ID: master, Limit: limit}
+	if startAfter != nil {
+		if len(startAfter) != 32 {
+			return nil, false, fmt.Errorf("incorrect start key len")
+		}
+		req.Mode |= 1
+		req.StartAfter = startAfter
+	}
+	if modifiedAfter > 0 {
+		req.Mode |= 1 << 2
+		req.ModifiedAfter = modifiedAfter
+	}
+
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, req, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch t := resp.(type) {
+	case ValidatorStats:
+		if !t.ID.Equals(master) {
+			return nil, false, fmt.Errorf("incorrect block in response")
+		}
+
+		// stats are delivered only as a part of proof, so it is always checked
+		stateExtra, err := CheckShardMcStateExtraProof(master, []*cell.Cell{t.StateProof, t.DataProof})
+		if err != nil {
+			return nil, false, fmt.Errorf("incorrect proof: %w", err)
+		}
+
+		var info tlb.McStateExtraBlockInfo
+		if err = tlb.LoadFromCellAsProof(&info, stateExtra.Info.BeginParse()); err != nil {
+			return nil, false, fmt.Errorf("failed to load mc state extra info: %w", err)
+		}
+
+		stats, err := info.LoadBlockCreateStats()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to load block create stats: %w", err)
+		}
+
+		if t.Count < 0 || t.Count > limit {
+			return nil, false, fmt.Errorf("incorrect entries count %d for limit %d", t.Count, limit)
+		}
+
+		all, pruned, err := stats.LoadAllFromProof()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to load block create stats from proof: %w", err)
+		}
+
+		res := make([]tlb.ValidatorCreateStats, 0, t.Count)
+		var more bool
+		for _, st := range all {
+			if startAfter != nil && bytes.Compare(st.PublicKey, startAfter) <= 0 {
+				continue
+			}
+
+			if modifiedAfter > 0 && st.Stats.MasterchainBlocks.LastUpdated < modifiedAfter &&
+				st.Stats.ShardBlocks.LastUpdated < modifiedAfter {
+				continue
+			}
+
+			if len(res) == int(t.Count) {
+				more = true
+				break
+			}
+			res = append(res, st)
+		}
+
+		if len(res) != int(t.Count) {
+			return nil, false, fmt.Errorf("proof contains %d entries, but %d expected", len(res), t.Count)
+		}
+
+		last := startAfter
+		if len(res) > 0 {
+			last = res[len(res)-1].PublicKey
+		}
+
+		// every entry in the returned range (and after it, when complete) should be present in proof,
+		// otherwise server could hide some of them in pruned branches
+		var after bool
+		for _, p := range pruned {
+			from, to := dictKeyPrefixRange(p, 256)
+			if startAfter != nil && bytes.Compare(to, startAfter) <= 0 {
+				continue
+			}
+			if !t.Complete && (last == nil || bytes.Compare(from, last) > 0) {
+				after = true
+				continue
+			}
+			return nil, false, fmt.Errorf("proof has pruned entries in the requested range")
+		}
+
+		if t.Complete && more {
+			return nil, false, fmt.Errorf("response is complete, but proof contains more entries")
+		}
+		if !t.Complete && !after && !validatorStatsKeyAfter(all, last) {
+			return nil, false, fmt.Errorf("response is incomplete, but proof has no more entries")
+		}
+		return res, !t.Complete, nil
+	case LSError:
+		return nil, false, t
+	}
+	return nil, false, errUnexpectedResponse(resp)
+}
+
+// dictKeyPrefixRange - returns the smallest and the biggest keys with the given prefix
+func dictKeyPrefixRange(prefix *cell.Slice, keySz uint) (from, to []byte) {
+	sz := prefix.BitsLeft()
+	bits := prefix.Copy().MustLoadSlice(sz)
+
+	from = make([]byte, (keySz+7)/8)
+	to = make([]byte, (keySz+7)/8)
+	copy(from, bits)
+	copy(to, bits)
+	for i := sz; i < keySz; i++ {
+		to[i/8] |= 0x80 >> (i % 8)
+	}
+	return from, to
+}
+
+func validatorStatsKeyAfter(list []tlb.ValidatorCreateStats, key []byte) bool {
+	for _, st := range list {
+		if key == nil || bytes.Compare(st.PublicKey, key) > 0 {
+			return true
+		}
+	}
+	return false
+}
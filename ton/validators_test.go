@@ -0,0 +1,109 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func testValidatorKey(i int) []byte {
+	return bytes.Repeat([]byte{byte(i * 32)}, 32)
+}
+
+func TestAPIClient_GetValidatorStats(t *testing.T) {
+	stats := cell.NewDict(256)
+	for i := 0; i < 8; i++ {
+		val, err := tlb.ToCell(tlb.CreatorStats{
+			MasterchainBlocks: tlb.Counters{LastUpdated: uint32(100 + i), Total: uint64(i)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = stats.Set(cell.BeginCell().MustStoreSlice(testValidatorKey(i), 256).EndCell(), val); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info := cell.BeginCell().
+		MustStoreUInt(1, 16).
+		MustStoreUInt(0, 32).MustStoreUInt(0, 32).MustStoreBoolBit(false).
+		MustStoreDict(nil).MustStoreBoolBit(false).MustStoreUInt(0, 64).
+		MustStoreBoolBit(false).
+		MustStoreBoolBit(false).
+		MustStoreUInt(0x17, 8).MustStoreDict(stats).
+		EndCell()
+	block := newTestBlock(t, -1, 10, testBlockParts{mcStateExtraInfo: info})
+
+	// proof - builds response with proof of the given keys, all keys are included when keys are nil.
+	// Only forks can be pruned, leaves without refs are always present in proof.
+	proof := func(count int32, complete bool, keys ...int) ValidatorStats {
+		stateSk, infoSk := mcExtraInfoSkeleton()
+		dictSk := infoSk.ProofRef(0)
+		if keys == nil {
+			dictSk.SetRecursive()
+		}
+		for _, k := range keys {
+			if _, _, err := stats.LoadValueWithProof(cell.BeginCell().MustStoreSlice(testValidatorKey(k), 256).EndCell(), dictSk); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		proofs := block.stateProof(t, stateSk)
+		return ValidatorStats{ID: block.id, Count: count, Complete: complete, StateProof: proofs[0], DataProof: proofs[1]}
+	}
+
+	tests := []struct {
+		name          string
+		resp          ValidatorStats
+		limit         int32
+		startAfter    []byte
+		modifiedAfter uint32
+		keys          []int
+		incomplete    bool
+		fail          bool
+	}{
+		{name: "first page", resp: proof(3, false, 0, 1, 2), limit: 3, keys: []int{0, 1, 2}, incomplete: true},
+		{name: "last page", resp: proof(2, true, 5, 6, 7), limit: 10, startAfter: testValidatorKey(5), keys: []int{6, 7}},
+		{name: "modified after", resp: proof(2, true, nil...), limit: 10, modifiedAfter: 106, keys: []int{6, 7}},
+		{name: "all", resp: proof(8, true, nil...), limit: 8, keys: []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{name: "hidden entries in range", resp: proof(3, false, 0, 4), limit: 3, fail: true},
+		{name: "hidden entries after complete", resp: proof(3, true, 0, 1, 2), limit: 3, fail: true},
+		{name: "more entries than complete", resp: proof(3, true, nil...), limit: 3, fail: true},
+		{name: "incomplete without next entries", resp: proof(8, false, nil...), limit: 10, fail: true},
+		{name: "negative count", resp: proof(-1, true, nil...), limit: 3, fail: true},
+		{name: "count above limit", resp: proof(3, false, 0, 1, 2), limit: 2, fail: true},
+		{name: "missing entries", resp: proof(3, false, 0, 1), limit: 3, fail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := NewAPIClient(&proofClient{answer: func(payload tl.Serializable) tl.Serializable {
+				return tt.resp
+			}})
+
+			list, incomplete, err := api.GetValidatorStats(context.Background(), block.id, tt.limit, tt.startAfter, tt.modifiedAfter)
+			if tt.fail {
+				if err == nil {
+					t.Fatal("tampered proof should be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if incomplete != tt.incomplete || len(list) != len(tt.keys) {
+				t.Fatal("incorrect result", incomplete, len(list))
+			}
+			for i, k := range tt.keys {
+				if !bytes.Equal(list[i].PublicKey, testValidatorKey(k)) || list[i].Stats.MasterchainBlocks.Total != uint64(k) {
+					t.Fatal("incorrect entry", i)
+				}
+			}
+		})
+	}
+}
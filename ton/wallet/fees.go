@@ -0,0 +1,189 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// FeeEstimate - expected fees of the wallet transaction, all values are in nanotons
+type FeeEstimate struct {
+	// ImportFee - fee for the external message import, paid from wallet balance
+	ImportFee tlb.Coins
+	// ForwardFees - sum of forward and ihr fees of all outgoing messages
+	ForwardFees tlb.Coins
+	// StorageFee - storage fee which is due since the last paid time
+	StorageFee tlb.Coins
+	// GasLimit - maximum amount of gas which can be consumed by wallet
+	GasLimit uint64
+	// GasFee - price of GasLimit, real gas fee is usually lower
+	GasFee tlb.Coins
+	// Total - sum of all fees, upper bound of the balance decrease, excluding messages values
+	Total tlb.Coins
+}
+
+// EstimateFees - calculates fees of the transfer using config params 18, 20, 21, 24 and 25.
+// Account is the current state of the sender wallet, ext is the signed external message,
+// import fee is calculated only when ext is not nil.
+func EstimateFees(cfg *ton.BlockchainConfig, acc *tlb.Account, ext *tlb.ExternalMessage, messages []*Message) (*FeeEstimate, error) {
+	var addr *address.Address
+	if acc != nil && acc.State != nil && acc.State.IsValid {
+		addr = acc.State.Address
+	} else if ext != nil {
+		addr = ext.DstAddr
+	}
+	masterchain := addr != nil && addr.Workchain() == address.MasterchainID
+
+	gasPrices, err := cfg.GetGasLimitsPrices(masterchain)
+	if err != nil {
+		return nil, err
+	}
+	srcPrices, err := cfg.GetMsgForwardPrices(masterchain)
+	if err != nil {
+		return nil, err
+	}
+
+	importFee := big.NewInt(0)
+	if ext != nil {
+		c, err := tlb.ToCell(ext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize external message: %w", err)
+		}
+		importFee = srcPrices.CalcFwdFee(c.RefsStorageStat())
+	}
+
+	fwdFees := big.NewInt(0)
+	for i, m := range messages {
+		if m.InternalMessage == nil {
+			return nil, fmt.Errorf("message %d is nil", i)
+		}
+
+		prices := srcPrices
+		if dst := m.InternalMessage.DstAddr; !masterchain && dst != nil && dst.Workchain() == address.MasterchainID {
+			if prices, err = cfg.GetMsgForwardPrices(true); err != nil {
+				return nil, err
+			}
+		}
+
+		c, err := tlb.ToCell(m.InternalMessage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize message %d: %w", i, err)
+		}
+
+		fee := prices.CalcFwdFee(c.RefsStorageStat())
+		fwdFees.Add(fwdFees, fee)
+		if !m.InternalMessage.IHRDisabled {
+			fwdFees.Add(fwdFees, prices.CalcIHRFee(fee))
+		}
+	}
+
+	storageFee := big.NewInt(0)
+	gasLimit := gasPrices.GasLimit
+	if acc != nil && acc.State != nil && acc.State.IsValid {
+		storagePrices, err := cfg.GetStoragePrices()
+		if err != nil {
+			return nil, err
+		}
+
+		used := acc.State.StorageInfo.StorageUsed
+		var cells, bits uint64
+		if used.CellsUsed != nil {
+			cells = used.CellsUsed.Uint64()
+		}
+		if used.BitsUsed != nil {
+			bits = used.BitsUsed.Uint64()
+		}
+
+		storageFee = tlb.CalcStorageFee(storagePrices, masterchain, cells, bits,
+			acc.State.StorageInfo.LastPaid, uint32(time.Now().Unix()))
+		if acc.State.StorageInfo.DuePayment != nil {
+			storageFee.Add(storageFee, acc.State.StorageInfo.DuePayment.Nano())
+		}
+
+		// gas can not be bought for more than the wallet has
+		gasLimit = gasPrices.CalcGasBought(acc.State.Balance.Nano())
+	}
+	gasFee := gasPrices.CalcGasFee(gasLimit)
+
+	total := new(big.Int).Add(importFee, fwdFees)
+	total.Add(total, storageFee)
+	total.Add(total, gasFee)
+
+	return &FeeEstimate{
+		ImportFee:   tlb.FromNanoTON(importFee),
+		ForwardFees: tlb.FromNanoTON(fwdFees),
+		StorageFee:  tlb.FromNanoTON(storageFee),
+		GasLimit:    gasLimit,
+		GasFee:      tlb.FromNanoTON(gasFee),
+		Total:       tlb.FromNanoTON(total),
+	}, nil
+}
+
+// EstimateFees - fetches wallet state, builds external message for the given messages and estimates its fees,
+// config should contain params 18, 20, 21, 24 and 25, it can be fetched once using GetBlockchainConfig.
+func (w *Wallet) EstimateFees(ctx context.Context, cfg *ton.BlockchainConfig, messages []*Message) (*FeeEstimate, error) {
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	acc, err := w.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, w.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	est, err := w.estimationWallet()
+	if err != nil {
+		return nil, err
+	}
+
+	initialized := acc.IsActive && acc.State.Status == tlb.AccountStatusActive
+	ext, err := est.PrepareExternalMessageForMany(ctx, !initialized, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	return EstimateFees(cfg, acc, ext, messages)
+}
+
+// estimationSigner - returns zero signature, it has the same size as the real one, so fees are the same
+type estimationSigner struct {
+	pub ed25519.PublicKey
+}
+
+func (s estimationSigner) PublicKey() ed25519.PublicKey {
+	return s.pub
+}
+
+func (s estimationSigner) Sign(_ context.Context, _ []byte) ([]byte, error) {
+	return make([]byte, ed25519.SignatureSize), nil
+}
+
+// estimationWallet - copy of the wallet which builds messages without side effects:
+// signer is not called and highload v3 query id is not allocated
+func (w *Wallet) estimationWallet() (*Wallet, error) {
+	cp := *w
+	cp.key = nil
+	cp.signer = estimationSigner{pub: w.signer.PublicKey()}
+
+	if cfg, ok := w.ver.(ConfigHighloadV3); ok {
+		ttl := cfg.MessageTTL
+		cfg.MessageBuilder = func(ctx context.Context, subWalletId uint32) (uint32, int64, error) {
+			return 0, timeNow().Unix() - int64(ttl/2), nil
+		}
+		cp.ver = cfg
+	}
+
+	spec, err := getSpec(&cp)
+	if err != nil {
+		return nil, err
+	}
+	cp.spec = spec
+	return &cp, nil
+}
@@ -0,0 +1,193 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func testFeesConfig() *ton.BlockchainConfig {
+	gas := func(flatPrice, price uint64) *cell.Cell {
+		c, _ := tlb.ToCell(tlb.GasLimitsPrices{
+			FlatGasLimit:    100,
+			FlatGasPrice:    flatPrice,
+			GasPrice:        price,
+			GasLimit:        1000000,
+			SpecialGasLimit: 1000000,
+			GasCredit:       10000,
+			BlockGasLimit:   10000000,
+			FreezeDueLimit:  100000000,
+			DeleteDueLimit:  1000000000,
+		})
+		return c
+	}
+	msg := func(lump, bit, cl uint64) *cell.Cell {
+		c, _ := tlb.ToCell(tlb.MsgForwardPrices{
+			LumpPrice:      lump,
+			BitPrice:       bit,
+			CellPrice:      cl,
+			IHRPriceFactor: 98304,
+			FirstFrac:      21845,
+			NextFrac:       21845,
+		})
+		return c
+	}
+
+	storage := cell.NewDict(32)
+	sp, _ := tlb.ToCell(tlb.StoragePrices{BitPricePS: 1, CellPricePS: 500, McBitPricePS: 1000, McCellPricePS: 500000})
+	_ = storage.SetIntKey(big.NewInt(0), sp)
+
+	return ton.NewBlockchainConfig(map[int32]*cell.Cell{
+		18: storage.AsCell(),
+		20: gas(1000000, 655360000),
+		21: gas(40000, 26214400),
+		24: msg(10000000, 655360000, 65536000000),
+		25: msg(400000, 26214400, 2621440000),
+	})
+}
+
+func TestEstimateFees(t *testing.T) {
+	cfg := testFeesConfig()
+	addr := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+
+	msgs := []*Message{
+		SimpleMessage(addr, tlb.MustFromTON("1"), nil),
+		SimpleMessage(addr, tlb.MustFromTON("1"), cell.BeginCell().MustStoreSlice(make([]byte, 125), 1000).EndCell()),
+	}
+
+	res, err := EstimateFees(cfg, nil, nil, msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// first message has no refs, so only lump price is paid,
+	// second has body in ref: 400000 + ceil((26214400*1000 + 2621440000)/65536)
+	if res.ForwardFees.Nano().Uint64() != 400000+840000 {
+		t.Fatal("incorrect forward fees", res.ForwardFees.Nano().String())
+	}
+	if res.ImportFee.Nano().Sign() != 0 || res.StorageFee.Nano().Sign() != 0 {
+		t.Fatal("import and storage fees should be zero")
+	}
+	if res.GasLimit != 1000000 {
+		t.Fatal("incorrect gas limit", res.GasLimit)
+	}
+
+	pub, _, _ := ed25519.GenerateKey(nil)
+	acc := &tlb.Account{
+		IsActive: true,
+		State: &tlb.AccountState{
+			IsValid: true,
+			Address: addr,
+			StorageInfo: tlb.StorageInfo{
+				StorageUsed: tlb.StorageUsed{
+					CellsUsed: big.NewInt(3),
+					BitsUsed:  big.NewInt(1000),
+				},
+				LastPaid: uint32(time.Now().Unix()) - 3600,
+			},
+			AccountStorage: tlb.AccountStorage{
+				Status:  tlb.AccountStatusActive,
+				Balance: tlb.MustFromTON("0.01"),
+			},
+		},
+	}
+
+	ext := &tlb.ExternalMessage{
+		DstAddr: addr,
+		Body:    cell.BeginCell().MustStoreSlice(pub, 256).MustStoreRef(cell.BeginCell().EndCell()).EndCell(),
+	}
+
+	res, err = EstimateFees(cfg, acc, ext, msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.ImportFee.Nano().Uint64() != 400000+40000 {
+		t.Fatal("incorrect import fee", res.ImportFee.Nano().String())
+	}
+	if res.StorageFee.Nano().Sign() <= 0 {
+		t.Fatal("storage fee should be charged")
+	}
+	// 0.01 TON is enough only for 25000 gas units
+	if res.GasLimit != 25000 || res.GasFee.Nano().Uint64() != 10000000 {
+		t.Fatal("incorrect gas limit", res.GasLimit, res.GasFee.Nano().String())
+	}
+
+	total := new(big.Int).Add(res.ImportFee.Nano(), res.ForwardFees.Nano())
+	total.Add(total, res.StorageFee.Nano())
+	total.Add(total, res.GasFee.Nano())
+	if total.Cmp(res.Total.Nano()) != 0 {
+		t.Fatal("incorrect total")
+	}
+}
+
+func TestWallet_EstimateFees(t *testing.T) {
+	m := &MockAPI{
+		getBlockInfo: func(ctx context.Context) (*ton.BlockIDExt, error) {
+			return &ton.BlockIDExt{}, nil
+		},
+		getAccount: func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+			return &tlb.Account{}, nil
+		},
+	}
+
+	signer := &remoteSigner{key: ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))}
+	allocated := 0
+	w, err := FromSigner(m, signer, ConfigHighloadV3{
+		MessageTTL: 60,
+		MessageBuilder: func(ctx context.Context, subWalletId uint32) (uint32, int64, error) {
+			allocated++
+			return 1, time.Now().Unix(), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	res, err := w.EstimateFees(context.Background(), testFeesConfig(), []*Message{SimpleMessage(addr, tlb.MustFromTON("1"), nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ImportFee.Nano().Sign() <= 0 {
+		t.Fatal("import fee should be calculated")
+	}
+	if signer.calls != 0 || allocated != 0 {
+		t.Fatal("estimation should not sign or allocate query id", signer.calls, allocated)
+	}
+
+	// estimated message should have the same size as the real one
+	est, err := w.estimationWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := []*Message{SimpleMessage(addr, tlb.MustFromTON("1"), nil)}
+	signed, err := w.PrepareExternalMessageForMany(context.Background(), true, msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake, err := est.PrepareExternalMessageForMany(context.Background(), true, msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedCell, err := tlb.ToCell(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeCell, err := tlb.ToCell(fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedCells, signedBits := signedCell.RefsStorageStat()
+	fakeCells, fakeBits := fakeCell.RefsStorageStat()
+	if signedCells != fakeCells || signedBits != fakeBits || signedCell.BitsSize() != fakeCell.BitsSize() {
+		t.Fatal("estimation message size differs")
+	}
+}
@@ -22,7 +22,7 @@ type SpecHighloadV2R2 struct {
 	SpecQuery
 }
 
-func (s *SpecHighloadV2R2) BuildMessage(_ context.Context, messages []*Message) (*cell.Cell, error) {
+func (s *SpecHighloadV2R2) BuildMessage(ctx context.Context, messages []*Message) (*cell.Cell, error) {
 	if len(messages) > 254 {
 		return nil, errors.New("for this type of wallet max 254 messages can be sent in the same time")
 	}
@@ -58,7 +58,10 @@ func (s *SpecHighloadV2R2) BuildMessage(_ context.Context, messages []*Message)
 		MustStoreUInt(boundedID, 64).
 		MustStoreDict(dict)
 
-	sign := payload.EndCell().Sign(s.wallet.key)
+	sign, err := s.wallet.sign(ctx, payload.EndCell())
+	if err != nil {
+		return nil, err
+	}
 	msg := cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell()
 
 	return msg, nil
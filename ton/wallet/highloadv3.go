@@ -18,7 +18,8 @@ type ConfigHighloadV3 struct {
 
 	// This function wil be used to get query id and creation time for the new message.
 	// ID can be iterator from your database, max id is 1<<23, when it is higher, start from 0 and repeat
-	// MessageBuilder should be defined if you want to send transactions
+	// MessageBuilder should be defined if you want to send transactions,
+	// or SpecHighloadV3.UseQueryIDAllocator can be used to set built-in allocator
 	MessageBuilder func(ctx context.Context, subWalletId uint32) (id uint32, createdAt int64, err error)
 }
 
@@ -83,8 +84,13 @@ func (s *SpecHighloadV3) BuildMessage(ctx context.Context, messages []*Message)
 		MustStoreUInt(uint64(s.config.MessageTTL), 22).
 		EndCell()
 
+	sign, err := s.wallet.sign(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
 	return cell.BeginCell().
-		MustStoreSlice(payload.Sign(s.wallet.key), 512).
+		MustStoreSlice(sign, 512).
 		MustStoreRef(payload).EndCell(), nil
 }
 
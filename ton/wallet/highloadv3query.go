@@ -0,0 +1,387 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Query id of highload v3 is 23 bits: shift (13 bits) and bit number (10 bits).
+// Processed ids are stored by contract as bitmaps in dictionaries keyed by shift.
+const (
+	HighloadV3MaxQueryID = 1<<23 - 1
+
+	_HighloadV3BitNumberBits = 10
+	// bitmap is stored in the cell, so it cannot be longer than 1023 bits
+	_HighloadV3MaxBitNumber = 1022
+	// max lag of created at from local time, because block time is behind
+	_HighloadV3MaxCreatedAtLag = 30
+	// time after expiration, while query can still be processed by the shard block, which is not yet in masterchain
+	_HighloadV3ExpiredMargin = 30
+)
+
+var (
+	ErrNoFreeQueryID    = errors.New("no free query id, all of them are used in the ttl window")
+	ErrUnknownQueryID   = errors.New("query id was not allocated or already forgotten")
+	ErrQueryIDSubwallet = errors.New("allocator is bound to another subwallet")
+)
+
+type QueryStatus int
+
+const (
+	// QueryStatusPending - query is not processed yet, but it still can be
+	QueryStatusPending QueryStatus = iota
+	// QueryStatusProcessed - query was processed by contract
+	QueryStatusProcessed
+	// QueryStatusExpired - query was not processed and will never be, messages can be resent with a new query id
+	QueryStatusExpired
+)
+
+// QueryAllocation - query id which was given to the message
+type QueryAllocation struct {
+	QueryID   uint32
+	CreatedAt int64
+}
+
+// QueryIDStore - persists allocated query ids, so they are not reused after restart
+// while messages with them can still be processed
+type QueryIDStore interface {
+	LoadAllocations(ctx context.Context, addr *address.Address) ([]QueryAllocation, error)
+	SaveAllocation(ctx context.Context, addr *address.Address, a QueryAllocation) error
+	// RemoveAllocations - removes allocations created before the given time, they are not needed anymore
+	RemoveAllocations(ctx context.Context, addr *address.Address, createdBefore int64) error
+}
+
+// MemoryQueryIDStore - keeps allocations in memory, they are lost on restart,
+// so after restart allocator relies only on the contract state
+type MemoryQueryIDStore struct {
+	list map[string][]QueryAllocation
+	mx   sync.Mutex
+}
+
+// HighloadV3Data - parsed persistent data of highload wallet v3
+type HighloadV3Data struct {
+	PublicKey     []byte           `tlb:"bits 256"`
+	SubwalletID   uint32           `tlb:"## 32"`
+	OldQueries    *cell.Dictionary `tlb:"dict 13"`
+	Queries       *cell.Dictionary `tlb:"dict 13"`
+	LastCleanTime uint64           `tlb:"## 64"`
+	Timeout       uint32           `tlb:"## 22"`
+}
+
+// QueryIDAllocator - allocates query ids for highload v3 wallet, which are not used by the pending
+// or recently processed messages, use SpecHighloadV3.UseQueryIDAllocator to create it
+type QueryIDAllocator struct {
+	spec  *SpecHighloadV3
+	store QueryIDStore
+
+	allocated map[uint32]int64
+	chain     *HighloadV3Data
+	cursor    uint32
+	loaded    bool
+
+	mx sync.Mutex
+}
+
+func NewMemoryQueryIDStore() *MemoryQueryIDStore {
+	return &MemoryQueryIDStore{list: map[string][]QueryAllocation{}}
+}
+
+func (m *MemoryQueryIDStore) LoadAllocations(_ context.Context, addr *address.Address) ([]QueryAllocation, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	return append([]QueryAllocation{}, m.list[addr.String()]...), nil
+}
+
+func (m *MemoryQueryIDStore) SaveAllocation(_ context.Context, addr *address.Address, a QueryAllocation) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.list[addr.String()] = append(m.list[addr.String()], a)
+	return nil
+}
+
+func (m *MemoryQueryIDStore) RemoveAllocations(_ context.Context, addr *address.Address, createdBefore int64) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	var left []QueryAllocation
+	for _, a := range m.list[addr.String()] {
+		if a.CreatedAt >= createdBefore {
+			left = append(left, a)
+		}
+	}
+	m.list[addr.String()] = left
+	return nil
+}
+
+// ParseHighloadV3Data - parses data cell of highload v3 wallet account
+func ParseHighloadV3Data(data *cell.Cell) (*HighloadV3Data, error) {
+	if data == nil {
+		return nil, fmt.Errorf("data is nil")
+	}
+
+	var d HighloadV3Data
+	if err := tlb.LoadFromCell(&d, data.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse highload v3 data: %w", err)
+	}
+	return &d, nil
+}
+
+// IsQueryUsed - checks if query id is marked as processed in the current or old queries
+func (d *HighloadV3Data) IsQueryUsed(queryID uint32) (bool, error) {
+	for _, dict := range []*cell.Dictionary{d.Queries, d.OldQueries} {
+		used, err := isQueryInBitmap(dict, queryID)
+		if err != nil {
+			return false, err
+		}
+		if used {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isQueryInBitmap(dict *cell.Dictionary, queryID uint32) (bool, error) {
+	if dict == nil {
+		return false, nil
+	}
+
+	shift, bitNumber := queryID>>_HighloadV3BitNumberBits, queryID&(1<<_HighloadV3BitNumberBits-1)
+
+	val, err := dict.LoadValueByIntKey(big.NewInt(int64(shift)))
+	if err != nil {
+		if errors.Is(err, cell.ErrNoSuchKeyInDict) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to load queries bitmap: %w", err)
+	}
+
+	// bitmap is stored in ref, contract gets it with udict_get_ref
+	bitmap, err := val.LoadRef()
+	if err != nil {
+		return false, fmt.Errorf("failed to load queries bitmap ref: %w", err)
+	}
+
+	if bitmap.BitsLeft() <= uint(bitNumber) {
+		return false, nil
+	}
+
+	if _, err = bitmap.LoadSlice(uint(bitNumber)); err != nil {
+		return false, err
+	}
+	return bitmap.LoadBoolBit()
+}
+
+// ParseHighloadV3QueryID - returns query id and creation time from the external message body of highload v3 wallet
+func ParseHighloadV3QueryID(body *cell.Cell) (queryID uint32, createdAt int64, err error) {
+	s := body.BeginParse()
+	if _, err = s.LoadSlice(512); err != nil {
+		return 0, 0, fmt.Errorf("failed to load signature: %w", err)
+	}
+
+	payload, err := s.LoadRef()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load payload: %w", err)
+	}
+
+	// subwallet id, message and mode are skipped
+	if _, err = payload.LoadUInt(32 + 8); err != nil {
+		return 0, 0, fmt.Errorf("failed to load payload header: %w", err)
+	}
+
+	id, err := payload.LoadUInt(23)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load query id: %w", err)
+	}
+
+	at, err := payload.LoadUInt(64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load created at: %w", err)
+	}
+	return uint32(id), int64(at), nil
+}
+
+// UseQueryIDAllocator - creates allocator for the wallet and uses it as MessageBuilder.
+// Allocations are persisted to store before message is built, so ids are not reused after restart.
+func (s *SpecHighloadV3) UseQueryIDAllocator(store QueryIDStore) *QueryIDAllocator {
+	a := &QueryIDAllocator{
+		spec:      s,
+		store:     store,
+		allocated: map[uint32]int64{},
+	}
+	s.config.MessageBuilder = a.Allocate
+	return a
+}
+
+// Sync - loads processed query ids from the contract state,
+// it is done automatically on the first allocation
+func (a *QueryIDAllocator) Sync(ctx context.Context) error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	return a.sync(ctx)
+}
+
+func (a *QueryIDAllocator) sync(ctx context.Context) error {
+	w := a.spec.wallet
+
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block: %w", err)
+	}
+
+	acc, err := w.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, w.addr)
+	if err != nil {
+		return fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	if !acc.IsActive || acc.State.Status != tlb.AccountStatusActive {
+		a.chain = nil
+		return nil
+	}
+
+	data, err := ParseHighloadV3Data(acc.Data)
+	if err != nil {
+		return err
+	}
+	a.chain = data
+	return nil
+}
+
+func (a *QueryIDAllocator) load(ctx context.Context) error {
+	list, err := a.store.LoadAllocations(ctx, a.spec.wallet.addr)
+	if err != nil {
+		return fmt.Errorf("failed to load allocations: %w", err)
+	}
+
+	for _, al := range list {
+		a.allocated[al.QueryID] = al.CreatedAt
+		if al.QueryID >= a.cursor {
+			a.cursor = al.QueryID + 1
+		}
+	}
+
+	if err = a.sync(ctx); err != nil {
+		return fmt.Errorf("failed to sync with contract: %w", err)
+	}
+	a.loaded = true
+	return nil
+}
+
+// Allocate - returns free query id and creation time for the new message, compatible with MessageBuilder.
+// Id is free when it was not given to the message during the last 2 ttl windows, because contract keeps
+// processed ids up to 2 ttl, and when it is not marked as processed in the last synced contract state.
+func (a *QueryIDAllocator) Allocate(ctx context.Context, subwallet uint32) (uint32, int64, error) {
+	if subwallet != a.spec.wallet.subwallet {
+		return 0, 0, ErrQueryIDSubwallet
+	}
+
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	if !a.loaded {
+		if err := a.load(ctx); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	ttl := int64(a.spec.config.MessageTTL)
+	now := timeNow().Unix()
+
+	forgetBefore := now - 2*ttl
+	for id, at := range a.allocated {
+		if at < forgetBefore {
+			delete(a.allocated, id)
+		}
+	}
+	if err := a.store.RemoveAllocations(ctx, a.spec.wallet.addr, forgetBefore); err != nil {
+		return 0, 0, fmt.Errorf("failed to remove old allocations: %w", err)
+	}
+
+	for i := uint32(0); i <= HighloadV3MaxQueryID; i++ {
+		id := (a.cursor + i) % (HighloadV3MaxQueryID + 1)
+		if id&(1<<_HighloadV3BitNumberBits-1) > _HighloadV3MaxBitNumber {
+			continue
+		}
+
+		if _, ok := a.allocated[id]; ok {
+			continue
+		}
+
+		if a.chain != nil {
+			used, err := a.chain.IsQueryUsed(id)
+			if err != nil {
+				return 0, 0, err
+			}
+			if used {
+				continue
+			}
+		}
+
+		lag := ttl / 2
+		if lag > _HighloadV3MaxCreatedAtLag {
+			lag = _HighloadV3MaxCreatedAtLag
+		}
+		createdAt := now - lag
+
+		if err := a.store.SaveAllocation(ctx, a.spec.wallet.addr, QueryAllocation{QueryID: id, CreatedAt: createdAt}); err != nil {
+			return 0, 0, fmt.Errorf("failed to save allocation: %w", err)
+		}
+
+		a.allocated[id] = createdAt
+		a.cursor = id + 1
+		return id, createdAt, nil
+	}
+
+	return 0, 0, ErrNoFreeQueryID
+}
+
+// Status - checks if the message with query id was processed, using processed? get method.
+// Expired status is returned only for the known allocations, when query is not processed and ttl is passed
+// by the time of the block used for the check, with a margin for shard blocks not yet committed to masterchain.
+// Contract forgets processed ids after 2 ttl windows, so status should be checked before it.
+func (a *QueryIDAllocator) Status(ctx context.Context, queryID uint32) (QueryStatus, error) {
+	w := a.spec.wallet
+
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block: %w", err)
+	}
+	api := w.api.WaitForBlock(block.SeqNo)
+
+	processed, err := runIntGetMethod(ctx, api, block, w.addr, "processed?", big.NewInt(int64(queryID)), 0)
+	if err != nil {
+		return 0, err
+	}
+
+	if processed.Sign() != 0 {
+		return QueryStatusProcessed, nil
+	}
+
+	a.mx.Lock()
+	createdAt, ok := a.allocated[queryID]
+	a.mx.Unlock()
+
+	if !ok {
+		return 0, ErrUnknownQueryID
+	}
+
+	utime, err := blockTime(ctx, api, block)
+	if err != nil {
+		return 0, err
+	}
+
+	// contract accepts messages only while block time is before created at + ttl
+	if utime >= createdAt+int64(a.spec.config.MessageTTL)+_HighloadV3ExpiredMargin {
+		return QueryStatusExpired, nil
+	}
+	return QueryStatusPending, nil
+}
@@ -0,0 +1,174 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func highloadV3TestData(t *testing.T, pub ed25519.PublicKey, usedQuery uint32) *cell.Cell {
+	queries := cell.NewDict(13)
+	// bitmap with only bit of used query set
+	bitNumber := usedQuery & 1023
+	bitmap := cell.BeginCell().MustStoreUInt(0, uint(bitNumber)).MustStoreBoolBit(true)
+	if err := queries.SetIntKey(big.NewInt(int64(usedQuery>>10)), cell.BeginCell().MustStoreRef(bitmap.EndCell()).EndCell()); err != nil {
+		t.Fatal(err)
+	}
+
+	return cell.BeginCell().
+		MustStoreSlice(pub, 256).
+		MustStoreUInt(DefaultSubwallet, 32).
+		MustStoreDict(nil).
+		MustStoreDict(queries).
+		MustStoreUInt(1000, 64).
+		MustStoreUInt(60, 22).
+		EndCell()
+}
+
+func TestHighloadV3Data_IsQueryUsed(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	d, err := ParseHighloadV3Data(highloadV3TestData(t, pkey.Public().(ed25519.PublicKey), 1<<10|5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, exp := range map[uint32]bool{1<<10 | 5: true, 1<<10 | 4: false, 1<<10 | 6: false, 5: false} {
+		used, err := d.IsQueryUsed(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if used != exp {
+			t.Fatal("incorrect used flag for", id)
+		}
+	}
+}
+
+func TestQueryIDAllocator(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	processed := map[uint32]bool{}
+	blockNow := time.Unix(1000000, 0)
+
+	m := &MockAPI{
+		getBlockInfo: func(ctx context.Context) (*ton.BlockIDExt, error) {
+			return &ton.BlockIDExt{}, nil
+		},
+		getAccount: func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+			return &tlb.Account{
+				IsActive: true,
+				State: &tlb.AccountState{
+					IsValid:        true,
+					AccountStorage: tlb.AccountStorage{Status: tlb.AccountStatusActive},
+				},
+				Data: highloadV3TestData(t, pkey.Public().(ed25519.PublicKey), 0),
+			}, nil
+		},
+		runGetMethod: func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+			if method != "processed?" {
+				t.Fatal("unexpected method", method)
+			}
+			if processed[uint32(params[0].(*big.Int).Uint64())] {
+				return ton.NewExecutionResult([]any{big.NewInt(-1)}), nil
+			}
+			return ton.NewExecutionResult([]any{big.NewInt(0)}), nil
+		},
+		getBlockData: func(ctx context.Context, block *ton.BlockIDExt) (*tlb.Block, error) {
+			return queueTestBlock(blockNow), nil
+		},
+	}
+
+	w, err := FromPrivateKey(m, pkey, ConfigHighloadV3{MessageTTL: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewMemoryQueryIDStore()
+	alloc := w.GetSpec().(*SpecHighloadV3).UseQueryIDAllocator(store)
+
+	msg := &Message{
+		Mode: PayGasSeparately,
+		InternalMessage: &tlb.InternalMessage{
+			DstAddr: w.WalletAddress(),
+			Amount:  tlb.MustFromTON("0.1"),
+		},
+	}
+
+	ext, err := w.PrepareExternalMessageForMany(context.Background(), false, []*Message{msg})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, createdAt, err := ParseHighloadV3QueryID(ext.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0 is used on chain
+	if id != 1 || createdAt != timeNow().Unix()-30 {
+		t.Fatal("incorrect query id or created at", id, createdAt)
+	}
+
+	// new allocator should continue after persisted allocations
+	w2, _ := FromPrivateKey(m, pkey, ConfigHighloadV3{MessageTTL: 60})
+	alloc2 := w2.GetSpec().(*SpecHighloadV3).UseQueryIDAllocator(store)
+	if id2, _, err := alloc2.Allocate(context.Background(), DefaultSubwallet); err != nil || id2 != 2 {
+		t.Fatal("incorrect next query id", id2, err)
+	}
+
+	if _, _, err = alloc.Allocate(context.Background(), DefaultSubwallet+1); err != ErrQueryIDSubwallet {
+		t.Fatal("subwallet should be checked")
+	}
+
+	if st, err := alloc.Status(context.Background(), 1); err != nil || st != QueryStatusPending {
+		t.Fatal("should be pending", st, err)
+	}
+
+	// local time is after ttl, but block time is not, so message still can be processed
+	timeNow = func() time.Time {
+		return time.Unix(1000000+600, 0)
+	}
+	if st, err := alloc.Status(context.Background(), 1); err != nil || st != QueryStatusPending {
+		t.Fatal("should be pending by block time", st, err)
+	}
+
+	// created at + ttl + margin is the first block time, when message cannot be processed
+	blockNow = time.Unix(createdAt+60+_HighloadV3ExpiredMargin-1, 0)
+	if st, err := alloc.Status(context.Background(), 1); err != nil || st != QueryStatusPending {
+		t.Fatal("should be pending in margin", st, err)
+	}
+
+	blockNow = time.Unix(createdAt+60+_HighloadV3ExpiredMargin, 0)
+	if st, err := alloc.Status(context.Background(), 1); err != nil || st != QueryStatusExpired {
+		t.Fatal("should be expired", st, err)
+	}
+
+	processed[1] = true
+	if st, err := alloc.Status(context.Background(), 1); err != nil || st != QueryStatusProcessed {
+		t.Fatal("should be processed", st, err)
+	}
+
+	if _, err = alloc.Status(context.Background(), 100); err != ErrUnknownQueryID {
+		t.Fatal("unknown query should be reported")
+	}
+
+	// after 2 ttl windows allocations are forgotten
+	timeNow = func() time.Time {
+		return time.Unix(1000000+200, 0)
+	}
+	if _, _, err = alloc.Allocate(context.Background(), DefaultSubwallet); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := store.LoadAllocations(context.Background(), w.Address()); len(list) != 1 {
+		t.Fatal("old allocations should be removed from store", len(list))
+	}
+}
@@ -0,0 +1,760 @@
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrTransferExists = errors.New("transfer with this id is already queued")
+
+type TransferState int
+
+const (
+	// TransferStatePending - transfer is waiting to be included into external message
+	TransferStatePending TransferState = iota
+	// TransferStateSent - external message with transfer is sent, waiting for transaction
+	TransferStateSent
+	// TransferStateConfirmed - transaction of external message is found, its action phase succeeded
+	// and all expected messages were created, it is final state.
+	// Highload v3 sends batch of many transfers to itself, so for it this state means the batch was accepted
+	// and forwarded, payouts happen in the next internal transactions and are not checked.
+	TransferStateConfirmed
+	// TransferStateFailed - transaction was found but its messages were not sent, see Error,
+	// or message was expired and transaction was not found, with DeliveryAtMostOnce,
+	// or with DeliveryAtLeastOnce when it cannot be proven that message was not processed
+	TransferStateFailed
+)
+
+type DeliveryGuarantee int
+
+const (
+	// DeliveryAtLeastOnce - transfers of expired messages without found transaction are queued again,
+	// but only when wallet state proves that message was not processed: seqno is not increased after it,
+	// or query id is not marked as processed by highload wallet. Otherwise, for example when transaction
+	// is older than MaxTxScan, transfers are marked as failed to be resolved manually.
+	DeliveryAtLeastOnce DeliveryGuarantee = iota
+	// DeliveryAtMostOnce - transfers of expired messages without found transaction are marked as failed,
+	// to be resolved manually
+	DeliveryAtMostOnce
+)
+
+// TransferRecord - persisted transfer intent and its delivery state
+type TransferRecord struct {
+	ID string
+	// Mode - send mode of the message
+	Mode uint8
+	// Message - BoC of internal message
+	Message []byte
+	State   TransferState
+
+	// InMsgHash - hash of the external message body, used to find transaction,
+	// and as a key of the external message saved to store once for all transfers of the batch
+	InMsgHash []byte
+	// ExpireAt - unix time after which external message cannot be accepted by wallet
+	ExpireAt int64
+	// SentAt - unix time of the last broadcast
+	SentAt   int64
+	Attempts int
+
+	TxHash []byte
+	TxLT   uint64
+	// Error - reason of failure when transaction was found but transfers were not sent
+	Error string
+
+	CreatedAt int64
+}
+
+// TransferStore - persists transfer records, saves must be durable when they return,
+// because external message is sent only after its records are saved
+type TransferStore interface {
+	// CreateTransfer - saves new record, ErrTransferExists should be returned when id is already used
+	CreateTransfer(ctx context.Context, rec *TransferRecord) error
+	// SaveTransfers - saves records of the same external message atomically, all of them or none
+	SaveTransfers(ctx context.Context, recs []*TransferRecord) error
+	GetTransfer(ctx context.Context, id string) (*TransferRecord, error)
+	// ListTransfers - returns records in the given states, in order of creation
+	ListTransfers(ctx context.Context, states ...TransferState) ([]*TransferRecord, error)
+	// SaveExternalMessage - saves BoC of the external message, it is kept to rebroadcast exactly
+	// the same message with the same seqno or query id
+	SaveExternalMessage(ctx context.Context, inMsgHash []byte, boc []byte) error
+	GetExternalMessage(ctx context.Context, inMsgHash []byte) ([]byte, error)
+	// RemoveExternalMessage - removes external message when its transfers are not sent anymore
+	RemoveExternalMessage(ctx context.Context, inMsgHash []byte) error
+}
+
+type TransferQueueConfig struct {
+	Guarantee DeliveryGuarantee
+	// BatchSize - max number of transfers in one external message, max supported by the wallet is used when 0
+	BatchSize int
+	// RebroadcastInterval - how often not confirmed message is sent again, 15 seconds by default
+	RebroadcastInterval time.Duration
+	// ExpirationGrace - additional wait after expiration before giving up on the transaction search,
+	// because block time is behind and transaction may be not visible yet, 1 minute by default
+	ExpirationGrace time.Duration
+	// MaxTxScan - max number of the last wallet transactions to scan for confirmation, 100 by default
+	MaxTxScan int
+}
+
+// TransferQueue - persists transfer intents, sends them in batches, rebroadcasts not confirmed messages
+// and marks transfers final only when their transaction is found
+type TransferQueue struct {
+	wallet *Wallet
+	store  TransferStore
+	config TransferQueueConfig
+
+	mx sync.Mutex
+}
+
+// MemoryTransferStore - keeps records in memory, useful for tests, durable store should be used in production
+type MemoryTransferStore struct {
+	records  map[string]*TransferRecord
+	order    []string
+	messages map[string][]byte
+	mx       sync.Mutex
+}
+
+func NewMemoryTransferStore() *MemoryTransferStore {
+	return &MemoryTransferStore{records: map[string]*TransferRecord{}, messages: map[string][]byte{}}
+}
+
+func (m *MemoryTransferStore) CreateTransfer(_ context.Context, rec *TransferRecord) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	if _, ok := m.records[rec.ID]; ok {
+		return ErrTransferExists
+	}
+	cp := *rec
+	m.records[rec.ID] = &cp
+	m.order = append(m.order, rec.ID)
+	return nil
+}
+
+func (m *MemoryTransferStore) SaveTransfers(_ context.Context, recs []*TransferRecord) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	for _, rec := range recs {
+		if _, ok := m.records[rec.ID]; !ok {
+			return fmt.Errorf("transfer %s is not found", rec.ID)
+		}
+	}
+	for _, rec := range recs {
+		cp := *rec
+		m.records[rec.ID] = &cp
+	}
+	return nil
+}
+
+func (m *MemoryTransferStore) GetTransfer(_ context.Context, id string) (*TransferRecord, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	rec, ok := m.records[id]
+	if !ok {
+		return nil, fmt.Errorf("transfer %s is not found", id)
+	}
+	cp := *rec
+	return &cp, nil
+}
+
+func (m *MemoryTransferStore) ListTransfers(_ context.Context, states ...TransferState) ([]*TransferRecord, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	var list []*TransferRecord
+	for _, id := range m.order {
+		rec := m.records[id]
+		for _, st := range states {
+			if rec.State == st {
+				cp := *rec
+				list = append(list, &cp)
+				break
+			}
+		}
+	}
+	return list, nil
+}
+
+func (m *MemoryTransferStore) SaveExternalMessage(_ context.Context, inMsgHash []byte, boc []byte) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.messages[string(inMsgHash)] = append([]byte{}, boc...)
+	return nil
+}
+
+func (m *MemoryTransferStore) GetExternalMessage(_ context.Context, inMsgHash []byte) ([]byte, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	boc, ok := m.messages[string(inMsgHash)]
+	if !ok {
+		return nil, fmt.Errorf("external message %s is not found", hex.EncodeToString(inMsgHash))
+	}
+	return append([]byte{}, boc...), nil
+}
+
+func (m *MemoryTransferStore) RemoveExternalMessage(_ context.Context, inMsgHash []byte) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	delete(m.messages, string(inMsgHash))
+	return nil
+}
+
+func NewTransferQueue(w *Wallet, store TransferStore, config TransferQueueConfig) *TransferQueue {
+	if config.BatchSize <= 0 || config.BatchSize > maxBatchSize(w) {
+		config.BatchSize = maxBatchSize(w)
+	}
+	if config.RebroadcastInterval <= 0 {
+		config.RebroadcastInterval = 15 * time.Second
+	}
+	if config.ExpirationGrace <= 0 {
+		config.ExpirationGrace = time.Minute
+	}
+	if config.MaxTxScan <= 0 {
+		config.MaxTxScan = 100
+	}
+
+	return &TransferQueue{
+		wallet: w,
+		store:  store,
+		config: config,
+	}
+}
+
+func maxBatchSize(w *Wallet) int {
+	switch v := w.ver.(type) {
+	case ConfigHighloadV3:
+		return 254 * 254
+	case ConfigV5R1Beta, ConfigV5R1Final:
+		return 255
+	case Version:
+		switch v {
+		case HighloadV2R2, HighloadV2Verified:
+			return 254
+		}
+	}
+	return 4
+}
+
+// isSeqnoBased - seqno wallets can process only one message at a time,
+// so the next batch is sent only after previous is confirmed or expired
+func isSeqnoBased(w *Wallet) bool {
+	switch w.spec.(type) {
+	case *SpecHighloadV2R2, *SpecHighloadV3:
+		return false
+	}
+	return true
+}
+
+func messageTTL(w *Wallet) time.Duration {
+	switch s := w.spec.(type) {
+	case *SpecHighloadV3:
+		return time.Duration(s.config.MessageTTL) * time.Second
+	case interface{ getMessagesTTL() uint32 }:
+		return time.Duration(s.getMessagesTTL()) * time.Second
+	}
+	return 0
+}
+
+// Enqueue - persists transfer intent, id is used for idempotency, ErrTransferExists is returned for the known id
+func (q *TransferQueue) Enqueue(ctx context.Context, id string, message *Message) error {
+	if message == nil || message.InternalMessage == nil {
+		return fmt.Errorf("internal message cannot be nil")
+	}
+
+	c, err := tlb.ToCell(message.InternalMessage)
+	if err != nil {
+		return fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	return q.store.CreateTransfer(ctx, &TransferRecord{
+		ID:        id,
+		Mode:      message.Mode,
+		Message:   c.ToBOC(),
+		State:     TransferStatePending,
+		CreatedAt: timeNow().Unix(),
+	})
+}
+
+// Status - returns current record of transfer
+func (q *TransferQueue) Status(ctx context.Context, id string) (*TransferRecord, error) {
+	return q.store.GetTransfer(ctx, id)
+}
+
+// Run - processes queue with interval until context is done
+func (q *TransferQueue) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		if err := q.Process(ctx); err != nil {
+			log.Println("[WARNING] transfer queue processing error:", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Process - makes one iteration: checks sent messages, rebroadcasts them or gives up after expiration,
+// and sends next batch of pending transfers. It is safe to call after restart, state is taken from store.
+func (q *TransferQueue) Process(ctx context.Context) error {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	sent, err := q.store.ListTransfers(ctx, TransferStateSent)
+	if err != nil {
+		return fmt.Errorf("failed to list sent transfers: %w", err)
+	}
+
+	inFlight := 0
+	for _, batch := range groupByMessage(sent) {
+		final, err := q.checkBatch(ctx, batch)
+		if err != nil {
+			return err
+		}
+		if !final {
+			inFlight++
+		}
+	}
+
+	if inFlight > 0 && isSeqnoBased(q.wallet) {
+		return nil
+	}
+
+	pending, err := q.store.ListTransfers(ctx, TransferStatePending)
+	if err != nil {
+		return fmt.Errorf("failed to list pending transfers: %w", err)
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+	if len(pending) > q.config.BatchSize {
+		pending = pending[:q.config.BatchSize]
+	}
+	return q.sendBatch(ctx, pending)
+}
+
+func groupByMessage(list []*TransferRecord) [][]*TransferRecord {
+	groups := map[string][]*TransferRecord{}
+	var keys []string
+	for _, rec := range list {
+		k := string(rec.InMsgHash)
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], rec)
+	}
+
+	// oldest message first, to keep seqno order on rebroadcast
+	sort.SliceStable(keys, func(i, j int) bool {
+		return groups[keys[i]][0].ExpireAt < groups[keys[j]][0].ExpireAt
+	})
+
+	res := make([][]*TransferRecord, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, groups[k])
+	}
+	return res
+}
+
+func (q *TransferQueue) sendBatch(ctx context.Context, records []*TransferRecord) error {
+	messages := make([]*Message, 0, len(records))
+	for _, rec := range records {
+		c, err := cell.FromBOC(rec.Message)
+		if err != nil {
+			return fmt.Errorf("failed to parse message of transfer %s: %w", rec.ID, err)
+		}
+
+		var msg tlb.InternalMessage
+		if err = tlb.LoadFromCell(&msg, c.BeginParse()); err != nil {
+			return fmt.Errorf("failed to parse message of transfer %s: %w", rec.ID, err)
+		}
+		messages = append(messages, &Message{Mode: rec.Mode, InternalMessage: &msg})
+	}
+
+	ext, err := q.wallet.BuildExternalMessageForMany(ctx, messages)
+	if err != nil {
+		return fmt.Errorf("failed to build external message: %w", err)
+	}
+
+	extCell, err := tlb.ToCell(ext)
+	if err != nil {
+		return fmt.Errorf("failed to serialize external message: %w", err)
+	}
+
+	// message is saved once for the batch, records keep only its hash
+	inMsgHash := ext.Body.Hash()
+	if err = q.store.SaveExternalMessage(ctx, inMsgHash, extCell.ToBOC()); err != nil {
+		return fmt.Errorf("failed to save external message: %w", err)
+	}
+
+	now := timeNow()
+	for _, rec := range records {
+		rec.State = TransferStateSent
+		rec.InMsgHash = inMsgHash
+		rec.ExpireAt = now.Add(messageTTL(q.wallet)).Unix()
+		rec.SentAt = now.Unix()
+		rec.Attempts++
+	}
+
+	// message is sent only after all its transfers are persisted as sent,
+	// so after crash it will be rebroadcasted or checked, but not built again
+	if err = q.store.SaveTransfers(ctx, records); err != nil {
+		return fmt.Errorf("failed to save transfers: %w", err)
+	}
+
+	if err = q.wallet.api.SendExternalMessage(ctx, ext); err != nil {
+		// will be rebroadcasted
+		log.Println("[WARNING] failed to send external message", hex.EncodeToString(ext.Body.Hash()), ":", err.Error())
+	}
+	return nil
+}
+
+// checkBatch - returns true when transfers of the message reached the final or pending state
+func (q *TransferQueue) checkBatch(ctx context.Context, batch []*TransferRecord) (bool, error) {
+	head := batch[0]
+	inMsgHash := head.InMsgHash
+
+	tx, err := q.wallet.api.FindLastTransactionByInMsgHash(ctx, q.wallet.addr, inMsgHash, q.config.MaxTxScan)
+	if err != nil && (!errors.Is(err, ton.ErrTxWasNotFound) || errors.Is(err, ton.ErrArchiveNodeNeeded)) {
+		return false, fmt.Errorf("failed to find transaction: %w", err)
+	}
+
+	now := timeNow()
+	switch {
+	case tx != nil:
+		txErr := checkTransferTx(tx, expectedOutMessages(q.wallet, len(batch)))
+		for _, rec := range batch {
+			rec.State = TransferStateConfirmed
+			rec.TxHash = tx.Hash
+			rec.TxLT = tx.LT
+			if txErr != nil {
+				// seqno or query id is already used, so message cannot be sent again,
+				// it is not known which transfers were skipped, so all are failed to be resolved manually
+				rec.State = TransferStateFailed
+				rec.Error = txErr.Error()
+			}
+		}
+		return true, q.finishBatch(ctx, inMsgHash, batch)
+	case now.After(time.Unix(head.ExpireAt, 0).Add(q.config.ExpirationGrace)):
+		ext, err := q.loadExternalMessage(ctx, inMsgHash)
+		if err != nil {
+			return false, err
+		}
+
+		status := expiredMaybeProcessed
+		if q.config.Guarantee == DeliveryAtLeastOnce {
+			if status, err = q.checkExpired(ctx, ext); err != nil {
+				return false, fmt.Errorf("failed to check expired message: %w", err)
+			}
+			if status == expiredNotYet {
+				// block time is behind, message can still be processed
+				return false, nil
+			}
+		}
+
+		for _, rec := range batch {
+			switch {
+			case status == expiredNotProcessed:
+				rec.State = TransferStatePending
+				rec.InMsgHash = nil
+				rec.ExpireAt = 0
+			case q.config.Guarantee == DeliveryAtMostOnce:
+				rec.State = TransferStateFailed
+				rec.Error = "message expired and transaction was not found"
+			default:
+				rec.State = TransferStateFailed
+				rec.Error = "message expired, transaction was not found, but wallet state shows that message may be processed"
+			}
+		}
+		return true, q.finishBatch(ctx, inMsgHash, batch)
+	case now.Unix() > head.ExpireAt:
+		// expired, waiting for grace period, no sense to rebroadcast
+		return false, nil
+	case now.Sub(time.Unix(head.SentAt, 0)) >= q.config.RebroadcastInterval:
+		ext, err := q.loadExternalMessage(ctx, inMsgHash)
+		if err != nil {
+			return false, err
+		}
+
+		if err = q.wallet.api.SendExternalMessage(ctx, ext); err != nil {
+			log.Println("[WARNING] failed to rebroadcast external message", hex.EncodeToString(inMsgHash), ":", err.Error())
+			return false, nil
+		}
+
+		for _, rec := range batch {
+			rec.SentAt = now.Unix()
+		}
+		if err = q.store.SaveTransfers(ctx, batch); err != nil {
+			return false, fmt.Errorf("failed to save transfers: %w", err)
+		}
+	}
+	return false, nil
+}
+
+// finishBatch - saves transfers which are not waiting for the message anymore, and removes the message
+func (q *TransferQueue) finishBatch(ctx context.Context, inMsgHash []byte, batch []*TransferRecord) error {
+	if err := q.store.SaveTransfers(ctx, batch); err != nil {
+		return fmt.Errorf("failed to save transfers: %w", err)
+	}
+
+	if err := q.store.RemoveExternalMessage(ctx, inMsgHash); err != nil {
+		log.Println("[WARNING] failed to remove external message", hex.EncodeToString(inMsgHash), ":", err.Error())
+	}
+	return nil
+}
+
+func (q *TransferQueue) loadExternalMessage(ctx context.Context, inMsgHash []byte) (*tlb.ExternalMessage, error) {
+	boc, err := q.store.GetExternalMessage(ctx, inMsgHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get external message: %w", err)
+	}
+
+	c, err := cell.FromBOC(boc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse external message: %w", err)
+	}
+
+	var ext tlb.ExternalMessage
+	if err = tlb.LoadFromCell(&ext, c.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse external message: %w", err)
+	}
+	return &ext, nil
+}
+
+type expiredStatus int
+
+const (
+	// expiredNotYet - message is still valid by the time of the last block
+	expiredNotYet expiredStatus = iota
+	// expiredNotProcessed - message is not valid anymore, and wallet state proves it was not processed
+	expiredNotProcessed
+	// expiredMaybeProcessed - message is not valid anymore, but it cannot be proven that it was not processed
+	expiredMaybeProcessed
+)
+
+// checkExpired - checks expired message, which transaction was not found, using the wallet state.
+// Expiration is checked by the time of the last block, because contract checks it by the block time,
+// which is behind the local time.
+func (q *TransferQueue) checkExpired(ctx context.Context, ext *tlb.ExternalMessage) (expiredStatus, error) {
+	w := q.wallet
+
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block: %w", err)
+	}
+	api := w.api.WaitForBlock(block.SeqNo)
+
+	utime, err := blockTime(ctx, api, block)
+	if err != nil {
+		return 0, err
+	}
+
+	switch s := w.spec.(type) {
+	case *SpecHighloadV3:
+		queryID, createdAt, err := ParseHighloadV3QueryID(ext.Body)
+		if err != nil {
+			return 0, err
+		}
+
+		// contract accepts message only when created_at > now - timeout
+		validUntil := createdAt + int64(s.config.MessageTTL)
+		if utime < validUntil {
+			return expiredNotYet, nil
+		}
+
+		processed, err := runIntGetMethod(ctx, api, block, w.addr, "processed?", big.NewInt(int64(queryID)), 0)
+		if err != nil {
+			return 0, err
+		}
+		if processed.Sign() != 0 {
+			return expiredMaybeProcessed, nil
+		}
+
+		// processed id is removed only on the second cleanup after processing, which happens later than
+		// processing time + timeout, so when last cleanup was before valid until time, id cannot be removed yet
+		lastClean, err := runIntGetMethod(ctx, api, block, w.addr, "get_last_clean_time")
+		if err != nil {
+			return 0, err
+		}
+		if lastClean.Int64() > validUntil {
+			return expiredMaybeProcessed, nil
+		}
+		return expiredNotProcessed, nil
+	case *SpecHighloadV2R2:
+		sl := ext.Body.BeginParse()
+		// signature and subwallet id
+		if _, err = sl.LoadSlice(512 + 32); err != nil {
+			return 0, fmt.Errorf("failed to parse message: %w", err)
+		}
+
+		queryID, err := sl.LoadUInt(64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse query id: %w", err)
+		}
+
+		// upper 32 bits of query id is valid until time
+		if utime <= int64(queryID>>32) {
+			return expiredNotYet, nil
+		}
+
+		// returns 0 only when query is not processed and it is newer than the last cleanup
+		processed, err := runIntGetMethod(ctx, api, block, w.addr, "processed?", new(big.Int).SetUint64(queryID))
+		if err != nil {
+			return 0, err
+		}
+		if processed.Sign() != 0 {
+			return expiredMaybeProcessed, nil
+		}
+		return expiredNotProcessed, nil
+	default:
+		seqno, validUntil, err := parseSeqnoMessage(s, ext.Body)
+		if err != nil {
+			return 0, err
+		}
+
+		if utime <= validUntil {
+			return expiredNotYet, nil
+		}
+
+		acc, err := api.GetAccount(ctx, block, w.addr)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get account state: %w", err)
+		}
+		if !acc.IsActive || acc.State.Status != tlb.AccountStatusActive {
+			// not deployed, so nothing was processed
+			return expiredNotProcessed, nil
+		}
+
+		current, err := runIntGetMethod(ctx, api, block, w.addr, "seqno")
+		if err != nil {
+			return 0, err
+		}
+
+		// seqno is increased by each accepted message, so when it is still not greater,
+		// message was not accepted, and it cannot be anymore
+		if current.Cmp(new(big.Int).SetUint64(uint64(seqno))) > 0 {
+			return expiredMaybeProcessed, nil
+		}
+		return expiredNotProcessed, nil
+	}
+}
+
+// parseSeqnoMessage - returns seqno and valid until time from the external message body of seqno based wallet
+func parseSeqnoMessage(spec any, body *cell.Cell) (uint32, int64, error) {
+	var skip uint
+	switch spec.(type) {
+	case *SpecV3, *SpecV4R2:
+		// signature and subwallet id
+		skip = 512 + 32
+	case *SpecV5R1Final:
+		// op and wallet id
+		skip = 32 + 32
+	case *SpecV5R1Beta:
+		// op, network global id, workchain, version and subwallet id
+		skip = 32 + 32 + 8 + 8 + 32
+	default:
+		return 0, 0, fmt.Errorf("seqno of %T wallet message cannot be parsed", spec)
+	}
+
+	sl := body.BeginParse()
+	if _, err := sl.LoadSlice(skip); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	validUntil, err := sl.LoadUInt(32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse valid until: %w", err)
+	}
+
+	seqno, err := sl.LoadUInt(32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse seqno: %w", err)
+	}
+	return uint32(seqno), int64(validUntil), nil
+}
+
+func runIntGetMethod(ctx context.Context, api ton.APIClientWrapped, block *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*big.Int, error) {
+	res, err := api.RunGetMethod(ctx, block, addr, method, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s method: %w", method, err)
+	}
+
+	val, err := res.Int(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s result: %w", method, err)
+	}
+	return val, nil
+}
+
+// blockTime - returns generation time of the block, contracts check expiration by it
+func blockTime(ctx context.Context, api ton.APIClientWrapped, block *ton.BlockIDExt) (int64, error) {
+	if ext, ok := api.(ton.APIClientExtended); ok {
+		header, err := ext.GetBlockHeader(ctx, block)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get block header: %w", err)
+		}
+		return int64(header.GenUtime), nil
+	}
+
+	data, err := api.GetBlockData(ctx, block)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block data: %w", err)
+	}
+	return int64(data.BlockInfo.GenUtime), nil
+}
+
+// expectedOutMessages - number of messages created by transaction of external message with n transfers,
+// highload v3 sends one message always, many transfers are packed and sent to the wallet itself
+func expectedOutMessages(w *Wallet, n int) int {
+	if _, ok := w.spec.(*SpecHighloadV3); ok {
+		return 1
+	}
+	return n
+}
+
+// checkTransferTx - verifies that wallet transaction sent all messages
+func checkTransferTx(tx *tlb.Transaction, outMessages int) error {
+	desc, ok := tx.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if !ok {
+		return fmt.Errorf("unexpected transaction type %T", tx.Description.Description)
+	}
+	if desc.Aborted {
+		return fmt.Errorf("transaction aborted")
+	}
+
+	if vm, ok := desc.ComputePhase.Phase.(tlb.ComputePhaseVM); ok && !vm.Success {
+		return fmt.Errorf("compute phase failed with exit code %d", vm.Details.ExitCode)
+	}
+
+	if desc.ActionPhase == nil {
+		return fmt.Errorf("no action phase")
+	}
+	if !desc.ActionPhase.Success {
+		return fmt.Errorf("action phase failed with result code %d", desc.ActionPhase.ResultCode)
+	}
+	if desc.ActionPhase.SkippedActions > 0 {
+		return fmt.Errorf("%d actions skipped", desc.ActionPhase.SkippedActions)
+	}
+	if int(tx.OutMsgCount) != outMessages {
+		return fmt.Errorf("%d messages sent, expected %d", tx.OutMsgCount, outMessages)
+	}
+	return nil
+}
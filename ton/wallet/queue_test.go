@@ -0,0 +1,309 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+func queueTestTx(hash []byte, outMsgs uint16, success bool) *tlb.Transaction {
+	return &tlb.Transaction{
+		Hash:        hash,
+		LT:          777,
+		OutMsgCount: outMsgs,
+		Description: tlb.TransactionDescription{
+			Description: tlb.TransactionDescriptionOrdinary{
+				ComputePhase: tlb.ComputePhase{Phase: tlb.ComputePhaseVM{Success: true}},
+				ActionPhase:  &tlb.ActionPhase{Success: success, ResultCode: 37, MessagesCreated: outMsgs},
+			},
+		},
+	}
+}
+
+func queueTestBlock(utime time.Time) *tlb.Block {
+	var b tlb.Block
+	b.BlockInfo.GenUtime = uint32(utime.Unix())
+	return &b
+}
+
+func TestTransferQueue(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	timeNow = func() time.Time {
+		return now
+	}
+
+	var seqno int64
+	var sent []*tlb.ExternalMessage
+	confirmed := map[string]uint16{}
+
+	m := &MockAPI{
+		getBlockInfo: func(ctx context.Context) (*ton.BlockIDExt, error) {
+			return &ton.BlockIDExt{}, nil
+		},
+		getAccount: func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+			return &tlb.Account{
+				IsActive: true,
+				State: &tlb.AccountState{
+					IsValid:        true,
+					AccountStorage: tlb.AccountStorage{Status: tlb.AccountStatusActive},
+				},
+			}, nil
+		},
+		runGetMethod: func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+			return ton.NewExecutionResult([]any{big.NewInt(seqno)}), nil
+		},
+		sendExternalMessage: func(ctx context.Context, msg *tlb.ExternalMessage) error {
+			sent = append(sent, msg)
+			return nil
+		},
+		findTxByInMsgHash: func(ctx context.Context, addr *address.Address, msgHash []byte, maxTxNumToScan ...int) (*tlb.Transaction, error) {
+			if n, ok := confirmed[string(msgHash)]; ok {
+				return queueTestTx(msgHash, n, true), nil
+			}
+			return nil, ton.ErrTxWasNotFound
+		},
+		getBlockData: func(ctx context.Context, block *ton.BlockIDExt) (*tlb.Block, error) {
+			return queueTestBlock(now), nil
+		},
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	w, err := FromPrivateKey(m, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewMemoryTransferStore()
+	q := NewTransferQueue(w, store, TransferQueueConfig{})
+	ctx := context.Background()
+
+	for i := 0; i < 6; i++ {
+		if err = q.Enqueue(ctx, fmt.Sprint("t", i), &Message{
+			Mode: PayGasSeparately,
+			InternalMessage: &tlb.InternalMessage{
+				DstAddr: w.WalletAddress(),
+				Amount:  tlb.MustFromTON("0.1"),
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = q.Enqueue(ctx, "t0", &Message{InternalMessage: &tlb.InternalMessage{DstAddr: w.WalletAddress()}}); err != ErrTransferExists {
+		t.Fatal("duplicate should be rejected", err)
+	}
+
+	checkStates := func(exp map[string]TransferState) {
+		t.Helper()
+		for id, st := range exp {
+			rec, err := q.Status(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rec.State != st {
+				t.Fatal("incorrect state of", id, rec.State, "expected", st)
+			}
+		}
+	}
+
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatal("batch should be sent", len(sent))
+	}
+	if len(store.messages) != 1 {
+		t.Fatal("external message should be saved once for batch", len(store.messages))
+	}
+	checkStates(map[string]TransferState{"t0": TransferStateSent, "t3": TransferStateSent, "t4": TransferStatePending})
+
+	// next batch should wait for previous seqno, message is not rebroadcasted before interval
+	now = now.Add(5 * time.Second)
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatal("nothing should be sent", len(sent))
+	}
+
+	now = now.Add(15 * time.Second)
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || !bytes.Equal(sent[0].Body.Hash(), sent[1].Body.Hash()) {
+		t.Fatal("same message should be rebroadcasted", len(sent))
+	}
+
+	confirmed[string(sent[0].Body.Hash())] = 4
+	seqno++
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 3 || bytes.Equal(sent[2].Body.Hash(), sent[0].Body.Hash()) {
+		t.Fatal("next batch should be sent", len(sent))
+	}
+	if _, ok := store.messages[string(sent[0].Body.Hash())]; ok || len(store.messages) != 1 {
+		t.Fatal("message of confirmed batch should be removed")
+	}
+	checkStates(map[string]TransferState{"t0": TransferStateConfirmed, "t3": TransferStateConfirmed, "t4": TransferStateSent, "t5": TransferStateSent})
+
+	if rec, _ := q.Status(ctx, "t1"); rec.TxLT != 777 || rec.Attempts != 1 {
+		t.Fatal("incorrect confirmed record", rec.TxLT, rec.Attempts)
+	}
+
+	// expired and not found, should be built again with new seqno after grace period
+	now = now.Add(3*time.Minute + 30*time.Second)
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 3 {
+		t.Fatal("expired message should not be rebroadcasted", len(sent))
+	}
+	checkStates(map[string]TransferState{"t4": TransferStateSent})
+
+	now = now.Add(time.Minute)
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 4 || bytes.Equal(sent[3].Body.Hash(), sent[2].Body.Hash()) {
+		t.Fatal("expired transfers should be sent in new message", len(sent))
+	}
+	if rec, _ := q.Status(ctx, "t4"); rec.State != TransferStateSent || rec.Attempts != 2 {
+		t.Fatal("incorrect resent record", rec.State, rec.Attempts)
+	}
+
+	// at most once gives up on expired messages
+	q.config.Guarantee = DeliveryAtMostOnce
+	now = now.Add(5 * time.Minute)
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkStates(map[string]TransferState{"t4": TransferStateFailed, "t5": TransferStateFailed})
+}
+
+func TestCheckTransferTx(t *testing.T) {
+	if err := checkTransferTx(queueTestTx(nil, 3, true), 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkTransferTx(queueTestTx(nil, 3, false), 3); err == nil {
+		t.Fatal("failed action phase should be rejected")
+	}
+	if err := checkTransferTx(queueTestTx(nil, 1, true), 3); err == nil {
+		t.Fatal("not all messages sent should be rejected")
+	}
+
+	tx := queueTestTx(nil, 3, true)
+	tx.Description.Description.(tlb.TransactionDescriptionOrdinary).ActionPhase.SkippedActions = 1
+	if err := checkTransferTx(tx, 3); err == nil {
+		t.Fatal("skipped actions should be rejected")
+	}
+	if err := checkTransferTx(&tlb.Transaction{Description: tlb.TransactionDescription{Description: tlb.TransactionDescriptionTickTock{}}}, 0); err == nil {
+		t.Fatal("not ordinary transaction should be rejected")
+	}
+}
+
+func TestTransferQueue_NotFoundTransaction(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	timeNow = func() time.Time {
+		return now
+	}
+
+	var seqno int64
+	var sent []*tlb.ExternalMessage
+	var findErr error
+	m := &MockAPI{
+		getBlockInfo: func(ctx context.Context) (*ton.BlockIDExt, error) {
+			return &ton.BlockIDExt{}, nil
+		},
+		getAccount: func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+			return &tlb.Account{
+				IsActive: true,
+				State: &tlb.AccountState{
+					IsValid:        true,
+					AccountStorage: tlb.AccountStorage{Status: tlb.AccountStatusActive},
+				},
+			}, nil
+		},
+		runGetMethod: func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+			return ton.NewExecutionResult([]any{big.NewInt(seqno)}), nil
+		},
+		sendExternalMessage: func(ctx context.Context, msg *tlb.ExternalMessage) error {
+			sent = append(sent, msg)
+			return nil
+		},
+		findTxByInMsgHash: func(ctx context.Context, addr *address.Address, msgHash []byte, maxTxNumToScan ...int) (*tlb.Transaction, error) {
+			return nil, findErr
+		},
+		getBlockData: func(ctx context.Context, block *ton.BlockIDExt) (*tlb.Block, error) {
+			// block time is behind the local time
+			return queueTestBlock(now.Add(-2 * time.Minute)), nil
+		},
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	w, err := FromPrivateKey(m, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewMemoryTransferStore()
+	q := NewTransferQueue(w, store, TransferQueueConfig{MaxTxScan: 15})
+	ctx := context.Background()
+
+	if err = q.Enqueue(ctx, "t0", &Message{
+		Mode:            PayGasSeparately,
+		InternalMessage: &tlb.InternalMessage{DstAddr: w.WalletAddress(), Amount: tlb.MustFromTON("0.1")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// transaction exists but it is deeper than MaxTxScan, so it is not found
+	findErr = fmt.Errorf("scan limit of 15 transactions was reached: %w", ton.ErrTxWasNotFound)
+	seqno = 1
+
+	now = now.Add(4*time.Minute + 30*time.Second)
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if rec, _ := q.Status(ctx, "t0"); rec.State != TransferStateSent {
+		t.Fatal("message is valid by the block time, transfer should wait", rec.State)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatal("transfer should not be sent again when seqno is used", len(sent))
+	}
+	rec, _ := q.Status(ctx, "t0")
+	if rec.State != TransferStateFailed || rec.Error == "" {
+		t.Fatal("transfer with not proven delivery should be failed", rec.State, rec.Error)
+	}
+
+	// archive error cannot be resolved by the wallet state
+	if err = q.Enqueue(ctx, "t1", &Message{
+		Mode:            PayGasSeparately,
+		InternalMessage: &tlb.InternalMessage{DstAddr: w.WalletAddress(), Amount: tlb.MustFromTON("0.1")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err = q.Process(ctx); err != nil {
+		t.Fatal(err)
+	}
+	findErr = ton.ErrArchiveNodeNeeded
+	if err = q.Process(ctx); !errors.Is(err, ton.ErrArchiveNodeNeeded) {
+		t.Fatal("archive error should be returned", err)
+	}
+}
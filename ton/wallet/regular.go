@@ -24,6 +24,10 @@ func (s *SpecRegular) SetMessagesTTL(ttl uint32) {
 	s.messagesTTL = ttl
 }
 
+func (s *SpecRegular) getMessagesTTL() uint32 {
+	return s.messagesTTL
+}
+
 type SpecSeqno struct {
 	// Instead of calling contract 'seqno' method,
 	// this function wil be used (if not nil) to get seqno for new transaction.
@@ -0,0 +1,59 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrNoPrivateKey = errors.New("wallet has no private key, it uses external signer")
+
+// Signer - signs hashes of wallet messages, so the private key can be kept outside of the process,
+// for example in HSM, cloud KMS or in a separate signing service
+type Signer interface {
+	// PublicKey - ed25519 public key of the wallet
+	PublicKey() ed25519.PublicKey
+	// Sign - returns ed25519 signature of the cell hash
+	Sign(ctx context.Context, cellHash []byte) ([]byte, error)
+}
+
+// InMemorySigner - signs with the private key stored in memory, used by FromPrivateKey
+type InMemorySigner struct {
+	key ed25519.PrivateKey
+}
+
+func NewInMemorySigner(key ed25519.PrivateKey) *InMemorySigner {
+	return &InMemorySigner{key: key}
+}
+
+func (s *InMemorySigner) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *InMemorySigner) Sign(_ context.Context, cellHash []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, cellHash), nil
+}
+
+// sign - signs cell hash with the wallet signer and checks the result,
+// to not send message which will be rejected by contract because of the broken remote signer
+func (w *Wallet) sign(ctx context.Context, c *cell.Cell) ([]byte, error) {
+	hash := c.Hash()
+
+	if _, ok := w.signer.(estimationSigner); ok {
+		// zero signature is used only to calculate message size
+		return w.signer.Sign(ctx, hash)
+	}
+
+	signature, err := w.signer.Sign(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+
+	if len(signature) != ed25519.SignatureSize || !ed25519.Verify(w.signer.PublicKey(), hash, signature) {
+		return nil, fmt.Errorf("signer returned invalid signature")
+	}
+	return signature, nil
+}
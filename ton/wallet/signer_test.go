@@ -0,0 +1,81 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+type remoteSigner struct {
+	key    ed25519.PrivateKey
+	calls  int
+	broken bool
+}
+
+func (r *remoteSigner) PublicKey() ed25519.PublicKey {
+	return r.key.Public().(ed25519.PublicKey)
+}
+
+func (r *remoteSigner) Sign(ctx context.Context, cellHash []byte) ([]byte, error) {
+	r.calls++
+	if r.broken {
+		return make([]byte, ed25519.SignatureSize), nil
+	}
+	return ed25519.Sign(r.key, cellHash), nil
+}
+
+func TestFromSigner(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	signer := &remoteSigner{key: pkey}
+
+	w, err := FromSigner(&MockAPI{}, signer, HighloadV2R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wKey, err := FromPrivateKey(&MockAPI{}, pkey, HighloadV2R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !w.WalletAddress().Equals(wKey.WalletAddress()) {
+		t.Fatal("address should be the same as for private key")
+	}
+	if w.PrivateKey() != nil {
+		t.Fatal("private key should not be available")
+	}
+
+	msg := &Message{
+		Mode: 1,
+		InternalMessage: &tlb.InternalMessage{
+			DstAddr: w.WalletAddress(),
+			Amount:  tlb.MustFromTON("0.1"),
+		},
+	}
+
+	body, err := w.GetSpec().(*SpecHighloadV2R2).BuildMessage(context.Background(), []*Message{msg})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := body.BeginParse()
+	sign := s.MustLoadSlice(512)
+	if !ed25519.Verify(signer.PublicKey(), s.MustToCell().Hash(), sign) {
+		t.Fatal("sign incorrect")
+	}
+	if signer.calls != 1 {
+		t.Fatal("signer should be called once")
+	}
+
+	signer.broken = true
+	if _, err = w.GetSpec().(*SpecHighloadV2R2).BuildMessage(context.Background(), []*Message{msg}); err == nil {
+		t.Fatal("invalid signature should be rejected")
+	}
+
+	if _, err = w.BuildTransferEncrypted(context.Background(), w.WalletAddress(), tlb.MustFromTON("0.1"), false, "secret"); !errors.Is(err, ErrNoPrivateKey) {
+		t.Fatal("encrypted comment should require private key, got", err)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
@@ -66,29 +65,7 @@ func (s *SpecV5R1Final) BuildMessage(ctx context.Context, _ bool, _ *ton.BlockID
 		return nil, fmt.Errorf("failed to fetch seqno: %w", err)
 	}
 
-	actions, err := packV5Actions(messages)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build actions: %w", err)
-	}
-
-	walletId := V5R1ID{
-		NetworkGlobalID: s.config.NetworkGlobalID,
-		WorkChain:       s.config.Workchain,
-		SubwalletNumber: uint16(s.wallet.subwallet),
-		WalletVersion:   0,
-	}
-
-	payload := cell.BeginCell().
-		MustStoreUInt(0x7369676e, 32).                                                                    // external sign op code
-		MustStoreUInt(uint64(walletId.Serialized()), 32).                                                 // serialized WalletId
-		MustStoreUInt(uint64(time.Now().Add(time.Duration(s.messagesTTL)*time.Second).UTC().Unix()), 32). // validUntil
-		MustStoreUInt(uint64(seq), 32).                                                                   // seq (block)
-		MustStoreBuilder(actions)                                                                         // Action list
-
-	sign := payload.EndCell().Sign(s.wallet.key)
-	msg := cell.BeginCell().MustStoreBuilder(payload).MustStoreSlice(sign, 512).EndCell()
-
-	return msg, nil
+	return s.buildSignedRequest(ctx, _V5OpExternalSigned, seq, messages, nil)
 }
 
 // Validate messages
@@ -104,8 +81,8 @@ func validateMessageFields(messages []*Message) error {
 	return nil
 }
 
-// Pack Actions
-func packV5Actions(messages []*Message) (*cell.Builder, error) {
+// Pack OutList of action_send_msg
+func packV5OutList(messages []*Message) (*cell.Cell, error) {
 	if err := validateMessageFields(messages); err != nil {
 		return nil, err
 	}
@@ -130,7 +107,5 @@ func packV5Actions(messages []*Message) (*cell.Builder, error) {
 
 		list = cell.BeginCell().MustStoreRef(list).MustStoreBuilder(msg).EndCell()
 	}
-
-	// Ensure the action list ends with 0, 1 as per the new specification
-	return cell.BeginCell().MustStoreUInt(1, 1).MustStoreRef(list).MustStoreUInt(0, 1), nil
+	return list, nil
 }
@@ -0,0 +1,194 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	_V5OpExternalSigned = 0x7369676e // "sign"
+	_V5OpInternalSigned = 0x73696e74 // "sint"
+	_V5OpExtension      = 0x6578746e // "extn"
+)
+
+// V5R1Data - parsed persistent data of wallet v5r1
+type V5R1Data struct {
+	SignatureAllowed bool             `tlb:"bool"`
+	Seqno            uint32           `tlb:"## 32"`
+	WalletID         uint32           `tlb:"## 32"`
+	PublicKey        []byte           `tlb:"bits 256"`
+	Extensions       *cell.Dictionary `tlb:"dict 256"`
+}
+
+// ExtensionAddresses - returns addresses of the installed extensions,
+// dictionary keeps only hashes, extensions are always in the wallet's workchain
+func (d *V5R1Data) ExtensionAddresses(workchain int32) ([]*address.Address, error) {
+	if d.Extensions == nil {
+		return nil, nil
+	}
+
+	kvs, err := d.Extensions.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extensions dict: %w", err)
+	}
+
+	list := make([]*address.Address, 0, len(kvs))
+	for _, kv := range kvs {
+		hash, err := kv.Key.LoadSlice(256)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load extension hash: %w", err)
+		}
+		list = append(list, address.NewAddress(0, byte(workchain), hash))
+	}
+	return list, nil
+}
+
+// ParseV5R1Data - parses data cell of wallet v5r1 account
+func ParseV5R1Data(data *cell.Cell) (*V5R1Data, error) {
+	if data == nil {
+		return nil, fmt.Errorf("data is nil")
+	}
+
+	var d V5R1Data
+	if err := tlb.LoadFromCell(&d, data.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse v5r1 data: %w", err)
+	}
+	return &d, nil
+}
+
+// GetData - fetches and parses current wallet data, it includes extensions and signature mode
+func (s *SpecV5R1Final) GetData(ctx context.Context) (*V5R1Data, error) {
+	block, err := s.wallet.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	acc, err := s.wallet.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, s.wallet.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	if !acc.IsActive || acc.State.Status != tlb.AccountStatusActive {
+		// not deployed wallet has signature enabled and no extensions
+		return &V5R1Data{
+			SignatureAllowed: true,
+			WalletID:         s.walletID().Serialized(),
+			PublicKey:        s.wallet.signer.PublicKey(),
+		}, nil
+	}
+	return ParseV5R1Data(acc.Data)
+}
+
+// GetExtensions - returns addresses of the installed extensions
+func (s *SpecV5R1Final) GetExtensions(ctx context.Context) ([]*address.Address, error) {
+	data, err := s.GetData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return data.ExtensionAddresses(s.wallet.addr.Workchain())
+}
+
+// BuildMessageWithActions - builds signed external message body with messages to send and extended actions.
+// Note that ActionSetSignatureAuthAllowed is accepted by contract only from extension, use BuildV5ExtensionRequest for it.
+func (s *SpecV5R1Final) BuildMessageWithActions(ctx context.Context, messages []*Message, actions []tlb.ExtendedAction) (*cell.Cell, error) {
+	seq, err := s.seqnoFetcher(ctx, s.wallet.subwallet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch seqno: %w", err)
+	}
+	return s.buildSignedRequest(ctx, _V5OpExternalSigned, seq, messages, actions)
+}
+
+// BuildInternalSignedMessage - builds body of the internal message signed by the wallet key (gasless transfer).
+// Any relayer can deliver it to the wallet address in the internal message and pay for the gas,
+// seqno should be passed explicitly, because the message may be delivered later.
+func (s *SpecV5R1Final) BuildInternalSignedMessage(ctx context.Context, seqno uint32, messages []*Message, actions []tlb.ExtendedAction) (*cell.Cell, error) {
+	return s.buildSignedRequest(ctx, _V5OpInternalSigned, seqno, messages, actions)
+}
+
+// BuildInternalSignedTransfer - wraps internal signed body into the message for the relayer,
+// amount is attached to pay the wallet gas, rest is returned to relayer as the bounce is enabled
+func (s *SpecV5R1Final) BuildInternalSignedTransfer(body *cell.Cell, amount tlb.Coins) *Message {
+	return &Message{
+		Mode: PayGasSeparately + IgnoreErrors,
+		InternalMessage: &tlb.InternalMessage{
+			IHRDisabled: true,
+			Bounce:      true,
+			DstAddr:     s.wallet.addr,
+			Amount:      amount,
+			Body:        body,
+		},
+	}
+}
+
+// BuildV5ExtensionRequest - builds body of the request which installed extension sends to the wallet,
+// it is not signed, wallet checks that sender is in the extensions list
+func BuildV5ExtensionRequest(queryID uint64, messages []*Message, actions []tlb.ExtendedAction) (*cell.Cell, error) {
+	inner, err := packV5InnerRequest(messages, actions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build actions: %w", err)
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(_V5OpExtension, 32).
+		MustStoreUInt(queryID, 64).
+		MustStoreBuilder(inner).
+		EndCell(), nil
+}
+
+func (s *SpecV5R1Final) walletID() V5R1ID {
+	return V5R1ID{
+		NetworkGlobalID: s.config.NetworkGlobalID,
+		WorkChain:       s.config.Workchain,
+		SubwalletNumber: uint16(s.wallet.subwallet),
+		WalletVersion:   0,
+	}
+}
+
+func (s *SpecV5R1Final) buildSignedRequest(ctx context.Context, op uint32, seqno uint32, messages []*Message, actions []tlb.ExtendedAction) (*cell.Cell, error) {
+	inner, err := packV5InnerRequest(messages, actions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build actions: %w", err)
+	}
+
+	payload := cell.BeginCell().
+		MustStoreUInt(uint64(op), 32).
+		MustStoreUInt(uint64(s.walletID().Serialized()), 32).
+		MustStoreUInt(uint64(timeNow().Add(time.Duration(s.messagesTTL)*time.Second).UTC().Unix()), 32). // valid until
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreBuilder(inner)
+
+	sign, err := s.wallet.sign(ctx, payload.EndCell())
+	if err != nil {
+		return nil, err
+	}
+	return cell.BeginCell().MustStoreBuilder(payload).MustStoreSlice(sign, 512).EndCell(), nil
+}
+
+// packV5InnerRequest - serializes out actions and extended actions:
+// out_actions:(Maybe ^OutList) has_other_actions:(## 1) other_actions:ActionList
+func packV5InnerRequest(messages []*Message, actions []tlb.ExtendedAction) (*cell.Builder, error) {
+	var list *cell.Cell
+	if len(messages) > 0 || len(actions) == 0 {
+		var err error
+		if list, err = packV5OutList(messages); err != nil {
+			return nil, err
+		}
+	}
+
+	b := cell.BeginCell().MustStoreMaybeRef(list)
+	if len(actions) == 0 {
+		return b.MustStoreBoolBit(false), nil
+	}
+
+	// first action is stored inline, next ones are linked by refs
+	head, err := tlb.ExtendedActionsToCell(actions)
+	if err != nil {
+		return nil, err
+	}
+	return b.MustStoreBoolBit(true).MustStoreBuilder(head.ToBuilder()), nil
+}
@@ -0,0 +1,154 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestBuildV5ExtensionRequest(t *testing.T) {
+	ext := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+
+	body, err := BuildV5ExtensionRequest(7, nil, []tlb.ExtendedAction{
+		{Action: tlb.ActionAddExtension{Addr: ext}},
+		{Action: tlb.ActionSetSignatureAuthAllowed{Allowed: false}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := body.BeginParse()
+	if s.MustLoadUInt(32) != _V5OpExtension || s.MustLoadUInt(64) != 7 {
+		t.Fatal("incorrect header")
+	}
+	if s.MustLoadMaybeRef() != nil {
+		t.Fatal("out actions should be empty")
+	}
+	if !s.MustLoadBoolBit() {
+		t.Fatal("has other actions should be set")
+	}
+
+	actions, err := tlb.LoadExtendedActions(s.MustToCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 2 {
+		t.Fatal("incorrect actions num", len(actions))
+	}
+	if a, ok := actions[0].Action.(tlb.ActionAddExtension); !ok || !a.Addr.Equals(ext) {
+		t.Fatal("incorrect add extension action")
+	}
+	if a, ok := actions[1].Action.(tlb.ActionSetSignatureAuthAllowed); !ok || a.Allowed {
+		t.Fatal("incorrect set signature action")
+	}
+}
+
+func TestSpecV5R1Final_BuildInternalSignedMessage(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	w, err := FromPrivateKey(&MockAPI{}, pkey, ConfigV5R1Final{NetworkGlobalID: MainnetGlobalID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := w.GetSpec().(*SpecV5R1Final)
+
+	msg := &Message{
+		Mode: PayGasSeparately + IgnoreErrors,
+		InternalMessage: &tlb.InternalMessage{
+			DstAddr: w.WalletAddress(),
+			Amount:  tlb.MustFromTON("0.1"),
+		},
+	}
+	ext := address.NewAddress(0, 0, make([]byte, 32))
+
+	body, err := spec.BuildInternalSignedMessage(context.Background(), 5, []*Message{msg}, []tlb.ExtendedAction{
+		{Action: tlb.ActionDeleteExtension{Addr: ext}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := body.BeginParse()
+	payload := cell.BeginCell()
+	if s.MustLoadUInt(32) != _V5OpInternalSigned {
+		t.Fatal("incorrect op")
+	}
+	if s.MustLoadUInt(32) != uint64(spec.walletID().Serialized()) {
+		t.Fatal("incorrect wallet id")
+	}
+	if s.MustLoadUInt(32) != uint64(timeNow().Add(3*time.Minute).Unix()) {
+		t.Fatal("incorrect valid until")
+	}
+	if s.MustLoadUInt(32) != 5 {
+		t.Fatal("incorrect seqno")
+	}
+
+	list, err := tlb.LoadOutList(s.MustLoadMaybeRef().MustToCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatal("incorrect out actions")
+	}
+	if !s.MustLoadBoolBit() {
+		t.Fatal("has other actions should be set")
+	}
+	if s.MustLoadUInt(8) != 0x03 || !s.MustLoadAddr().Equals(ext) {
+		t.Fatal("incorrect delete extension action")
+	}
+
+	// signature is the last 512 bits of the body
+	bs := body.BeginParse()
+	payload.MustStoreSlice(bs.MustLoadSlice(bs.BitsLeft()-512), body.BitsSize()-512).MustStoreRef(bs.MustLoadRef().MustToCell())
+	if !ed25519.Verify(w.PublicKey(), payload.EndCell().Hash(), bs.MustLoadSlice(512)) {
+		t.Fatal("sign incorrect")
+	}
+
+	relay := spec.BuildInternalSignedTransfer(body, tlb.MustFromTON("0.05"))
+	if !relay.InternalMessage.DstAddr.Equals(w.Address()) || relay.InternalMessage.Body != body {
+		t.Fatal("incorrect relay message")
+	}
+}
+
+func TestParseV5R1Data(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	extHash := make([]byte, 32)
+	extHash[31] = 1
+
+	dict := cell.NewDict(256)
+	if err := dict.Set(cell.BeginCell().MustStoreSlice(extHash, 256).EndCell(), cell.BeginCell().MustStoreInt(-1, 1).EndCell()); err != nil {
+		t.Fatal(err)
+	}
+
+	data := cell.BeginCell().
+		MustStoreBoolBit(false).
+		MustStoreUInt(12, 32).
+		MustStoreUInt(777, 32).
+		MustStoreSlice(pkey.Public().(ed25519.PublicKey), 256).
+		MustStoreDict(dict).
+		EndCell()
+
+	d, err := ParseV5R1Data(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.SignatureAllowed || d.Seqno != 12 || d.WalletID != 777 {
+		t.Fatal("incorrect data")
+	}
+
+	list, err := d.ExtensionAddresses(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Workchain() != -1 || list[0].Data()[31] != 1 {
+		t.Fatal("incorrect extensions", list)
+	}
+}
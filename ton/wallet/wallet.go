@@ -144,10 +144,12 @@ type Message struct {
 }
 
 type Wallet struct {
-	api  TonAPI
-	key  ed25519.PrivateKey
-	addr *address.Address
-	ver  VersionConfig
+	api TonAPI
+	// key - nil when wallet is created with external signer
+	key    ed25519.PrivateKey
+	signer Signer
+	addr   *address.Address
+	ver    VersionConfig
 
 	// Can be used to operate multiple wallets with the same key and version.
 	// use GetSubwallet if you need it.
@@ -158,6 +160,16 @@ type Wallet struct {
 }
 
 func FromPrivateKey(api TonAPI, key ed25519.PrivateKey, version VersionConfig) (*Wallet, error) {
+	w, err := FromSigner(api, NewInMemorySigner(key), version)
+	if err != nil {
+		return nil, err
+	}
+	w.key = key
+	return w, nil
+}
+
+// FromSigner - creates wallet which signs messages using the given signer, private key is not required
+func FromSigner(api TonAPI, signer Signer, version VersionConfig) (*Wallet, error) {
 	var subwallet uint32 = DefaultSubwallet
 
 	// default subwallet depends on wallet type
@@ -167,14 +179,14 @@ func FromPrivateKey(api TonAPI, key ed25519.PrivateKey, version VersionConfig) (
 		subwallet = 0
 	}
 
-	addr, err := AddressFromPubKey(key.Public().(ed25519.PublicKey), version, subwallet)
+	addr, err := AddressFromPubKey(signer.PublicKey(), version, subwallet)
 	if err != nil {
 		return nil, err
 	}
 
 	w := &Wallet{
 		api:       api,
-		key:       key,
+		signer:    signer,
 		addr:      addr,
 		ver:       version,
 		subwallet: subwallet,
@@ -263,12 +275,21 @@ func (w *Wallet) WalletAddress() *address.Address {
 	return w.addr.Bounce(false)
 }
 
+// PrivateKey - returns nil when wallet is created with FromSigner
 func (w *Wallet) PrivateKey() ed25519.PrivateKey {
 	return w.key
 }
 
+func (w *Wallet) PublicKey() ed25519.PublicKey {
+	return w.signer.PublicKey()
+}
+
+func (w *Wallet) Signer() Signer {
+	return w.signer
+}
+
 func (w *Wallet) GetSubwallet(subwallet uint32) (*Wallet, error) {
-	addr, err := AddressFromPubKey(w.key.Public().(ed25519.PublicKey), w.ver, subwallet)
+	addr, err := AddressFromPubKey(w.signer.PublicKey(), w.ver, subwallet)
 	if err != nil {
 		return nil, err
 	}
@@ -276,6 +297,7 @@ func (w *Wallet) GetSubwallet(subwallet uint32) (*Wallet, error) {
 	sub := &Wallet{
 		api:       w.api,
 		key:       w.key,
+		signer:    w.signer,
 		addr:      addr,
 		ver:       w.ver,
 		subwallet: subwallet,
@@ -335,7 +357,7 @@ func (w *Wallet) BuildExternalMessageForMany(ctx context.Context, messages []*Me
 func (w *Wallet) PrepareExternalMessageForMany(ctx context.Context, withStateInit bool, messages []*Message) (_ *tlb.ExternalMessage, err error) {
 	var stateInit *tlb.StateInit
 	if withStateInit {
-		stateInit, err = GetStateInit(w.key.Public().(ed25519.PublicKey), w.ver, w.subwallet)
+		stateInit, err = GetStateInit(w.signer.PublicKey(), w.ver, w.subwallet)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get state init: %w", err)
 		}
@@ -407,6 +429,11 @@ func (w *Wallet) BuildTransfer(to *address.Address, amount tlb.Coins, bounce boo
 func (w *Wallet) BuildTransferEncrypted(ctx context.Context, to *address.Address, amount tlb.Coins, bounce bool, comment string) (_ *Message, err error) {
 	var body *cell.Cell
 	if comment != "" {
+		if w.key == nil {
+			// shared secret for encryption cannot be calculated with signature only
+			return nil, ErrNoPrivateKey
+		}
+
 		key, err := GetPublicKey(ctx, w.api, to)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get destination contract (wallet) public key")
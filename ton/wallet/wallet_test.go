@@ -25,13 +25,14 @@ type MockAPI struct {
 	sendExternalMessage func(ctx context.Context, msg *tlb.ExternalMessage) error
 	runGetMethod        func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error)
 	listTransactions    func(ctx context.Context, addr *address.Address, limit uint32, lt uint64, txHash []byte) ([]*tlb.Transaction, error)
+	findTxByInMsgHash   func(ctx context.Context, addr *address.Address, msgHash []byte, maxTxNumToScan ...int) (*tlb.Transaction, error)
+	getBlockData        func(ctx context.Context, block *ton.BlockIDExt) (*tlb.Block, error)
 
 	extMsgSent *tlb.ExternalMessage
 }
 
 func (m MockAPI) FindLastTransactionByInMsgHash(ctx context.Context, addr *address.Address, msgHash []byte, maxTxNumToScan ...int) (*tlb.Transaction, error) {
-	//TODO implement me
-	panic("implement me")
+	return m.findTxByInMsgHash(ctx, addr, msgHash, maxTxNumToScan...)
 }
 
 func (m MockAPI) FindLastTransactionByOutMsgHash(ctx context.Context, addr *address.Address, msgHash []byte, maxTxNumToScan ...int) (*tlb.Transaction, error) {
@@ -46,6 +47,7 @@ func (m MockAPI) WaitForBlock(seqno uint32) ton.APIClientWrapped {
 		MSendExternalMessage: m.sendExternalMessage,
 		MRunGetMethod:        m.runGetMethod,
 		MListTransactions:    m.listTransactions,
+		MGetBlockData:        m.getBlockData,
 	}
 }
 
@@ -0,0 +1,306 @@
+package ton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+var ErrLTNotInDB = errors.New("transaction lt is not in liteserver db")
+var ErrCursorNotInHistory = errors.New("cursor transaction is not found in account history")
+
+// TransactionCursor - position of the last processed transaction of the account
+type TransactionCursor struct {
+	LT   uint64
+	Hash []byte
+}
+
+// CheckpointStore - persistent storage for the watcher cursors, should be safe for concurrent use
+type CheckpointStore interface {
+	// LoadCursor - returns saved cursor of the address, or nil when there is no one
+	LoadCursor(ctx context.Context, addr *address.Address) (*TransactionCursor, error)
+	SaveCursor(ctx context.Context, addr *address.Address, cursor *TransactionCursor) error
+}
+
+// MemoryCheckpointStore - in-memory CheckpointStore, cursors are lost on restart
+type MemoryCheckpointStore struct {
+	cursors map[string]TransactionCursor
+	mx      sync.RWMutex
+}
+
+// WatchEvent - new transaction of the watched address, or error of its processing
+type WatchEvent struct {
+	Address     *address.Address
+	Transaction *tlb.Transaction
+
+	// Err - is set when the address cannot be processed now, for example ErrLTNotInDB
+	// when liteserver has no required history, or ErrCursorNotInHistory. Watching is continued.
+	Err error
+}
+
+// TransactionWatcher - follows transactions of the dynamic set of addresses,
+// each address has its own cursor, which is persisted in the CheckpointStore after the delivery of every transaction.
+type TransactionWatcher struct {
+	api   APIClientWrapped
+	store CheckpointStore
+
+	// PollInterval - delay between checks of the addresses state
+	PollInterval time.Duration
+	// Workers - number of addresses processed in parallel
+	Workers int
+
+	accounts map[string]*watchedAccount
+	mx       sync.RWMutex
+}
+
+type watchedAccount struct {
+	addr   *address.Address
+	from   *TransactionCursor
+	cursor *TransactionCursor
+
+	// hashes of recently delivered transactions, to not deliver them twice
+	recent     map[string]bool
+	recentList []string
+
+	errReported bool
+}
+
+const watcherRecentTxs = 64
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{
+		cursors: map[string]TransactionCursor{},
+	}
+}
+
+func (s *MemoryCheckpointStore) LoadCursor(_ context.Context, addr *address.Address) (*TransactionCursor, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	cur, ok := s.cursors[watcherKey(addr)]
+	if !ok {
+		return nil, nil
+	}
+	return &cur, nil
+}
+
+func (s *MemoryCheckpointStore) SaveCursor(_ context.Context, addr *address.Address, cursor *TransactionCursor) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.cursors[watcherKey(addr)] = TransactionCursor{
+		LT:   cursor.LT,
+		Hash: append([]byte{}, cursor.Hash...),
+	}
+	return nil
+}
+
+// NewTransactionWatcher - creates watcher, when store is nil, in-memory store is used
+func NewTransactionWatcher(api APIClientWrapped, store CheckpointStore) *TransactionWatcher {
+	if store == nil {
+		store = NewMemoryCheckpointStore()
+	}
+
+	return &TransactionWatcher{
+		api:          api,
+		store:        store,
+		PollInterval: 3 * time.Second,
+		Workers:      8,
+		accounts:     map[string]*watchedAccount{},
+	}
+}
+
+// Add - starts watching of the address. Cursor saved in the store has priority,
+// from is used only when there is no saved one, nil from means processing from the first transaction.
+func (w *TransactionWatcher) Add(addr *address.Address, from *TransactionCursor) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	key := watcherKey(addr)
+	if _, ok := w.accounts[key]; ok {
+		return
+	}
+
+	w.accounts[key] = &watchedAccount{
+		addr:   addr,
+		from:   from,
+		recent: map[string]bool{},
+	}
+}
+
+// Remove - stops watching of the address, saved cursor is kept in the store
+func (w *TransactionWatcher) Remove(addr *address.Address) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	delete(w.accounts, watcherKey(addr))
+}
+
+// Run - watches the addresses and sends events to the channel, till workerCtx is done, then channel is closed.
+// Transactions of each address are delivered in order, from old to new.
+func (w *TransactionWatcher) Run(workerCtx context.Context, channel chan<- *WatchEvent) {
+	defer func() {
+		close(channel)
+	}()
+
+	ctx := w.api.Client().StickyContext(workerCtx)
+	var lastSeqno uint32
+
+	wait := 0 * time.Second
+	for {
+		select {
+		case <-workerCtx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = w.PollInterval
+
+		reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		master, err := w.api.CurrentMasterchainInfo(reqCtx)
+		cancel()
+		if err != nil {
+			continue
+		}
+
+		// liteserver may be switched to the one which is behind, so we wait for it to reach the seen block
+		if master.SeqNo < lastSeqno {
+			continue
+		}
+		lastSeqno = master.SeqNo
+		api := w.api.WaitForBlock(master.SeqNo)
+
+		w.mx.RLock()
+		list := make([]*watchedAccount, 0, len(w.accounts))
+		for _, acc := range w.accounts {
+			list = append(list, acc)
+		}
+		w.mx.RUnlock()
+
+		workers := w.Workers
+		if workers <= 0 {
+			workers = 1
+		}
+
+		var failed bool
+		var failedMx sync.Mutex
+		var wg sync.WaitGroup
+
+		sem := make(chan struct{}, workers)
+		for _, acc := range list {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(acc *watchedAccount) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+
+				if err := w.processAccount(ctx, api, master, acc, channel); err != nil {
+					failedMx.Lock()
+					failed = true
+					failedMx.Unlock()
+				}
+			}(acc)
+		}
+		wg.Wait()
+
+		if workerCtx.Err() != nil {
+			return
+		}
+
+		if failed {
+			if nCtx, err := w.api.Client().StickyContextNextNode(ctx); err == nil {
+				ctx = nCtx
+			}
+		}
+	}
+}
+
+func (w *TransactionWatcher) processAccount(ctx context.Context, api APIClientWrapped, master *BlockIDExt, acc *watchedAccount, channel chan<- *WatchEvent) error {
+	report := func(err error) error {
+		if !acc.errReported {
+			acc.errReported = true
+			select {
+			case <-ctx.Done():
+			case channel <- &WatchEvent{Address: acc.addr, Err: err}:
+			}
+		}
+		return err
+	}
+
+	if acc.cursor == nil {
+		cur, err := w.store.LoadCursor(ctx, acc.addr)
+		if err != nil {
+			return report(fmt.Errorf("failed to load cursor: %w", err))
+		}
+
+		if cur == nil {
+			cur = acc.from
+			if cur == nil {
+				cur = &TransactionCursor{}
+			}
+		}
+		acc.cursor = cur
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	state, err := api.GetAccount(reqCtx, master, acc.addr)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	if !state.IsActive || state.LastTxLT == 0 {
+		// no transactions
+		return nil
+	}
+
+	var sinceHash []byte
+	if acc.cursor.LT > 0 {
+		sinceHash = acc.cursor.Hash
+	}
+
+	reqCtx, cancel = context.WithTimeout(ctx, 60*time.Second)
+	transactions, err := listTransactionsSince(reqCtx, api, acc.addr, state.LastTxLT, state.LastTxHash, acc.cursor.LT, sinceHash)
+	cancel()
+	if err != nil {
+		if errors.Is(err, ErrLTNotInDB) || errors.Is(err, ErrCursorNotInHistory) {
+			return report(err)
+		}
+		return err
+	}
+	acc.errReported = false
+
+	for _, tx := range transactions {
+		key := string(tx.Hash)
+		if !acc.recent[key] {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case channel <- &WatchEvent{Address: acc.addr, Transaction: tx}:
+			}
+
+			acc.recent[key] = true
+			acc.recentList = append(acc.recentList, key)
+			if len(acc.recentList) > watcherRecentTxs {
+				delete(acc.recent, acc.recentList[0])
+				acc.recentList = acc.recentList[1:]
+			}
+		}
+
+		acc.cursor = &TransactionCursor{LT: tx.LT, Hash: tx.Hash}
+		if err = w.store.SaveCursor(ctx, acc.addr, acc.cursor); err != nil {
+			return report(fmt.Errorf("failed to save cursor: %w", err))
+		}
+	}
+	return nil
+}
+
+func watcherKey(addr *address.Address) string {
+	return fmt.Sprintf("%d:%x", addr.Workchain(), addr.Data())
+}
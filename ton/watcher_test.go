@@ -0,0 +1,283 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type txHistoryClient struct {
+	txs       []*cell.Cell // from old to new
+	notInDBLT uint64
+}
+
+func (m *txHistoryClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	req := payload.(GetTransactions)
+	if m.notInDBLT > 0 && uint64(req.LT) <= m.notInDBLT {
+		*result.(*tl.Serializable) = LSError{Code: -400, Text: "lt not in db"}
+		return nil
+	}
+
+	var list []*cell.Cell
+	for i := len(m.txs) - 1; i >= 0 && len(list) < int(req.Limit); i-- {
+		var tx tlb.Transaction
+		if err := tlb.LoadFromCell(&tx, m.txs[i].BeginParse()); err != nil {
+			return err
+		}
+		if tx.LT > uint64(req.LT) {
+			continue
+		}
+		list = append(list, m.txs[i])
+	}
+
+	if len(list) == 0 {
+		*result.(*tl.Serializable) = LSError{Code: 0, Text: "no transactions"}
+		return nil
+	}
+	*result.(*tl.Serializable) = TransactionList{Transactions: cell.ToBOCWithFlags(list, false)}
+	return nil
+}
+
+func (m *txHistoryClient) StickyContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (m *txHistoryClient) StickyNodeID(ctx context.Context) uint32 {
+	return 0
+}
+
+func (m *txHistoryClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (m *txHistoryClient) StickyContextNextNodeBalanced(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func makeTxHistory(t *testing.T, num int) []*cell.Cell {
+	var list []*cell.Cell
+	prevHash, prevLT := make([]byte, 32), uint64(0)
+	for i := 0; i < num; i++ {
+		tx := tlb.Transaction{
+			AccountAddr: make([]byte, 32),
+			LT:          uint64(i+1) * 10,
+			PrevTxHash:  prevHash,
+			PrevTxLT:    prevLT,
+			OrigStatus:  tlb.AccountStatusActive,
+			EndStatus:   tlb.AccountStatusActive,
+			StateUpdate: tlb.HashUpdate{OldHash: make([]byte, 32), NewHash: make([]byte, 32)},
+			Description: tlb.TransactionDescription{
+				Description: tlb.TransactionDescriptionStorage{
+					StoragePhase: tlb.StoragePhase{
+						StorageFeesCollected: tlb.ZeroCoins,
+						StatusChange:         tlb.AccStatusChange{Type: tlb.AccStatusChangeUnchanged},
+					},
+				},
+			},
+		}
+		tx.TotalFees.Coins = tlb.ZeroCoins
+
+		c, err := tlb.ToCell(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, c)
+		prevHash, prevLT = c.Hash(), tx.LT
+	}
+	return list
+}
+
+func TestListTransactionsSince(t *testing.T) {
+	txs := makeTxHistory(t, 25)
+	client := &txHistoryClient{txs: txs}
+	api := NewAPIClient(client, ProofCheckPolicyUnsafe)
+	addr := address.NewAddress(0, 0, make([]byte, 32))
+	last := txs[len(txs)-1]
+
+	list, err := listTransactionsSince(context.Background(), api, addr, 250, last.Hash(), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 25 || list[0].LT != 10 || list[24].LT != 250 {
+		t.Fatal("incorrect full history", len(list))
+	}
+
+	list, err = listTransactionsSince(context.Background(), api, addr, 250, last.Hash(), 120, txs[11].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 13 || list[0].LT != 130 {
+		t.Fatal("incorrect history after cursor", len(list))
+	}
+
+	list, err = listTransactionsSince(context.Background(), api, addr, 250, last.Hash(), 250, last.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Fatal("should be nothing new")
+	}
+
+	_, err = listTransactionsSince(context.Background(), api, addr, 250, last.Hash(), 120, txs[10].Hash())
+	if !errors.Is(err, ErrCursorNotInHistory) {
+		t.Fatal("cursor hash mismatch should be detected, got", err)
+	}
+
+	_, err = listTransactionsSince(context.Background(), api, addr, 250, last.Hash(), 125, txs[11].Hash())
+	if !errors.Is(err, ErrCursorNotInHistory) {
+		t.Fatal("cursor lt mismatch should be detected, got", err)
+	}
+
+	client.notInDBLT = 100
+	_, err = listTransactionsSince(context.Background(), api, addr, 250, last.Hash(), 0, nil)
+	if !errors.Is(err, ErrLTNotInDB) {
+		t.Fatal("lt not in db should be reported, got", err)
+	}
+}
+
+func TestMemoryCheckpointStore(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	addr := address.NewAddress(0, 0, make([]byte, 32))
+
+	cur, err := store.LoadCursor(context.Background(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur != nil {
+		t.Fatal("should be no cursor")
+	}
+
+	if err = store.SaveCursor(context.Background(), addr, &TransactionCursor{LT: 7, Hash: []byte{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// bounceable flag should not affect the key
+	cur, err = store.LoadCursor(context.Background(), addr.Bounce(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur == nil || cur.LT != 7 || len(cur.Hash) != 2 {
+		t.Fatal("incorrect cursor")
+	}
+}
+
+// watcherTestAPI - serves account state with the given last transaction, history is served by txHistoryClient
+type watcherTestAPI struct {
+	*APIClient
+	txs  []*cell.Cell
+	last int
+	mx   sync.Mutex
+}
+
+func (m *watcherTestAPI) CurrentMasterchainInfo(ctx context.Context) (*BlockIDExt, error) {
+	return &BlockIDExt{Workchain: -1, Shard: -0x8000000000000000, SeqNo: 100}, nil
+}
+
+func (m *watcherTestAPI) WaitForBlock(seqno uint32) APIClientWrapped {
+	return m
+}
+
+func (m *watcherTestAPI) GetAccount(ctx context.Context, block *BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return &tlb.Account{IsActive: true, LastTxLT: uint64(m.last+1) * 10, LastTxHash: m.txs[m.last].Hash()}, nil
+}
+
+func (m *watcherTestAPI) setLast(i int) {
+	m.mx.Lock()
+	m.last = i
+	m.mx.Unlock()
+}
+
+func TestTransactionWatcher(t *testing.T) {
+	txs := makeTxHistory(t, 25)
+	api := &watcherTestAPI{APIClient: NewAPIClient(&txHistoryClient{txs: txs}, ProofCheckPolicyUnsafe), txs: txs, last: 19}
+	addr := address.NewAddress(0, 0, make([]byte, 32))
+	store := NewMemoryCheckpointStore()
+
+	w := NewTransactionWatcher(api, store)
+	w.PollInterval = 10 * time.Millisecond
+	w.Add(addr, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan *WatchEvent)
+	go w.Run(ctx, events)
+
+	expectLT := uint64(10)
+	read := func(num int) {
+		t.Helper()
+		for i := 0; i < num; i++ {
+			select {
+			case e := <-events:
+				if e.Err != nil {
+					t.Fatal(e.Err)
+				}
+				if e.Transaction.LT != expectLT || !e.Address.Equals(addr) {
+					t.Fatal("incorrect transaction", e.Transaction.LT, "expected", expectLT)
+				}
+				expectLT += 10
+			case <-time.After(3 * time.Second):
+				t.Fatal("transaction was not delivered")
+			}
+		}
+	}
+
+	read(20)
+	api.setLast(24)
+	read(5)
+
+	// nothing new should be delivered
+	select {
+	case e := <-events:
+		t.Fatal("unexpected event", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cur, err := store.LoadCursor(context.Background(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur == nil || cur.LT != 250 || !bytes.Equal(cur.Hash, txs[24].Hash()) {
+		t.Fatal("cursor should be saved after delivered transaction")
+	}
+
+	// history before lt 100 is pruned, so cursor at lt 50 cannot be reached
+	api = &watcherTestAPI{APIClient: NewAPIClient(&txHistoryClient{txs: txs, notInDBLT: 100}, ProofCheckPolicyUnsafe), txs: txs, last: 24}
+	w = NewTransactionWatcher(api, nil)
+	w.PollInterval = 10 * time.Millisecond
+	w.Add(addr, &TransactionCursor{LT: 50, Hash: txs[4].Hash()})
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+
+	events = make(chan *WatchEvent)
+	go w.Run(ctx2, events)
+
+	var reported int
+	timeout := time.After(200 * time.Millisecond)
+wait:
+	for {
+		select {
+		case e := <-events:
+			if !errors.Is(e.Err, ErrLTNotInDB) {
+				t.Fatal("lt not in db should be reported, got", e.Err, e.Transaction)
+			}
+			reported++
+		case <-timeout:
+			break wait
+		}
+	}
+	if reported != 1 {
+		t.Fatal("error should be reported once, got", reported)
+	}
+}
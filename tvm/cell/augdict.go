@@ -0,0 +1,417 @@
+package cell
+
+import (
+	"fmt"
+)
+
+// AugmentedExtra - describes extra values stored in the nodes of the augmented dictionary (HashmapAug)
+type AugmentedExtra interface {
+	// SkipExtra - reads extra value from the loader, loader should be positioned right after it
+	SkipExtra(loader *Slice) error
+	// AggregateExtra - calculates extra of the fork node from the extras of its children
+	AggregateExtra(left, right *Slice) (*Builder, error)
+	// EmptyExtra - extra of the empty dictionary
+	EmptyExtra() (*Builder, error)
+}
+
+// AugmentedDictionary - dictionary where each node contains extra value,
+// extra of the fork node is aggregated from the extras of its children
+type AugmentedDictionary struct {
+	keySz uint
+	extra AugmentedExtra
+
+	root *Cell
+}
+
+type AugDictKV struct {
+	Key   *Slice
+	Extra *Slice
+	Value *Slice
+}
+
+func NewAugmentedDict(keySz uint, extra AugmentedExtra) *AugmentedDictionary {
+	return &AugmentedDictionary{
+		keySz: keySz,
+		extra: extra,
+	}
+}
+
+// AsAugmentedDict - uses cell as a root of HashmapAug
+func (c *Cell) AsAugmentedDict(keySz uint, extra AugmentedExtra) *AugmentedDictionary {
+	return &AugmentedDictionary{
+		keySz: keySz,
+		extra: extra,
+		root:  c,
+	}
+}
+
+func (c *Slice) MustLoadAugmentedDict(keySz uint, extra AugmentedExtra) *AugmentedDictionary {
+	ld, err := c.LoadAugmentedDict(keySz, extra)
+	if err != nil {
+		panic(err)
+	}
+	return ld
+}
+
+// LoadAugmentedDict - loads HashmapAugE, root extra which follows the root reference is skipped,
+// because it is the same as extra of the root node
+func (c *Slice) LoadAugmentedDict(keySz uint, extra AugmentedExtra) (*AugmentedDictionary, error) {
+	root, err := c.LoadMaybeRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ref for dict, err: %w", err)
+	}
+
+	if err = extra.SkipExtra(c); err != nil {
+		return nil, fmt.Errorf("failed to load dict extra, err: %w", err)
+	}
+
+	d := NewAugmentedDict(keySz, extra)
+	if root != nil {
+		if d.root, err = root.ToCell(); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func (d *AugmentedDictionary) GetKeySize() uint {
+	return d.keySz
+}
+
+func (d *AugmentedDictionary) Copy() *AugmentedDictionary {
+	return &AugmentedDictionary{
+		keySz: d.keySz,
+		extra: d.extra,
+		root:  d.root,
+	}
+}
+
+func (d *AugmentedDictionary) IsEmpty() bool {
+	return d == nil || d.root == nil
+}
+
+// AsCell - returns root cell of HashmapAug, nil if dictionary is empty
+func (d *AugmentedDictionary) AsCell() *Cell {
+	return d.root
+}
+
+// Extra - returns aggregated extra of the whole dictionary
+func (d *AugmentedDictionary) Extra() (*Slice, error) {
+	if d.root == nil {
+		b, err := d.extra.EmptyExtra()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build empty extra: %w", err)
+		}
+		return b.ToSlice(), nil
+	}
+	return d.nodeExtra(d.root, d.keySz)
+}
+
+// LoadAll - parses all leafs of the dictionary with their extras
+func (d *AugmentedDictionary) LoadAll() ([]AugDictKV, error) {
+	if d.root == nil {
+		return []AugDictKV{}, nil
+	}
+	return d.mapInner(d.keySz, d.root.BeginParse(), BeginCell())
+}
+
+func (d *AugmentedDictionary) mapInner(keySz uint, loader *Slice, keyPrefix *Builder) ([]AugDictKV, error) {
+	sz, keyPrefix, err := loadLabel(keySz, loader, keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if sz < keySz {
+		left, err := loader.LoadRef()
+		if err != nil {
+			return nil, err
+		}
+		right, err := loader.LoadRef()
+		if err != nil {
+			return nil, err
+		}
+
+		keysL, err := d.mapInner(keySz-(1+sz), left, keyPrefix.Copy().MustStoreUInt(0, 1))
+		if err != nil {
+			return nil, err
+		}
+		keysR, err := d.mapInner(keySz-(1+sz), right, keyPrefix.Copy().MustStoreUInt(1, 1))
+		if err != nil {
+			return nil, err
+		}
+		return append(keysL, keysR...), nil
+	}
+
+	extra, err := d.loadExtra(loader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load leaf extra: %w", err)
+	}
+
+	return []AugDictKV{{
+		Key:   keyPrefix.ToSlice(),
+		Extra: extra,
+		Value: loader,
+	}}, nil
+}
+
+// LoadValue - searches key in the dictionary and returns its value without extra
+//
+//	If key is not found ErrNoSuchKeyInDict will be returned
+func (d *AugmentedDictionary) LoadValue(key *Cell) (*Slice, error) {
+	value, _, err := d.LoadValueWithExtra(key)
+	return value, err
+}
+
+// LoadValueWithExtra - searches key in the dictionary and returns its value and leaf extra
+//
+//	If key is not found ErrNoSuchKeyInDict will be returned
+func (d *AugmentedDictionary) LoadValueWithExtra(key *Cell) (*Slice, *Slice, error) {
+	if key.BitsSize() != d.keySz {
+		return nil, nil, fmt.Errorf("incorrect key size")
+	}
+
+	leaf, err := d.findLeaf(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	extra, err := d.loadExtra(leaf)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load leaf extra: %w", err)
+	}
+	return leaf, extra, nil
+}
+
+func (d *AugmentedDictionary) findLeaf(key *Cell) (*Slice, error) {
+	if d.root == nil {
+		return nil, ErrNoSuchKeyInDict
+	}
+
+	branch := d.root
+	lKey := key.BeginParse()
+	for {
+		s := branch.BeginParse()
+		sz, keyPrefix, err := loadLabel(lKey.BitsLeft(), s, BeginCell())
+		if err != nil {
+			return nil, err
+		}
+
+		for lbl := keyPrefix.ToSlice(); sz > 0; sz-- {
+			if lKey.MustLoadUInt(1) != lbl.MustLoadUInt(1) {
+				return nil, ErrNoSuchKeyInDict
+			}
+		}
+
+		if lKey.BitsLeft() == 0 {
+			return s, nil
+		}
+
+		branch, err = branch.PeekRef(int(lKey.MustLoadUInt(1)))
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Set - sets value with the leaf extra by the key and recalculates extras of the affected forks,
+// if value is nil, key will be deleted
+func (d *AugmentedDictionary) Set(key, value, extra *Cell) error {
+	if key.BitsSize() != d.keySz {
+		return fmt.Errorf("invalid key size")
+	}
+
+	var leaf *Builder
+	if value != nil {
+		if extra == nil {
+			return fmt.Errorf("extra is required for value")
+		}
+
+		leaf = extra.ToBuilder()
+		if err := leaf.StoreBuilder(value.ToBuilder()); err != nil {
+			return fmt.Errorf("failed to store value: %w", err)
+		}
+	}
+
+	root, err := d.set(d.root, key.BeginParse(), leaf)
+	if err != nil {
+		return fmt.Errorf("failed to set value in dict, err: %w", err)
+	}
+	d.root = root
+	return nil
+}
+
+func (d *AugmentedDictionary) Delete(key *Cell) error {
+	return d.Set(key, nil, nil)
+}
+
+func (d *AugmentedDictionary) set(branch *Cell, key *Slice, leaf *Builder) (*Cell, error) {
+	keySz := key.BitsLeft()
+	if branch == nil {
+		if leaf == nil {
+			return nil, nil
+		}
+		return storeNode(key, keySz, leaf)
+	}
+
+	s := branch.BeginParse()
+	sz, label, err := loadLabel(keySz, s, BeginCell())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load label: %w", err)
+	}
+
+	var matches uint
+	for l, k := label.ToSlice(), key.Copy(); matches < sz; matches++ {
+		if l.MustLoadUInt(1) != k.MustLoadUInt(1) {
+			break
+		}
+	}
+
+	if matches < sz {
+		if leaf == nil {
+			// key is not exists, nothing to delete
+			return branch, nil
+		}
+
+		// label is not matches our key, we need to split it into the new fork
+		lbl := label.ToSlice()
+		common := BeginCell().MustStoreSlice(lbl.MustLoadSlice(matches), matches)
+		oldBit := lbl.MustLoadUInt(1)
+
+		old, err := storeNode(lbl, keySz-(matches+1), s.ToBuilder())
+		if err != nil {
+			return nil, err
+		}
+
+		key.MustLoadSlice(matches + 1)
+		created, err := storeNode(key, keySz-(matches+1), leaf)
+		if err != nil {
+			return nil, err
+		}
+
+		if oldBit == 0 {
+			return d.storeFork(common.ToSlice(), keySz, old, created)
+		}
+		return d.storeFork(common.ToSlice(), keySz, created, old)
+	}
+
+	if sz == keySz {
+		if leaf == nil {
+			return nil, nil
+		}
+		return storeNode(label.ToSlice(), keySz, leaf)
+	}
+
+	key.MustLoadSlice(sz)
+	idx := int(key.MustLoadUInt(1))
+
+	refs := [2]*Cell{}
+	for i := range refs {
+		if refs[i], err = branch.PeekRef(i); err != nil {
+			return nil, fmt.Errorf("failed to peek %d ref: %w", i, err)
+		}
+	}
+
+	ref, err := d.set(refs[idx], key, leaf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dive into %d ref of branch: %w", idx, err)
+	}
+
+	if ref == nil {
+		// deleted, replace fork with the neighbour node
+		nIdx := idx ^ 1
+		ns := refs[nIdx].BeginParse()
+		_, nLabel, err := loadLabel(keySz-(sz+1), ns, label.MustStoreUInt(uint64(nIdx), 1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load neighbour label: %w", err)
+		}
+		return storeNode(nLabel.ToSlice(), keySz, ns.ToBuilder())
+	}
+
+	refs[idx] = ref
+	return d.storeFork(label.ToSlice(), keySz, refs[0], refs[1])
+}
+
+func (d *AugmentedDictionary) storeFork(label *Slice, keySz uint, left, right *Cell) (*Cell, error) {
+	childSz := keySz - (label.BitsLeft() + 1)
+
+	leftExtra, err := d.nodeExtra(left, childSz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load left extra: %w", err)
+	}
+	rightExtra, err := d.nodeExtra(right, childSz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load right extra: %w", err)
+	}
+
+	extra, err := d.extra.AggregateExtra(leftExtra, rightExtra)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate extra: %w", err)
+	}
+
+	b := BeginCell()
+	if err = storeLabel(b, label, keySz); err != nil {
+		return nil, fmt.Errorf("failed to store label: %w", err)
+	}
+	if err = b.StoreRef(left); err != nil {
+		return nil, err
+	}
+	if err = b.StoreRef(right); err != nil {
+		return nil, err
+	}
+	if err = b.StoreBuilder(extra); err != nil {
+		return nil, fmt.Errorf("failed to store extra: %w", err)
+	}
+	return b.EndCell(), nil
+}
+
+// nodeExtra - loads extra of the leaf or fork node
+func (d *AugmentedDictionary) nodeExtra(node *Cell, keySz uint) (*Slice, error) {
+	s := node.BeginParse()
+	sz, _, err := loadLabel(keySz, s, BeginCell())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load label: %w", err)
+	}
+
+	if sz < keySz {
+		// skip fork refs, extra is after them
+		if _, err = s.LoadRef(); err != nil {
+			return nil, err
+		}
+		if _, err = s.LoadRef(); err != nil {
+			return nil, err
+		}
+	}
+	return d.loadExtra(s)
+}
+
+// loadExtra - loads extra from the loader and returns it as a separate slice
+func (d *AugmentedDictionary) loadExtra(loader *Slice) (*Slice, error) {
+	before := loader.Copy()
+	if err := d.extra.SkipExtra(loader); err != nil {
+		return nil, err
+	}
+
+	bits := before.BitsLeft() - loader.BitsLeft()
+	data, err := before.LoadSlice(bits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Slice{
+		bitsSz: bits,
+		data:   data,
+		refs:   before.refs[:before.RefsNum()-loader.RefsNum()],
+	}, nil
+}
+
+func storeNode(label *Slice, keySz uint, body *Builder) (*Cell, error) {
+	b := BeginCell()
+	if err := storeLabel(b, label, keySz); err != nil {
+		return nil, fmt.Errorf("failed to store label: %w", err)
+	}
+
+	if err := b.StoreBuilder(body); err != nil {
+		return nil, fmt.Errorf("failed to store node body: %w", err)
+	}
+	return b.EndCell(), nil
+}
@@ -210,7 +210,7 @@ func (b *Builder) StoreBigInt(value *big.Int, sz uint) error {
 
 		// 11111111 is -1, so we need to add 1 to our negative value first,
 		// because we already have -1 in 'i'
-		value = value.Add(value, one)
+		value = new(big.Int).Add(value, one)
 		value = value.Add(value, i)
 	}
 
@@ -388,6 +388,27 @@ func (b *Builder) MustStoreDict(dict *Dictionary) *Builder {
 	return b
 }
 
+func (b *Builder) MustStoreAugmentedDict(dict *AugmentedDictionary) *Builder {
+	err := b.StoreAugmentedDict(dict)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// StoreAugmentedDict - stores dictionary as HashmapAugE, root reference is followed by the whole dictionary extra
+func (b *Builder) StoreAugmentedDict(dict *AugmentedDictionary) error {
+	extra, err := dict.Extra()
+	if err != nil {
+		return err
+	}
+
+	if err = b.StoreMaybeRef(dict.AsCell()); err != nil {
+		return err
+	}
+	return b.StoreBuilder(extra.ToBuilder())
+}
+
 func (b *Builder) MustStoreMaybeRef(ref *Cell) *Builder {
 	err := b.StoreMaybeRef(ref)
 	if err != nil {
@@ -556,7 +577,7 @@ func (b *Builder) Copy() *Builder {
 	return &Builder{
 		bitsSz: b.bitsSz,
 		data:   data,
-		refs:   b.refs,
+		refs:   append([]*Cell{}, b.refs...),
 	}
 }
 
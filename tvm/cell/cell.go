@@ -109,6 +109,45 @@ func (c *Cell) RefsNum() uint {
 	return uint(len(c.refs))
 }
 
+// StorageStat - returns number of unique cells and their bits in the tree, as it is counted for storage fees
+func (c *Cell) StorageStat() (cells, bits uint64) {
+	return c.storageStat(false)
+}
+
+// RefsStorageStat - same as StorageStat, but root cell is not counted, as it is done for forward fees of messages
+func (c *Cell) RefsStorageStat() (cells, bits uint64) {
+	return c.storageStat(true)
+}
+
+func (c *Cell) storageStat(skipRoot bool) (cells, bits uint64) {
+	seen := map[string]bool{}
+
+	var visit func(cl *Cell)
+	visit = func(cl *Cell) {
+		key := string(cl.Hash())
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		cells++
+		bits += uint64(cl.bitsSz)
+		for _, ref := range cl.refs {
+			visit(ref)
+		}
+	}
+
+	if !skipRoot {
+		visit(c)
+		return cells, bits
+	}
+
+	for _, ref := range c.refs {
+		visit(ref)
+	}
+	return cells, bits
+}
+
 func (c *Cell) MustPeekRef(i int) *Cell {
 	return c.refs[i]
 }
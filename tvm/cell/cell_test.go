@@ -277,3 +277,21 @@ func TestCellRecursive(t *testing.T) {
 		log.Fatal("incorrect err:", err.Error())
 	}
 }
+
+func TestCell_StorageStat(t *testing.T) {
+	shared := BeginCell().MustStoreUInt(1, 8).EndCell()
+	a := BeginCell().MustStoreUInt(2, 16).MustStoreRef(shared).EndCell()
+	b := BeginCell().MustStoreUInt(3, 32).MustStoreRef(shared).EndCell()
+	root := BeginCell().MustStoreUInt(4, 64).MustStoreRef(a).MustStoreRef(b).MustStoreRef(shared).EndCell()
+
+	cells, bits := root.StorageStat()
+	if cells != 4 || bits != 8+16+32+64 {
+		t.Fatal("incorrect storage stat", cells, bits)
+	}
+
+	// shared cell is counted once for all refs
+	cells, bits = root.RefsStorageStat()
+	if cells != 3 || bits != 8+16+32 {
+		t.Fatal("incorrect refs storage stat", cells, bits)
+	}
+}
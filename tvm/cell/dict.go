@@ -96,7 +96,7 @@ func (d *Dictionary) storeLeaf(keyPfx *Slice, value *Builder, keyOffset uint) (*
 	}
 
 	b := BeginCell()
-	if err := d.storeLabel(b, keyPfx, keyOffset); err != nil {
+	if err := storeLabel(b, keyPfx, keyOffset); err != nil {
 		return nil, fmt.Errorf("failed to store label: %w", err)
 	}
 
@@ -206,12 +206,12 @@ func (d *Dictionary) Set(key, value *Cell) error {
 		b := BeginCell()
 		// label is not matches our key, we need to split it
 		nkPart := kPart.ToSlice().MustLoadSlice(bitsMatches)
-		if err = d.storeLabel(b, BeginCell().MustStoreSlice(nkPart, bitsMatches).ToSlice(), keyOffset); err != nil {
+		if err = storeLabel(b, BeginCell().MustStoreSlice(nkPart, bitsMatches).ToSlice(), keyOffset); err != nil {
 			return nil, fmt.Errorf("failed to store middle label: %w", err)
 		}
 
 		b1 := BeginCell()
-		if err = d.storeLabel(b1, kPartSlice, keyOffset-(bitsMatches+1)); err != nil {
+		if err = storeLabel(b1, kPartSlice, keyOffset-(bitsMatches+1)); err != nil {
 			return nil, fmt.Errorf("failed to store middle left label: %w", err)
 		}
 		b1.MustStoreBuilder(s.ToBuilder())
@@ -326,14 +326,38 @@ func (d *Dictionary) All() []*HashmapKV {
 	return old
 }
 
-func (d *Dictionary) LoadAll() ([]DictKV, error) {
+// LoadAll - loads all key-values of dictionary,
+// when skipPruned is true, pruned branches (in case of proof) are skipped instead of failing
+func (d *Dictionary) LoadAll(skipPruned ...bool) ([]DictKV, error) {
 	if d.root == nil {
 		return []DictKV{}, nil
 	}
-	return d.mapInner(d.keySz, d.keySz, d.root.BeginParse(), BeginCell())
+	skip := len(skipPruned) > 0 && skipPruned[0]
+	if skip && d.root.GetType() == PrunedCellType {
+		return []DictKV{}, nil
+	}
+	return d.mapInner(d.keySz, d.keySz, d.root.BeginParse(), BeginCell(), skip, nil)
 }
 
-func (d *Dictionary) mapInner(keySz, leftKeySz uint, loader *Slice, keyPrefix *Builder) ([]DictKV, error) {
+// LoadAllWithPruned - loads all key-values of dictionary from proof, and key prefixes of the pruned branches,
+// so it can be checked which keys are hidden by the proof
+func (d *Dictionary) LoadAllWithPruned() ([]DictKV, []*Slice, error) {
+	pruned := []*Slice{}
+	if d.root == nil {
+		return []DictKV{}, pruned, nil
+	}
+	if d.root.GetType() == PrunedCellType {
+		return []DictKV{}, append(pruned, BeginCell().ToSlice()), nil
+	}
+
+	kvs, err := d.mapInner(d.keySz, d.keySz, d.root.BeginParse(), BeginCell(), true, &pruned)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kvs, pruned, nil
+}
+
+func (d *Dictionary) mapInner(keySz, leftKeySz uint, loader *Slice, keyPrefix *Builder, skipPruned bool, pruned *[]*Slice) ([]DictKV, error) {
 	var err error
 	var sz uint
 
@@ -344,28 +368,28 @@ func (d *Dictionary) mapInner(keySz, leftKeySz uint, loader *Slice, keyPrefix *B
 
 	// until key size is not equals we go deeper
 	if keyPrefix.BitsUsed() < keySz {
-		// 0 bit branch
-		left, err := loader.LoadRef()
-		if err != nil {
-			return nil, err
-		}
+		var keys []DictKV
+		for bit := uint64(0); bit <= 1; bit++ {
+			branch, err := loader.LoadRefCell()
+			if err != nil {
+				return nil, err
+			}
 
-		keysL, err := d.mapInner(keySz, leftKeySz-(1+sz), left, keyPrefix.Copy().MustStoreUInt(0, 1))
-		if err != nil {
-			return nil, err
-		}
+			if skipPruned && branch.GetType() == PrunedCellType {
+				if pruned != nil {
+					*pruned = append(*pruned, keyPrefix.Copy().MustStoreUInt(bit, 1).ToSlice())
+				}
+				continue
+			}
 
-		// 1 bit branch
-		right, err := loader.LoadRef()
-		if err != nil {
-			return nil, err
-		}
-		keysR, err := d.mapInner(keySz, leftKeySz-(1+sz), right, keyPrefix.Copy().MustStoreUInt(1, 1))
-		if err != nil {
-			return nil, err
+			branchKeys, err := d.mapInner(keySz, leftKeySz-(1+sz), branch.BeginParse(), keyPrefix.Copy().MustStoreUInt(bit, 1), skipPruned, pruned)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, branchKeys...)
 		}
 
-		return append(keysL, keysR...), nil
+		return keys, nil
 	}
 
 	return []DictKV{{
@@ -527,7 +551,7 @@ func loadLabel(sz uint, loader *Slice, key *Builder) (uint, *Builder, error) {
 	return uint(ln), key, nil
 }
 
-func (d *Dictionary) storeLabel(b *Builder, data *Slice, keyLen uint) error {
+func storeLabel(b *Builder, data *Slice, keyLen uint) error {
 	ln := uint64(data.BitsLeft())
 	// short unary 0
 	if ln == 0 {
@@ -555,21 +579,21 @@ func (d *Dictionary) storeLabel(b *Builder, data *Slice, keyLen uint) error {
 		}
 
 		if cmpInt.Cmp(big.NewInt(0)) == 0 { // compare with all zeroes
-			return d.storeSame(b, ln, bitsLen, 0)
+			return storeSame(b, ln, bitsLen, 0)
 		} else if cmpInt.BitLen() == int(ln) && cmpInt.Cmp(new(big.Int).Sub(new(big.Int).
 			Lsh(big.NewInt(1), uint(ln)),
 			big.NewInt(1))) == 0 { // compare with all ones
-			return d.storeSame(b, ln, bitsLen, 1)
+			return storeSame(b, ln, bitsLen, 1)
 		}
 	}
 
 	if shortLength <= longLen {
-		return d.storeShort(b, ln, dataBits)
+		return storeShort(b, ln, dataBits)
 	}
-	return d.storeLong(b, ln, bitsLen, dataBits)
+	return storeLong(b, ln, bitsLen, dataBits)
 }
 
-func (d *Dictionary) storeShort(b *Builder, partSz uint64, bits []byte) error {
+func storeShort(b *Builder, partSz uint64, bits []byte) error {
 	// magic
 	if err := b.StoreUInt(0b0, 1); err != nil {
 		return err
@@ -583,7 +607,7 @@ func (d *Dictionary) storeShort(b *Builder, partSz uint64, bits []byte) error {
 	return b.StoreSlice(bits, uint(partSz))
 }
 
-func (d *Dictionary) storeSame(b *Builder, partSz, bitsLen uint64, bit uint64) error {
+func storeSame(b *Builder, partSz, bitsLen uint64, bit uint64) error {
 	// magic
 	if err := b.StoreUInt(0b11, 2); err != nil {
 		return err
@@ -596,7 +620,7 @@ func (d *Dictionary) storeSame(b *Builder, partSz, bitsLen uint64, bit uint64) e
 	return b.StoreUInt(partSz, uint(bitsLen))
 }
 
-func (d *Dictionary) storeLong(b *Builder, partSz, bitsLen uint64, bits []byte) error {
+func storeLong(b *Builder, partSz, bitsLen uint64, bits []byte) error {
 	// magic
 	if err := b.StoreUInt(0b10, 2); err != nil {
 		return err
@@ -621,3 +645,11 @@ func (d *Dictionary) AsCell() *Cell {
 func (d *Dictionary) ToCell() (*Cell, error) {
 	return d.root, nil
 }
+
+// MarshalJSON - dictionary is represented as a BOC of its root cell, empty dictionary is null
+func (d *Dictionary) MarshalJSON() ([]byte, error) {
+	if d.IsEmpty() {
+		return []byte("null"), nil
+	}
+	return d.root.MarshalJSON()
+}
@@ -323,3 +323,74 @@ func Test_ReplaceDict(t *testing.T) {
 		panic(err)
 	}
 }
+
+func TestDictionary_LoadAllSkipPruned(t *testing.T) {
+	d := NewDict(32)
+	for i := int64(0); i < 20; i++ {
+		if err := d.SetIntKey(big.NewInt(i*7), BeginCell().MustStoreUInt(uint64(i), 8).EndCell()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sk := CreateProofSkeleton()
+	if _, _, err := d.LoadValueWithProof(BeginCell().MustStoreUInt(21, 32).EndCell(), sk); err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := d.AsCell().CreateProof(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err = UnwrapProof(proof, d.AsCell().Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = proof.AsDict(32).LoadAll(); err == nil {
+		t.Fatal("pruned branches should not be loaded")
+	}
+
+	all, err := proof.AsDict(32).LoadAll(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == 0 || len(all) >= 20 {
+		t.Fatal("incorrect keys num from proof", len(all))
+	}
+
+	found := false
+	for _, kv := range all {
+		if kv.Key.MustLoadUInt(32) == 21 {
+			found = kv.Value.MustLoadUInt(8) == 3
+		}
+	}
+	if !found {
+		t.Fatal("proven key not found")
+	}
+	kvs, pruned, err := proof.AsDict(32).LoadAllWithPruned()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != len(all) || len(pruned) == 0 {
+		t.Fatal("incorrect keys or pruned branches num", len(kvs), len(pruned))
+	}
+
+	// every key should be either loaded or hidden by pruned branch
+	for i := uint64(0); i < 20; i++ {
+		key := i * 7
+		covered := 0
+		for _, kv := range kvs {
+			if kv.Key.Copy().MustLoadUInt(32) == key {
+				covered++
+			}
+		}
+		for _, p := range pruned {
+			if p.BitsLeft() == 0 || key>>(32-p.BitsLeft()) == p.Copy().MustLoadUInt(p.BitsLeft()) {
+				covered++
+			}
+		}
+		if covered != 1 {
+			t.Fatal("key should be covered once", key, covered)
+		}
+	}
+}
@@ -0,0 +1,485 @@
+package emulator
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	actionSendMsg       = 0x0ec3c86d
+	actionSetCode       = 0xad4de08e
+	actionReserve       = 0x36e6b809
+	actionChangeLibrary = 0x26fa1dd4
+)
+
+const (
+	sendModePayFeesSeparately = 1
+	sendModeIgnoreErrors      = 2
+	sendModeBounceOnFail      = 16
+	sendModeDestroyIfZero     = 32
+	sendModeCarryMsgBalance   = 64
+	sendModeCarryAllBalance   = 128
+)
+
+// action result codes, same as in the node implementation
+const (
+	resultInvalidList      = 32
+	resultTooManyActions   = 33
+	resultUnknownAction    = 34
+	resultInvalidSource    = 35
+	resultInvalidDest      = 36
+	resultNotEnoughFunds   = 37
+	resultNotEnoughExtra   = 38
+	resultCannotPayFwdFees = 40
+	resultMsgTooLarge      = 40
+	resultInvalidReserve   = 44
+	resultInvalidLibrary   = 41
+)
+
+const maxActions = 255
+
+type actionPhase struct {
+	t *transaction
+
+	list    []*cell.Slice
+	state   *tlb.StateInit
+	balance *big.Int
+	// reserved - amount which cannot be spent by next actions
+	reserved *big.Int
+
+	totalFwdFees    *big.Int
+	totalActionFees *big.Int
+	outMsgs         []*cell.Cell
+	destroy         bool
+	bounce          bool
+
+	res *tlb.ActionPhase
+}
+
+func newActionPhase(t *transaction, actions *cell.Cell, state *tlb.StateInit) (*actionPhase, error) {
+	ap := &actionPhase{
+		t:               t,
+		state:           &tlb.StateInit{Code: state.Code, Data: state.Data, Lib: state.Lib, Depth: state.Depth, TickTock: state.TickTock},
+		balance:         new(big.Int).Set(t.balance),
+		reserved:        big.NewInt(0),
+		totalFwdFees:    big.NewInt(0),
+		totalActionFees: big.NewInt(0),
+		res: &tlb.ActionPhase{
+			Valid:        true,
+			StatusChange: tlb.AccStatusChange{Type: tlb.AccStatusChangeUnchanged},
+			TotalMsgSize: tlb.StorageUsedShort{Cells: big.NewInt(0), Bits: big.NewInt(0)},
+		},
+	}
+
+	if actions == nil {
+		actions = cell.BeginCell().EndCell()
+	}
+	ap.res.ActionListHash = actions.Hash()
+
+	// out_list$_ {n:#} prev:^(OutList n) action:OutAction = OutList (n + 1);
+	for c := actions; ; {
+		if c.BitsSize() == 0 && c.RefsNum() == 0 {
+			break
+		}
+
+		if len(ap.list) >= maxActions {
+			ap.invalidList(resultTooManyActions)
+			return ap, nil
+		}
+
+		s := c.BeginParse()
+		prev, err := s.LoadRefCell()
+		if err != nil {
+			ap.invalidList(resultInvalidList)
+			return ap, nil
+		}
+		ap.list = append(ap.list, s)
+		c = prev
+	}
+
+	// list is stored from the last action to the first
+	for i, j := 0, len(ap.list)-1; i < j; i, j = i+1, j-1 {
+		ap.list[i], ap.list[j] = ap.list[j], ap.list[i]
+	}
+	ap.res.TotalActions = uint16(len(ap.list))
+
+	return ap, nil
+}
+
+func (ap *actionPhase) invalidList(code int32) {
+	ap.list = nil
+	ap.res.Valid = false
+	ap.res.ResultCode = code
+}
+
+func (ap *actionPhase) run() *tlb.ActionPhase {
+	if !ap.res.Valid {
+		return ap.res
+	}
+
+	for i, s := range ap.list {
+		var code int32
+		tag, err := s.LoadUInt(32)
+		if err != nil {
+			code = resultInvalidList
+		} else {
+			switch tag {
+			case actionSendMsg:
+				code = ap.sendMsg(s)
+			case actionReserve:
+				ap.res.SpecActions++
+				code = ap.reserve(s)
+			case actionSetCode:
+				ap.res.SpecActions++
+				code = ap.setCode(s)
+			case actionChangeLibrary:
+				ap.res.SpecActions++
+				code = ap.changeLibrary(s)
+			default:
+				code = resultUnknownAction
+			}
+		}
+
+		if code == -1 {
+			ap.res.SkippedActions++
+			continue
+		}
+
+		if code != 0 {
+			arg := int32(i)
+			ap.res.ResultCode = code
+			ap.res.ResultArg = &arg
+			if code == resultNotEnoughFunds || code == resultCannotPayFwdFees {
+				ap.res.NoFunds = true
+			}
+			ap.fillFees()
+			return ap.res
+		}
+	}
+
+	if ap.destroy {
+		ap.res.StatusChange.Type = tlb.AccStatusChangeDeleted
+	}
+	ap.res.Success = true
+	ap.fillFees()
+	return ap.res
+}
+
+func (ap *actionPhase) fillFees() {
+	if ap.totalFwdFees.Sign() > 0 {
+		fwd := tlb.FromNanoTON(ap.totalFwdFees)
+		ap.res.TotalFwdFees = &fwd
+	}
+	if ap.totalActionFees.Sign() > 0 {
+		fees := tlb.FromNanoTON(ap.totalActionFees)
+		ap.res.TotalActionFees = &fees
+	}
+}
+
+// commit - applies successful action phase results to transaction
+func (ap *actionPhase) commit(data *cell.Cell) {
+	t := ap.t
+
+	t.balance = ap.balance
+	t.totalFees.Add(t.totalFees, ap.totalActionFees)
+	t.outMsgs = append(t.outMsgs, ap.outMsgs...)
+
+	t.stateInit = ap.state
+	t.stateInit.Data = data
+	t.status = tlb.AccountStatusActive
+
+	if ap.destroy {
+		t.status = tlb.AccountStatusNonExist
+		t.stateInit = nil
+	}
+}
+
+// spendable - balance which can be spent without touching reserved amount
+func (ap *actionPhase) spendable() *big.Int {
+	res := new(big.Int).Sub(ap.balance, ap.reserved)
+	if res.Sign() < 0 {
+		res.SetInt64(0)
+	}
+	return res
+}
+
+// action_send_msg#0ec3c86d mode:(## 8) out_msg:^(MessageRelaxed Any) = OutAction;
+func (ap *actionPhase) sendMsg(s *cell.Slice) int32 {
+	t := ap.t
+
+	mode, err := s.LoadUInt(8)
+	if err != nil {
+		return resultInvalidList
+	}
+	msgCell, err := s.LoadRefCell()
+	if err != nil {
+		return resultInvalidList
+	}
+
+	skip := func(code int32) int32 {
+		if mode&sendModeBounceOnFail != 0 {
+			ap.bounce = true
+		}
+		if mode&sendModeIgnoreErrors != 0 {
+			return -1
+		}
+		return code
+	}
+
+	const validModes = sendModePayFeesSeparately | sendModeIgnoreErrors | sendModeBounceOnFail |
+		sendModeDestroyIfZero | sendModeCarryMsgBalance | sendModeCarryAllBalance
+	if mode&^validModes != 0 || (mode&sendModeCarryMsgBalance != 0 && mode&sendModeCarryAllBalance != 0) {
+		return resultUnknownAction
+	}
+
+	var msg tlb.Message
+	if err = msg.LoadFromCell(msgCell.BeginParse()); err != nil || msg.MsgType == tlb.MsgTypeExternalIn {
+		return skip(resultInvalidList)
+	}
+
+	if src := msg.Msg.SenderAddr(); src != nil && src.Type() != address.NoneAddress {
+		if src.Type() != address.StdAddress || src.Workchain() != t.addr.Workchain() ||
+			string(src.Data()) != string(t.addr.Data()) {
+			return skip(resultInvalidSource)
+		}
+	}
+
+	dst := msg.Msg.DestAddr()
+	if msg.MsgType == tlb.MsgTypeInternal && (dst == nil || dst.Type() != address.StdAddress) {
+		return skip(resultInvalidDest)
+	}
+
+	mc := t.isMc
+	if dst != nil && dst.Type() == address.StdAddress && dst.Workchain() == address.MasterchainID {
+		mc = true
+	}
+	prices := t.cfg.msgFor(mc)
+
+	// fee is calculated for the message with body and state init, root is not counted
+	cells, bits := msgCell.RefsStorageStat()
+	if cells > t.cfg.limits.maxMsgCells || bits > t.cfg.limits.maxMsgBits {
+		return skip(resultMsgTooLarge)
+	}
+	fwdFee := prices.CalcFwdFee(cells, bits)
+
+	if msg.MsgType == tlb.MsgTypeExternalOut {
+		out := msg.AsExternalOut()
+		if ap.spendable().Cmp(fwdFee) < 0 {
+			return skip(resultCannotPayFwdFees)
+		}
+
+		out.SrcAddr = t.addr
+		out.CreatedLT = t.lt + 1 + uint64(len(ap.outMsgs))
+		out.CreatedAt = t.now
+
+		c, err := tlb.ToCell(out)
+		if err != nil {
+			return skip(resultInvalidList)
+		}
+
+		ap.balance.Sub(ap.balance, fwdFee)
+		ap.totalFwdFees.Add(ap.totalFwdFees, fwdFee)
+		ap.totalActionFees.Add(ap.totalActionFees, fwdFee)
+		ap.addMsg(c, cells, bits)
+		return ap.afterSend(mode)
+	}
+
+	in := msg.AsInternal()
+	if in.ExtraCurrencies != nil && !in.ExtraCurrencies.IsEmpty() {
+		return skip(resultNotEnoughExtra)
+	}
+
+	value := new(big.Int).Set(in.Amount.Nano())
+	switch {
+	case mode&sendModeCarryAllBalance != 0:
+		value = ap.spendable()
+	case mode&sendModeCarryMsgBalance != 0:
+		value.Add(value, t.msgRemaining)
+		if mode&sendModePayFeesSeparately == 0 {
+			value.Sub(value, t.gasFees)
+			if value.Sign() < 0 {
+				value.SetInt64(0)
+			}
+		}
+	}
+
+	ihrFee := big.NewInt(0)
+	if !in.IHRDisabled {
+		ihrFee = prices.CalcIHRFee(fwdFee)
+	}
+	fees := new(big.Int).Add(fwdFee, ihrFee)
+
+	toSpend := new(big.Int).Set(value)
+	if mode&sendModePayFeesSeparately != 0 {
+		toSpend.Add(toSpend, fees)
+	} else {
+		if value.Cmp(fees) < 0 {
+			return skip(resultCannotPayFwdFees)
+		}
+		value.Sub(value, fees)
+	}
+
+	if ap.spendable().Cmp(toSpend) < 0 {
+		return skip(resultNotEnoughFunds)
+	}
+
+	firstPart := prices.CalcFirstFrac(fwdFee)
+
+	in.SrcAddr = t.addr
+	in.Amount = tlb.FromNanoTON(value)
+	in.IHRFee = tlb.FromNanoTON(ihrFee)
+	in.FwdFee = tlb.FromNanoTON(new(big.Int).Sub(fwdFee, firstPart))
+	in.CreatedLT = t.lt + 1 + uint64(len(ap.outMsgs))
+	in.CreatedAt = t.now
+
+	c, err := tlb.ToCell(in)
+	if err != nil {
+		return skip(resultInvalidList)
+	}
+
+	if mode&sendModeCarryMsgBalance != 0 {
+		t.msgRemaining = big.NewInt(0)
+	}
+
+	ap.balance.Sub(ap.balance, toSpend)
+	ap.totalFwdFees.Add(ap.totalFwdFees, fees)
+	ap.totalActionFees.Add(ap.totalActionFees, firstPart)
+	ap.addMsg(c, cells, bits)
+	return ap.afterSend(mode)
+}
+
+func (ap *actionPhase) addMsg(c *cell.Cell, cells, bits uint64) {
+	ap.outMsgs = append(ap.outMsgs, c)
+	ap.res.MessagesCreated++
+	ap.res.TotalMsgSize.Cells.Add(ap.res.TotalMsgSize.Cells, new(big.Int).SetUint64(cells))
+	ap.res.TotalMsgSize.Bits.Add(ap.res.TotalMsgSize.Bits, new(big.Int).SetUint64(bits))
+}
+
+func (ap *actionPhase) afterSend(mode uint64) int32 {
+	if mode&sendModeDestroyIfZero != 0 && mode&sendModeCarryAllBalance != 0 && ap.balance.Sign() == 0 {
+		ap.destroy = true
+	}
+	return 0
+}
+
+// action_reserve_currency#36e6b809 mode:(## 8) currency:CurrencyCollection = OutAction;
+func (ap *actionPhase) reserve(s *cell.Slice) int32 {
+	mode, err := s.LoadUInt(8)
+	if err != nil {
+		return resultInvalidList
+	}
+
+	var cc tlb.CurrencyCollection
+	if err = tlb.LoadFromCell(&cc, s); err != nil {
+		return resultInvalidList
+	}
+
+	if mode&^0xF != 0 || (mode&8 != 0 && mode&4 == 0) {
+		return resultInvalidReserve
+	}
+
+	amount := new(big.Int).Set(cc.Coins.Nano())
+	if mode&4 != 0 {
+		if mode&8 != 0 {
+			amount.Sub(ap.t.balanceBefore, amount)
+		} else {
+			amount.Add(ap.t.balanceBefore, amount)
+		}
+		if amount.Sign() < 0 {
+			return resultInvalidReserve
+		}
+	}
+
+	available := ap.spendable()
+	if mode&2 != 0 && amount.Cmp(available) > 0 {
+		amount = available
+	}
+	if amount.Cmp(available) > 0 {
+		return resultNotEnoughFunds
+	}
+
+	if mode&1 != 0 {
+		// reserve all except amount
+		amount.Sub(available, amount)
+	}
+
+	ap.reserved.Add(ap.reserved, amount)
+	return 0
+}
+
+// action_set_code#ad4de08e new_code:^Cell = OutAction;
+func (ap *actionPhase) setCode(s *cell.Slice) int32 {
+	code, err := s.LoadRefCell()
+	if err != nil {
+		return resultInvalidList
+	}
+	ap.state.Code = code
+	return 0
+}
+
+// action_change_library#26fa1dd4 mode:(## 7) libref:LibRef = OutAction;
+func (ap *actionPhase) changeLibrary(s *cell.Slice) int32 {
+	mode, err := s.LoadUInt(7)
+	if err != nil {
+		return resultInvalidList
+	}
+	if mode&^3 != 0 {
+		return resultInvalidLibrary
+	}
+
+	isRef, err := s.LoadBoolBit()
+	if err != nil {
+		return resultInvalidList
+	}
+
+	var hash []byte
+	var lib *cell.Cell
+	if isRef {
+		if lib, err = s.LoadRefCell(); err != nil {
+			return resultInvalidList
+		}
+		hash = lib.Hash()
+	} else if hash, err = s.LoadSlice(256); err != nil {
+		return resultInvalidList
+	}
+
+	libs := cell.NewDict(256)
+	if ap.state.Lib != nil {
+		libs = ap.state.Lib.Copy()
+	}
+
+	key := cell.BeginCell().MustStoreSlice(hash, 256).EndCell()
+	if mode == 0 {
+		if err = libs.Delete(key); err != nil {
+			return resultInvalidLibrary
+		}
+	} else {
+		if lib == nil {
+			existing, err := libs.LoadValue(key)
+			if err != nil {
+				return resultInvalidLibrary
+			}
+			if _, err = existing.LoadBoolBit(); err != nil {
+				return resultInvalidLibrary
+			}
+			if lib, err = existing.LoadRefCell(); err != nil {
+				return resultInvalidLibrary
+			}
+		}
+
+		// simple_lib$_ public:Bool root:^Cell = SimpleLib;
+		val := cell.BeginCell().MustStoreBoolBit(mode&2 != 0).MustStoreRef(lib).EndCell()
+		if err = libs.Set(key, val); err != nil {
+			return resultInvalidLibrary
+		}
+	}
+
+	if libs.IsEmpty() {
+		libs = nil
+	}
+	ap.state.Lib = libs
+	return 0
+}
@@ -0,0 +1,134 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Config - source of blockchain config params, ton.BlockchainConfig implements it
+type Config interface {
+	Get(id int32) *cell.Cell
+	All() map[int32]*cell.Cell
+}
+
+var ErrConfigParamNotFound = errors.New("config param not found")
+
+type sizeLimits struct {
+	maxMsgBits  uint64
+	maxMsgCells uint64
+}
+
+type parsedConfig struct {
+	root          *cell.Cell
+	globalVersion int
+
+	gas     [2]*tlb.GasLimitsPrices // masterchain, basechain
+	msg     [2]*tlb.MsgForwardPrices
+	storage []tlb.StoragePrices
+	limits  sizeLimits
+}
+
+func parseConfig(cfg Config) (*parsedConfig, error) {
+	res := &parsedConfig{
+		limits: sizeLimits{
+			maxMsgBits:  1 << 21,
+			maxMsgCells: 1 << 13,
+		},
+	}
+
+	dict := cell.NewDict(32)
+	for id, c := range cfg.All() {
+		if err := dict.SetIntKey(big.NewInt(int64(id)), cell.BeginCell().MustStoreRef(c).EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to build config dict: %w", err)
+		}
+	}
+	res.root = dict.AsCell()
+
+	if c := cfg.Get(8); c != nil {
+		s := c.BeginParse()
+		if tag, err := s.LoadUInt(8); err != nil || tag != 0xc4 {
+			return nil, fmt.Errorf("invalid config param 8")
+		}
+		ver, err := s.LoadUInt(32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load global version: %w", err)
+		}
+		res.globalVersion = int(ver)
+	}
+
+	for i, id := range []int32{20, 21} {
+		c := cfg.Get(id)
+		if c == nil {
+			return nil, fmt.Errorf("%w: %d", ErrConfigParamNotFound, id)
+		}
+		var g tlb.GasLimitsPrices
+		if err := tlb.LoadFromCell(&g, c.BeginParse()); err != nil {
+			return nil, fmt.Errorf("failed to parse config param %d: %w", id, err)
+		}
+		res.gas[i] = &g
+	}
+
+	for i, id := range []int32{24, 25} {
+		c := cfg.Get(id)
+		if c == nil {
+			return nil, fmt.Errorf("%w: %d", ErrConfigParamNotFound, id)
+		}
+		var m tlb.MsgForwardPrices
+		if err := tlb.LoadFromCell(&m, c.BeginParse()); err != nil {
+			return nil, fmt.Errorf("failed to parse config param %d: %w", id, err)
+		}
+		res.msg[i] = &m
+	}
+
+	if c := cfg.Get(18); c != nil {
+		kvs, err := c.AsDict(32).LoadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config param 18: %w", err)
+		}
+		for _, kv := range kvs {
+			var p tlb.StoragePrices
+			if err = tlb.LoadFromCell(&p, kv.Value); err != nil {
+				return nil, fmt.Errorf("failed to parse config param 18: %w", err)
+			}
+			res.storage = append(res.storage, p)
+		}
+		sort.Slice(res.storage, func(i, j int) bool {
+			return res.storage[i].UTimeSince < res.storage[j].UTimeSince
+		})
+	}
+
+	if c := cfg.Get(43); c != nil {
+		s := c.BeginParse()
+		tag, err := s.LoadUInt(8)
+		if err != nil || (tag != 0x01 && tag != 0x02) {
+			return nil, fmt.Errorf("invalid config param 43")
+		}
+		if res.limits.maxMsgBits, err = s.LoadUInt(32); err != nil {
+			return nil, fmt.Errorf("failed to parse config param 43: %w", err)
+		}
+		if res.limits.maxMsgCells, err = s.LoadUInt(32); err != nil {
+			return nil, fmt.Errorf("failed to parse config param 43: %w", err)
+		}
+	}
+
+	return res, nil
+}
+
+func (c *parsedConfig) gasFor(masterchain bool) *tlb.GasLimitsPrices {
+	if masterchain {
+		return c.gas[0]
+	}
+	return c.gas[1]
+}
+
+func (c *parsedConfig) msgFor(masterchain bool) *tlb.MsgForwardPrices {
+	if masterchain {
+		return c.msg[0]
+	}
+	return c.msg[1]
+}
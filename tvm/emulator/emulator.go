@@ -0,0 +1,92 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrAddressMismatch = errors.New("message destination is not matches account address")
+var ErrCannotPayImport = errors.New("account balance is not enough to pay for external message import")
+
+// ExternalNotAcceptedError - contract has not accepted external message,
+// such message will not be included in block and no transaction will be created
+type ExternalNotAcceptedError struct {
+	ExitCode int32
+}
+
+func (e ExternalNotAcceptedError) Error() string {
+	return fmt.Sprintf("external message was not accepted by contract, exit code %d", e.ExitCode)
+}
+
+// Params - environment of emulated transaction, all fields are optional
+type Params struct {
+	// Now - unix time of transaction, current time is used when zero
+	Now uint32
+	// LT - logical time of transaction, when zero it is calculated from account and message
+	LT uint64
+	// BlockLT - logical time of block start, LT is used when zero
+	BlockLT uint64
+	// RandSeed - 32 bytes of block random seed, zeroes are used when nil
+	RandSeed []byte
+	// Libraries - public libraries of masterchain which can be used by contract
+	Libraries []*cell.Cell
+}
+
+type Emulator struct {
+	config *parsedConfig
+}
+
+type Result struct {
+	Transaction *tlb.Transaction
+	// TransactionCell - serialized transaction, its hash is a transaction hash
+	TransactionCell *cell.Cell
+	// Account - state of account after transaction
+	Account     *tlb.Account
+	OutMessages []*tlb.Message
+}
+
+// NewEmulator - creates transaction emulator, config can be fetched using ton.APIClient GetBlockchainConfig,
+// params 18, 20, 21, 24 and 25 are required for fees calculation, param 8 is used for tvm version.
+func NewEmulator(config Config) (*Emulator, error) {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Emulator{config: cfg}, nil
+}
+
+// EmulateExternal - emulates transaction which is triggered by external message,
+// ExternalNotAcceptedError is returned when contract has not accepted message
+func (e *Emulator) EmulateExternal(acc *tlb.Account, msg *tlb.ExternalMessage, params *Params) (*Result, error) {
+	return e.emulate(acc, &tlb.Message{
+		MsgType: tlb.MsgTypeExternalIn,
+		Msg:     msg,
+	}, params)
+}
+
+// EmulateInternal - emulates transaction which is triggered by internal message
+func (e *Emulator) EmulateInternal(acc *tlb.Account, msg *tlb.InternalMessage, params *Params) (*Result, error) {
+	return e.emulate(acc, &tlb.Message{
+		MsgType: tlb.MsgTypeInternal,
+		Msg:     msg,
+	}, params)
+}
+
+func (e *Emulator) emulate(acc *tlb.Account, msg *tlb.Message, params *Params) (*Result, error) {
+	if params == nil {
+		params = &Params{}
+	}
+
+	t, err := newTransaction(e.config, acc, msg, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = t.run(); err != nil {
+		return nil, err
+	}
+	return t.result()
+}
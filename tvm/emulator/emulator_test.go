@@ -0,0 +1,359 @@
+package emulator
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const _V3R2CodeHex = "B5EE9C724101010100710000DEFF0020DD2082014C97BA218201339CBAB19F71B0ED44D0D31FD31F31D70BFFE304E0A4F2608308D71820D31FD31FD31FF82313BBF263ED44D0D31FD31FD3FFD15132BAF2A15144BAF2A204F901541055F910F2A3F8009320D74A96D307D402FB00E8D101A4C8CB1FCB1FCBFFC9ED5410BD6DAD"
+
+const testSubwallet = 698983191
+const testNow = 1700000000
+
+type testConfig map[int32]*cell.Cell
+
+func (c testConfig) Get(id int32) *cell.Cell {
+	return c[id]
+}
+
+func (c testConfig) All() map[int32]*cell.Cell {
+	return c
+}
+
+func gasPricesCell(flatPrice, price, credit uint64) *cell.Cell {
+	return cell.BeginCell().
+		MustStoreUInt(0xd1, 8).MustStoreUInt(100, 64).MustStoreUInt(flatPrice, 64).
+		MustStoreUInt(0xde, 8).
+		MustStoreUInt(price, 64).
+		MustStoreUInt(1000000, 64).
+		MustStoreUInt(1000000, 64).
+		MustStoreUInt(credit, 64).
+		MustStoreUInt(10000000, 64).
+		MustStoreUInt(100000000, 64).
+		MustStoreUInt(1000000000, 64).
+		EndCell()
+}
+
+func msgPricesCell(lump, bit, cl uint64) *cell.Cell {
+	return cell.BeginCell().
+		MustStoreUInt(0xea, 8).
+		MustStoreUInt(lump, 64).
+		MustStoreUInt(bit, 64).
+		MustStoreUInt(cl, 64).
+		MustStoreUInt(98304, 32).
+		MustStoreUInt(21845, 16).
+		MustStoreUInt(21845, 16).
+		EndCell()
+}
+
+func testEmulator(t *testing.T) *Emulator {
+	storage := cell.NewDict(32)
+	if err := storage.SetIntKey(big.NewInt(0), cell.BeginCell().
+		MustStoreUInt(0xcc, 8).MustStoreUInt(0, 32).
+		MustStoreUInt(1, 64).MustStoreUInt(500, 64).
+		MustStoreUInt(1000, 64).MustStoreUInt(500000, 64).
+		EndCell()); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := NewEmulator(testConfig{
+		8:  cell.BeginCell().MustStoreUInt(0xc4, 8).MustStoreUInt(9, 32).MustStoreUInt(0, 64).EndCell(),
+		18: storage.AsCell(),
+		20: gasPricesCell(1000000, 655360000, 10000),
+		21: gasPricesCell(40000, 26214400, 10000),
+		24: msgPricesCell(10000000, 655360000, 65536000000),
+		25: msgPricesCell(400000, 26214400, 2621440000),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func testWallet(t *testing.T, pub ed25519.PublicKey, seqno uint64, balance uint64, active bool) (*tlb.Account, *tlb.StateInit) {
+	boc, _ := hex.DecodeString(_V3R2CodeHex)
+	code, err := cell.FromBOC(boc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	si := &tlb.StateInit{
+		Code: code,
+		Data: cell.BeginCell().
+			MustStoreUInt(seqno, 32).
+			MustStoreUInt(testSubwallet, 32).
+			MustStoreSlice(pub, 256).
+			EndCell(),
+	}
+	siCell, err := tlb.ToCell(si)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := address.NewAddress(0, 0, siCell.Hash())
+
+	storage := tlb.AccountStorage{
+		Status:            tlb.AccountStatusUninit,
+		LastTransactionLT: 1000,
+		Balance:           tlb.FromNanoTONU(balance),
+	}
+	if active {
+		storage.Status = tlb.AccountStatusActive
+		storage.StateInit = si
+	}
+
+	storageCell, err := storage.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells, bits := storageCell.StorageStat()
+
+	acc := &tlb.Account{
+		IsActive: true,
+		State: &tlb.AccountState{
+			IsValid: true,
+			Address: addr,
+			StorageInfo: tlb.StorageInfo{
+				StorageUsed: tlb.StorageUsed{
+					CellsUsed:       new(big.Int).SetUint64(cells),
+					BitsUsed:        new(big.Int).SetUint64(bits),
+					PublicCellsUsed: big.NewInt(0),
+				},
+				LastPaid: testNow - 3600,
+			},
+			AccountStorage: storage,
+		},
+		LastTxLT:   999,
+		LastTxHash: make([]byte, 32),
+	}
+	if active {
+		acc.Code = si.Code
+		acc.Data = si.Data
+	}
+	return acc, si
+}
+
+func walletTransfer(priv ed25519.PrivateKey, seqno uint64, to *address.Address, amount uint64, mode uint64) *cell.Cell {
+	msg, err := tlb.ToCell(&tlb.InternalMessage{
+		IHRDisabled: true,
+		Bounce:      true,
+		DstAddr:     to,
+		Amount:      tlb.FromNanoTONU(amount),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	payload := cell.BeginCell().
+		MustStoreUInt(testSubwallet, 32).
+		MustStoreUInt(testNow+60, 32).
+		MustStoreUInt(seqno, 32).
+		MustStoreUInt(mode, 8).
+		MustStoreRef(msg)
+
+	return cell.BeginCell().
+		MustStoreSlice(payload.EndCell().Sign(priv), 512).
+		MustStoreBuilder(payload).
+		EndCell()
+}
+
+func checkBalanceFlow(t *testing.T, before *big.Int, credit *big.Int, res *Result) {
+	out := new(big.Int)
+	for _, m := range res.OutMessages {
+		if m.MsgType != tlb.MsgTypeInternal {
+			continue
+		}
+		in := m.AsInternal()
+		out.Add(out, in.Amount.Nano())
+		out.Add(out, in.FwdFee.Nano())
+		out.Add(out, in.IHRFee.Nano())
+	}
+
+	after := new(big.Int).Add(res.Account.State.Balance.Nano(), res.Transaction.TotalFees.Coins.Nano())
+	after.Add(after, out)
+	if new(big.Int).Add(before, credit).Cmp(after) != 0 {
+		t.Fatal("balance is not conserved", before.String(), credit.String(), after.String())
+	}
+}
+
+func TestEmulator_ExternalTransfer(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	e := testEmulator(t)
+
+	acc, _ := testWallet(t, pub, 5, 3000000000, true)
+	dst := address.NewAddress(0, 0, make([]byte, 32))
+
+	res, err := e.EmulateExternal(acc, &tlb.ExternalMessage{
+		DstAddr: acc.State.Address,
+		Body:    walletTransfer(priv, 5, dst, 1000000000, 3),
+	}, &Params{Now: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc, ok := res.Transaction.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if !ok {
+		t.Fatal("incorrect description type")
+	}
+	if desc.Aborted {
+		t.Fatal("transaction should not be aborted")
+	}
+	if vm, ok := desc.ComputePhase.Phase.(tlb.ComputePhaseVM); !ok || !vm.Success {
+		t.Fatal("compute phase should be successful")
+	}
+	if desc.ActionPhase == nil || !desc.ActionPhase.Success || desc.ActionPhase.MessagesCreated != 1 {
+		t.Fatal("action phase should be successful")
+	}
+	if desc.StoragePhase == nil || desc.StoragePhase.StorageFeesCollected.Nano().Sign() <= 0 {
+		t.Fatal("storage fees should be collected")
+	}
+
+	if len(res.OutMessages) != 1 {
+		t.Fatal("incorrect out messages count", len(res.OutMessages))
+	}
+	out := res.OutMessages[0].AsInternal()
+	if out.Amount.Nano().Uint64() != 1000000000 {
+		t.Fatal("incorrect out message amount", out.Amount.String())
+	}
+	if !out.SrcAddr.Equals(acc.State.Address) || !out.DstAddr.Equals(dst) {
+		t.Fatal("incorrect out message addresses")
+	}
+	if out.CreatedLT != res.Transaction.LT+1 {
+		t.Fatal("incorrect out message lt")
+	}
+
+	if seqno := res.Account.Data.BeginParse().MustLoadUInt(32); seqno != 6 {
+		t.Fatal("incorrect seqno", seqno)
+	}
+	if res.Account.State.LastTransactionLT != res.Transaction.LT+2 {
+		t.Fatal("incorrect account last lt")
+	}
+	checkBalanceFlow(t, acc.State.Balance.Nano(), big.NewInt(0), res)
+
+	var tx tlb.Transaction
+	if err = tlb.LoadFromCell(&tx, res.TransactionCell.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if tx.OutMsgCount != 1 || tx.TotalFees.Coins.Nano().Cmp(res.Transaction.TotalFees.Coins.Nano()) != 0 {
+		t.Fatal("transaction is incorrectly serialized")
+	}
+
+	// next transaction should be emulated on top of the new state
+	res, err = e.EmulateExternal(res.Account, &tlb.ExternalMessage{
+		DstAddr: acc.State.Address,
+		Body:    walletTransfer(priv, 6, dst, 1000000000, 3),
+	}, &Params{Now: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.OutMessages) != 1 {
+		t.Fatal("incorrect out messages count for second transfer")
+	}
+}
+
+func TestEmulator_ExternalNotAccepted(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	_, wrongKey, _ := ed25519.GenerateKey(nil)
+	e := testEmulator(t)
+
+	acc, _ := testWallet(t, pub, 5, 3000000000, true)
+
+	_, err := e.EmulateExternal(acc, &tlb.ExternalMessage{
+		DstAddr: acc.State.Address,
+		Body:    walletTransfer(wrongKey, 5, acc.State.Address, 1000000000, 3),
+	}, &Params{Now: testNow})
+
+	var notAccepted ExternalNotAcceptedError
+	if !errors.As(err, &notAccepted) {
+		t.Fatal("message should not be accepted", err)
+	}
+	if notAccepted.ExitCode != 35 {
+		t.Fatal("incorrect exit code", notAccepted.ExitCode)
+	}
+}
+
+func TestEmulator_Deploy(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	e := testEmulator(t)
+
+	acc, si := testWallet(t, pub, 0, 1000000000, false)
+
+	res, err := e.EmulateExternal(acc, &tlb.ExternalMessage{
+		DstAddr:   acc.State.Address,
+		StateInit: si,
+		Body:      walletTransfer(priv, 0, acc.State.Address, 1, 3),
+	}, &Params{Now: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Transaction.OrigStatus != tlb.AccountStatusUninit || res.Transaction.EndStatus != tlb.AccountStatusActive {
+		t.Fatal("account should be activated")
+	}
+	if res.Account.State.Status != tlb.AccountStatusActive || res.Account.Code == nil {
+		t.Fatal("incorrect account state")
+	}
+	if seqno := res.Account.Data.BeginParse().MustLoadUInt(32); seqno != 1 {
+		t.Fatal("incorrect seqno", seqno)
+	}
+}
+
+func TestEmulator_InternalBounce(t *testing.T) {
+	e := testEmulator(t)
+
+	src := address.NewAddress(0, 0, make([]byte, 32))
+	dst := address.NewAddress(0, 0, append(make([]byte, 31), 1))
+
+	res, err := e.EmulateInternal(nil, &tlb.InternalMessage{
+		IHRDisabled: true,
+		Bounce:      true,
+		SrcAddr:     src,
+		DstAddr:     dst,
+		Amount:      tlb.FromNanoTONU(500000000),
+		FwdFee:      tlb.FromNanoTONU(0),
+		CreatedLT:   5000,
+		CreatedAt:   testNow,
+		Body:        cell.BeginCell().MustStoreUInt(0x12345678, 32).EndCell(),
+	}, &Params{Now: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc := res.Transaction.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if _, ok := desc.ComputePhase.Phase.(tlb.ComputePhaseSkipped); !ok {
+		t.Fatal("compute phase should be skipped")
+	}
+	if desc.BouncePhase == nil {
+		t.Fatal("message should be bounced")
+	}
+	if _, ok := desc.BouncePhase.Phase.(tlb.BouncePhaseOk); !ok {
+		t.Fatal("bounce should be successful")
+	}
+	if res.Transaction.LT != 5001 {
+		t.Fatal("incorrect transaction lt", res.Transaction.LT)
+	}
+
+	if len(res.OutMessages) != 1 {
+		t.Fatal("bounce message should be created")
+	}
+	out := res.OutMessages[0].AsInternal()
+	if !out.Bounced || out.Bounce || !out.DstAddr.Equals(src) {
+		t.Fatal("incorrect bounce message")
+	}
+
+	body := out.Body.BeginParse()
+	if body.MustLoadUInt(32) != 0xFFFFFFFF || body.MustLoadUInt(32) != 0x12345678 {
+		t.Fatal("incorrect bounce message body")
+	}
+
+	if res.Transaction.EndStatus != tlb.AccountStatusNonExist || res.Account.State.IsValid {
+		t.Fatal("account should not exist")
+	}
+	checkBalanceFlow(t, big.NewInt(0), big.NewInt(500000000), res)
+}
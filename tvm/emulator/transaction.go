@@ -0,0 +1,669 @@
+package emulator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"github.com/xssnick/tonutils-go/tvm/vm"
+)
+
+type transaction struct {
+	cfg    *parsedConfig
+	params *Params
+
+	acc     *tlb.Account
+	addr    *address.Address
+	isMc    bool
+	now     uint32
+	lt      uint64
+	oldHash []byte
+
+	msg        *tlb.Message
+	msgCell    *cell.Cell
+	isExternal bool
+	bounce     bool
+	msgValue   *big.Int
+	// msgRemaining - value of inbound message which is not spent yet
+	msgRemaining *big.Int
+
+	origStatus tlb.AccountStatus
+	status     tlb.AccountStatus
+	balance    *big.Int
+	lastPaid   uint32
+	due        *big.Int
+	stateInit  *tlb.StateInit
+	frozenHash []byte
+	extra      *cell.Dictionary
+
+	totalFees     *big.Int
+	storageFees   *big.Int
+	gasFees       *big.Int
+	computeRan    bool
+	balanceBefore *big.Int
+
+	desc    tlb.TransactionDescriptionOrdinary
+	outMsgs []*cell.Cell
+}
+
+func newTransaction(cfg *parsedConfig, acc *tlb.Account, msg *tlb.Message, params *Params) (*transaction, error) {
+	t := &transaction{
+		cfg:          cfg,
+		params:       params,
+		acc:          acc,
+		msg:          msg,
+		isExternal:   msg.MsgType == tlb.MsgTypeExternalIn,
+		msgValue:     big.NewInt(0),
+		msgRemaining: big.NewInt(0),
+		balance:      big.NewInt(0),
+		due:          big.NewInt(0),
+		totalFees:    big.NewInt(0),
+		storageFees:  big.NewInt(0),
+		gasFees:      big.NewInt(0),
+		now:          params.Now,
+		status:       tlb.AccountStatusNonExist,
+	}
+
+	if t.now == 0 {
+		t.now = uint32(time.Now().Unix())
+	}
+
+	var err error
+	if t.msgCell, err = tlb.ToCell(msg.Msg); err != nil {
+		return nil, fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	dst := msg.Msg.DestAddr()
+	if dst == nil || dst.Type() != address.StdAddress {
+		return nil, fmt.Errorf("message destination should be a standard address")
+	}
+
+	oldState := &tlb.AccountState{}
+	if acc != nil && acc.State != nil && acc.State.IsValid {
+		oldState = acc.State
+		if !bytes.Equal(oldState.Address.Data(), dst.Data()) || oldState.Address.Workchain() != dst.Workchain() {
+			return nil, ErrAddressMismatch
+		}
+
+		t.status = oldState.Status
+		t.balance = new(big.Int).Set(oldState.Balance.Nano())
+		t.extra = oldState.ExtraCurrencies
+		t.lastPaid = oldState.StorageInfo.LastPaid
+		if oldState.StorageInfo.DuePayment != nil {
+			t.due = new(big.Int).Set(oldState.StorageInfo.DuePayment.Nano())
+		}
+
+		switch t.status {
+		case tlb.AccountStatusActive:
+			t.stateInit = oldState.StateInit
+			if t.stateInit == nil {
+				t.stateInit = &tlb.StateInit{Code: acc.Code, Data: acc.Data}
+			}
+		case tlb.AccountStatusFrozen:
+			t.frozenHash = oldState.StateHash
+		}
+	}
+	t.origStatus = t.status
+	t.addr = address.NewAddress(0, byte(dst.Workchain()), dst.Data())
+	t.isMc = dst.Workchain() == address.MasterchainID
+
+	oldCell, err := oldState.ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize account state: %w", err)
+	}
+	t.oldHash = oldCell.Hash()
+
+	t.lt = params.LT
+	if t.lt == 0 {
+		if acc != nil && acc.State != nil && acc.State.IsValid {
+			t.lt = acc.State.LastTransactionLT + 1
+		}
+		if !t.isExternal {
+			if lt := msg.AsInternal().CreatedLT + 1; lt > t.lt {
+				t.lt = lt
+			}
+		}
+	}
+
+	if !t.isExternal {
+		in := msg.AsInternal()
+		t.bounce = in.Bounce
+		t.msgValue = new(big.Int).Set(in.Amount.Nano())
+		t.msgRemaining = new(big.Int).Set(t.msgValue)
+	}
+
+	return t, nil
+}
+
+func (t *transaction) run() error {
+	t.desc.CreditFirst = !t.bounce
+
+	if t.isExternal {
+		// import fee is paid from account balance
+		cells, bits := t.msgCell.RefsStorageStat()
+		fee := t.cfg.msgFor(t.isMc).CalcFwdFee(cells, bits)
+		if t.balance.Cmp(fee) < 0 {
+			return ErrCannotPayImport
+		}
+		t.balance.Sub(t.balance, fee)
+		t.totalFees.Add(t.totalFees, fee)
+
+		t.desc.StoragePhase = t.storagePhase()
+	} else if t.desc.CreditFirst {
+		t.desc.CreditPhase = t.creditPhase()
+		t.desc.StoragePhase = t.storagePhase()
+	} else {
+		t.desc.StoragePhase = t.storagePhase()
+		t.desc.CreditPhase = t.creditPhase()
+	}
+
+	t.balanceBefore = new(big.Int).Set(t.balance)
+
+	compute, res, newState, err := t.computePhase()
+	if err != nil {
+		return err
+	}
+	t.desc.ComputePhase = tlb.ComputePhase{Phase: compute}
+
+	computeSuccess := false
+	if vmPhase, ok := compute.(tlb.ComputePhaseVM); ok {
+		computeSuccess = vmPhase.Success
+	}
+
+	bounceRequired := !computeSuccess
+	if computeSuccess {
+		ap, err := newActionPhase(t, res.Actions, newState)
+		if err != nil {
+			return err
+		}
+
+		phase := ap.run()
+		t.desc.ActionPhase = phase
+
+		if phase.Success {
+			ap.commit(res.Data)
+		} else {
+			bounceRequired = ap.bounce
+		}
+		t.desc.Aborted = !phase.Success
+		t.desc.Destroyed = phase.StatusChange.Type == tlb.AccStatusChangeDeleted
+	} else {
+		t.desc.Aborted = true
+	}
+
+	if t.desc.Aborted && bounceRequired && t.bounce && !t.isExternal {
+		t.desc.BouncePhase = t.bouncePhase()
+	}
+
+	if t.status == tlb.AccountStatusUninit && t.balance.Sign() == 0 {
+		// uninitialized accounts without balance are not stored
+		t.status = tlb.AccountStatusNonExist
+	}
+	return nil
+}
+
+func (t *transaction) storagePhase() *tlb.StoragePhase {
+	phase := &tlb.StoragePhase{
+		StorageFeesCollected: tlb.FromNanoTONU(0),
+		StatusChange:         tlb.AccStatusChange{Type: tlb.AccStatusChangeUnchanged},
+	}
+
+	if t.status == tlb.AccountStatusNonExist {
+		t.lastPaid = t.now
+		return phase
+	}
+
+	var cells, bits uint64
+	if t.acc != nil && t.acc.State != nil {
+		used := t.acc.State.StorageInfo.StorageUsed
+		if used.CellsUsed != nil {
+			cells = used.CellsUsed.Uint64()
+		}
+		if used.BitsUsed != nil {
+			bits = used.BitsUsed.Uint64()
+		}
+	}
+
+	fee := tlb.CalcStorageFee(t.cfg.storage, t.isMc, cells, bits, t.lastPaid, t.now)
+	if t.now > t.lastPaid {
+		t.lastPaid = t.now
+	}
+	fee.Add(fee, t.due)
+
+	collected := fee
+	if t.balance.Cmp(fee) < 0 {
+		collected = new(big.Int).Set(t.balance)
+		t.due = new(big.Int).Sub(fee, t.balance)
+	} else {
+		t.due = big.NewInt(0)
+	}
+	t.balance.Sub(t.balance, collected)
+	t.totalFees.Add(t.totalFees, collected)
+	t.storageFees = collected
+
+	phase.StorageFeesCollected = tlb.FromNanoTON(collected)
+	if t.due.Sign() > 0 {
+		due := tlb.FromNanoTON(t.due)
+		phase.StorageFeesDue = &due
+
+		gp := t.cfg.gasFor(t.isMc)
+		if t.due.Cmp(new(big.Int).SetUint64(gp.DeleteDueLimit)) > 0 && t.status != tlb.AccountStatusActive {
+			t.status = tlb.AccountStatusNonExist
+			phase.StatusChange.Type = tlb.AccStatusChangeDeleted
+		} else if t.due.Cmp(new(big.Int).SetUint64(gp.FreezeDueLimit)) > 0 && t.status == tlb.AccountStatusActive {
+			if stCell, err := tlb.ToCell(t.stateInit); err == nil {
+				t.frozenHash = stCell.Hash()
+				t.stateInit = nil
+				t.status = tlb.AccountStatusFrozen
+				phase.StatusChange.Type = tlb.AccStatusChangeFrozen
+			}
+		}
+	}
+	return phase
+}
+
+func (t *transaction) creditPhase() *tlb.CreditPhase {
+	phase := &tlb.CreditPhase{}
+
+	if t.due.Sign() > 0 {
+		collected := new(big.Int).Set(t.due)
+		if collected.Cmp(t.msgRemaining) > 0 {
+			collected.Set(t.msgRemaining)
+		}
+		t.due.Sub(t.due, collected)
+		t.msgRemaining.Sub(t.msgRemaining, collected)
+		t.totalFees.Add(t.totalFees, collected)
+
+		c := tlb.FromNanoTON(collected)
+		phase.DueFeesCollected = &c
+	}
+
+	t.balance.Add(t.balance, t.msgRemaining)
+	phase.Credit = tlb.CurrencyCollection{
+		Coins: tlb.FromNanoTON(new(big.Int).Set(t.msgRemaining)),
+	}
+
+	if t.status == tlb.AccountStatusNonExist && t.balance.Sign() > 0 {
+		t.status = tlb.AccountStatusUninit
+	}
+	return phase
+}
+
+func (t *transaction) msgStateInit() *tlb.StateInit {
+	switch m := t.msg.Msg.(type) {
+	case *tlb.InternalMessage:
+		return m.StateInit
+	case *tlb.ExternalMessage:
+		return m.StateInit
+	}
+	return nil
+}
+
+func (t *transaction) computePhase() (any, *vm.ExecutionResult, *tlb.StateInit, error) {
+	skip := func(reason tlb.ComputeSkipReasonType) (any, *vm.ExecutionResult, *tlb.StateInit, error) {
+		return tlb.ComputePhaseSkipped{Reason: tlb.ComputeSkipReason{Type: reason}}, nil, nil, nil
+	}
+
+	state := t.stateInit
+	activated := false
+	if t.status != tlb.AccountStatusActive {
+		si := t.msgStateInit()
+		if si == nil {
+			if t.isExternal {
+				return nil, nil, nil, ExternalNotAcceptedError{ExitCode: -1}
+			}
+			return skip(tlb.ComputeSkipReasonNoState)
+		}
+
+		siCell, err := tlb.ToCell(si)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to serialize message state init: %w", err)
+		}
+
+		expected := t.addr.Data()
+		if t.status == tlb.AccountStatusFrozen {
+			expected = t.frozenHash
+		}
+		if !bytes.Equal(siCell.Hash(), expected) {
+			if t.isExternal {
+				return nil, nil, nil, ExternalNotAcceptedError{ExitCode: -1}
+			}
+			return skip(tlb.ComputeSkipReasonBadState)
+		}
+		state = si
+		activated = true
+	}
+
+	if state.Code == nil {
+		if t.isExternal {
+			return nil, nil, nil, ExternalNotAcceptedError{ExitCode: -1}
+		}
+		return skip(tlb.ComputeSkipReasonNoState)
+	}
+
+	gp := t.cfg.gasFor(t.isMc)
+	gasMax := gp.CalcGasBought(t.balance)
+
+	var gasLimit, gasCredit uint64
+	if t.isExternal {
+		gasCredit = gp.GasCredit
+		if gasCredit > gasMax {
+			gasCredit = gasMax
+		}
+	} else {
+		gasLimit = gp.CalcGasBought(t.msgRemaining)
+		if gasLimit > gasMax {
+			gasLimit = gasMax
+		}
+	}
+
+	if gasLimit == 0 && gasCredit == 0 {
+		if t.isExternal {
+			return nil, nil, nil, ExternalNotAcceptedError{ExitCode: -1}
+		}
+		return skip(tlb.ComputeSkipReasonNoGas)
+	}
+
+	seed := make([]byte, 32)
+	copy(seed, t.params.RandSeed)
+	randSeed := sha256.Sum256(append(seed, t.addr.Data()...))
+
+	blockLT := t.params.BlockLT
+	if blockLT == 0 {
+		blockLT = t.lt
+	}
+
+	c7, err := (&vm.ContractInfo{
+		Now:           t.now,
+		BlockLT:       blockLT,
+		LT:            t.lt,
+		RandSeed:      randSeed[:],
+		Balance:       new(big.Int).Set(t.balance),
+		Address:       t.addr,
+		Config:        t.cfg.root,
+		Code:          state.Code,
+		IncomingValue: new(big.Int).Set(t.msgValue),
+		StorageFees:   new(big.Int).Set(t.storageFees),
+		DuePayment:    new(big.Int).Set(t.due),
+	}).ToC7()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to build c7: %w", err)
+	}
+
+	body := cell.BeginCell().EndCell()
+	if p := t.msg.Msg.Payload(); p != nil {
+		body = p
+	}
+
+	selector := int64(0)
+	if t.isExternal {
+		selector = -1
+	}
+
+	// tlb stack top is a bottom of vm stack
+	stack := tlb.NewStack()
+	stack.Push(selector)
+	stack.Push(body.BeginParse())
+	stack.Push(t.msgCell)
+	stack.Push(new(big.Int).Set(t.msgValue))
+	stack.Push(new(big.Int).Set(t.balance))
+
+	machine := vm.NewTVM()
+	if t.cfg.globalVersion > 0 {
+		machine.SetGlobalVersion(t.cfg.globalVersion)
+	}
+	machine.AddLibraries(t.params.Libraries...)
+	for _, s := range []*tlb.StateInit{t.stateInit, t.msgStateInit()} {
+		if s != nil {
+			machine.AddLibraries(libraryCells(s.Lib)...)
+		}
+	}
+
+	res, err := machine.Execute(state.Code, state.Data, c7, vm.Gas{
+		Max:    int64(gasMax),
+		Limit:  int64(gasLimit),
+		Credit: int64(gasCredit),
+	}, stack)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to execute contract: %w", err)
+	}
+
+	if t.isExternal && res.GasCredit != 0 {
+		return nil, nil, nil, ExternalNotAcceptedError{ExitCode: res.ExitCode}
+	}
+
+	gasUsed := uint64(res.GasUsed)
+	fees := gp.CalcGasFee(gasUsed)
+	if fees.Cmp(t.balance) > 0 {
+		fees.Set(t.balance)
+	}
+	t.balance.Sub(t.balance, fees)
+	t.totalFees.Add(t.totalFees, fees)
+	t.gasFees = fees
+	t.computeRan = true
+
+	phase := tlb.ComputePhaseVM{
+		Success:          (res.ExitCode == 0 || res.ExitCode == 1) && res.Committed,
+		MsgStateUsed:     activated,
+		AccountActivated: activated,
+		GasFees:          tlb.FromNanoTON(fees),
+	}
+	phase.Details.GasUsed = new(big.Int).SetUint64(gasUsed)
+	phase.Details.GasLimit = new(big.Int).SetUint64(gasLimit)
+	if gasCredit > 0 {
+		phase.Details.GasCredit = new(big.Int).SetUint64(gasCredit)
+	}
+	phase.Details.ExitCode = res.ExitCode
+	phase.Details.VMSteps = uint32(res.Steps)
+	phase.Details.VMInitStateHash = make([]byte, 32)
+	phase.Details.VMFinalStateHash = make([]byte, 32)
+
+	return phase, res, state, nil
+}
+
+func (t *transaction) bouncePhase() *tlb.BouncePhase {
+	msg := t.msg.AsInternal()
+
+	body := cell.BeginCell().MustStoreUInt(0xFFFFFFFF, 32)
+	if msg.Body != nil {
+		s := msg.Body.BeginParse()
+		sz := s.BitsLeft()
+		if sz > 256 {
+			sz = 256
+		}
+		body.MustStoreSlice(s.MustLoadSlice(sz), sz)
+	}
+
+	prices := t.cfg.msgFor(t.isMc || msg.SrcAddr.Workchain() == address.MasterchainID)
+	fwd := prices.CalcFwdFee(0, 0)
+
+	value := new(big.Int).Set(t.msgRemaining)
+	if t.computeRan {
+		value.Sub(value, t.gasFees)
+		if value.Sign() < 0 {
+			value.SetInt64(0)
+		}
+	}
+
+	size := tlb.StorageUsedShort{Cells: big.NewInt(0), Bits: big.NewInt(0)}
+	if value.Cmp(fwd) < 0 || t.balance.Cmp(value) < 0 {
+		return &tlb.BouncePhase{Phase: tlb.BouncePhaseNoFunds{
+			MsgSize:    size,
+			ReqFwdFees: tlb.FromNanoTON(fwd),
+		}}
+	}
+
+	t.balance.Sub(t.balance, value)
+	value.Sub(value, fwd)
+
+	collected := prices.CalcFirstFrac(fwd)
+	t.totalFees.Add(t.totalFees, collected)
+
+	out := &tlb.InternalMessage{
+		IHRDisabled: true,
+		Bounce:      false,
+		Bounced:     true,
+		SrcAddr:     t.addr,
+		DstAddr:     msg.SrcAddr,
+		Amount:      tlb.FromNanoTON(value),
+		IHRFee:      tlb.FromNanoTONU(0),
+		FwdFee:      tlb.FromNanoTON(new(big.Int).Sub(fwd, collected)),
+		CreatedLT:   t.lt + 1,
+		CreatedAt:   t.now,
+		Body:        body.EndCell(),
+	}
+
+	outCell, err := tlb.ToCell(out)
+	if err == nil {
+		t.outMsgs = append(t.outMsgs, outCell)
+	}
+
+	return &tlb.BouncePhase{Phase: tlb.BouncePhaseOk{
+		MsgSize: size,
+		MsgFees: tlb.FromNanoTON(collected),
+		FwdFees: tlb.FromNanoTON(new(big.Int).Sub(fwd, collected)),
+	}}
+}
+
+func (t *transaction) result() (*Result, error) {
+	storage := tlb.AccountStorage{
+		Status:            t.status,
+		LastTransactionLT: t.lt + 1 + uint64(len(t.outMsgs)),
+		Balance:           tlb.FromNanoTON(t.balance),
+		ExtraCurrencies:   t.extra,
+	}
+	switch t.status {
+	case tlb.AccountStatusActive:
+		storage.StateInit = t.stateInit
+	case tlb.AccountStatusFrozen:
+		storage.StateHash = t.frozenHash
+	}
+
+	newState := &tlb.AccountState{}
+	if t.status != tlb.AccountStatusNonExist {
+		storageCell, err := storage.ToCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize new account storage: %w", err)
+		}
+		cells, bits := storageCell.StorageStat()
+
+		newState = &tlb.AccountState{
+			IsValid: true,
+			Address: t.addr,
+			StorageInfo: tlb.StorageInfo{
+				StorageUsed: tlb.StorageUsed{
+					CellsUsed:       new(big.Int).SetUint64(cells),
+					BitsUsed:        new(big.Int).SetUint64(bits),
+					PublicCellsUsed: big.NewInt(0),
+				},
+				LastPaid: t.lastPaid,
+			},
+			AccountStorage: storage,
+		}
+		if t.due.Sign() > 0 {
+			due := tlb.FromNanoTON(t.due)
+			newState.StorageInfo.DuePayment = &due
+		}
+	}
+
+	newCell, err := newState.ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize new account state: %w", err)
+	}
+
+	prevHash := make([]byte, 32)
+	var prevLT uint64
+	if t.acc != nil {
+		if len(t.acc.LastTxHash) == 32 {
+			prevHash = t.acc.LastTxHash
+		}
+		prevLT = t.acc.LastTxLT
+	}
+
+	tx := &tlb.Transaction{
+		AccountAddr: t.addr.Data(),
+		LT:          t.lt,
+		PrevTxHash:  prevHash,
+		PrevTxLT:    prevLT,
+		Now:         t.now,
+		OutMsgCount: uint16(len(t.outMsgs)),
+		OrigStatus:  t.origStatus,
+		EndStatus:   t.status,
+		TotalFees:   tlb.CurrencyCollection{Coins: tlb.FromNanoTON(t.totalFees)},
+		StateUpdate: tlb.HashUpdate{
+			OldHash: t.oldHash,
+			NewHash: newCell.Hash(),
+		},
+		Description: tlb.TransactionDescription{Description: t.desc},
+	}
+	tx.IO.In = t.msg
+
+	var outMsgs []*tlb.Message
+	if len(t.outMsgs) > 0 {
+		dict := cell.NewDict(15)
+		for i, c := range t.outMsgs {
+			if err = dict.SetIntKey(big.NewInt(int64(i)), cell.BeginCell().MustStoreRef(c).EndCell()); err != nil {
+				return nil, fmt.Errorf("failed to store out message: %w", err)
+			}
+
+			var m tlb.Message
+			if err = m.LoadFromCell(c.BeginParse()); err != nil {
+				return nil, fmt.Errorf("failed to parse out message: %w", err)
+			}
+			outMsgs = append(outMsgs, &m)
+		}
+		tx.IO.Out = &tlb.MessagesList{List: dict}
+	}
+
+	txCell, err := tlb.ToCell(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	tx.Hash = txCell.Hash()
+
+	acc := &tlb.Account{
+		IsActive:   newState.IsValid,
+		State:      newState,
+		LastTxLT:   t.lt,
+		LastTxHash: tx.Hash,
+	}
+	if t.status == tlb.AccountStatusActive {
+		acc.Code = t.stateInit.Code
+		acc.Data = t.stateInit.Data
+	}
+
+	return &Result{
+		Transaction:     tx,
+		TransactionCell: txCell,
+		Account:         acc,
+		OutMessages:     outMsgs,
+	}, nil
+}
+
+func libraryCells(libs *cell.Dictionary) []*cell.Cell {
+	if libs == nil || libs.IsEmpty() {
+		return nil
+	}
+
+	kvs, err := libs.LoadAll()
+	if err != nil {
+		return nil
+	}
+
+	var res []*cell.Cell
+	for _, kv := range kvs {
+		// simple_lib$_ public:Bool root:^Cell
+		if _, err = kv.Value.LoadBoolBit(); err != nil {
+			continue
+		}
+		if lib, err := kv.Value.LoadRefCell(); err == nil {
+			res = append(res, lib)
+		}
+	}
+	return res
+}
@@ -0,0 +1,351 @@
+package vm
+
+import (
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type continuation interface {
+	// jump - executes continuation logic and returns next continuation to jump to, or nil
+	jump(st *state) (continuation, error)
+	// data - returns control data of continuation, nil if continuation has no data
+	data() *controlData
+	// copy - returns a copy which can be safely modified
+	copy() continuation
+}
+
+// registers - control registers set, nil values are undefined
+type registers struct {
+	c  [4]continuation
+	d  [2]*cell.Cell
+	c7 []any
+}
+
+// controlData - saved registers, captured stack and number of args of continuation
+type controlData struct {
+	save  registers
+	stack *stack
+	nargs int
+	cp    int
+}
+
+func newControlData() controlData {
+	return controlData{nargs: -1, cp: -1}
+}
+
+func (d *controlData) copy() controlData {
+	res := *d
+	if d.stack != nil {
+		res.stack = d.stack.copy()
+	}
+	return res
+}
+
+// define - sets register if it is not set yet
+func (r *registers) define(i int, v any) error {
+	switch {
+	case i >= 0 && i < 4:
+		c, ok := v.(continuation)
+		if !ok {
+			return errTypeCheck("not a continuation")
+		}
+		if r.c[i] != nil {
+			return errTypeCheck("control register is already defined")
+		}
+		r.c[i] = c
+	case i == 4 || i == 5:
+		c, ok := v.(*cell.Cell)
+		if !ok {
+			return errTypeCheck("not a cell")
+		}
+		if r.d[i-4] != nil {
+			return errTypeCheck("control register is already defined")
+		}
+		r.d[i-4] = c
+	case i == 7:
+		c, ok := v.([]any)
+		if !ok {
+			return errTypeCheck("not a tuple")
+		}
+		if r.c7 != nil {
+			return errTypeCheck("control register is already defined")
+		}
+		r.c7 = c
+	default:
+		return errRangeCheck("invalid control register")
+	}
+	return nil
+}
+
+func (r *registers) get(i int) any {
+	switch {
+	case i >= 0 && i < 4:
+		if r.c[i] == nil {
+			return nil
+		}
+		return r.c[i]
+	case i == 4 || i == 5:
+		if r.d[i-4] == nil {
+			return nil
+		}
+		return r.d[i-4]
+	case i == 7:
+		if r.c7 == nil {
+			return nil
+		}
+		return r.c7
+	}
+	return nil
+}
+
+func (r *registers) set(i int, v any) error {
+	switch {
+	case i >= 0 && i < 4:
+		c, ok := v.(continuation)
+		if !ok {
+			return errTypeCheck("not a continuation")
+		}
+		r.c[i] = c
+	case i == 4 || i == 5:
+		c, ok := v.(*cell.Cell)
+		if !ok {
+			return errTypeCheck("not a cell")
+		}
+		r.d[i-4] = c
+	case i == 7:
+		c, ok := v.([]any)
+		if !ok {
+			return errTypeCheck("not a tuple")
+		}
+		r.c7 = c
+	default:
+		return errRangeCheck("invalid control register")
+	}
+	return nil
+}
+
+func validRegister(i int) bool {
+	return (i >= 0 && i <= 5) || i == 7
+}
+
+// OrdinaryContinuation - continuation which executes code
+type ordCont struct {
+	cdata controlData
+	code  *cell.Slice
+}
+
+func newOrdCont(code *cell.Slice, cp int) *ordCont {
+	d := newControlData()
+	d.cp = cp
+	return &ordCont{cdata: d, code: code}
+}
+
+func (c *ordCont) jump(st *state) (continuation, error) {
+	st.adjustRegisters(&c.cdata.save)
+	st.setCode(c.code.Copy(), c.cdata.cp)
+	return nil, nil
+}
+
+func (c *ordCont) data() *controlData {
+	return &c.cdata
+}
+
+func (c *ordCont) copy() continuation {
+	return &ordCont{cdata: c.cdata.copy(), code: c.code}
+}
+
+// quitCont - stops execution with exit code
+type quitCont struct {
+	exitCode int64
+}
+
+func (c *quitCont) jump(st *state) (continuation, error) {
+	st.halt(c.exitCode)
+	return nil, nil
+}
+
+func (c *quitCont) data() *controlData {
+	return nil
+}
+
+func (c *quitCont) copy() continuation {
+	return c
+}
+
+// excQuitCont - default exception handler, stops execution with exit code from the stack
+type excQuitCont struct{}
+
+func (c *excQuitCont) jump(st *state) (continuation, error) {
+	code, err := st.stack.popIntRange(0, 0xffff)
+	if err != nil {
+		code = 0
+	}
+	st.halt(code)
+	return nil, nil
+}
+
+func (c *excQuitCont) data() *controlData {
+	return nil
+}
+
+func (c *excQuitCont) copy() continuation {
+	return c
+}
+
+// pushIntCont - pushes int and jumps to next
+type pushIntCont struct {
+	value int64
+	next  continuation
+}
+
+func (c *pushIntCont) jump(st *state) (continuation, error) {
+	st.stack.pushInt(c.value)
+	return c.next, nil
+}
+
+func (c *pushIntCont) data() *controlData {
+	return nil
+}
+
+func (c *pushIntCont) copy() continuation {
+	return c
+}
+
+type repeatCont struct {
+	body, after continuation
+	count       int64
+}
+
+func (c *repeatCont) jump(st *state) (continuation, error) {
+	if c.count <= 0 {
+		return c.after, nil
+	}
+	if hasC0(c.body) {
+		return c.body, nil
+	}
+	st.regs.c[0] = &repeatCont{body: c.body, after: c.after, count: c.count - 1}
+	return c.body, nil
+}
+
+func (c *repeatCont) data() *controlData {
+	return nil
+}
+
+func (c *repeatCont) copy() continuation {
+	return c
+}
+
+type againCont struct {
+	body continuation
+}
+
+func (c *againCont) jump(st *state) (continuation, error) {
+	if !hasC0(c.body) {
+		st.regs.c[0] = c
+	}
+	return c.body, nil
+}
+
+func (c *againCont) data() *controlData {
+	return nil
+}
+
+func (c *againCont) copy() continuation {
+	return c
+}
+
+type untilCont struct {
+	body, after continuation
+}
+
+func (c *untilCont) jump(st *state) (continuation, error) {
+	term, err := st.stack.popBool()
+	if err != nil {
+		return nil, err
+	}
+	if term {
+		return c.after, nil
+	}
+	if !hasC0(c.body) {
+		st.regs.c[0] = c
+	}
+	return c.body, nil
+}
+
+func (c *untilCont) data() *controlData {
+	return nil
+}
+
+func (c *untilCont) copy() continuation {
+	return c
+}
+
+type whileCont struct {
+	cond, body, after continuation
+	checkCond         bool
+}
+
+func (c *whileCont) jump(st *state) (continuation, error) {
+	if c.checkCond {
+		ok, err := st.stack.popBool()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return c.after, nil
+		}
+		if !hasC0(c.body) {
+			st.regs.c[0] = &whileCont{cond: c.cond, body: c.body, after: c.after, checkCond: false}
+		}
+		return c.body, nil
+	}
+
+	if !hasC0(c.cond) {
+		st.regs.c[0] = &whileCont{cond: c.cond, body: c.body, after: c.after, checkCond: true}
+	}
+	return c.cond, nil
+}
+
+func (c *whileCont) data() *controlData {
+	return nil
+}
+
+func (c *whileCont) copy() continuation {
+	return c
+}
+
+// argExtCont - wrapper to attach control data to continuation which has no own
+type argExtCont struct {
+	cdata controlData
+	ext   continuation
+}
+
+func (c *argExtCont) jump(st *state) (continuation, error) {
+	st.adjustRegisters(&c.cdata.save)
+	if c.cdata.cp != -1 {
+		st.cp = c.cdata.cp
+	}
+	return c.ext, nil
+}
+
+func (c *argExtCont) data() *controlData {
+	return &c.cdata
+}
+
+func (c *argExtCont) copy() continuation {
+	return &argExtCont{cdata: c.cdata.copy(), ext: c.ext}
+}
+
+func hasC0(c continuation) bool {
+	d := c.data()
+	return d != nil && d.save.c[0] != nil
+}
+
+// forceData - returns a copy of continuation with control data which can be modified
+func forceData(c continuation) (continuation, *controlData) {
+	c = c.copy()
+	if d := c.data(); d != nil {
+		return c, d
+	}
+	ext := &argExtCont{cdata: newControlData(), ext: c}
+	return ext, &ext.cdata
+}
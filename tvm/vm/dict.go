@@ -0,0 +1,566 @@
+package vm
+
+import (
+	"math/bits"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// hashmap (HashmapE) operations implemented over raw cells,
+// every visited node is loaded through the vm to account gas properly.
+
+type dictSetMode int
+
+const (
+	dictModeSet dictSetMode = iota
+	dictModeReplace
+	dictModeAdd
+)
+
+type dictNode struct {
+	label    []byte
+	labelLen uint
+	rest     *cell.Slice
+}
+
+func labelLenBits(maxLen uint) uint {
+	return uint(bits.Len(maxLen))
+}
+
+func (st *state) loadDictNode(c *cell.Cell, maxLen uint) (*dictNode, error) {
+	s, err := st.loadCell(c)
+	if err != nil {
+		return nil, err
+	}
+
+	lbl, ln, err := parseDictLabel(s, maxLen)
+	if err != nil {
+		return nil, err
+	}
+	return &dictNode{label: lbl, labelLen: ln, rest: s}, nil
+}
+
+func parseDictLabel(s *cell.Slice, maxLen uint) ([]byte, uint, error) {
+	first, err := s.LoadUInt(1)
+	if err != nil {
+		return nil, 0, errDict("invalid dictionary label")
+	}
+
+	if first == 0 {
+		// hml_short$0
+		ln := uint(0)
+		for {
+			bit, err := s.LoadUInt(1)
+			if err != nil {
+				return nil, 0, errDict("invalid dictionary label")
+			}
+			if bit == 0 {
+				break
+			}
+			ln++
+		}
+		if ln > maxLen {
+			return nil, 0, errDict("invalid dictionary label")
+		}
+		data, err := s.LoadSlice(ln)
+		if err != nil {
+			return nil, 0, errDict("invalid dictionary label")
+		}
+		return data, ln, nil
+	}
+
+	second, err := s.LoadUInt(1)
+	if err != nil {
+		return nil, 0, errDict("invalid dictionary label")
+	}
+
+	if second == 0 {
+		// hml_long$10
+		ln, err := s.LoadUInt(labelLenBits(maxLen))
+		if err != nil || uint(ln) > maxLen {
+			return nil, 0, errDict("invalid dictionary label")
+		}
+		data, err := s.LoadSlice(uint(ln))
+		if err != nil {
+			return nil, 0, errDict("invalid dictionary label")
+		}
+		return data, uint(ln), nil
+	}
+
+	// hml_same$11
+	bit, err := s.LoadUInt(1)
+	if err != nil {
+		return nil, 0, errDict("invalid dictionary label")
+	}
+	ln, err := s.LoadUInt(labelLenBits(maxLen))
+	if err != nil || uint(ln) > maxLen {
+		return nil, 0, errDict("invalid dictionary label")
+	}
+
+	data := make([]byte, (ln+7)/8)
+	if bit == 1 {
+		for i := range data {
+			data[i] = 0xFF
+		}
+	}
+	return data, uint(ln), nil
+}
+
+func storeDictLabel(b *cell.Builder, label []byte, ln, maxLen uint) error {
+	if ln == 0 {
+		return b.StoreUInt(0, 2)
+	}
+
+	k := labelLenBits(maxLen)
+	same := true
+	first := getBit(label, 0)
+	for i := uint(1); i < ln; i++ {
+		if getBit(label, i) != first {
+			same = false
+			break
+		}
+	}
+
+	if same && ln > 1 && k < 2*ln-1 {
+		bit := uint64(0)
+		if first {
+			bit = 1
+		}
+		if err := b.StoreUInt(0b110|bit, 3); err != nil {
+			return err
+		}
+		return b.StoreUInt(uint64(ln), k)
+	}
+
+	if k < ln {
+		if err := b.StoreUInt(0b10, 2); err != nil {
+			return err
+		}
+		if err := b.StoreUInt(uint64(ln), k); err != nil {
+			return err
+		}
+		return b.StoreSlice(label, ln)
+	}
+
+	if err := b.StoreUInt(0, 1); err != nil {
+		return err
+	}
+	for i := uint(0); i < ln; i++ {
+		if err := b.StoreUInt(1, 1); err != nil {
+			return err
+		}
+	}
+	if err := b.StoreUInt(0, 1); err != nil {
+		return err
+	}
+	return b.StoreSlice(label, ln)
+}
+
+// commonPrefixLen - returns length of common prefix of key[off:] and label
+func commonPrefixLen(key []byte, off uint, label []byte, ln uint) uint {
+	for i := uint(0); i < ln; i++ {
+		if getBit(key, off+i) != getBit(label, i) {
+			return i
+		}
+	}
+	return ln
+}
+
+type bitPart struct {
+	data []byte
+	ln   uint
+}
+
+func concatBits(parts ...bitPart) ([]byte, uint) {
+	total := uint(0)
+	for _, p := range parts {
+		total += p.ln
+	}
+	res := make([]byte, (total+7)/8)
+	pos := uint(0)
+	for _, p := range parts {
+		for i := uint(0); i < p.ln; i++ {
+			if getBit(p.data, i) {
+				res[pos/8] |= 0x80 >> (pos % 8)
+			}
+			pos++
+		}
+	}
+	return res, total
+}
+
+func (st *state) makeDictLeaf(label []byte, ln, maxLen uint, value *cell.Builder) (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := storeDictLabel(b, label, ln, maxLen); err != nil {
+		return nil, errCellOverflow()
+	}
+	if err := b.StoreBuilder(value); err != nil {
+		return nil, errCellOverflow()
+	}
+	if err := st.registerCellCreate(); err != nil {
+		return nil, err
+	}
+	return b.EndCell(), nil
+}
+
+func (st *state) makeDictFork(label []byte, ln, maxLen uint, left, right *cell.Cell) (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := storeDictLabel(b, label, ln, maxLen); err != nil {
+		return nil, errCellOverflow()
+	}
+	if err := b.StoreRef(left); err != nil {
+		return nil, errCellOverflow()
+	}
+	if err := b.StoreRef(right); err != nil {
+		return nil, errCellOverflow()
+	}
+	if err := st.registerCellCreate(); err != nil {
+		return nil, err
+	}
+	return b.EndCell(), nil
+}
+
+// dictLookup - returns value slice for the key, or nil if key is not found
+func (st *state) dictLookup(root *cell.Cell, key []byte, n uint) (*cell.Slice, error) {
+	cur, off := root, uint(0)
+	for cur != nil {
+		node, err := st.loadDictNode(cur, n-off)
+		if err != nil {
+			return nil, err
+		}
+		if commonPrefixLen(key, off, node.label, node.labelLen) != node.labelLen {
+			return nil, nil
+		}
+		off += node.labelLen
+		if off == n {
+			return node.rest, nil
+		}
+
+		refs := sliceRefs(node.rest)
+		if len(refs) != 2 {
+			return nil, errDict("invalid dictionary fork")
+		}
+		if getBit(key, off) {
+			cur = refs[1]
+		} else {
+			cur = refs[0]
+		}
+		off++
+	}
+	return nil, nil
+}
+
+// dictSet - sets value for the key according to mode, returns new root, old value (if any) and change flag
+func (st *state) dictSet(root *cell.Cell, key []byte, n uint, value *cell.Builder, mode dictSetMode) (*cell.Cell, *cell.Slice, bool, error) {
+	if root == nil {
+		if mode == dictModeReplace {
+			return nil, nil, false, nil
+		}
+		leaf, err := st.makeDictLeaf(key, n, n, value)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return leaf, nil, true, nil
+	}
+	return st.dictSetRec(root, key, 0, n, value, mode)
+}
+
+func (st *state) dictSetRec(c *cell.Cell, key []byte, off, n uint, value *cell.Builder, mode dictSetMode) (*cell.Cell, *cell.Slice, bool, error) {
+	maxLen := n - off
+	node, err := st.loadDictNode(c, maxLen)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	common := commonPrefixLen(key, off, node.label, node.labelLen)
+	if common < node.labelLen {
+		if mode == dictModeReplace {
+			return c, nil, false, nil
+		}
+
+		// split node: new fork at the point of difference
+		oldChild, err := st.rebuildWithLabel(node, extractBits(node.label, common+1, node.labelLen-common-1), node.labelLen-common-1, maxLen-common-1)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		newLeaf, err := st.makeDictLeaf(extractBits(key, off+common+1, n-off-common-1), n-off-common-1, maxLen-common-1, value)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		left, right := newLeaf, oldChild
+		if getBit(key, off+common) {
+			left, right = oldChild, newLeaf
+		}
+		fork, err := st.makeDictFork(extractBits(node.label, 0, common), common, maxLen, left, right)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return fork, nil, true, nil
+	}
+
+	if node.labelLen == maxLen {
+		if mode == dictModeAdd {
+			return c, node.rest, false, nil
+		}
+		leaf, err := st.makeDictLeaf(node.label, node.labelLen, maxLen, value)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return leaf, node.rest, true, nil
+	}
+
+	refs := sliceRefs(node.rest)
+	if len(refs) != 2 {
+		return nil, nil, false, errDict("invalid dictionary fork")
+	}
+
+	idx := 0
+	if getBit(key, off+node.labelLen) {
+		idx = 1
+	}
+	child, old, changed, err := st.dictSetRec(refs[idx], key, off+node.labelLen+1, n, value, mode)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !changed {
+		return c, old, false, nil
+	}
+	refs[idx] = child
+
+	fork, err := st.makeDictFork(node.label, node.labelLen, maxLen, refs[0], refs[1])
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return fork, old, true, nil
+}
+
+// rebuildWithLabel - creates node with the same content but another label
+func (st *state) rebuildWithLabel(node *dictNode, label []byte, ln, maxLen uint) (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := storeDictLabel(b, label, ln, maxLen); err != nil {
+		return nil, errCellOverflow()
+	}
+	res, ok := storeSliceTo(b, node.rest)
+	if !ok {
+		return nil, errCellOverflow()
+	}
+	if err := st.registerCellCreate(); err != nil {
+		return nil, err
+	}
+	return res.EndCell(), nil
+}
+
+// dictDelete - removes key from dict, returns new root and old value, old value is nil when key is not found
+func (st *state) dictDelete(root *cell.Cell, key []byte, n uint) (*cell.Cell, *cell.Slice, error) {
+	if root == nil {
+		return nil, nil, nil
+	}
+	return st.dictDeleteRec(root, key, 0, n)
+}
+
+func (st *state) dictDeleteRec(c *cell.Cell, key []byte, off, n uint) (*cell.Cell, *cell.Slice, error) {
+	maxLen := n - off
+	node, err := st.loadDictNode(c, maxLen)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if commonPrefixLen(key, off, node.label, node.labelLen) != node.labelLen {
+		return c, nil, nil
+	}
+	if node.labelLen == maxLen {
+		return nil, node.rest, nil
+	}
+
+	refs := sliceRefs(node.rest)
+	if len(refs) != 2 {
+		return nil, nil, errDict("invalid dictionary fork")
+	}
+
+	idx := 0
+	if getBit(key, off+node.labelLen) {
+		idx = 1
+	}
+	child, old, err := st.dictDeleteRec(refs[idx], key, off+node.labelLen+1, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	if old == nil {
+		return c, nil, nil
+	}
+
+	if child != nil {
+		refs[idx] = child
+		fork, err := st.makeDictFork(node.label, node.labelLen, maxLen, refs[0], refs[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		return fork, old, nil
+	}
+
+	// only one child is left, merge it with this node
+	otherIdx := 1 - idx
+	childMax := maxLen - node.labelLen - 1
+	other, err := st.loadDictNode(refs[otherIdx], childMax)
+	if err != nil {
+		return nil, nil, err
+	}
+	label, ln := concatBits(
+		bitPart{node.label, node.labelLen},
+		bitPart{[]byte{byte(otherIdx) << 7}, 1},
+		bitPart{other.label, other.labelLen},
+	)
+	merged, err := st.rebuildWithLabel(other, label, ln, maxLen)
+	if err != nil {
+		return nil, nil, err
+	}
+	return merged, old, nil
+}
+
+// dictMinMax - finds min or max key in the dict, when invertFirst is set, first bit is treated as a sign
+func (st *state) dictMinMax(root *cell.Cell, n uint, max, invertFirst bool) ([]byte, *cell.Slice, error) {
+	if root == nil {
+		return nil, nil, nil
+	}
+	key := make([]byte, (n+7)/8)
+	val, err := st.dictMinMaxRec(root, key, 0, n, max, invertFirst)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, val, nil
+}
+
+func (st *state) dictMinMaxRec(c *cell.Cell, key []byte, off, n uint, max, invertFirst bool) (*cell.Slice, error) {
+	for {
+		node, err := st.loadDictNode(c, n-off)
+		if err != nil {
+			return nil, err
+		}
+		for i := uint(0); i < node.labelLen; i++ {
+			setBit(key, off+i, getBit(node.label, i))
+		}
+		off += node.labelLen
+		if off == n {
+			return node.rest, nil
+		}
+
+		refs := sliceRefs(node.rest)
+		if len(refs) != 2 {
+			return nil, errDict("invalid dictionary fork")
+		}
+
+		bit := max
+		if invertFirst && off == 0 {
+			bit = !bit
+		}
+		setBit(key, off, bit)
+		if bit {
+			c = refs[1]
+		} else {
+			c = refs[0]
+		}
+		off++
+	}
+}
+
+// dictNearest - finds next (or previous) key relative to the given one
+func (st *state) dictNearest(root *cell.Cell, key []byte, n uint, next, allowEq, invertFirst bool) ([]byte, *cell.Slice, error) {
+	if root == nil {
+		return nil, nil, nil
+	}
+	res := make([]byte, (n+7)/8)
+	val, err := st.dictNearestRec(root, key, res, 0, n, next, allowEq, invertFirst)
+	if err != nil || val == nil {
+		return nil, nil, err
+	}
+	return res, val, nil
+}
+
+func (st *state) dictNearestRec(c *cell.Cell, key, res []byte, off, n uint, next, allowEq, invertFirst bool) (*cell.Slice, error) {
+	node, err := st.loadDictNode(c, n-off)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBit := func(pos uint, b bool) bool {
+		if invertFirst && pos == 0 {
+			return !b
+		}
+		return b
+	}
+
+	common := commonPrefixLen(key, off, node.label, node.labelLen)
+	for i := uint(0); i < node.labelLen; i++ {
+		setBit(res, off+i, getBit(node.label, i))
+	}
+
+	if common < node.labelLen {
+		pos := off + common
+		lblBigger := orderBit(pos, getBit(node.label, common)) && !orderBit(pos, getBit(key, pos))
+		if lblBigger != next {
+			return nil, nil
+		}
+		// whole subtree is on the required side, take its extreme key
+		if off+node.labelLen == n {
+			return node.rest, nil
+		}
+		return st.subtreeExtreme(node, res, off+node.labelLen, n, !next, invertFirst)
+	}
+
+	off += node.labelLen
+	if off == n {
+		if allowEq {
+			return node.rest, nil
+		}
+		return nil, nil
+	}
+
+	refs := sliceRefs(node.rest)
+	if len(refs) != 2 {
+		return nil, errDict("invalid dictionary fork")
+	}
+
+	keyBit := getBit(key, off)
+	idx := 0
+	if keyBit {
+		idx = 1
+	}
+	setBit(res, off, keyBit)
+	val, err := st.dictNearestRec(refs[idx], key, res, off+1, n, next, allowEq, invertFirst)
+	if err != nil || val != nil {
+		return val, err
+	}
+
+	// try sibling subtree if it is on the required side
+	if orderBit(off, keyBit) == next {
+		return nil, nil
+	}
+	setBit(res, off, !keyBit)
+	return st.dictMinMaxRec(refs[1-idx], res, off+1, n, !next, invertFirst)
+}
+
+func (st *state) subtreeExtreme(node *dictNode, res []byte, off, n uint, max, invertFirst bool) (*cell.Slice, error) {
+	refs := sliceRefs(node.rest)
+	if len(refs) != 2 {
+		return nil, errDict("invalid dictionary fork")
+	}
+	bit := max
+	if invertFirst && off == 0 {
+		bit = !bit
+	}
+	setBit(res, off, bit)
+	idx := 0
+	if bit {
+		idx = 1
+	}
+	return st.dictMinMaxRec(refs[idx], res, off+1, n, max, invertFirst)
+}
+
+func setBit(data []byte, i uint, v bool) {
+	if v {
+		data[i/8] |= 0x80 >> (i % 8)
+	} else {
+		data[i/8] &^= 0x80 >> (i % 8)
+	}
+}
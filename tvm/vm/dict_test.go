@@ -0,0 +1,100 @@
+package vm
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func dictKey32(k uint32) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, k)
+	return key
+}
+
+func TestDict_SetDelete(t *testing.T) {
+	st := newState(nil, newStack(), GasWithLimit(1<<50), nil)
+
+	var root *cell.Cell
+	keys := map[uint32]uint64{}
+	for i := 0; i < 300; i++ {
+		k := rand.Uint32() % 5000
+		v := rand.Uint64()
+		keys[k] = v
+
+		key := dictKey32(k)
+		var err error
+		root, _, _, err = st.dictSet(root, key, 32, cell.BeginCell().MustStoreUInt(v, 64), dictModeSet)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func() {
+		all, err := root.AsDict(32).LoadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != len(keys) {
+			t.Fatal("incorrect dict size", len(all), len(keys))
+		}
+		for _, kv := range all {
+			k := uint32(kv.Key.MustLoadUInt(32))
+			if keys[k] != kv.Value.MustLoadUInt(64) {
+				t.Fatal("incorrect value for key", k)
+			}
+		}
+
+		for k, v := range keys {
+			val, err := st.dictLookup(root, dictKey32(k), 32)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if val == nil || val.MustLoadUInt(64) != v {
+				t.Fatal("incorrect lookup for key", k)
+			}
+		}
+	}
+	check()
+
+	n := 0
+	for k, v := range keys {
+		if n++; n%2 == 0 {
+			continue
+		}
+
+		var old *cell.Slice
+		var err error
+		root, old, err = st.dictDelete(root, dictKey32(k), 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if old == nil || old.MustLoadUInt(64) != v {
+			t.Fatal("incorrect deleted value for key", k)
+		}
+		delete(keys, k)
+	}
+	check()
+
+	minKey, _, err := st.dictMinMax(root, 32, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := range keys {
+		if k < binary.BigEndian.Uint32(minKey) {
+			t.Fatal("incorrect min key")
+		}
+	}
+
+	next, _, err := st.dictNearest(root, minKey, 32, true, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := range keys {
+		if k > binary.BigEndian.Uint32(minKey) && k < binary.BigEndian.Uint32(next) {
+			t.Fatal("incorrect next key")
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Standard TVM exit codes (exceptions)
+const (
+	ExitCodeSuccess         = 0
+	ExitCodeSuccessAlt      = 1
+	ExitCodeStackUnderflow  = 2
+	ExitCodeStackOverflow   = 3
+	ExitCodeIntegerOverflow = 4
+	ExitCodeRangeCheck      = 5
+	ExitCodeInvalidOpcode   = 6
+	ExitCodeTypeCheck       = 7
+	ExitCodeCellOverflow    = 8
+	ExitCodeCellUnderflow   = 9
+	ExitCodeDictionaryError = 10
+	ExitCodeUnknown         = 11
+	ExitCodeFatal           = 12
+	ExitCodeOutOfGas        = 13
+
+	// ExitCodeOutOfGasFinal - out of gas cannot be caught, vm is terminated with ~13
+	ExitCodeOutOfGasFinal = -14
+)
+
+var ErrNoCode = errors.New("code cell is nil")
+
+// vmError - tvm exception, can be caught by TRY or by c2 handler
+type vmError struct {
+	code int64
+	arg  any
+	msg  string
+}
+
+func (e vmError) Error() string {
+	if e.msg == "" {
+		return fmt.Sprintf("tvm exception %d", e.code)
+	}
+	return fmt.Sprintf("tvm exception %d: %s", e.code, e.msg)
+}
+
+// errOutOfGas - cannot be handled by contract, vm stops immediately
+var errOutOfGas = errors.New("out of gas")
+
+func throwErr(code int64, msg string) error {
+	return vmError{code: code, msg: msg}
+}
+
+func errStackUnderflow() error {
+	return throwErr(ExitCodeStackUnderflow, "stack underflow")
+}
+
+func errTypeCheck(msg string) error {
+	return throwErr(ExitCodeTypeCheck, msg)
+}
+
+func errRangeCheck(msg string) error {
+	return throwErr(ExitCodeRangeCheck, msg)
+}
+
+func errIntOverflow() error {
+	return throwErr(ExitCodeIntegerOverflow, "integer overflow")
+}
+
+func errCellOverflow() error {
+	return throwErr(ExitCodeCellOverflow, "cell overflow")
+}
+
+func errCellUnderflow() error {
+	return throwErr(ExitCodeCellUnderflow, "cell underflow")
+}
+
+func errDict(msg string) error {
+	return throwErr(ExitCodeDictionaryError, msg)
+}
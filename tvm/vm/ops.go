@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type opFunc func(st *state) error
+
+type opcode struct {
+	name   string
+	prefix uint64
+	bits   uint
+	exec   opFunc
+}
+
+// opcodes are prefix-free, so we can look them up by length, starting from the shortest
+var opTable = map[uint]map[uint64]*opcode{}
+var opLengths []uint
+
+const maxOpcodeLookupBits = 24
+
+func reg(prefix uint64, bits uint, name string, fn opFunc) {
+	if bits > maxOpcodeLookupBits {
+		panic(fmt.Sprintf("opcode %s prefix is too long", name))
+	}
+
+	tbl := opTable[bits]
+	if tbl == nil {
+		tbl = map[uint64]*opcode{}
+		opTable[bits] = tbl
+
+		opLengths = append(opLengths, bits)
+		sort.Slice(opLengths, func(i, j int) bool {
+			return opLengths[i] < opLengths[j]
+		})
+	}
+
+	if old := tbl[prefix]; old != nil {
+		panic(fmt.Sprintf("opcode %s conflicts with %s", name, old.name))
+	}
+
+	tbl[prefix] = &opcode{
+		name:   name,
+		prefix: prefix,
+		bits:   bits,
+		exec:   fn,
+	}
+}
+
+// regRange - registers opcodes family with argument embedded into the lookup key
+func regRange(prefix uint64, bits, argBits uint, name string, fn func(st *state, arg int) error) {
+	regRangeFrom(prefix, bits, argBits, 0, name, fn)
+}
+
+// regRangeFrom - same as regRange, but argument values below from are not registered
+func regRangeFrom(prefix uint64, bits, argBits uint, from int, name string, fn func(st *state, arg int) error) {
+	for i := from; i < 1<<argBits; i++ {
+		arg := i
+		reg(prefix<<argBits|uint64(i), bits+argBits, name, func(st *state) error {
+			return fn(st, arg)
+		})
+	}
+}
+
+func decodeOpcode(code *cell.Slice) (*opcode, uint) {
+	left := code.BitsLeft()
+	n := uint(maxOpcodeLookupBits)
+	if left < n {
+		n = left
+	}
+
+	bits, err := code.PreloadUInt(n)
+	if err != nil {
+		return nil, 0
+	}
+
+	for _, l := range opLengths {
+		if l > n {
+			break
+		}
+		if op := opTable[l][bits>>(n-l)]; op != nil {
+			return op, l
+		}
+	}
+	return nil, 0
+}
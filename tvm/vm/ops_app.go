@@ -0,0 +1,970 @@
+package vm
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// indexes of c7[0] (SmartContractInfo) params
+const (
+	paramNow            = 3
+	paramBlockLT        = 4
+	paramLT             = 5
+	paramRandSeed       = 6
+	paramBalance        = 7
+	paramMyAddr         = 8
+	paramConfigRoot     = 9
+	paramMyCode         = 10
+	paramIncomingValue  = 11
+	paramStorageFees    = 12
+	paramPrevBlocksInfo = 13
+	paramUnpackedConfig = 14
+	paramDuePayment     = 15
+)
+
+// indexes of unpacked config tuple
+const (
+	unpackedStoragePrices = iota
+	unpackedGlobalID
+	unpackedMcGasPrices
+	unpackedGasPrices
+	unpackedMcFwdPrices
+	unpackedFwdPrices
+	unpackedSizeLimits
+)
+
+const (
+	chksignFreeCount = 10
+	chksignGasPrice  = 4000
+)
+
+func init() {
+	reg(0xF800, 16, "ACCEPT", func(st *state) error {
+		st.gas.changeLimit(st.gas.max)
+		return nil
+	})
+	reg(0xF801, 16, "SETGASLIMIT", func(st *state) error {
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		limit := int64(0)
+		if x.Sign() > 0 {
+			limit = int64(^uint64(0) >> 1)
+			if x.IsInt64() {
+				limit = x.Int64()
+			}
+		}
+		if limit < st.gas.consumed() {
+			return errOutOfGas
+		}
+		st.gas.changeLimit(limit)
+		return nil
+	})
+	reg(0xF806, 16, "GASCONSUMED", func(st *state) error {
+		st.stack.pushInt(st.gas.consumed())
+		return nil
+	})
+	reg(0xF80F, 16, "COMMIT", func(st *state) error {
+		if !st.tryCommit() {
+			return throwErr(ExitCodeCellOverflow, "cannot commit too deep cells as new data/actions")
+		}
+		return nil
+	})
+
+	reg(0xF810, 16, "RANDU256", func(st *state) error {
+		x, err := nextRandom(st)
+		if err != nil {
+			return err
+		}
+		st.stack.push(x)
+		return nil
+	})
+	reg(0xF811, 16, "RAND", func(st *state) error {
+		y, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		x, err := nextRandom(st)
+		if err != nil {
+			return err
+		}
+		x.Mul(x, y)
+		st.stack.push(x.Rsh(x, 256))
+		return nil
+	})
+	reg(0xF814, 16, "SETRAND", func(st *state) error {
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if !fitsBits(x, 256, false) {
+			return errRangeCheck("new random seed out of range")
+		}
+		return st.setParam(paramRandSeed, x)
+	})
+	reg(0xF815, 16, "ADDRAND", func(st *state) error {
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if !fitsBits(x, 256, false) {
+			return errRangeCheck("mixed seed value out of range")
+		}
+		seed, err := st.randSeed()
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		h.Write(intToBits(seed, 256))
+		h.Write(intToBits(x, 256))
+		return st.setParam(paramRandSeed, new(big.Int).SetBytes(h.Sum(nil)))
+	})
+
+	regRange(0xF82, 12, 4, "GETPARAM", func(st *state, i int) error {
+		v, err := st.getParam(i)
+		if err != nil {
+			return err
+		}
+		st.stack.push(v)
+		return nil
+	})
+	reg(0xF830, 16, "CONFIGDICT", func(st *state) error {
+		v, err := st.getParam(paramConfigRoot)
+		if err != nil {
+			return err
+		}
+		st.stack.push(v)
+		st.stack.pushInt(32)
+		return nil
+	})
+	reg(0xF832, 16, "CONFIGPARAM", func(st *state) error {
+		return execConfigParam(st, false)
+	})
+	reg(0xF833, 16, "CONFIGOPTPARAM", func(st *state) error {
+		return execConfigParam(st, true)
+	})
+	reg(0xF834, 16, "PREVMCBLOCKS", func(st *state) error {
+		return execPrevBlocksInfo(st, 0)
+	})
+	reg(0xF835, 16, "PREVKEYBLOCK", func(st *state) error {
+		return execPrevBlocksInfo(st, 1)
+	})
+	reg(0xF836, 16, "PREVMCBLOCKS_100", func(st *state) error {
+		return execPrevBlocksInfo(st, 2)
+	})
+	reg(0xF838, 16, "GLOBALID", func(st *state) error {
+		s, err := st.unpackedConfigSlice(unpackedGlobalID)
+		if err != nil {
+			return err
+		}
+		id, err := loadInt(s.Copy(), 32, true, true)
+		if err != nil {
+			return err
+		}
+		st.stack.push(id)
+		return nil
+	})
+	reg(0xF839, 16, "GETGASFEE", func(st *state) error {
+		return execGasFee(st, false)
+	})
+	reg(0xF83A, 16, "GETSTORAGEFEE", func(st *state) error {
+		return execStorageFee(st)
+	})
+	reg(0xF83B, 16, "GETFORWARDFEE", func(st *state) error {
+		return execForwardFee(st, false)
+	})
+	reg(0xF83C, 16, "GETPRECOMPILEDGAS", func(st *state) error {
+		st.stack.push(nil)
+		return nil
+	})
+	reg(0xF83D, 16, "GETORIGINALFWDFEE", func(st *state) error {
+		isMc, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		fee, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if fee.Sign() < 0 {
+			return errRangeCheck("fwd_fee is negative")
+		}
+		prices, err := st.fwdPrices(isMc)
+		if err != nil {
+			return err
+		}
+		res := new(big.Int).Lsh(fee, 16)
+		st.stack.push(res.Div(res, big.NewInt(int64(1<<16-prices.firstFrac))))
+		return nil
+	})
+	reg(0xF83E, 16, "GETGASFEESIMPLE", func(st *state) error {
+		return execGasFee(st, true)
+	})
+	reg(0xF83F, 16, "GETFORWARDFEESIMPLE", func(st *state) error {
+		return execForwardFee(st, true)
+	})
+
+	reg(0xF840, 16, "GETGLOBVAR", func(st *state) error {
+		k, err := st.stack.popIntRange(0, 254)
+		if err != nil {
+			return err
+		}
+		return execGetGlobal(st, int(k))
+	})
+	regRangeFrom(0xF84>>1, 11, 5, 1, "GETGLOB", func(st *state, k int) error {
+		return execGetGlobal(st, k)
+	})
+	reg(0xF860, 16, "SETGLOBVAR", func(st *state) error {
+		k, err := st.stack.popIntRange(0, 254)
+		if err != nil {
+			return err
+		}
+		return execSetGlobal(st, int(k))
+	})
+	regRangeFrom(0xF86>>1, 11, 5, 1, "SETGLOB", func(st *state, k int) error {
+		return execSetGlobal(st, k)
+	})
+
+	for i := 0; i < 8; i++ {
+		signed, store, lenBits := i&1 != 0, i&2 != 0, uint(4)
+		if i&4 != 0 {
+			lenBits = 5
+		}
+		reg(0xFA00+uint64(i), 16, "LDVARINT", func(st *state) error {
+			if store {
+				return execStoreVarInt(st, lenBits, signed)
+			}
+			return execLoadVarInt(st, lenBits, signed)
+		})
+	}
+	for i := 0; i < 8; i++ {
+		mode := i
+		reg(0xFA40+uint64(i), 16, "LDMSGADDR", func(st *state) error {
+			return execMsgAddr(st, mode>>1, mode&1 != 0)
+		})
+	}
+
+	reg(0xFB00, 16, "SENDRAWMSG", func(st *state) error {
+		mode, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		msg, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		return st.installAction(cell.BeginCell().
+			MustStoreUInt(0x0ec3c86d, 32).
+			MustStoreUInt(uint64(mode), 8).
+			MustStoreRef(msg))
+	})
+	reg(0xFB02, 16, "RAWRESERVE", func(st *state) error {
+		return execReserve(st, false)
+	})
+	reg(0xFB03, 16, "RAWRESERVEX", func(st *state) error {
+		return execReserve(st, true)
+	})
+	reg(0xFB04, 16, "SETCODE", func(st *state) error {
+		code, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		return st.installAction(cell.BeginCell().
+			MustStoreUInt(0xad4de08e, 32).
+			MustStoreRef(code))
+	})
+	reg(0xFB06, 16, "SETLIBCODE", func(st *state) error {
+		mode, err := st.stack.popIntRange(0, 31)
+		if err != nil {
+			return err
+		}
+		if mode&15 > 2 {
+			return errRangeCheck("invalid library mode")
+		}
+		code, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		return st.installAction(cell.BeginCell().
+			MustStoreUInt(0x26fa1dd4, 32).
+			MustStoreUInt(uint64(mode)*2+1, 8).
+			MustStoreRef(code))
+	})
+	reg(0xFB07, 16, "CHANGELIB", func(st *state) error {
+		mode, err := st.stack.popIntRange(0, 31)
+		if err != nil {
+			return err
+		}
+		if mode&15 > 2 {
+			return errRangeCheck("invalid library mode")
+		}
+		hash, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if !fitsBits(hash, 256, false) {
+			return errRangeCheck("library hash must be non-negative")
+		}
+		return st.installAction(cell.BeginCell().
+			MustStoreUInt(0x26fa1dd4, 32).
+			MustStoreUInt(uint64(mode)*2, 8).
+			MustStoreBigUInt(hash, 256))
+	})
+}
+
+func (st *state) randSeed() (*big.Int, error) {
+	v, err := st.getParam(paramRandSeed)
+	if err != nil {
+		return nil, err
+	}
+	seed, ok := v.(*big.Int)
+	if !ok {
+		return nil, errTypeCheck("random seed is not an integer")
+	}
+	if !fitsBits(seed, 256, false) {
+		return nil, errRangeCheck("random seed out of range")
+	}
+	return seed, nil
+}
+
+func nextRandom(st *state) (*big.Int, error) {
+	seed, err := st.randSeed()
+	if err != nil {
+		return nil, err
+	}
+	hash := sha512.Sum512(intToBits(seed, 256))
+	if err = st.setParam(paramRandSeed, new(big.Int).SetBytes(hash[:32])); err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(hash[32:]), nil
+}
+
+// setParam - replaces value in c7[0] tuple
+func (st *state) setParam(idx int, v any) error {
+	if len(st.regs.c7) == 0 {
+		return errRangeCheck("c7 is empty")
+	}
+	info, ok := st.regs.c7[0].([]any)
+	if !ok {
+		return errTypeCheck("c7[0] is not a tuple")
+	}
+	if idx >= len(info) {
+		return errRangeCheck("param index is out of range")
+	}
+
+	newInfo := append([]any{}, info...)
+	newInfo[idx] = v
+	newC7 := append([]any{}, st.regs.c7...)
+	newC7[0] = newInfo
+	st.regs.c7 = newC7
+	return st.consumeTupleGas(len(newInfo) + len(newC7))
+}
+
+func execConfigParam(st *state, opt bool) error {
+	idx, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	v, err := st.getParam(paramConfigRoot)
+	if err != nil {
+		return err
+	}
+
+	var res *cell.Slice
+	if root, ok := v.(*cell.Cell); ok && idx.IsInt64() && fitsBits(idx, 32, true) {
+		if res, err = st.dictLookup(root, intToBits(idx, 32), 32); err != nil {
+			return err
+		}
+	}
+
+	var param *cell.Cell
+	if res != nil && res.RefsNum() > 0 {
+		param, _ = res.PreloadRefCell()
+	}
+
+	if opt {
+		if param == nil {
+			st.stack.push(nil)
+		} else {
+			st.stack.push(param)
+		}
+		return nil
+	}
+	if param == nil {
+		st.stack.pushBool(false)
+		return nil
+	}
+	st.stack.push(param)
+	st.stack.pushBool(true)
+	return nil
+}
+
+func execPrevBlocksInfo(st *state, idx int) error {
+	v, err := st.getParam(paramPrevBlocksInfo)
+	if err != nil {
+		return err
+	}
+	t, ok := v.([]any)
+	if !ok {
+		return errTypeCheck("prev blocks info is not a tuple")
+	}
+	if idx >= len(t) {
+		return errRangeCheck("prev blocks info index is out of range")
+	}
+	st.stack.push(t[idx])
+	return nil
+}
+
+func (st *state) unpackedConfigSlice(idx int) (*cell.Slice, error) {
+	v, err := st.getParam(paramUnpackedConfig)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := v.([]any)
+	if !ok {
+		return nil, errTypeCheck("unpacked config is not a tuple")
+	}
+	if idx >= len(t) {
+		return nil, errRangeCheck("unpacked config index is out of range")
+	}
+	s, ok := t[idx].(*cell.Slice)
+	if !ok {
+		return nil, errTypeCheck("unpacked config param is not a slice")
+	}
+	return s, nil
+}
+
+type gasPrices struct {
+	flatLimit uint64
+	flatPrice uint64
+	price     uint64
+}
+
+type fwdPrices struct {
+	lumpPrice uint64
+	bitPrice  uint64
+	cellPrice uint64
+	firstFrac uint64
+}
+
+func (st *state) gasPrices(isMc bool) (*gasPrices, error) {
+	idx := unpackedGasPrices
+	if isMc {
+		idx = unpackedMcGasPrices
+	}
+	s, err := st.unpackedConfigSlice(idx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := parseGasPrices(s.Copy())
+	if err != nil {
+		return nil, errCellUnderflow()
+	}
+	return res, nil
+}
+
+func parseGasPrices(s *cell.Slice) (*gasPrices, error) {
+	res := &gasPrices{}
+	tag, err := s.LoadUInt(8)
+	if err != nil {
+		return nil, err
+	}
+	if tag == 0xd1 {
+		if res.flatLimit, err = s.LoadUInt(64); err != nil {
+			return nil, err
+		}
+		if res.flatPrice, err = s.LoadUInt(64); err != nil {
+			return nil, err
+		}
+		if tag, err = s.LoadUInt(8); err != nil {
+			return nil, err
+		}
+	}
+	if tag != 0xdd && tag != 0xde {
+		return nil, errCellUnderflow()
+	}
+	if res.price, err = s.LoadUInt(64); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (st *state) fwdPrices(isMc bool) (*fwdPrices, error) {
+	idx := unpackedFwdPrices
+	if isMc {
+		idx = unpackedMcFwdPrices
+	}
+	s, err := st.unpackedConfigSlice(idx)
+	if err != nil {
+		return nil, err
+	}
+	s = s.Copy()
+
+	res := &fwdPrices{}
+	if tag, err := s.LoadUInt(8); err != nil || tag != 0xea {
+		return nil, errCellUnderflow()
+	}
+	if res.lumpPrice, err = s.LoadUInt(64); err != nil {
+		return nil, errCellUnderflow()
+	}
+	if res.bitPrice, err = s.LoadUInt(64); err != nil {
+		return nil, errCellUnderflow()
+	}
+	if res.cellPrice, err = s.LoadUInt(64); err != nil {
+		return nil, errCellUnderflow()
+	}
+	if _, err = s.LoadUInt(32); err != nil {
+		return nil, errCellUnderflow()
+	}
+	if res.firstFrac, err = s.LoadUInt(16); err != nil {
+		return nil, errCellUnderflow()
+	}
+	return res, nil
+}
+
+// mulDivCeil64 - returns ceil(x * price / 2^16)
+func mulDivCeil64(x *big.Int, price uint64) *big.Int {
+	res := new(big.Int).Mul(x, new(big.Int).SetUint64(price))
+	res.Add(res, big.NewInt(1<<16-1))
+	return res.Rsh(res, 16)
+}
+
+func execGasFee(st *state, simple bool) error {
+	isMc, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	gas, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	if gas.Sign() < 0 || gas.BitLen() > 63 {
+		return errRangeCheck("gas is out of range")
+	}
+	prices, err := st.gasPrices(isMc)
+	if err != nil {
+		return err
+	}
+
+	if simple {
+		st.stack.push(mulDivCeil64(gas, prices.price))
+		return nil
+	}
+
+	flatLimit := new(big.Int).SetUint64(prices.flatLimit)
+	if gas.Cmp(flatLimit) <= 0 {
+		st.stack.push(new(big.Int).SetUint64(prices.flatPrice))
+		return nil
+	}
+	res := mulDivCeil64(new(big.Int).Sub(gas, flatLimit), prices.price)
+	st.stack.push(res.Add(res, new(big.Int).SetUint64(prices.flatPrice)))
+	return nil
+}
+
+func execForwardFee(st *state, simple bool) error {
+	isMc, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	bits, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	cells, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	if bits.Sign() < 0 || cells.Sign() < 0 || bits.BitLen() > 63 || cells.BitLen() > 63 {
+		return errRangeCheck("bits or cells are out of range")
+	}
+	prices, err := st.fwdPrices(isMc)
+	if err != nil {
+		return err
+	}
+
+	sum := new(big.Int).Mul(bits, new(big.Int).SetUint64(prices.bitPrice))
+	sum.Add(sum, new(big.Int).Mul(cells, new(big.Int).SetUint64(prices.cellPrice)))
+	sum.Add(sum, big.NewInt(1<<16-1))
+	sum.Rsh(sum, 16)
+	if !simple {
+		sum.Add(sum, new(big.Int).SetUint64(prices.lumpPrice))
+	}
+	st.stack.push(sum)
+	return nil
+}
+
+func execStorageFee(st *state) error {
+	isMc, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	delta, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	bits, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	cells, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	if delta.Sign() < 0 || bits.Sign() < 0 || cells.Sign() < 0 {
+		return errRangeCheck("negative storage fee arguments")
+	}
+
+	v, err := st.getParam(paramUnpackedConfig)
+	if err != nil {
+		return err
+	}
+	t, ok := v.([]any)
+	if !ok || len(t) <= unpackedStoragePrices {
+		return errTypeCheck("unpacked config is not a tuple")
+	}
+	if t[unpackedStoragePrices] == nil {
+		st.stack.pushInt(0)
+		return nil
+	}
+	s, ok := t[unpackedStoragePrices].(*cell.Slice)
+	if !ok {
+		return errTypeCheck("storage prices is not a slice")
+	}
+	s = s.Copy()
+
+	if tag, err := s.LoadUInt(8); err != nil || tag != 0xcc {
+		return errCellUnderflow()
+	}
+	var prices [4]uint64
+	if _, err = s.LoadUInt(32); err != nil {
+		return errCellUnderflow()
+	}
+	for i := range prices {
+		if prices[i], err = s.LoadUInt(64); err != nil {
+			return errCellUnderflow()
+		}
+	}
+
+	bitPrice, cellPrice := prices[0], prices[1]
+	if isMc {
+		bitPrice, cellPrice = prices[2], prices[3]
+	}
+
+	sum := new(big.Int).Mul(bits, new(big.Int).SetUint64(bitPrice))
+	sum.Add(sum, new(big.Int).Mul(cells, new(big.Int).SetUint64(cellPrice)))
+	sum.Mul(sum, delta)
+	sum.Add(sum, big.NewInt(1<<16-1))
+	st.stack.push(sum.Rsh(sum, 16))
+	return nil
+}
+
+func execGetGlobal(st *state, k int) error {
+	if k >= len(st.regs.c7) {
+		st.stack.push(nil)
+		return nil
+	}
+	st.stack.push(st.regs.c7[k])
+	return nil
+}
+
+func execSetGlobal(st *state, k int) error {
+	x, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	if k >= len(st.regs.c7) && x == nil {
+		return nil
+	}
+
+	sz := len(st.regs.c7)
+	if k >= sz {
+		sz = k + 1
+	}
+	c7 := make([]any, sz)
+	copy(c7, st.regs.c7)
+	c7[k] = x
+	st.regs.c7 = c7
+	return st.consumeTupleGas(sz)
+}
+
+func execLoadVarInt(st *state, lenBits uint, signed bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+	s = s.Copy()
+	ln, err := s.LoadUInt(lenBits)
+	if err != nil {
+		return errCellUnderflow()
+	}
+	x, err := loadInt(s, uint(ln*8), signed, false)
+	if err != nil {
+		return err
+	}
+	st.stack.push(x)
+	st.stack.push(s)
+	return nil
+}
+
+func execStoreVarInt(st *state, lenBits uint, signed bool) error {
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	b, err := st.stack.popBuilder()
+	if err != nil {
+		return err
+	}
+
+	maxLen := uint(1)<<lenBits - 1
+	ln := uint(0)
+	for ; ln <= maxLen; ln++ {
+		if fitsBits(x, ln*8, signed) {
+			break
+		}
+	}
+	if ln > maxLen {
+		return errRangeCheck("integer does not fit into var integer")
+	}
+	if b.BitsLeft() < lenBits+ln*8 {
+		return errCellOverflow()
+	}
+
+	b = b.Copy()
+	if err = b.StoreUInt(uint64(ln), lenBits); err != nil {
+		return errCellOverflow()
+	}
+	if err = storeInt(b, x, ln*8, signed); err != nil {
+		return err
+	}
+	st.stack.push(b)
+	return nil
+}
+
+// parsedAddr - parsed MsgAddress
+type parsedAddr struct {
+	kind    int
+	anycast *cell.Slice
+	wc      int64
+	addr    *cell.Slice
+}
+
+// loadMsgAddr - parses MsgAddress at the beginning of slice, returns parsed address and its size in bits
+func loadMsgAddr(s *cell.Slice) (*parsedAddr, uint, bool) {
+	s = s.Copy()
+	before := s.BitsLeft()
+
+	kind, err := s.LoadUInt(2)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	res := &parsedAddr{kind: int(kind)}
+	switch kind {
+	case 0:
+	case 1:
+		ln, err := s.LoadUInt(9)
+		if err != nil {
+			return nil, 0, false
+		}
+		data, err := s.LoadSlice(uint(ln))
+		if err != nil {
+			return nil, 0, false
+		}
+		if res.addr, err = buildSlice(data, uint(ln), nil); err != nil {
+			return nil, 0, false
+		}
+	default:
+		hasAnycast, err := s.LoadUInt(1)
+		if err != nil {
+			return nil, 0, false
+		}
+		if hasAnycast == 1 {
+			depth, err := s.LoadUInt(5)
+			if err != nil || depth < 1 || depth > 30 {
+				return nil, 0, false
+			}
+			pfx, err := s.LoadSlice(uint(depth))
+			if err != nil {
+				return nil, 0, false
+			}
+			if res.anycast, err = buildSlice(pfx, uint(depth), nil); err != nil {
+				return nil, 0, false
+			}
+		}
+
+		ln, wcBits := uint64(256), uint(8)
+		if kind == 3 {
+			if ln, err = s.LoadUInt(9); err != nil {
+				return nil, 0, false
+			}
+			wcBits = 32
+		}
+		wc, err := loadInt(s, wcBits, true, false)
+		if err != nil {
+			return nil, 0, false
+		}
+		res.wc = wc.Int64()
+
+		data, err := s.LoadSlice(uint(ln))
+		if err != nil {
+			return nil, 0, false
+		}
+		if res.addr, err = buildSlice(data, uint(ln), nil); err != nil {
+			return nil, 0, false
+		}
+	}
+	return res, before - s.BitsLeft(), true
+}
+
+// execMsgAddr - mode 0 is LDMSGADDR, 1 is PARSEMSGADDR, 2 is REWRITESTDADDR, 3 is REWRITEVARADDR
+func execMsgAddr(st *state, mode int, quiet bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	fail := func() error {
+		if !quiet {
+			return errCellUnderflow()
+		}
+		if mode == 0 {
+			st.stack.push(s)
+		}
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	switch mode {
+	case 0:
+		_, bits, ok := loadMsgAddr(s)
+		if !ok {
+			return fail()
+		}
+		addr, err := subSlice(s, 0, bits, 0, 0)
+		if err != nil {
+			return err
+		}
+		rest, err := subSlice(s, bits, s.BitsLeft()-bits, 0, s.RefsNum())
+		if err != nil {
+			return err
+		}
+		st.stack.push(addr)
+		st.stack.push(rest)
+	case 1:
+		addr, bits, ok := loadMsgAddr(s)
+		if !ok || bits != s.BitsLeft() {
+			return fail()
+		}
+		var t []any
+		switch addr.kind {
+		case 0:
+			t = []any{big.NewInt(0)}
+		case 1:
+			t = []any{big.NewInt(1), addr.addr}
+		default:
+			var anycast any
+			if addr.anycast != nil {
+				anycast = addr.anycast
+			}
+			t = []any{big.NewInt(int64(addr.kind)), anycast, big.NewInt(addr.wc), addr.addr}
+		}
+		st.stack.push(t)
+		if err = st.consumeTupleGas(len(t)); err != nil {
+			return err
+		}
+	default:
+		addr, bits, ok := loadMsgAddr(s)
+		if !ok || bits != s.BitsLeft() {
+			return fail()
+		}
+		if addr.kind < 2 {
+			return fail()
+		}
+
+		data := sliceBits(addr.addr)
+		ln := addr.addr.BitsLeft()
+		if addr.anycast != nil {
+			depth := addr.anycast.BitsLeft()
+			if depth > ln {
+				return fail()
+			}
+			pfx := sliceBits(addr.anycast)
+			for i := uint(0); i < depth; i++ {
+				setBit(data, i, getBit(pfx, i))
+			}
+		}
+
+		st.stack.push(big.NewInt(addr.wc))
+		if mode == 2 {
+			if ln != 256 {
+				return fail()
+			}
+			st.stack.push(bitsToInt(data, 256, false))
+		} else {
+			res, err := buildSlice(data, ln, nil)
+			if err != nil {
+				return err
+			}
+			st.stack.push(res)
+		}
+	}
+
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func execReserve(st *state, withExtra bool) error {
+	mode, err := st.stack.popIntRange(0, 31)
+	if err != nil {
+		return err
+	}
+	var extra *cell.Cell
+	if withExtra {
+		if extra, err = st.stack.popMaybeCell(); err != nil {
+			return err
+		}
+	}
+	amount, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	if amount.Sign() < 0 || !fitsBits(amount, 120, false) {
+		return errRangeCheck("amount of nanograms must be non-negative")
+	}
+
+	b := cell.BeginCell().
+		MustStoreUInt(0x36e6b809, 32).
+		MustStoreUInt(uint64(mode), 8)
+	if err = b.StoreBigCoins(amount); err != nil {
+		return errRangeCheck("invalid amount")
+	}
+	if err = b.StoreMaybeRef(extra); err != nil {
+		return errCellOverflow()
+	}
+	return st.installAction(b)
+}
+
+// installAction - prepends action to the list in c5
+func (st *state) installAction(action *cell.Builder) error {
+	prev := st.regs.d[1]
+	if prev == nil {
+		return errTypeCheck("c5 is not a cell")
+	}
+	if err := st.registerCellCreate(); err != nil {
+		return err
+	}
+	b := cell.BeginCell().MustStoreRef(prev)
+	if err := b.StoreBuilder(action); err != nil {
+		return errCellOverflow()
+	}
+	st.regs.d[1] = b.EndCell()
+	return nil
+}
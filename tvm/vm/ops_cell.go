@@ -0,0 +1,1326 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	reg(0xC8, 8, "NEWC", func(st *state) error {
+		st.stack.push(cell.BeginCell())
+		return nil
+	})
+	reg(0xC9, 8, "ENDC", func(st *state) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		if err = st.registerCellCreate(); err != nil {
+			return err
+		}
+		st.stack.push(b.EndCell())
+		return nil
+	})
+	regRange(0xCA, 8, 8, "STI", func(st *state, c int) error {
+		return execStoreInt(st, uint(c+1), true, false, false)
+	})
+	regRange(0xCB, 8, 8, "STU", func(st *state, c int) error {
+		return execStoreInt(st, uint(c+1), false, false, false)
+	})
+	reg(0xCC, 8, "STREF", func(st *state) error {
+		return execStoreRef(st, false, false)
+	})
+	reg(0xCD, 8, "STBREFR", func(st *state) error {
+		return execStoreBuilderRef(st, true, false)
+	})
+	reg(0xCE, 8, "STSLICE", func(st *state) error {
+		return execStoreSlice(st, false, false)
+	})
+	regRange(0xCF0, 12, 4, "STIX", func(st *state, mode int) error {
+		var bits uint
+		if mode&8 != 0 {
+			c, err := st.argUInt(8)
+			if err != nil {
+				return err
+			}
+			bits = uint(c + 1)
+		} else {
+			sign := int64(257)
+			if mode&1 != 0 {
+				sign = 256
+			}
+			l, err := st.stack.popIntRange(0, sign)
+			if err != nil {
+				return err
+			}
+			bits = uint(l)
+		}
+		return execStoreInt(st, bits, mode&1 == 0, mode&2 != 0, mode&4 != 0)
+	})
+	regRange(0xCF1, 12, 4, "STREF/STB", func(st *state, mode int) error {
+		quiet, rev := mode&8 != 0, mode&4 != 0
+		switch mode & 3 {
+		case 0:
+			return execStoreRef(st, rev, quiet)
+		case 1:
+			return execStoreBuilderRef(st, rev, quiet)
+		case 2:
+			return execStoreSlice(st, rev, quiet)
+		default:
+			return execStoreBuilder(st, rev, quiet)
+		}
+	})
+	reg(0xCF20, 16, "STREFCONST", func(st *state) error {
+		return execStoreConstRefs(st, 1)
+	})
+	reg(0xCF21, 16, "STREF2CONST", func(st *state) error {
+		return execStoreConstRefs(st, 2)
+	})
+	reg(0xCF23, 16, "ENDXC", func(st *state) error {
+		special, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		if err = st.registerCellCreate(); err != nil {
+			return err
+		}
+		c := b.EndCell()
+		if special {
+			raw := c.ToRawUnsafe()
+			if raw.BitsSz < 8 {
+				return errCellOverflow()
+			}
+			special := cell.FromRawUnsafe(cell.RawUnsafeCell{
+				IsSpecial: true,
+				LevelMask: raw.LevelMask,
+				BitsSz:    raw.BitsSz,
+				Data:      raw.Data,
+				Refs:      raw.Refs,
+			})
+			if special.GetType() == cell.UnknownCellType {
+				return errCellOverflow()
+			}
+			c = special
+		}
+		st.stack.push(c)
+		return nil
+	})
+	regRange(0xCF28>>2, 14, 2, "STILE", func(st *state, mode int) error {
+		bits := uint(32)
+		if mode&2 != 0 {
+			bits = 64
+		}
+		signed := mode&1 == 0
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if !fitsBits(x, bits, signed) {
+			return errRangeCheck("integer does not fit")
+		}
+		if b.BitsLeft() < bits {
+			return errCellOverflow()
+		}
+		le := reverseBytes(intToBits(x, bits))
+		b = b.Copy()
+		if err = b.StoreSlice(le, bits); err != nil {
+			return errCellOverflow()
+		}
+		st.stack.push(b)
+		return nil
+	})
+	reg(0xCF30, 16, "BDEPTH", func(st *state) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(builderDepth(b)))
+		return nil
+	})
+	reg(0xCF31, 16, "BBITS", func(st *state) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(b.BitsUsed()))
+		return nil
+	})
+	reg(0xCF32, 16, "BREFS", func(st *state) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(b.RefsUsed()))
+		return nil
+	})
+	reg(0xCF33, 16, "BBITREFS", func(st *state) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(b.BitsUsed()))
+		st.stack.pushInt(int64(b.RefsUsed()))
+		return nil
+	})
+	reg(0xCF35, 16, "BREMBITS", func(st *state) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(b.BitsLeft()))
+		return nil
+	})
+	reg(0xCF36, 16, "BREMREFS", func(st *state) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(b.RefsLeft()))
+		return nil
+	})
+	reg(0xCF37, 16, "BREMBITREFS", func(st *state) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(b.BitsLeft()))
+		st.stack.pushInt(int64(b.RefsLeft()))
+		return nil
+	})
+	regRange(0xCF38>>2, 14, 2, "BCHKBITS", func(st *state, mode int) error {
+		return execBuilderCheck(st, mode, false)
+	})
+	regRange(0xCF3C>>2, 14, 2, "BCHKBITSQ", func(st *state, mode int) error {
+		return execBuilderCheck(st, mode, true)
+	})
+	reg(0xCF40, 16, "STZEROES", func(st *state) error {
+		return execStoreSame(st, 0)
+	})
+	reg(0xCF41, 16, "STONES", func(st *state) error {
+		return execStoreSame(st, 1)
+	})
+	reg(0xCF42, 16, "STSAME", func(st *state) error {
+		x, err := st.stack.popIntRange(0, 1)
+		if err != nil {
+			return err
+		}
+		return execStoreSame(st, int(x))
+	})
+	reg(0x19F, 9, "STSLICECONST", func(st *state) error {
+		args, err := st.argUInt(5)
+		if err != nil {
+			return err
+		}
+		s, err := st.argSlice(uint(8*(args&7)+2), int(args>>3))
+		if err != nil {
+			return err
+		}
+		if s, err = trimCompletionTag(s); err != nil {
+			return err
+		}
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		res, ok := storeSliceTo(b, s)
+		if !ok {
+			return errCellOverflow()
+		}
+		st.stack.push(res)
+		return nil
+	})
+
+	reg(0xD0, 8, "CTOS", func(st *state) error {
+		c, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		s, err := st.loadCell(c)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	})
+	reg(0xD1, 8, "ENDS", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if s.BitsLeft() > 0 || s.RefsNum() > 0 {
+			return throwErr(ExitCodeCellUnderflow, "extra data remaining in deserialized cell")
+		}
+		return nil
+	})
+	regRange(0xD2, 8, 8, "LDI", func(st *state, c int) error {
+		return execLoadInt(st, uint(c+1), true, false, false)
+	})
+	regRange(0xD3, 8, 8, "LDU", func(st *state, c int) error {
+		return execLoadInt(st, uint(c+1), false, false, false)
+	})
+	reg(0xD4, 8, "LDREF", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if s.RefsNum() == 0 {
+			return errCellUnderflow()
+		}
+		s = s.Copy()
+		ref, err := s.LoadRefCell()
+		if err != nil {
+			return errCellUnderflow()
+		}
+		st.stack.push(ref)
+		st.stack.push(s)
+		return nil
+	})
+	reg(0xD5, 8, "LDREFRTOS", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if s.RefsNum() == 0 {
+			return errCellUnderflow()
+		}
+		s = s.Copy()
+		ref, err := s.LoadRefCell()
+		if err != nil {
+			return errCellUnderflow()
+		}
+		st.stack.push(s)
+		rs, err := st.loadCell(ref)
+		if err != nil {
+			return err
+		}
+		st.stack.push(rs)
+		return nil
+	})
+	regRange(0xD6, 8, 8, "LDSLICE", func(st *state, c int) error {
+		return execLoadSlice(st, uint(c+1), false, false)
+	})
+	regRange(0xD70, 12, 4, "LDIX", func(st *state, mode int) error {
+		var bits uint
+		if mode&8 != 0 {
+			c, err := st.argUInt(8)
+			if err != nil {
+				return err
+			}
+			bits = uint(c + 1)
+		} else {
+			max := int64(257)
+			if mode&1 != 0 {
+				max = 256
+			}
+			l, err := st.stack.popIntRange(0, max)
+			if err != nil {
+				return err
+			}
+			bits = uint(l)
+		}
+		return execLoadInt(st, bits, mode&1 == 0, mode&2 != 0, mode&4 != 0)
+	})
+	regRange(0xD710>>3, 13, 3, "PLDUZ", func(st *state, c int) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		bits := uint(32 * (c + 1))
+		data := sliceBits(s)
+		n := s.BitsLeft()
+		if n > bits {
+			n = bits
+		}
+		x := bitsToInt(data, n, false)
+		x.Lsh(x, bits-n)
+		st.stack.push(s)
+		st.stack.push(x)
+		return nil
+	})
+	regRange(0xD718>>2, 14, 2, "LDSLICEX", func(st *state, mode int) error {
+		l, err := st.stack.popIntRange(0, 1023)
+		if err != nil {
+			return err
+		}
+		return execLoadSlice(st, uint(l), mode&1 != 0, mode&2 != 0)
+	})
+	regRange(0xD71C>>2, 14, 2, "LDSLICE", func(st *state, mode int) error {
+		c, err := st.argUInt(8)
+		if err != nil {
+			return err
+		}
+		return execLoadSlice(st, uint(c+1), mode&1 != 0, mode&2 != 0)
+	})
+	reg(0xD720, 16, "SDCUTFIRST", func(st *state) error {
+		return execSliceCut(st, false, false, false)
+	})
+	reg(0xD721, 16, "SDSKIPFIRST", func(st *state) error {
+		return execSliceCut(st, true, false, false)
+	})
+	reg(0xD722, 16, "SDCUTLAST", func(st *state) error {
+		return execSliceCut(st, false, true, false)
+	})
+	reg(0xD723, 16, "SDSKIPLAST", func(st *state) error {
+		return execSliceCut(st, true, true, false)
+	})
+	reg(0xD724, 16, "SDSUBSTR", func(st *state) error {
+		l2, err := st.stack.popIntRange(0, 1023)
+		if err != nil {
+			return err
+		}
+		l1, err := st.stack.popIntRange(0, 1023)
+		if err != nil {
+			return err
+		}
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		res, err := subSlice(s, uint(l1), uint(l2), 0, 0)
+		if err != nil {
+			return err
+		}
+		st.stack.push(res)
+		return nil
+	})
+	reg(0xD726, 16, "SDBEGINSX", func(st *state) error {
+		pfx, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		return execBeginsWith(st, pfx, false)
+	})
+	reg(0xD727, 16, "SDBEGINSXQ", func(st *state) error {
+		pfx, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		return execBeginsWith(st, pfx, true)
+	})
+	regRange(0x35CA, 14, 1, "SDBEGINS", func(st *state, quiet int) error {
+		x, err := st.argUInt(7)
+		if err != nil {
+			return err
+		}
+		pfx, err := st.argSlice(uint(8*x+3), 0)
+		if err != nil {
+			return err
+		}
+		if pfx, err = trimCompletionTag(pfx); err != nil {
+			return err
+		}
+		return execBeginsWith(st, pfx, quiet == 1)
+	})
+	reg(0xD730, 16, "SCUTFIRST", func(st *state) error {
+		return execSliceCut(st, false, false, true)
+	})
+	reg(0xD731, 16, "SSKIPFIRST", func(st *state) error {
+		return execSliceCut(st, true, false, true)
+	})
+	reg(0xD732, 16, "SCUTLAST", func(st *state) error {
+		return execSliceCut(st, false, true, true)
+	})
+	reg(0xD733, 16, "SSKIPLAST", func(st *state) error {
+		return execSliceCut(st, true, true, true)
+	})
+	reg(0xD734, 16, "SUBSLICE", func(st *state) error {
+		r2, err := st.stack.popIntRange(0, 4)
+		if err != nil {
+			return err
+		}
+		l2, err := st.stack.popIntRange(0, 1023)
+		if err != nil {
+			return err
+		}
+		r1, err := st.stack.popIntRange(0, 4)
+		if err != nil {
+			return err
+		}
+		l1, err := st.stack.popIntRange(0, 1023)
+		if err != nil {
+			return err
+		}
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		res, err := subSlice(s, uint(l1), uint(l2), int(r1), int(r2))
+		if err != nil {
+			return err
+		}
+		st.stack.push(res)
+		return nil
+	})
+	reg(0xD736, 16, "SPLIT", func(st *state) error {
+		return execSplit(st, false)
+	})
+	reg(0xD737, 16, "SPLITQ", func(st *state) error {
+		return execSplit(st, true)
+	})
+	reg(0xD739, 16, "XCTOS", func(st *state) error {
+		c, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		if err = st.registerCellLoad(c); err != nil {
+			return err
+		}
+		st.stack.push(c.BeginParse())
+		st.stack.pushBool(c.GetType() != cell.OrdinaryCellType)
+		return nil
+	})
+	reg(0xD73A, 16, "XLOAD", func(st *state) error {
+		return execXLoad(st, false)
+	})
+	reg(0xD73B, 16, "XLOADQ", func(st *state) error {
+		return execXLoad(st, true)
+	})
+	for i := 0; i < 8; i++ {
+		if i&3 == 0 {
+			continue
+		}
+		mode := i
+		reg(0xD740|uint64(mode), 16, "SCHKBITS", func(st *state) error {
+			return execSliceCheck(st, mode&1 != 0, mode&2 != 0, mode&4 != 0)
+		})
+	}
+	reg(0xD748, 16, "PLDREFVAR", func(st *state) error {
+		n, err := st.stack.popIntRange(0, 3)
+		if err != nil {
+			return err
+		}
+		return execPreloadRef(st, int(n))
+	})
+	reg(0xD749, 16, "SBITS", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(s.BitsLeft()))
+		return nil
+	})
+	reg(0xD74A, 16, "SREFS", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(s.RefsNum()))
+		return nil
+	})
+	reg(0xD74B, 16, "SBITREFS", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(s.BitsLeft()))
+		st.stack.pushInt(int64(s.RefsNum()))
+		return nil
+	})
+	regRange(0xD74C>>2, 14, 2, "PLDREFIDX", func(st *state, n int) error {
+		return execPreloadRef(st, n)
+	})
+	regRange(0xD75, 12, 4, "LDILE", func(st *state, mode int) error {
+		bits := uint(32)
+		if mode&2 != 0 {
+			bits = 64
+		}
+		signed, preload, quiet := mode&1 == 0, mode&4 != 0, mode&8 != 0
+
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if s.BitsLeft() < bits {
+			if !quiet {
+				return errCellUnderflow()
+			}
+			if !preload {
+				st.stack.push(s)
+			}
+			st.stack.pushBool(false)
+			return nil
+		}
+
+		s = s.Copy()
+		data, err := s.LoadSlice(bits)
+		if err != nil {
+			return errCellUnderflow()
+		}
+		st.stack.push(bitsToInt(reverseBytes(data), bits, signed))
+		if !preload {
+			st.stack.push(s)
+		}
+		if quiet {
+			st.stack.pushBool(true)
+		}
+		return nil
+	})
+	reg(0xD760, 16, "LDZEROES", func(st *state) error {
+		return execLoadSame(st, 0)
+	})
+	reg(0xD761, 16, "LDONES", func(st *state) error {
+		return execLoadSame(st, 1)
+	})
+	reg(0xD762, 16, "LDSAME", func(st *state) error {
+		x, err := st.stack.popIntRange(0, 1)
+		if err != nil {
+			return err
+		}
+		return execLoadSame(st, int(x))
+	})
+	reg(0xD764, 16, "SDEPTH", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		depth := 0
+		for _, ref := range sliceRefs(s) {
+			if d := int(ref.Depth()) + 1; d > depth {
+				depth = d
+			}
+		}
+		st.stack.pushInt(int64(depth))
+		return nil
+	})
+	reg(0xD765, 16, "CDEPTH", func(st *state) error {
+		c, err := st.stack.popMaybeCell()
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			st.stack.pushInt(0)
+			return nil
+		}
+		st.stack.pushInt(int64(c.Depth()))
+		return nil
+	})
+
+	reg(0xC700, 16, "SEMPTY", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(s.BitsLeft() == 0 && s.RefsNum() == 0)
+		return nil
+	})
+	reg(0xC701, 16, "SDEMPTY", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(s.BitsLeft() == 0)
+		return nil
+	})
+	reg(0xC702, 16, "SREMPTY", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(s.RefsNum() == 0)
+		return nil
+	})
+	reg(0xC703, 16, "SDFIRST", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(s.BitsLeft() > 0 && getBit(sliceBits(s), 0))
+		return nil
+	})
+	reg(0xC704, 16, "SDLEXCMP", func(st *state) error {
+		a, b, err := popTwoSlices(st)
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(lexCompare(a, b)))
+		return nil
+	})
+	reg(0xC705, 16, "SDEQ", func(st *state) error {
+		a, b, err := popTwoSlices(st)
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(a.BitsLeft() == b.BitsLeft() && lexCompare(a, b) == 0)
+		return nil
+	})
+	for i := 0; i < 8; i++ {
+		mode := i
+		reg(0xC708|uint64(mode), 16, "SDPFX", func(st *state) error {
+			a, b, err := popTwoSlices(st)
+			if err != nil {
+				return err
+			}
+			if mode&1 != 0 {
+				a, b = b, a
+			}
+			// a is checked to be a prefix (or suffix) of b
+			res := isBitsPrefix(a, b, mode&4 != 0)
+			if mode&2 != 0 && a.BitsLeft() == b.BitsLeft() {
+				res = false
+			}
+			st.stack.pushBool(res)
+			return nil
+		})
+	}
+	for i := 0; i < 4; i++ {
+		mode := i
+		reg(0xC710|uint64(mode), 16, "SDCNTLEAD0", func(st *state) error {
+			s, err := st.stack.popSlice()
+			if err != nil {
+				return err
+			}
+			st.stack.pushInt(int64(countSame(s, mode&1, mode&2 != 0)))
+			return nil
+		})
+	}
+}
+
+func execStoreInt(st *state, bits uint, signed, rev, quiet bool) error {
+	var x *big.Int
+	var b *cell.Builder
+	var err error
+	if rev {
+		if x, err = st.stack.popInt(); err != nil {
+			return err
+		}
+		if b, err = st.stack.popBuilder(); err != nil {
+			return err
+		}
+	} else {
+		if b, err = st.stack.popBuilder(); err != nil {
+			return err
+		}
+		if x, err = st.stack.popInt(); err != nil {
+			return err
+		}
+	}
+
+	failCode := int64(0)
+	if b.BitsLeft() < bits {
+		failCode = -1
+	} else if !fitsBits(x, bits, signed) {
+		failCode = 1
+	}
+
+	if failCode != 0 {
+		if !quiet {
+			if failCode == -1 {
+				return errCellOverflow()
+			}
+			return errRangeCheck("integer does not fit")
+		}
+		if rev {
+			st.stack.push(b)
+			st.stack.push(x)
+		} else {
+			st.stack.push(x)
+			st.stack.push(b)
+		}
+		st.stack.pushInt(failCode)
+		return nil
+	}
+
+	b = b.Copy()
+	if err = storeInt(b, x, bits, signed); err != nil {
+		return err
+	}
+	st.stack.push(b)
+	if quiet {
+		st.stack.pushInt(0)
+	}
+	return nil
+}
+
+// popStoreArgs - pops value and builder in order depending on rev flag
+func popStoreArgs(st *state, rev bool) (any, *cell.Builder, error) {
+	var v any
+	var b *cell.Builder
+	var err error
+	if rev {
+		if v, err = st.stack.pop(); err != nil {
+			return nil, nil, err
+		}
+		if b, err = st.stack.popBuilder(); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if b, err = st.stack.popBuilder(); err != nil {
+			return nil, nil, err
+		}
+		if v, err = st.stack.pop(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return v, b, nil
+}
+
+func storeFailed(st *state, v any, b *cell.Builder, rev, quiet bool) error {
+	if !quiet {
+		return errCellOverflow()
+	}
+	if rev {
+		st.stack.push(b)
+		st.stack.push(v)
+	} else {
+		st.stack.push(v)
+		st.stack.push(b)
+	}
+	st.stack.pushInt(-1)
+	return nil
+}
+
+func storeSucceed(st *state, b *cell.Builder, quiet bool) error {
+	st.stack.push(b)
+	if quiet {
+		st.stack.pushInt(0)
+	}
+	return nil
+}
+
+func execStoreRef(st *state, rev, quiet bool) error {
+	v, b, err := popStoreArgs(st, rev)
+	if err != nil {
+		return err
+	}
+	c, ok := v.(*cell.Cell)
+	if !ok {
+		return errTypeCheck("not a cell")
+	}
+	if b.RefsLeft() == 0 {
+		return storeFailed(st, v, b, rev, quiet)
+	}
+	b = b.Copy()
+	if err = b.StoreRef(c); err != nil {
+		return errCellOverflow()
+	}
+	return storeSucceed(st, b, quiet)
+}
+
+func execStoreBuilderRef(st *state, rev, quiet bool) error {
+	v, b, err := popStoreArgs(st, rev)
+	if err != nil {
+		return err
+	}
+	bv, ok := v.(*cell.Builder)
+	if !ok {
+		return errTypeCheck("not a builder")
+	}
+	if b.RefsLeft() == 0 {
+		return storeFailed(st, v, b, rev, quiet)
+	}
+	if err = st.registerCellCreate(); err != nil {
+		return err
+	}
+	b = b.Copy()
+	if err = b.StoreRef(bv.EndCell()); err != nil {
+		return errCellOverflow()
+	}
+	return storeSucceed(st, b, quiet)
+}
+
+func execStoreSlice(st *state, rev, quiet bool) error {
+	v, b, err := popStoreArgs(st, rev)
+	if err != nil {
+		return err
+	}
+	s, ok := v.(*cell.Slice)
+	if !ok {
+		return errTypeCheck("not a slice")
+	}
+	res, ok := storeSliceTo(b, s)
+	if !ok {
+		return storeFailed(st, v, b, rev, quiet)
+	}
+	return storeSucceed(st, res, quiet)
+}
+
+func execStoreBuilder(st *state, rev, quiet bool) error {
+	v, b, err := popStoreArgs(st, rev)
+	if err != nil {
+		return err
+	}
+	bv, ok := v.(*cell.Builder)
+	if !ok {
+		return errTypeCheck("not a builder")
+	}
+	if b.BitsLeft() < bv.BitsUsed() || int(b.RefsLeft()) < bv.RefsUsed() {
+		return storeFailed(st, v, b, rev, quiet)
+	}
+	b = b.Copy()
+	if err = b.StoreBuilder(bv); err != nil {
+		return errCellOverflow()
+	}
+	return storeSucceed(st, b, quiet)
+}
+
+func storeSliceTo(b *cell.Builder, s *cell.Slice) (*cell.Builder, bool) {
+	if b.BitsLeft() < s.BitsLeft() || int(b.RefsLeft()) < s.RefsNum() {
+		return nil, false
+	}
+	b = b.Copy()
+	if err := b.StoreSlice(sliceBits(s), s.BitsLeft()); err != nil {
+		return nil, false
+	}
+	for _, ref := range sliceRefs(s) {
+		if err := b.StoreRef(ref); err != nil {
+			return nil, false
+		}
+	}
+	return b, true
+}
+
+func execStoreConstRefs(st *state, n int) error {
+	refs := make([]*cell.Cell, n)
+	for i := 0; i < n; i++ {
+		ref, err := st.argRef()
+		if err != nil {
+			return err
+		}
+		refs[i] = ref
+	}
+
+	b, err := st.stack.popBuilder()
+	if err != nil {
+		return err
+	}
+	if int(b.RefsLeft()) < n {
+		return errCellOverflow()
+	}
+	b = b.Copy()
+	for _, ref := range refs {
+		if err = b.StoreRef(ref); err != nil {
+			return errCellOverflow()
+		}
+	}
+	st.stack.push(b)
+	return nil
+}
+
+func execBuilderCheck(st *state, mode int, quiet bool) error {
+	var bits, refs int64
+	var err error
+
+	if mode == 0 {
+		c, err := st.argUInt(8)
+		if err != nil {
+			return err
+		}
+		bits = int64(c + 1)
+	} else {
+		if mode&2 != 0 {
+			if refs, err = st.stack.popIntRange(0, 7); err != nil {
+				return err
+			}
+		}
+		if mode&1 != 0 {
+			if bits, err = st.stack.popIntRange(0, 1023); err != nil {
+				return err
+			}
+		}
+	}
+
+	b, err := st.stack.popBuilder()
+	if err != nil {
+		return err
+	}
+
+	ok := int64(b.BitsLeft()) >= bits && int64(b.RefsLeft()) >= refs
+	if quiet {
+		st.stack.pushBool(ok)
+		return nil
+	}
+	if !ok {
+		return errCellOverflow()
+	}
+	return nil
+}
+
+func execStoreSame(st *state, bit int) error {
+	n, err := st.stack.popIntRange(0, 1023)
+	if err != nil {
+		return err
+	}
+	b, err := st.stack.popBuilder()
+	if err != nil {
+		return err
+	}
+	if int64(b.BitsLeft()) < n {
+		return errCellOverflow()
+	}
+
+	data := make([]byte, (n+7)/8)
+	if bit == 1 {
+		for i := range data {
+			data[i] = 0xFF
+		}
+	}
+
+	b = b.Copy()
+	if err = b.StoreSlice(data, uint(n)); err != nil {
+		return errCellOverflow()
+	}
+	st.stack.push(b)
+	return nil
+}
+
+func execLoadInt(st *state, bits uint, signed, preload, quiet bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	if s.BitsLeft() < bits {
+		if !quiet {
+			return errCellUnderflow()
+		}
+		if !preload {
+			st.stack.push(s)
+		}
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	s = s.Copy()
+	x, err := loadInt(s, bits, signed, false)
+	if err != nil {
+		return err
+	}
+	st.stack.push(x)
+	if !preload {
+		st.stack.push(s)
+	}
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func execLoadSlice(st *state, bits uint, preload, quiet bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	if s.BitsLeft() < bits {
+		if !quiet {
+			return errCellUnderflow()
+		}
+		if !preload {
+			st.stack.push(s)
+		}
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	s = s.Copy()
+	data, err := s.LoadSlice(bits)
+	if err != nil {
+		return errCellUnderflow()
+	}
+	part, err := buildSlice(data, bits, nil)
+	if err != nil {
+		return err
+	}
+
+	st.stack.push(part)
+	if !preload {
+		st.stack.push(s)
+	}
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+// execSliceCut - cuts or skips first or last bits (and refs when withRefs)
+func execSliceCut(st *state, skip, last, withRefs bool) error {
+	var refs int64
+	var err error
+	if withRefs {
+		if refs, err = st.stack.popIntRange(0, 4); err != nil {
+			return err
+		}
+	}
+	bits, err := st.stack.popIntRange(0, 1023)
+	if err != nil {
+		return err
+	}
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	l, r := uint(bits), int(refs)
+	if s.BitsLeft() < l || s.RefsNum() < r {
+		return errCellUnderflow()
+	}
+
+	total, totalRefs := s.BitsLeft(), s.RefsNum()
+	var res *cell.Slice
+	switch {
+	case !skip && !last:
+		res, err = subSlice(s, 0, l, 0, r)
+	case skip && !last:
+		res, err = subSlice(s, l, total-l, r, totalRefs-r)
+	case !skip && last:
+		res, err = subSlice(s, total-l, l, totalRefs-r, r)
+	default:
+		res, err = subSlice(s, 0, total-l, 0, totalRefs-r)
+	}
+	if err != nil {
+		return err
+	}
+	st.stack.push(res)
+	return nil
+}
+
+func execSplit(st *state, quiet bool) error {
+	refs, err := st.stack.popIntRange(0, 4)
+	if err != nil {
+		return err
+	}
+	bits, err := st.stack.popIntRange(0, 1023)
+	if err != nil {
+		return err
+	}
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	if s.BitsLeft() < uint(bits) || s.RefsNum() < int(refs) {
+		if !quiet {
+			return errCellUnderflow()
+		}
+		st.stack.push(s)
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	first, err := subSlice(s, 0, uint(bits), 0, int(refs))
+	if err != nil {
+		return err
+	}
+	rest, err := subSlice(s, uint(bits), s.BitsLeft()-uint(bits), int(refs), s.RefsNum()-int(refs))
+	if err != nil {
+		return err
+	}
+	st.stack.push(first)
+	st.stack.push(rest)
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func execBeginsWith(st *state, pfx *cell.Slice, quiet bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	if !isBitsPrefix(pfx, s, false) {
+		if !quiet {
+			return errCellUnderflow()
+		}
+		st.stack.push(s)
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	s = s.Copy()
+	if _, err = s.LoadSlice(pfx.BitsLeft()); err != nil {
+		return errCellUnderflow()
+	}
+	st.stack.push(s)
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func execXLoad(st *state, quiet bool) error {
+	c, err := st.stack.popCell()
+	if err != nil {
+		return err
+	}
+
+	switch c.GetType() {
+	case cell.OrdinaryCellType:
+	case cell.LibraryCellType:
+		lib, err := st.resolveLibrary(c)
+		if err != nil {
+			if !quiet {
+				return err
+			}
+			st.stack.pushBool(false)
+			return nil
+		}
+		c = lib
+	default:
+		if !quiet {
+			return errCellUnderflow()
+		}
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	st.stack.push(c)
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func execSliceCheck(st *state, checkBits, checkRefs, quiet bool) error {
+	var bits, refs int64
+	var err error
+	if checkRefs {
+		if refs, err = st.stack.popIntRange(0, 4); err != nil {
+			return err
+		}
+	}
+	if checkBits {
+		if bits, err = st.stack.popIntRange(0, 1023); err != nil {
+			return err
+		}
+	}
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	ok := int64(s.BitsLeft()) >= bits && int64(s.RefsNum()) >= refs
+	if quiet {
+		st.stack.pushBool(ok)
+		return nil
+	}
+	if !ok {
+		return errCellUnderflow()
+	}
+	return nil
+}
+
+func execPreloadRef(st *state, n int) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+	refs := sliceRefs(s)
+	if n >= len(refs) {
+		return errCellUnderflow()
+	}
+	st.stack.push(refs[n])
+	return nil
+}
+
+func execLoadSame(st *state, bit int) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+	n := countSame(s, bit, false)
+	s = s.Copy()
+	if _, err = s.LoadSlice(uint(n)); err != nil {
+		return errCellUnderflow()
+	}
+	st.stack.pushInt(int64(n))
+	st.stack.push(s)
+	return nil
+}
+
+// countSame - counts leading (or trailing) bits equal to bit
+func countSame(s *cell.Slice, bit int, trailing bool) int {
+	data := sliceBits(s)
+	n := s.BitsLeft()
+	cnt := 0
+	for i := uint(0); i < n; i++ {
+		idx := i
+		if trailing {
+			idx = n - 1 - i
+		}
+		if getBit(data, idx) != (bit == 1) {
+			break
+		}
+		cnt++
+	}
+	return cnt
+}
+
+func popTwoSlices(st *state) (*cell.Slice, *cell.Slice, error) {
+	b, err := st.stack.popSlice()
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := st.stack.popSlice()
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, b, nil
+}
+
+// lexCompare - compares data bits of slices lexicographically
+func lexCompare(a, b *cell.Slice) int {
+	ad, bd := sliceBits(a), sliceBits(b)
+	al, bl := a.BitsLeft(), b.BitsLeft()
+	for i := uint(0); i < al && i < bl; i++ {
+		x, y := getBit(ad, i), getBit(bd, i)
+		if x != y {
+			if y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case al < bl:
+		return -1
+	case al > bl:
+		return 1
+	}
+	return 0
+}
+
+// isBitsPrefix - checks that data of a is a prefix (or suffix) of b data
+func isBitsPrefix(a, b *cell.Slice, suffix bool) bool {
+	al, bl := a.BitsLeft(), b.BitsLeft()
+	if al > bl {
+		return false
+	}
+	ad, bd := sliceBits(a), sliceBits(b)
+	off := uint(0)
+	if suffix {
+		off = bl - al
+	}
+	for i := uint(0); i < al; i++ {
+		if getBit(ad, i) != getBit(bd, off+i) {
+			return false
+		}
+	}
+	return true
+}
+
+func builderDepth(b *cell.Builder) int {
+	c := b.EndCell()
+	if c.RefsNum() == 0 {
+		return 0
+	}
+	return int(c.Depth())
+}
+
+func reverseBytes(data []byte) []byte {
+	res := make([]byte, len(data))
+	for i := range data {
+		res[len(data)-1-i] = data[i]
+	}
+	return res
+}
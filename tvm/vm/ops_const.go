@@ -0,0 +1,157 @@
+package vm
+
+import (
+	"math/big"
+)
+
+func init() {
+	regRange(0x7, 4, 4, "PUSHINT", func(st *state, x int) error {
+		if x > 10 {
+			x -= 16
+		}
+		st.stack.pushInt(int64(x))
+		return nil
+	})
+	regRange(0x80, 8, 8, "PUSHINT", func(st *state, x int) error {
+		st.stack.pushInt(int64(int8(x)))
+		return nil
+	})
+	reg(0x81, 8, "PUSHINT", func(st *state) error {
+		x, err := st.argUInt(16)
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(int16(x)))
+		return nil
+	})
+	reg(0x82, 8, "PUSHINT", func(st *state) error {
+		l, err := st.argUInt(5)
+		if err != nil {
+			return err
+		}
+		n := uint(8*l + 19)
+		if st.code.BitsLeft() < n {
+			return throwErr(ExitCodeInvalidOpcode, "not enough bits for integer")
+		}
+		x, err := loadInt(st.code, n, true, false)
+		if err != nil {
+			return err
+		}
+		if err = st.consumeGas(int64(n) * gasPerBit); err != nil {
+			return err
+		}
+		st.stack.push(x)
+		return nil
+	})
+	regRange(0x83, 8, 8, "PUSHPOW2", func(st *state, x int) error {
+		if x == 0xFF {
+			st.stack.push(nan)
+			return nil
+		}
+		st.stack.push(new(big.Int).Lsh(big.NewInt(1), uint(x+1)))
+		return nil
+	})
+	regRange(0x84, 8, 8, "PUSHPOW2DEC", func(st *state, x int) error {
+		v := new(big.Int).Lsh(big.NewInt(1), uint(x+1))
+		st.stack.push(v.Sub(v, big.NewInt(1)))
+		return nil
+	})
+	regRange(0x85, 8, 8, "PUSHNEGPOW2", func(st *state, x int) error {
+		st.stack.push(new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), uint(x+1))))
+		return nil
+	})
+
+	reg(0x88, 8, "PUSHREF", func(st *state) error {
+		ref, err := st.argRef()
+		if err != nil {
+			return err
+		}
+		st.stack.push(ref)
+		return nil
+	})
+	reg(0x89, 8, "PUSHREFSLICE", func(st *state) error {
+		ref, err := st.argRef()
+		if err != nil {
+			return err
+		}
+		s, err := st.loadCell(ref)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	})
+	reg(0x8A, 8, "PUSHREFCONT", func(st *state) error {
+		ref, err := st.argRef()
+		if err != nil {
+			return err
+		}
+		c, err := st.refToCont(ref)
+		if err != nil {
+			return err
+		}
+		st.stack.push(c)
+		return nil
+	})
+	reg(0x8B, 8, "PUSHSLICE", func(st *state) error {
+		x, err := st.argUInt(4)
+		if err != nil {
+			return err
+		}
+		return pushSliceArg(st, uint(8*x+4), 0)
+	})
+	reg(0x8C, 8, "PUSHSLICE", func(st *state) error {
+		args, err := st.argUInt(7)
+		if err != nil {
+			return err
+		}
+		return pushSliceArg(st, uint(8*(args&31)+1), int(args>>5)+1)
+	})
+	reg(0x8D, 8, "PUSHSLICE", func(st *state) error {
+		args, err := st.argUInt(10)
+		if err != nil {
+			return err
+		}
+		refs := int(args >> 7)
+		if refs > 4 {
+			return throwErr(ExitCodeInvalidOpcode, "invalid PUSHSLICE refs number")
+		}
+		return pushSliceArg(st, uint(8*(args&127)+6), refs)
+	})
+	reg(0x47, 7, "PUSHCONT", func(st *state) error {
+		args, err := st.argUInt(9)
+		if err != nil {
+			return err
+		}
+		code, err := st.argSlice(uint(8*(args&127)), int(args>>7))
+		if err != nil {
+			return err
+		}
+		st.stack.push(newOrdCont(code, st.cp))
+		return nil
+	})
+	reg(0x9, 4, "PUSHCONT", func(st *state) error {
+		x, err := st.argUInt(4)
+		if err != nil {
+			return err
+		}
+		code, err := st.argSlice(uint(8*x), 0)
+		if err != nil {
+			return err
+		}
+		st.stack.push(newOrdCont(code, st.cp))
+		return nil
+	})
+}
+
+func pushSliceArg(st *state, bits uint, refs int) error {
+	s, err := st.argSlice(bits, refs)
+	if err != nil {
+		return err
+	}
+	if s, err = trimCompletionTag(s); err != nil {
+		return err
+	}
+	st.stack.push(s)
+	return nil
+}
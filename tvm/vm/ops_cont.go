@@ -0,0 +1,1187 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	reg(0xD8, 8, "EXECUTE", func(st *state) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.call(c, -1, -1)
+	})
+	reg(0xD9, 8, "JMPX", func(st *state) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.jump(c, -1)
+	})
+	regRange(0xDA, 8, 8, "CALLXARGS", func(st *state, args int) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.call(c, args>>4, args&15)
+	})
+	regRange(0xDB0, 12, 4, "CALLXARGS", func(st *state, p int) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.call(c, p, -1)
+	})
+	regRange(0xDB1, 12, 4, "JMPXARGS", func(st *state, p int) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.jump(c, p)
+	})
+	regRange(0xDB2, 12, 4, "RETARGS", func(st *state, r int) error {
+		return st.retArgs(r)
+	})
+	reg(0xDB30, 16, "RET", func(st *state) error {
+		return st.ret()
+	})
+	reg(0xDB31, 16, "RETALT", func(st *state) error {
+		return st.retAlt()
+	})
+	reg(0xDB32, 16, "BRANCH", func(st *state) error {
+		f, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		if f {
+			return st.ret()
+		}
+		return st.retAlt()
+	})
+	reg(0xDB34, 16, "CALLCC", func(st *state) error {
+		return execCallCC(st, -1, -1)
+	})
+	reg(0xDB35, 16, "JMPXDATA", func(st *state) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		st.stack.push(st.code.Copy())
+		return st.jump(c, -1)
+	})
+	reg(0xDB36, 16, "CALLCCARGS", func(st *state) error {
+		args, err := st.argUInt(8)
+		if err != nil {
+			return err
+		}
+		r := int(args & 15)
+		if r == 15 {
+			r = -1
+		}
+		return execCallCC(st, int(args>>4), r)
+	})
+	reg(0xDB38, 16, "CALLXVARARGS", func(st *state) error {
+		r, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		p, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.call(c, int(p), int(r))
+	})
+	reg(0xDB39, 16, "RETVARARGS", func(st *state) error {
+		r, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		return st.retArgs(int(r))
+	})
+	reg(0xDB3A, 16, "JMPXVARARGS", func(st *state) error {
+		p, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.jump(c, int(p))
+	})
+	reg(0xDB3B, 16, "CALLCCVARARGS", func(st *state) error {
+		r, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		p, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		return execCallCC(st, int(p), int(r))
+	})
+	reg(0xDB3C, 16, "CALLREF", func(st *state) error {
+		c, err := refArgToCont(st)
+		if err != nil {
+			return err
+		}
+		return st.call(c, -1, -1)
+	})
+	reg(0xDB3D, 16, "JMPREF", func(st *state) error {
+		c, err := refArgToCont(st)
+		if err != nil {
+			return err
+		}
+		return st.jump(c, -1)
+	})
+	reg(0xDB3E, 16, "JMPREFDATA", func(st *state) error {
+		c, err := refArgToCont(st)
+		if err != nil {
+			return err
+		}
+		st.stack.push(st.code.Copy())
+		return st.jump(c, -1)
+	})
+	reg(0xDB3F, 16, "RETDATA", func(st *state) error {
+		st.stack.push(st.code.Copy())
+		return st.ret()
+	})
+
+	reg(0xDC, 8, "IFRET", func(st *state) error {
+		return execIfRet(st, true, false)
+	})
+	reg(0xDD, 8, "IFNOTRET", func(st *state) error {
+		return execIfRet(st, false, false)
+	})
+	reg(0xDE, 8, "IF", func(st *state) error {
+		return execIf(st, true, false)
+	})
+	reg(0xDF, 8, "IFNOT", func(st *state) error {
+		return execIf(st, false, false)
+	})
+	reg(0xE0, 8, "IFJMP", func(st *state) error {
+		return execIf(st, true, true)
+	})
+	reg(0xE1, 8, "IFNOTJMP", func(st *state) error {
+		return execIf(st, false, true)
+	})
+	reg(0xE2, 8, "IFELSE", func(st *state) error {
+		c2, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		c1, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		f, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		if f {
+			return st.call(c1, -1, -1)
+		}
+		return st.call(c2, -1, -1)
+	})
+	reg(0xE300, 16, "IFREF", func(st *state) error {
+		return execIfRef(st, true, false)
+	})
+	reg(0xE301, 16, "IFNOTREF", func(st *state) error {
+		return execIfRef(st, false, false)
+	})
+	reg(0xE302, 16, "IFJMPREF", func(st *state) error {
+		return execIfRef(st, true, true)
+	})
+	reg(0xE303, 16, "IFNOTJMPREF", func(st *state) error {
+		return execIfRef(st, false, true)
+	})
+	reg(0xE304, 16, "CONDSEL", func(st *state) error {
+		return execCondSel(st, false)
+	})
+	reg(0xE305, 16, "CONDSELCHK", func(st *state) error {
+		return execCondSel(st, true)
+	})
+	reg(0xE308, 16, "IFRETALT", func(st *state) error {
+		return execIfRet(st, true, true)
+	})
+	reg(0xE309, 16, "IFNOTRETALT", func(st *state) error {
+		return execIfRet(st, false, true)
+	})
+	reg(0xE30D, 16, "IFREFELSE", func(st *state) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		ref, err := st.argRef()
+		if err != nil {
+			return err
+		}
+		f, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		if f {
+			if c, err = st.refToCont(ref); err != nil {
+				return err
+			}
+		}
+		return st.call(c, -1, -1)
+	})
+	reg(0xE30E, 16, "IFELSEREF", func(st *state) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		ref, err := st.argRef()
+		if err != nil {
+			return err
+		}
+		f, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		if !f {
+			if c, err = st.refToCont(ref); err != nil {
+				return err
+			}
+		}
+		return st.call(c, -1, -1)
+	})
+	reg(0xE30F, 16, "IFREFELSEREF", func(st *state) error {
+		ref1, err := st.argRef()
+		if err != nil {
+			return err
+		}
+		ref2, err := st.argRef()
+		if err != nil {
+			return err
+		}
+		f, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		ref := ref2
+		if f {
+			ref = ref1
+		}
+		c, err := st.refToCont(ref)
+		if err != nil {
+			return err
+		}
+		return st.call(c, -1, -1)
+	})
+	for i, fn := range []func(st *state, brk bool) error{execRepeat, execRepeatEnd, execUntil, execUntilEnd, execWhile, execWhileEnd, execAgain, execAgainEnd} {
+		exec := fn
+		reg(0xE4+uint64(i), 8, "LOOP", func(st *state) error {
+			return exec(st, false)
+		})
+		reg(0xE314+uint64(i), 16, "LOOPBRK", func(st *state) error {
+			return exec(st, true)
+		})
+	}
+	for i := 0; i < 4; i++ {
+		jmpIfSet, withRef := i&1 == 0, i&2 != 0
+		regRange(0x71C|uint64(i), 11, 5, "IFBITJMP", func(st *state, n int) error {
+			var c continuation
+			var ref *cell.Cell
+			var err error
+			if withRef {
+				if ref, err = st.argRef(); err != nil {
+					return err
+				}
+			} else if c, err = st.stack.popCont(); err != nil {
+				return err
+			}
+
+			x, err := st.stack.popInt()
+			if err != nil {
+				return err
+			}
+			st.stack.push(x)
+
+			if isBitSet(x, n) != jmpIfSet {
+				return nil
+			}
+			if withRef {
+				if c, err = st.refToCont(ref); err != nil {
+					return err
+				}
+			}
+			return st.jump(c, -1)
+		})
+	}
+
+	regRange(0xEC, 8, 8, "SETCONTARGS", func(st *state, args int) error {
+		more := args & 15
+		if more == 15 {
+			more = -1
+		}
+		return execSetContArgs(st, args>>4, more)
+	})
+	regRange(0xED0, 12, 4, "RETURNARGS", func(st *state, p int) error {
+		return execReturnArgs(st, p)
+	})
+	reg(0xED10, 16, "RETURNVARARGS", func(st *state) error {
+		p, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		return execReturnArgs(st, int(p))
+	})
+	reg(0xED11, 16, "SETCONTVARARGS", func(st *state) error {
+		more, err := st.stack.popIntRange(-1, 255)
+		if err != nil {
+			return err
+		}
+		copyN, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		return execSetContArgs(st, int(copyN), int(more))
+	})
+	reg(0xED12, 16, "SETNUMVARARGS", func(st *state) error {
+		more, err := st.stack.popIntRange(-1, 255)
+		if err != nil {
+			return err
+		}
+		return execSetContArgs(st, 0, int(more))
+	})
+	reg(0xED1E, 16, "BLESS", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.push(newOrdCont(s, st.cp))
+		return nil
+	})
+	reg(0xED1F, 16, "BLESSVARARGS", func(st *state) error {
+		more, err := st.stack.popIntRange(-1, 255)
+		if err != nil {
+			return err
+		}
+		copyN, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		return execBlessArgs(st, int(copyN), int(more))
+	})
+	regRange(0xEE, 8, 8, "BLESSARGS", func(st *state, args int) error {
+		more := args & 15
+		if more == 15 {
+			more = -1
+		}
+		return execBlessArgs(st, args>>4, more)
+	})
+
+	regRange(0xED4, 12, 4, "PUSHCTR", func(st *state, i int) error {
+		if !validRegister(i) {
+			return throwErr(ExitCodeInvalidOpcode, "invalid control register")
+		}
+		st.stack.push(st.regs.get(i))
+		return nil
+	})
+	regRange(0xED5, 12, 4, "POPCTR", func(st *state, i int) error {
+		if !validRegister(i) {
+			return throwErr(ExitCodeInvalidOpcode, "invalid control register")
+		}
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		return st.regs.set(i, v)
+	})
+	regRange(0xED6, 12, 4, "SETCONTCTR", func(st *state, i int) error {
+		if !validRegister(i) {
+			return throwErr(ExitCodeInvalidOpcode, "invalid control register")
+		}
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		c, d := forceData(c)
+		if err = d.save.define(i, v); err != nil {
+			return err
+		}
+		st.stack.push(c)
+		return nil
+	})
+	regRange(0xED7, 12, 4, "SETRETCTR", func(st *state, i int) error {
+		return execSetRegCtr(st, 0, i)
+	})
+	regRange(0xED8, 12, 4, "SETALTCTR", func(st *state, i int) error {
+		return execSetRegCtr(st, 1, i)
+	})
+	regRange(0xED9, 12, 4, "POPSAVE", func(st *state, i int) error {
+		if !validRegister(i) {
+			return throwErr(ExitCodeInvalidOpcode, "invalid control register")
+		}
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		c0 := st.regs.c[0]
+		if c0.data() == nil || c0.data().save.get(i) == nil {
+			var d *controlData
+			c0, d = forceData(c0)
+			if cur := st.regs.get(i); cur != nil {
+				if err = d.save.define(i, cur); err != nil {
+					return err
+				}
+			}
+		}
+		st.regs.c[0] = c0
+		return st.regs.set(i, v)
+	})
+	regRange(0xEDA, 12, 4, "SAVECTR", func(st *state, i int) error {
+		return execSaveCtr(st, i, true, false)
+	})
+	regRange(0xEDB, 12, 4, "SAVEALTCTR", func(st *state, i int) error {
+		return execSaveCtr(st, i, false, true)
+	})
+	regRange(0xEDC, 12, 4, "SAVEBOTHCTR", func(st *state, i int) error {
+		return execSaveCtr(st, i, true, true)
+	})
+	reg(0xEDE0, 16, "PUSHCTRX", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 16)
+		if err != nil {
+			return err
+		}
+		if !validRegister(int(i)) {
+			return errRangeCheck("invalid control register")
+		}
+		st.stack.push(st.regs.get(int(i)))
+		return nil
+	})
+	reg(0xEDE1, 16, "POPCTRX", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 16)
+		if err != nil {
+			return err
+		}
+		if !validRegister(int(i)) {
+			return errRangeCheck("invalid control register")
+		}
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		return st.regs.set(int(i), v)
+	})
+	reg(0xEDE2, 16, "SETCONTCTRX", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 16)
+		if err != nil {
+			return err
+		}
+		if !validRegister(int(i)) {
+			return errRangeCheck("invalid control register")
+		}
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		c, d := forceData(c)
+		if err = d.save.define(int(i), v); err != nil {
+			return err
+		}
+		st.stack.push(c)
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		mask := i + 1
+		reg(0xEDF0|uint64(i), 16, "COMPOS", func(st *state) error {
+			next, err := st.stack.popCont()
+			if err != nil {
+				return err
+			}
+			c, err := st.stack.popCont()
+			if err != nil {
+				return err
+			}
+			c, d := forceData(c)
+			if mask&1 != 0 && d.save.c[0] == nil {
+				d.save.c[0] = next
+			}
+			if mask&2 != 0 && d.save.c[1] == nil {
+				d.save.c[1] = next
+			}
+			st.stack.push(c)
+			return nil
+		})
+	}
+	reg(0xEDF3, 16, "ATEXIT", func(st *state) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		c, d := forceData(c)
+		if d.save.c[0] == nil {
+			d.save.c[0] = st.regs.c[0]
+		}
+		st.regs.c[0] = c
+		return nil
+	})
+	reg(0xEDF4, 16, "ATEXITALT", func(st *state) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		c, d := forceData(c)
+		if d.save.c[1] == nil {
+			d.save.c[1] = st.regs.c[1]
+		}
+		st.regs.c[1] = c
+		return nil
+	})
+	reg(0xEDF5, 16, "SETEXITALT", func(st *state) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		c, d := forceData(c)
+		if d.save.c[0] == nil {
+			d.save.c[0] = st.regs.c[0]
+		}
+		if d.save.c[1] == nil {
+			d.save.c[1] = st.regs.c[1]
+		}
+		st.regs.c[1] = c
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		from := i
+		reg(0xEDF6|uint64(i), 16, "THENRET", func(st *state) error {
+			c, err := st.stack.popCont()
+			if err != nil {
+				return err
+			}
+			c, d := forceData(c)
+			if d.save.c[0] == nil {
+				d.save.c[0] = st.regs.c[from]
+			}
+			st.stack.push(c)
+			return nil
+		})
+	}
+	reg(0xEDF8, 16, "INVERT", func(st *state) error {
+		st.regs.c[0], st.regs.c[1] = st.regs.c[1], st.regs.c[0]
+		return nil
+	})
+	reg(0xEDF9, 16, "BOOLEVAL", func(st *state) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		cc, err := st.extractCC(3, -1, -1)
+		if err != nil {
+			return err
+		}
+		st.regs.c[0] = &pushIntCont{value: -1, next: cc}
+		st.regs.c[1] = &pushIntCont{value: 0, next: cc}
+		return st.jump(c, -1)
+	})
+	reg(0xEDFA, 16, "SAMEALT", func(st *state) error {
+		st.regs.c[1] = st.regs.c[0]
+		return nil
+	})
+	reg(0xEDFB, 16, "SAMEALTSAVE", func(st *state) error {
+		c0, d := forceData(st.regs.c[0])
+		if d.save.c[1] == nil {
+			d.save.c[1] = st.regs.c[1]
+		}
+		st.regs.c[0] = c0
+		st.regs.c[1] = c0
+		return nil
+	})
+
+	regRange(0xF0, 8, 8, "CALLDICT", func(st *state, n int) error {
+		st.stack.pushInt(int64(n))
+		return st.call(st.regs.c[3], -1, -1)
+	})
+	reg(0x3C4, 10, "CALLDICT", func(st *state) error {
+		n, err := st.argUInt(14)
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(n))
+		return st.call(st.regs.c[3], -1, -1)
+	})
+	reg(0x3C5, 10, "JMPDICT", func(st *state) error {
+		n, err := st.argUInt(14)
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(n))
+		return st.jump(st.regs.c[3], -1)
+	})
+	reg(0x3C6, 10, "PREPAREDICT", func(st *state) error {
+		n, err := st.argUInt(14)
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(n))
+		st.stack.push(st.regs.c[3])
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		// 0 - THROW, 1 - THROWIF, 2 - THROWIFNOT
+		mode := i
+		regRange(0x3C8|uint64(mode), 10, 6, "THROW", func(st *state, n int) error {
+			return execThrow(st, int64(n), mode, false)
+		})
+	}
+	for i := 0; i < 6; i++ {
+		mode, withArg := i>>1, i&1 != 0
+		reg(0x1E58|uint64(i), 13, "THROW", func(st *state) error {
+			n, err := st.argUInt(11)
+			if err != nil {
+				return err
+			}
+			return execThrow(st, int64(n), mode, withArg)
+		})
+	}
+	for i := 0; i < 6; i++ {
+		args := i
+		reg(0xF2F0|uint64(args), 16, "THROWANY", func(st *state) error {
+			mode := 0
+			if args&6 != 0 {
+				mode = 1
+				if args&4 != 0 {
+					mode = 2
+				}
+			}
+			if mode != 0 {
+				f, err := st.stack.popBool()
+				if err != nil {
+					return err
+				}
+				if f != (mode == 1) {
+					if _, err = st.stack.popIntRange(0, 0xffff); err != nil {
+						return err
+					}
+					if args&1 != 0 {
+						_, err = st.stack.pop()
+					}
+					return err
+				}
+			}
+
+			n, err := st.stack.popIntRange(0, 0xffff)
+			if err != nil {
+				return err
+			}
+			return execThrow(st, n, 0, args&1 != 0)
+		})
+	}
+	reg(0xF2FF, 16, "TRY", func(st *state) error {
+		return execTry(st, -1, -1)
+	})
+	regRange(0xF3, 8, 8, "TRYARGS", func(st *state, args int) error {
+		return execTry(st, args>>4, args&15)
+	})
+
+	regRange(0xFF, 8, 8, "SETCP", func(st *state, cp int) error {
+		if cp == 0xF0 {
+			x, err := st.stack.popIntRange(-0x8000, 0x7fff)
+			if err != nil {
+				return err
+			}
+			cp = int(x)
+		} else if cp > 0xF0 {
+			cp -= 0x100
+		}
+		if cp != 0 {
+			return throwErr(ExitCodeInvalidOpcode, "unsupported codepage")
+		}
+		st.cp = cp
+		return nil
+	})
+}
+
+func refArgToCont(st *state) (continuation, error) {
+	ref, err := st.argRef()
+	if err != nil {
+		return nil, err
+	}
+	return st.refToCont(ref)
+}
+
+// isBitSet - checks bit n of integer in two's complement form
+func isBitSet(x *big.Int, n int) bool {
+	if x.Sign() >= 0 {
+		return x.Bit(n) == 1
+	}
+	return new(big.Int).Not(x).Bit(n) == 0
+}
+
+func execCallCC(st *state, passArgs, retArgs int) error {
+	c, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	cc, err := st.extractCC(3, passArgs, retArgs)
+	if err != nil {
+		return err
+	}
+	st.stack.push(cc)
+	return st.jump(c, -1)
+}
+
+func execIfRet(st *state, cond, alt bool) error {
+	f, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	if f != cond {
+		return nil
+	}
+	if alt {
+		return st.retAlt()
+	}
+	return st.ret()
+}
+
+func execIf(st *state, cond, jmp bool) error {
+	c, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	f, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	if f != cond {
+		return nil
+	}
+	if jmp {
+		return st.jump(c, -1)
+	}
+	return st.call(c, -1, -1)
+}
+
+func execIfRef(st *state, cond, jmp bool) error {
+	ref, err := st.argRef()
+	if err != nil {
+		return err
+	}
+	f, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	if f != cond {
+		return nil
+	}
+	c, err := st.refToCont(ref)
+	if err != nil {
+		return err
+	}
+	if jmp {
+		return st.jump(c, -1)
+	}
+	return st.call(c, -1, -1)
+}
+
+func execCondSel(st *state, check bool) error {
+	y, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+	x, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+	f, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	if check && !sameType(x, y) {
+		return errTypeCheck("two arguments of CONDSELCHK have different type")
+	}
+	if f {
+		st.stack.push(x)
+	} else {
+		st.stack.push(y)
+	}
+	return nil
+}
+
+func sameType(x, y any) bool {
+	switch x.(type) {
+	case nil:
+		return y == nil
+	case continuation:
+		_, ok := y.(continuation)
+		return ok
+	}
+	return typeName(x) == typeName(y)
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case *cell.Cell:
+		return "cell"
+	case *cell.Slice:
+		return "slice"
+	case *cell.Builder:
+		return "builder"
+	case []any:
+		return "tuple"
+	case continuation:
+		return "cont"
+	}
+	return "int"
+}
+
+func execRepeat(st *state, brk bool) error {
+	body, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	n, err := st.stack.popIntRange(-0x80000000, 0x7fffffff)
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return nil
+	}
+	cc, err := st.extractCC(1, -1, -1)
+	if err != nil {
+		return err
+	}
+	after, err := st.c1EnvelopeIf(brk, cc)
+	if err != nil {
+		return err
+	}
+	return st.jump(&repeatCont{body: body, after: after, count: n}, -1)
+}
+
+func execRepeatEnd(st *state, brk bool) error {
+	n, err := st.stack.popIntRange(-0x80000000, 0x7fffffff)
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return st.ret()
+	}
+	body, err := st.extractCC(0, -1, -1)
+	if err != nil {
+		return err
+	}
+	after, err := st.c1EnvelopeIf(brk, st.regs.c[0])
+	if err != nil {
+		return err
+	}
+	return st.jump(&repeatCont{body: body, after: after, count: n}, -1)
+}
+
+func execUntil(st *state, brk bool) error {
+	body, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	cc, err := st.extractCC(1, -1, -1)
+	if err != nil {
+		return err
+	}
+	after, err := st.c1EnvelopeIf(brk, cc)
+	if err != nil {
+		return err
+	}
+	return loopUntil(st, body, after)
+}
+
+func execUntilEnd(st *state, brk bool) error {
+	body, err := st.extractCC(0, -1, -1)
+	if err != nil {
+		return err
+	}
+	after, err := st.c1EnvelopeIf(brk, st.regs.c[0])
+	if err != nil {
+		return err
+	}
+	return loopUntil(st, body, after)
+}
+
+func loopUntil(st *state, body, after continuation) error {
+	if !hasC0(body) {
+		st.regs.c[0] = &untilCont{body: body, after: after}
+	}
+	return st.jump(body, -1)
+}
+
+func execWhile(st *state, brk bool) error {
+	body, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	cond, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	cc, err := st.extractCC(1, -1, -1)
+	if err != nil {
+		return err
+	}
+	after, err := st.c1EnvelopeIf(brk, cc)
+	if err != nil {
+		return err
+	}
+	return loopWhile(st, cond, body, after)
+}
+
+func execWhileEnd(st *state, brk bool) error {
+	cond, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	body, err := st.extractCC(0, -1, -1)
+	if err != nil {
+		return err
+	}
+	after, err := st.c1EnvelopeIf(brk, st.regs.c[0])
+	if err != nil {
+		return err
+	}
+	return loopWhile(st, cond, body, after)
+}
+
+func loopWhile(st *state, cond, body, after continuation) error {
+	if !hasC0(cond) {
+		st.regs.c[0] = &whileCont{cond: cond, body: body, after: after, checkCond: true}
+	}
+	return st.jump(cond, -1)
+}
+
+func execAgain(st *state, brk bool) error {
+	body, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	if brk {
+		cc, err := st.extractCC(3, -1, -1)
+		if err != nil {
+			return err
+		}
+		st.regs.c[1] = cc
+	}
+	return st.jump(&againCont{body: body}, -1)
+}
+
+func execAgainEnd(st *state, brk bool) error {
+	if brk {
+		c0, d := forceData(st.regs.c[0])
+		if d.save.c[1] == nil {
+			d.save.c[1] = st.regs.c[1]
+		}
+		st.regs.c[0] = c0
+		st.regs.c[1] = c0
+	}
+	body, err := st.extractCC(0, -1, -1)
+	if err != nil {
+		return err
+	}
+	return st.jump(&againCont{body: body}, -1)
+}
+
+// execSetContArgs - moves copyN stack elements into the continuation stack and sets its number of args
+func execSetContArgs(st *state, copyN, more int) error {
+	c, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	if copyN > 0 || more >= 0 {
+		c, err = setContArgs(st, c, copyN, more)
+		if err != nil {
+			return err
+		}
+	}
+	st.stack.push(c)
+	return nil
+}
+
+func setContArgs(st *state, c continuation, copyN, more int) (continuation, error) {
+	c, d := forceData(c)
+	if copyN > 0 {
+		if d.nargs >= 0 && d.nargs < copyN {
+			return nil, throwErr(ExitCodeStackOverflow, "too many arguments copied into a closure continuation")
+		}
+		if d.stack == nil {
+			d.stack = newStack()
+		}
+		if err := d.stack.moveFrom(st.stack, copyN); err != nil {
+			return nil, err
+		}
+		if err := st.consumeStackGas(d.stack.depth()); err != nil {
+			return nil, err
+		}
+		if d.nargs >= 0 {
+			d.nargs -= copyN
+		}
+	}
+	if more >= 0 {
+		if d.nargs > more {
+			// will throw an exception on jump
+			d.nargs = 0x40000000
+		} else if d.nargs < 0 {
+			d.nargs = more
+		}
+	}
+	return c, nil
+}
+
+func execReturnArgs(st *state, count int) error {
+	if err := st.stack.checkUnderflow(count); err != nil {
+		return err
+	}
+	copyN := st.stack.depth() - count
+	if copyN == 0 {
+		return nil
+	}
+
+	c0, d := forceData(st.regs.c[0])
+	if d.nargs >= 0 && d.nargs < copyN {
+		return throwErr(ExitCodeStackOverflow, "too many arguments copied into a closure continuation")
+	}
+
+	top, err := st.stack.splitTop(count, 0)
+	if err != nil {
+		return err
+	}
+	if d.stack == nil {
+		d.stack = newStack()
+	}
+	if err = d.stack.moveFrom(st.stack, copyN); err != nil {
+		return err
+	}
+	if err = st.consumeStackGas(d.stack.depth()); err != nil {
+		return err
+	}
+	if d.nargs >= 0 {
+		d.nargs -= copyN
+	}
+
+	st.stack = top
+	st.regs.c[0] = c0
+	return nil
+}
+
+func execBlessArgs(st *state, copyN, more int) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+	if err = st.stack.checkUnderflow(copyN); err != nil {
+		return err
+	}
+	c, err := setContArgs(st, newOrdCont(s, st.cp), copyN, more)
+	if err != nil {
+		return err
+	}
+	st.stack.push(c)
+	return nil
+}
+
+// execSetRegCtr - defines register i in saved list of c0 or c1
+func execSetRegCtr(st *state, which, i int) error {
+	if !validRegister(i) {
+		return throwErr(ExitCodeInvalidOpcode, "invalid control register")
+	}
+	v, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+	c, d := forceData(st.regs.c[which])
+	if err = d.save.define(i, v); err != nil {
+		return err
+	}
+	st.regs.c[which] = c
+	return nil
+}
+
+func execSaveCtr(st *state, i int, c0, c1 bool) error {
+	if !validRegister(i) {
+		return throwErr(ExitCodeInvalidOpcode, "invalid control register")
+	}
+	v := st.regs.get(i)
+	for which, need := range []bool{c0, c1} {
+		if !need {
+			continue
+		}
+		c, d := forceData(st.regs.c[which])
+		if d.save.get(i) == nil && v != nil {
+			if err := d.save.define(i, v); err != nil {
+				return err
+			}
+		}
+		st.regs.c[which] = c
+	}
+	return nil
+}
+
+// execThrow - mode 0 is unconditional, 1 is throw if true, 2 is throw if false
+func execThrow(st *state, code int64, mode int, withArg bool) error {
+	throw := true
+	if mode != 0 {
+		f, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		throw = f == (mode == 1)
+	}
+
+	var arg any
+	if withArg {
+		var err error
+		if arg, err = st.stack.pop(); err != nil {
+			return err
+		}
+	}
+	if !throw {
+		return nil
+	}
+	return vmError{code: code, arg: arg}
+}
+
+func execTry(st *state, stackCopy, retArgs int) error {
+	handler, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	body, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+
+	oldC2 := st.regs.c[2]
+	cc, err := st.extractCC(7, stackCopy, retArgs)
+	if err != nil {
+		return err
+	}
+
+	handler, d := forceData(handler)
+	if d.save.c[2] == nil {
+		d.save.c[2] = oldC2
+	}
+	if d.save.c[0] == nil {
+		d.save.c[0] = cc
+	}
+	st.regs.c[0] = cc
+	st.regs.c[2] = handler
+	return st.jump(body, -1)
+}
@@ -0,0 +1,210 @@
+package vm
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	reg(0xF900, 16, "HASHCU", func(st *state) error {
+		c, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		st.stack.push(new(big.Int).SetBytes(c.Hash()))
+		return nil
+	})
+	reg(0xF901, 16, "HASHSU", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if err = st.registerCellCreate(); err != nil {
+			return err
+		}
+		c, err := sliceToCell(s)
+		if err != nil {
+			return err
+		}
+		st.stack.push(new(big.Int).SetBytes(c.Hash()))
+		return nil
+	})
+	reg(0xF902, 16, "SHA256U", func(st *state) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if s.BitsLeft()%8 != 0 {
+			return errCellUnderflow()
+		}
+		hash := sha256.Sum256(sliceBits(s))
+		st.stack.push(new(big.Int).SetBytes(hash[:]))
+		return nil
+	})
+	reg(0xF910, 16, "CHKSIGNU", func(st *state) error {
+		return execCheckSign(st, false)
+	})
+	reg(0xF911, 16, "CHKSIGNS", func(st *state) error {
+		return execCheckSign(st, true)
+	})
+	for i := 0; i < 4; i++ {
+		isSlice, quiet := i&2 != 0, i&1 == 0
+		reg(0xF940+uint64(i), 16, "CDATASIZE", func(st *state) error {
+			return execDataSize(st, isSlice, quiet)
+		})
+	}
+
+	regRange(0xFE, 8, 8, "DEBUG", func(st *state, arg int) error {
+		if arg >= 0xF0 {
+			// DEBUGSTR, string payload is skipped
+			_, err := st.argSlice(uint(arg&15+1)*8, 0)
+			return err
+		}
+		return nil
+	})
+}
+
+func execCheckSign(st *state, fromSlice bool) error {
+	key, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	sig, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if fromSlice {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if s.BitsLeft()%8 != 0 {
+			return errCellUnderflow()
+		}
+		data = sliceBits(s)
+	} else {
+		hash, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if !fitsBits(hash, 256, false) {
+			return errRangeCheck("hash is out of range")
+		}
+		data = intToBits(hash, 256)
+	}
+
+	if sig.BitsLeft() < 512 {
+		return errCellUnderflow()
+	}
+	if !fitsBits(key, 256, false) {
+		return errRangeCheck("public key is out of range")
+	}
+
+	st.chksignCount++
+	if st.chksignCount > chksignFreeCount {
+		if err = st.consumeGas(chksignGasPrice); err != nil {
+			return err
+		}
+	}
+
+	signature, err := sig.PreloadSlice(512)
+	if err != nil {
+		return errCellUnderflow()
+	}
+	st.stack.pushBool(ed25519.Verify(intToBits(key, 256), data, signature))
+	return nil
+}
+
+func execDataSize(st *state, isSlice, quiet bool) error {
+	bound, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	if bound.Sign() < 0 {
+		return errRangeCheck("finite non-negative integer expected")
+	}
+
+	var roots []*cell.Cell
+	var cells, bits, refs int64
+	if isSlice {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		bits = int64(s.BitsLeft())
+		roots = sliceRefs(s)
+		refs = int64(len(roots))
+	} else {
+		c, err := st.stack.popMaybeCell()
+		if err != nil {
+			return err
+		}
+		if c != nil {
+			roots = []*cell.Cell{c}
+		}
+	}
+
+	limit := int64(-1)
+	if bound.IsInt64() {
+		limit = bound.Int64()
+	}
+
+	visited := map[string]bool{}
+	var visit func(c *cell.Cell) (bool, error)
+	visit = func(c *cell.Cell) (bool, error) {
+		key := string(c.Hash())
+		if visited[key] {
+			return true, nil
+		}
+		visited[key] = true
+
+		cells++
+		if limit >= 0 && cells > limit {
+			return false, nil
+		}
+		if err := st.registerCellLoad(c); err != nil {
+			return false, err
+		}
+
+		bits += int64(c.BitsSize())
+		refs += int64(c.RefsNum())
+		for i := 0; i < int(c.RefsNum()); i++ {
+			ref, err := c.PeekRef(i)
+			if err != nil {
+				return false, errCellUnderflow()
+			}
+			ok, err := visit(ref)
+			if err != nil || !ok {
+				return ok, err
+			}
+		}
+		return true, nil
+	}
+
+	for _, root := range roots {
+		ok, err := visit(root)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if !quiet {
+				return errCellOverflow()
+			}
+			st.stack.pushBool(false)
+			return nil
+		}
+	}
+
+	st.stack.pushInt(cells)
+	st.stack.pushInt(bits)
+	st.stack.pushInt(refs)
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
@@ -0,0 +1,670 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	dictKeySlice = iota
+	dictKeySigned
+	dictKeyUnsigned
+)
+
+const (
+	dictValueSlice = iota
+	dictValueRef
+	dictValueBuilder
+)
+
+func init() {
+	reg(0xF400, 16, "STDICT", func(st *state) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		d, err := st.stack.popMaybeCell()
+		if err != nil {
+			return err
+		}
+
+		need := 0
+		if d != nil {
+			need = 1
+		}
+		if b.BitsLeft() < 1 || int(b.RefsLeft()) < need {
+			return errCellOverflow()
+		}
+		b = b.Copy()
+		if err = b.StoreMaybeRef(d); err != nil {
+			return errCellOverflow()
+		}
+		st.stack.push(b)
+		return nil
+	})
+	reg(0xF401, 16, "SKIPDICT", func(st *state) error {
+		return execLoadDict(st, false, true, false)
+	})
+	reg(0xF402, 16, "LDDICTS", func(st *state) error {
+		return execLoadDictSlice(st, false)
+	})
+	reg(0xF403, 16, "PLDDICTS", func(st *state) error {
+		return execLoadDictSlice(st, true)
+	})
+	for i := 0; i < 4; i++ {
+		preload, quiet := i&1 != 0, i&2 != 0
+		reg(0xF404+uint64(i), 16, "LDDICT", func(st *state) error {
+			return execLoadDict(st, preload, false, quiet)
+		})
+	}
+
+	for i := 0; i < 6; i++ {
+		kind, ref := i>>1, i&1 != 0
+		valKind := dictValueSlice
+		if ref {
+			valKind = dictValueRef
+		}
+
+		reg(0xF40A+uint64(i), 16, "DICTGET", func(st *state) error {
+			return execDictGet(st, kind, ref)
+		})
+		reg(0xF412+uint64(i), 16, "DICTSET", func(st *state) error {
+			return execDictSet(st, kind, valKind, dictModeSet, false)
+		})
+		reg(0xF41A+uint64(i), 16, "DICTSETGET", func(st *state) error {
+			return execDictSet(st, kind, valKind, dictModeSet, true)
+		})
+		reg(0xF422+uint64(i), 16, "DICTREPLACE", func(st *state) error {
+			return execDictSet(st, kind, valKind, dictModeReplace, false)
+		})
+		reg(0xF42A+uint64(i), 16, "DICTREPLACEGET", func(st *state) error {
+			return execDictSet(st, kind, valKind, dictModeReplace, true)
+		})
+		reg(0xF432+uint64(i), 16, "DICTADD", func(st *state) error {
+			return execDictSet(st, kind, valKind, dictModeAdd, false)
+		})
+		reg(0xF43A+uint64(i), 16, "DICTADDGET", func(st *state) error {
+			return execDictSet(st, kind, valKind, dictModeAdd, true)
+		})
+		reg(0xF462+uint64(i), 16, "DICTDELGET", func(st *state) error {
+			return execDictDelete(st, kind, true, ref)
+		})
+	}
+
+	for i := 0; i < 3; i++ {
+		kind := i
+		reg(0xF441+uint64(i), 16, "DICTSETB", func(st *state) error {
+			return execDictSet(st, kind, dictValueBuilder, dictModeSet, false)
+		})
+		reg(0xF445+uint64(i), 16, "DICTSETGETB", func(st *state) error {
+			return execDictSet(st, kind, dictValueBuilder, dictModeSet, true)
+		})
+		reg(0xF449+uint64(i), 16, "DICTREPLACEB", func(st *state) error {
+			return execDictSet(st, kind, dictValueBuilder, dictModeReplace, false)
+		})
+		reg(0xF44D+uint64(i), 16, "DICTREPLACEGETB", func(st *state) error {
+			return execDictSet(st, kind, dictValueBuilder, dictModeReplace, true)
+		})
+		reg(0xF451+uint64(i), 16, "DICTADDB", func(st *state) error {
+			return execDictSet(st, kind, dictValueBuilder, dictModeAdd, false)
+		})
+		reg(0xF455+uint64(i), 16, "DICTADDGETB", func(st *state) error {
+			return execDictSet(st, kind, dictValueBuilder, dictModeAdd, true)
+		})
+		reg(0xF459+uint64(i), 16, "DICTDEL", func(st *state) error {
+			return execDictDelete(st, kind, false, false)
+		})
+		reg(0xF469+uint64(i), 16, "DICTGETOPTREF", func(st *state) error {
+			return execDictGetOptRef(st, kind)
+		})
+		reg(0xF46D+uint64(i), 16, "DICTSETGETOPTREF", func(st *state) error {
+			return execDictSetGetOptRef(st, kind)
+		})
+	}
+
+	for i := 4; i < 16; i++ {
+		kind, next, eq := i>>2-1, i&2 == 0, i&1 != 0
+		reg(0xF470+uint64(i), 16, "DICTGETNEXT", func(st *state) error {
+			return execDictNearest(st, kind, next, eq)
+		})
+	}
+
+	for i := 0; i < 32; i++ {
+		kind := (i>>1)&3 - 1
+		if kind < 0 {
+			continue
+		}
+		ref, max, remove := i&1 != 0, i&8 != 0, i&16 != 0
+		reg(0xF480+uint64(i), 16, "DICTMIN", func(st *state) error {
+			return execDictMinMax(st, kind, ref, max, remove)
+		})
+	}
+
+	for i := 0; i < 4; i++ {
+		kind := dictKeySigned + i&1
+		exec := i&2 != 0
+		reg(0xF4A0+uint64(i), 16, "DICTIGETJMP", func(st *state) error {
+			return execDictGetJmp(st, kind, exec, false)
+		})
+		reg(0xF4BC+uint64(i), 16, "DICTIGETJMPZ", func(st *state) error {
+			return execDictGetJmp(st, kind, exec, true)
+		})
+	}
+
+	reg(0x3D29, 14, "DICTPUSHCONST", func(st *state) error {
+		n, err := st.argUInt(10)
+		if err != nil {
+			return err
+		}
+		ref, err := st.argRef()
+		if err != nil {
+			return err
+		}
+		st.stack.push(ref)
+		st.stack.pushInt(int64(n))
+		return nil
+	})
+}
+
+func execLoadDict(st *state, preload, skip, quiet bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	bits, refs, ok := dictPrefixSize(s)
+	if !ok {
+		if !quiet {
+			return errCellUnderflow()
+		}
+		if !preload {
+			st.stack.push(s)
+		}
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	rest := s.Copy()
+	if _, err = rest.LoadSlice(bits); err != nil {
+		return errCellUnderflow()
+	}
+	var d *cell.Cell
+	if refs > 0 {
+		if d, err = rest.LoadRefCell(); err != nil {
+			return errCellUnderflow()
+		}
+	}
+
+	if !skip {
+		if d == nil {
+			st.stack.push(nil)
+		} else {
+			st.stack.push(d)
+		}
+	}
+	if !preload {
+		st.stack.push(rest)
+	}
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func execLoadDictSlice(st *state, preload bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	bits, refs, ok := dictPrefixSize(s)
+	if !ok {
+		return errCellUnderflow()
+	}
+
+	part, err := subSlice(s, 0, bits, 0, refs)
+	if err != nil {
+		return err
+	}
+	st.stack.push(part)
+
+	if !preload {
+		rest, err := subSlice(s, bits, s.BitsLeft()-bits, refs, s.RefsNum()-refs)
+		if err != nil {
+			return err
+		}
+		st.stack.push(rest)
+	}
+	return nil
+}
+
+// dictPrefixSize - returns size of HashmapE at the beginning of slice
+func dictPrefixSize(s *cell.Slice) (uint, int, bool) {
+	if s.BitsLeft() < 1 {
+		return 0, 0, false
+	}
+	if !getBit(sliceBits(s), 0) {
+		return 1, 0, true
+	}
+	if s.RefsNum() < 1 {
+		return 0, 0, false
+	}
+	return 1, 1, true
+}
+
+// popDictArgs - pops n and dictionary root
+func popDictArgs(st *state, maxN int64) (uint, *cell.Cell, error) {
+	n, err := st.stack.popIntRange(0, maxN)
+	if err != nil {
+		return 0, nil, err
+	}
+	d, err := st.stack.popMaybeCell()
+	if err != nil {
+		return 0, nil, err
+	}
+	return uint(n), d, nil
+}
+
+func dictMaxKeyLen(kind int) int64 {
+	switch kind {
+	case dictKeySigned:
+		return 257
+	case dictKeyUnsigned:
+		return 256
+	}
+	return 1023
+}
+
+// popDictKey - pops key and converts it to n bits, ok is false when int key does not fit
+func popDictKey(st *state, kind int, n uint) ([]byte, bool, error) {
+	if kind == dictKeySlice {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return nil, false, err
+		}
+		if s.BitsLeft() < n {
+			return nil, false, errCellUnderflow()
+		}
+		data, err := s.PreloadSlice(n)
+		if err != nil {
+			return nil, false, errCellUnderflow()
+		}
+		return data, true, nil
+	}
+
+	x, err := st.stack.popInt()
+	if err != nil {
+		return nil, false, err
+	}
+	if !fitsBits(x, n, kind == dictKeySigned) {
+		return nil, false, nil
+	}
+	return intToBits(x, n), true, nil
+}
+
+func pushDictKey(st *state, kind int, key []byte, n uint) error {
+	if kind == dictKeySlice {
+		s, err := buildSlice(key, n, nil)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	}
+	st.stack.push(bitsToInt(key, n, kind == dictKeySigned))
+	return nil
+}
+
+func pushDictValue(st *state, val *cell.Slice, ref bool) error {
+	if !ref {
+		st.stack.push(val)
+		return nil
+	}
+	if val.BitsLeft() != 0 || val.RefsNum() != 1 {
+		return errDict("dictionary value is not a reference")
+	}
+	c, err := val.PreloadRefCell()
+	if err != nil {
+		return errDict("dictionary value is not a reference")
+	}
+	st.stack.push(c)
+	return nil
+}
+
+func pushDictRoot(st *state, root *cell.Cell) {
+	if root == nil {
+		st.stack.push(nil)
+		return
+	}
+	st.stack.push(root)
+}
+
+func execDictGet(st *state, kind int, ref bool) error {
+	n, root, err := popDictArgs(st, dictMaxKeyLen(kind))
+	if err != nil {
+		return err
+	}
+	key, ok, err := popDictKey(st, kind, n)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	val, err := st.dictLookup(root, key, n)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		st.stack.pushBool(false)
+		return nil
+	}
+	if err = pushDictValue(st, val, ref); err != nil {
+		return err
+	}
+	st.stack.pushBool(true)
+	return nil
+}
+
+func popDictValue(st *state, valKind int) (*cell.Builder, error) {
+	switch valKind {
+	case dictValueRef:
+		c, err := st.stack.popCell()
+		if err != nil {
+			return nil, err
+		}
+		return cell.BeginCell().MustStoreRef(c), nil
+	case dictValueBuilder:
+		return st.stack.popBuilder()
+	}
+
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return nil, err
+	}
+	b, ok := storeSliceTo(cell.BeginCell(), s)
+	if !ok {
+		return nil, errCellOverflow()
+	}
+	return b, nil
+}
+
+func execDictSet(st *state, kind, valKind int, mode dictSetMode, get bool) error {
+	n, root, err := popDictArgs(st, dictMaxKeyLen(kind))
+	if err != nil {
+		return err
+	}
+	key, ok, err := popDictKey(st, kind, n)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errRangeCheck("not enough bits for a dictionary key")
+	}
+	val, err := popDictValue(st, valKind)
+	if err != nil {
+		return err
+	}
+
+	newRoot, old, changed, err := st.dictSet(root, key, n, val, mode)
+	if err != nil {
+		return err
+	}
+
+	pushDictRoot(st, newRoot)
+
+	switch mode {
+	case dictModeSet:
+		if !get {
+			return nil
+		}
+		if old == nil {
+			st.stack.pushBool(false)
+			return nil
+		}
+		if err = pushDictValue(st, old, valKind == dictValueRef); err != nil {
+			return err
+		}
+		st.stack.pushBool(true)
+	case dictModeReplace:
+		if get && changed {
+			if err = pushDictValue(st, old, valKind == dictValueRef); err != nil {
+				return err
+			}
+		}
+		st.stack.pushBool(changed)
+	case dictModeAdd:
+		if get && !changed {
+			if err = pushDictValue(st, old, valKind == dictValueRef); err != nil {
+				return err
+			}
+		}
+		st.stack.pushBool(changed)
+	}
+	return nil
+}
+
+func execDictDelete(st *state, kind int, get, ref bool) error {
+	n, root, err := popDictArgs(st, dictMaxKeyLen(kind))
+	if err != nil {
+		return err
+	}
+	key, ok, err := popDictKey(st, kind, n)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		pushDictRoot(st, root)
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	newRoot, old, err := st.dictDelete(root, key, n)
+	if err != nil {
+		return err
+	}
+	pushDictRoot(st, newRoot)
+	if old == nil {
+		st.stack.pushBool(false)
+		return nil
+	}
+	if get {
+		if err = pushDictValue(st, old, ref); err != nil {
+			return err
+		}
+	}
+	st.stack.pushBool(true)
+	return nil
+}
+
+func execDictGetOptRef(st *state, kind int) error {
+	n, root, err := popDictArgs(st, dictMaxKeyLen(kind))
+	if err != nil {
+		return err
+	}
+	key, ok, err := popDictKey(st, kind, n)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		st.stack.push(nil)
+		return nil
+	}
+
+	val, err := st.dictLookup(root, key, n)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		st.stack.push(nil)
+		return nil
+	}
+	return pushDictValue(st, val, true)
+}
+
+func execDictSetGetOptRef(st *state, kind int) error {
+	n, root, err := popDictArgs(st, dictMaxKeyLen(kind))
+	if err != nil {
+		return err
+	}
+	key, ok, err := popDictKey(st, kind, n)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errRangeCheck("not enough bits for a dictionary key")
+	}
+	val, err := st.stack.popMaybeCell()
+	if err != nil {
+		return err
+	}
+
+	var newRoot *cell.Cell
+	var old *cell.Slice
+	if val == nil {
+		newRoot, old, err = st.dictDelete(root, key, n)
+	} else {
+		newRoot, old, _, err = st.dictSet(root, key, n, cell.BeginCell().MustStoreRef(val), dictModeSet)
+	}
+	if err != nil {
+		return err
+	}
+
+	pushDictRoot(st, newRoot)
+	if old == nil {
+		st.stack.push(nil)
+		return nil
+	}
+	return pushDictValue(st, old, true)
+}
+
+func execDictNearest(st *state, kind int, next, eq bool) error {
+	n, root, err := popDictArgs(st, dictMaxKeyLen(kind))
+	if err != nil {
+		return err
+	}
+
+	var key []byte
+	if kind == dictKeySlice {
+		if key, _, err = popDictKey(st, kind, n); err != nil {
+			return err
+		}
+	} else {
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+
+		signed := kind == dictKeySigned
+		if !fitsBits(x, n, signed) {
+			// key is out of the range, so all keys are either bigger or smaller
+			above := x.Sign() > 0
+			if above == next {
+				st.stack.pushBool(false)
+				return nil
+			}
+			return pushDictExtreme(st, root, kind, n, !next)
+		}
+		key = intToBits(x, n)
+	}
+
+	resKey, val, err := st.dictNearest(root, key, n, next, eq, kind == dictKeySigned)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		st.stack.pushBool(false)
+		return nil
+	}
+	st.stack.push(val)
+	if err = pushDictKey(st, kind, resKey, n); err != nil {
+		return err
+	}
+	st.stack.pushBool(true)
+	return nil
+}
+
+func pushDictExtreme(st *state, root *cell.Cell, kind int, n uint, max bool) error {
+	key, val, err := st.dictMinMax(root, n, max, kind == dictKeySigned)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		st.stack.pushBool(false)
+		return nil
+	}
+	st.stack.push(val)
+	if err = pushDictKey(st, kind, key, n); err != nil {
+		return err
+	}
+	st.stack.pushBool(true)
+	return nil
+}
+
+func execDictMinMax(st *state, kind int, ref, max, remove bool) error {
+	n, root, err := popDictArgs(st, dictMaxKeyLen(kind))
+	if err != nil {
+		return err
+	}
+
+	key, val, err := st.dictMinMax(root, n, max, kind == dictKeySigned)
+	if err != nil {
+		return err
+	}
+
+	if remove {
+		if val != nil {
+			if root, _, err = st.dictDelete(root, key, n); err != nil {
+				return err
+			}
+		}
+		pushDictRoot(st, root)
+	}
+
+	if val == nil {
+		st.stack.pushBool(false)
+		return nil
+	}
+	if err = pushDictValue(st, val, ref); err != nil {
+		return err
+	}
+	if err = pushDictKey(st, kind, key, n); err != nil {
+		return err
+	}
+	st.stack.pushBool(true)
+	return nil
+}
+
+func execDictGetJmp(st *state, kind int, exec, pushZ bool) error {
+	n, root, err := popDictArgs(st, dictMaxKeyLen(kind))
+	if err != nil {
+		return err
+	}
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+
+	var val *cell.Slice
+	if fitsBits(x, n, kind == dictKeySigned) {
+		if val, err = st.dictLookup(root, intToBits(x, n), n); err != nil {
+			return err
+		}
+	}
+
+	if val == nil {
+		if pushZ {
+			st.stack.push(new(big.Int).Set(x))
+		}
+		return nil
+	}
+
+	cont := newOrdCont(val, st.cp)
+	if exec {
+		return st.call(cont, -1, -1)
+	}
+	return st.jump(cont, -1)
+}
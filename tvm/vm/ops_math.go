@@ -0,0 +1,468 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+var nan = tlb.StackNaN{}
+
+var (
+	minInt257 = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 256))
+	maxInt257 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+)
+
+func fitsInt257(x *big.Int) bool {
+	return x.Cmp(minInt257) >= 0 && x.Cmp(maxInt257) <= 0
+}
+
+// regArith - registers instruction and its quiet version with B7 prefix
+func regArith(prefix uint64, bits uint, name string, fn func(st *state, quiet bool) error) {
+	reg(prefix, bits, name, func(st *state) error {
+		return fn(st, false)
+	})
+	reg(0xB7<<bits|prefix, bits+8, "Q"+name, func(st *state) error {
+		return fn(st, true)
+	})
+}
+
+func regArithRange(prefix uint64, bits, argBits uint, name string, fn func(st *state, arg int, quiet bool) error) {
+	for i := 0; i < 1<<argBits; i++ {
+		arg := i
+		regArith(prefix<<argBits|uint64(i), bits+argBits, name, func(st *state, quiet bool) error {
+			return fn(st, arg, quiet)
+		})
+	}
+}
+
+func popInts(st *state, quiet bool, n int) ([]*big.Int, bool, error) {
+	if err := st.stack.checkUnderflow(n); err != nil {
+		return nil, false, err
+	}
+
+	res := make([]*big.Int, n)
+	hasNaN := false
+	for i := n - 1; i >= 0; i-- {
+		var err error
+		if quiet {
+			res[i], err = st.stack.popIntQuiet()
+		} else {
+			res[i], err = st.stack.popInt()
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if res[i] == nil {
+			hasNaN = true
+		}
+	}
+	return res, hasNaN, nil
+}
+
+// unaryOp - wraps operation over top integer
+func unaryOp(fn func(x *big.Int) *big.Int) func(st *state, quiet bool) error {
+	return func(st *state, quiet bool) error {
+		args, isNaN, err := popInts(st, quiet, 1)
+		if err != nil {
+			return err
+		}
+		if isNaN {
+			st.stack.push(nan)
+			return nil
+		}
+		return st.pushIntChecked(fn(args[0]), quiet)
+	}
+}
+
+// binaryOp - wraps operation over 2 top integers, y is a top
+func binaryOp(fn func(x, y *big.Int) *big.Int) func(st *state, quiet bool) error {
+	return func(st *state, quiet bool) error {
+		args, isNaN, err := popInts(st, quiet, 2)
+		if err != nil {
+			return err
+		}
+		if isNaN {
+			st.stack.push(nan)
+			return nil
+		}
+		return st.pushIntChecked(fn(args[0], args[1]), quiet)
+	}
+}
+
+func init() {
+	regArith(0xA0, 8, "ADD", binaryOp(func(x, y *big.Int) *big.Int {
+		return new(big.Int).Add(x, y)
+	}))
+	regArith(0xA1, 8, "SUB", binaryOp(func(x, y *big.Int) *big.Int {
+		return new(big.Int).Sub(x, y)
+	}))
+	regArith(0xA2, 8, "SUBR", binaryOp(func(x, y *big.Int) *big.Int {
+		return new(big.Int).Sub(y, x)
+	}))
+	regArith(0xA3, 8, "NEGATE", unaryOp(func(x *big.Int) *big.Int {
+		return new(big.Int).Neg(x)
+	}))
+	regArith(0xA4, 8, "INC", unaryOp(func(x *big.Int) *big.Int {
+		return new(big.Int).Add(x, big.NewInt(1))
+	}))
+	regArith(0xA5, 8, "DEC", unaryOp(func(x *big.Int) *big.Int {
+		return new(big.Int).Sub(x, big.NewInt(1))
+	}))
+	regArithRange(0xA6, 8, 8, "ADDCONST", func(st *state, c int, quiet bool) error {
+		return unaryOp(func(x *big.Int) *big.Int {
+			return new(big.Int).Add(x, big.NewInt(int64(int8(c))))
+		})(st, quiet)
+	})
+	regArithRange(0xA7, 8, 8, "MULCONST", func(st *state, c int, quiet bool) error {
+		return unaryOp(func(x *big.Int) *big.Int {
+			return new(big.Int).Mul(x, big.NewInt(int64(int8(c))))
+		})(st, quiet)
+	})
+	regArith(0xA8, 8, "MUL", binaryOp(func(x, y *big.Int) *big.Int {
+		return new(big.Int).Mul(x, y)
+	}))
+	regArith(0xA9, 8, "DIV", execDivFamily)
+
+	regArithRange(0xAA, 8, 8, "LSHIFT", func(st *state, c int, quiet bool) error {
+		return unaryOp(func(x *big.Int) *big.Int {
+			return new(big.Int).Lsh(x, uint(c+1))
+		})(st, quiet)
+	})
+	regArithRange(0xAB, 8, 8, "RSHIFT", func(st *state, c int, quiet bool) error {
+		return unaryOp(func(x *big.Int) *big.Int {
+			return new(big.Int).Rsh(x, uint(c+1))
+		})(st, quiet)
+	})
+	regArith(0xAC, 8, "LSHIFT", func(st *state, quiet bool) error {
+		y, err := st.stack.popIntRange(0, 1023)
+		if err != nil {
+			return err
+		}
+		return unaryOp(func(x *big.Int) *big.Int {
+			return new(big.Int).Lsh(x, uint(y))
+		})(st, quiet)
+	})
+	regArith(0xAD, 8, "RSHIFT", func(st *state, quiet bool) error {
+		y, err := st.stack.popIntRange(0, 1023)
+		if err != nil {
+			return err
+		}
+		return unaryOp(func(x *big.Int) *big.Int {
+			return new(big.Int).Rsh(x, uint(y))
+		})(st, quiet)
+	})
+	regArith(0xAE, 8, "POW2", func(st *state, quiet bool) error {
+		y, err := st.stack.popIntRange(0, 1023)
+		if err != nil {
+			return err
+		}
+		return st.pushIntChecked(new(big.Int).Lsh(big.NewInt(1), uint(y)), quiet)
+	})
+
+	regArith(0xB0, 8, "AND", binaryOp(func(x, y *big.Int) *big.Int {
+		return new(big.Int).And(x, y)
+	}))
+	regArith(0xB1, 8, "OR", binaryOp(func(x, y *big.Int) *big.Int {
+		return new(big.Int).Or(x, y)
+	}))
+	regArith(0xB2, 8, "XOR", binaryOp(func(x, y *big.Int) *big.Int {
+		return new(big.Int).Xor(x, y)
+	}))
+	regArith(0xB3, 8, "NOT", unaryOp(func(x *big.Int) *big.Int {
+		return new(big.Int).Not(x)
+	}))
+	regArithRange(0xB4, 8, 8, "FITS", func(st *state, c int, quiet bool) error {
+		return execFits(st, uint(c+1), true, quiet)
+	})
+	regArithRange(0xB5, 8, 8, "UFITS", func(st *state, c int, quiet bool) error {
+		return execFits(st, uint(c+1), false, quiet)
+	})
+	regArith(0xB600, 16, "FITSX", func(st *state, quiet bool) error {
+		c, err := st.stack.popIntRange(0, 1023)
+		if err != nil {
+			return err
+		}
+		return execFits(st, uint(c), true, quiet)
+	})
+	regArith(0xB601, 16, "UFITSX", func(st *state, quiet bool) error {
+		c, err := st.stack.popIntRange(0, 1023)
+		if err != nil {
+			return err
+		}
+		return execFits(st, uint(c), false, quiet)
+	})
+	regArith(0xB602, 16, "BITSIZE", func(st *state, quiet bool) error {
+		return unaryOp(func(x *big.Int) *big.Int {
+			return big.NewInt(int64(bitSize(x, true)))
+		})(st, quiet)
+	})
+	regArith(0xB603, 16, "UBITSIZE", func(st *state, quiet bool) error {
+		args, isNaN, err := popInts(st, quiet, 1)
+		if err != nil {
+			return err
+		}
+		if isNaN || args[0].Sign() < 0 {
+			if !quiet {
+				return errRangeCheck("negative integer")
+			}
+			st.stack.push(nan)
+			return nil
+		}
+		st.stack.pushInt(int64(bitSize(args[0], false)))
+		return nil
+	})
+	regArith(0xB608, 16, "MIN", binaryOp(func(x, y *big.Int) *big.Int {
+		if x.Cmp(y) <= 0 {
+			return x
+		}
+		return y
+	}))
+	regArith(0xB609, 16, "MAX", binaryOp(func(x, y *big.Int) *big.Int {
+		if x.Cmp(y) >= 0 {
+			return x
+		}
+		return y
+	}))
+	regArith(0xB60A, 16, "MINMAX", func(st *state, quiet bool) error {
+		args, isNaN, err := popInts(st, quiet, 2)
+		if err != nil {
+			return err
+		}
+		if isNaN {
+			st.stack.push(nan)
+			st.stack.push(nan)
+			return nil
+		}
+		if args[0].Cmp(args[1]) > 0 {
+			args[0], args[1] = args[1], args[0]
+		}
+		st.stack.push(args[0])
+		st.stack.push(args[1])
+		return nil
+	})
+	regArith(0xB60B, 16, "ABS", unaryOp(func(x *big.Int) *big.Int {
+		return new(big.Int).Abs(x)
+	}))
+
+	regArith(0xB8, 8, "SGN", unaryOp(func(x *big.Int) *big.Int {
+		return big.NewInt(int64(x.Sign()))
+	}))
+	regArith(0xB9, 8, "LESS", cmpOp(func(c int) bool { return c < 0 }))
+	regArith(0xBA, 8, "EQUAL", cmpOp(func(c int) bool { return c == 0 }))
+	regArith(0xBB, 8, "LEQ", cmpOp(func(c int) bool { return c <= 0 }))
+	regArith(0xBC, 8, "GREATER", cmpOp(func(c int) bool { return c > 0 }))
+	regArith(0xBD, 8, "NEQ", cmpOp(func(c int) bool { return c != 0 }))
+	regArith(0xBE, 8, "GEQ", cmpOp(func(c int) bool { return c >= 0 }))
+	regArith(0xBF, 8, "CMP", binaryOp(func(x, y *big.Int) *big.Int {
+		return big.NewInt(int64(x.Cmp(y)))
+	}))
+	regArithRange(0xC0, 8, 8, "EQINT", cmpIntOp(func(c int) bool { return c == 0 }))
+	regArithRange(0xC1, 8, 8, "LESSINT", cmpIntOp(func(c int) bool { return c < 0 }))
+	regArithRange(0xC2, 8, 8, "GTINT", cmpIntOp(func(c int) bool { return c > 0 }))
+	regArithRange(0xC3, 8, 8, "NEQINT", cmpIntOp(func(c int) bool { return c != 0 }))
+	reg(0xC4, 8, "ISNAN", func(st *state) error {
+		v, err := st.stack.popIntQuiet()
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(v == nil)
+		return nil
+	})
+	reg(0xC5, 8, "CHKNAN", func(st *state) error {
+		v, err := st.stack.popIntQuiet()
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return errIntOverflow()
+		}
+		st.stack.push(v)
+		return nil
+	})
+}
+
+func cmpOp(check func(c int) bool) func(st *state, quiet bool) error {
+	return binaryOp(func(x, y *big.Int) *big.Int {
+		if check(x.Cmp(y)) {
+			return big.NewInt(-1)
+		}
+		return big.NewInt(0)
+	})
+}
+
+func cmpIntOp(check func(c int) bool) func(st *state, arg int, quiet bool) error {
+	return func(st *state, arg int, quiet bool) error {
+		y := big.NewInt(int64(int8(arg)))
+		return unaryOp(func(x *big.Int) *big.Int {
+			if check(x.Cmp(y)) {
+				return big.NewInt(-1)
+			}
+			return big.NewInt(0)
+		})(st, quiet)
+	}
+}
+
+func execFits(st *state, bits uint, signed, quiet bool) error {
+	args, isNaN, err := popInts(st, quiet, 1)
+	if err != nil {
+		return err
+	}
+	if isNaN || !fitsBits(args[0], bits, signed) {
+		if !quiet {
+			return errIntOverflow()
+		}
+		st.stack.push(nan)
+		return nil
+	}
+	st.stack.push(args[0])
+	return nil
+}
+
+// bitSize - minimal number of bits to store integer
+func bitSize(x *big.Int, signed bool) int {
+	if !signed {
+		return x.BitLen()
+	}
+	if x.Sign() >= 0 {
+		if x.Sign() == 0 {
+			return 0
+		}
+		return x.BitLen() + 1
+	}
+	return new(big.Int).Not(x).BitLen() + 1
+}
+
+const (
+	roundFloor   = 0
+	roundNearest = 1
+	roundCeil    = 2
+)
+
+// divRound - divides x by y with rounding mode, y should not be zero
+func divRound(x, y *big.Int, mode int) (*big.Int, *big.Int) {
+	var q, r *big.Int
+	switch mode {
+	case roundNearest:
+		// floor((2x + y) / 2y)
+		num := new(big.Int).Lsh(x, 1)
+		num.Add(num, y)
+		den := new(big.Int).Lsh(y, 1)
+		q, _ = divRound(num, den, roundFloor)
+	default:
+		q, r = new(big.Int).QuoRem(x, y, new(big.Int))
+		if r.Sign() != 0 {
+			if mode == roundFloor && r.Sign() != y.Sign() {
+				q.Sub(q, big.NewInt(1))
+			} else if mode == roundCeil && r.Sign() == y.Sign() {
+				q.Add(q, big.NewInt(1))
+			}
+		}
+	}
+	r = new(big.Int).Sub(x, new(big.Int).Mul(q, y))
+	return q, r
+}
+
+// execDivFamily - A9mscdf [tt], combined multiplication, shift and division instructions
+func execDivFamily(st *state, quiet bool) error {
+	args, err := st.argUInt(8)
+	if err != nil {
+		return err
+	}
+
+	mul := args>>7&1 == 1
+	shiftMode := int(args >> 5 & 3)
+	constShift := args>>4&1 == 1
+	resMode := int(args >> 2 & 3)
+	round := int(args & 3)
+
+	if round == 3 || shiftMode == 3 || (!mul && shiftMode == 2) || (constShift && shiftMode == 0) {
+		return throwErr(ExitCodeInvalidOpcode, "invalid division instruction")
+	}
+
+	shift := -1
+	if constShift {
+		tt, err := st.argUInt(8)
+		if err != nil {
+			return err
+		}
+		shift = int(tt) + 1
+	} else if shiftMode != 0 {
+		z, err := st.stack.popIntRange(0, 256)
+		if err != nil {
+			return err
+		}
+		shift = int(z)
+	}
+
+	// number of integer operands left on stack
+	n := 2
+	if shiftMode != 0 {
+		n = 1
+		if mul {
+			n = 2
+		}
+	} else if mul {
+		n = 3
+	}
+	if resMode == 0 {
+		// additional addend
+		n++
+	}
+
+	ints, isNaN, err := popInts(st, quiet, n)
+	if err != nil {
+		return err
+	}
+
+	pushRes := func(q, r *big.Int) error {
+		if resMode == 1 || resMode == 0 || resMode == 3 {
+			if err := st.pushIntChecked(q, quiet); err != nil {
+				return err
+			}
+		}
+		if resMode == 2 || resMode == 0 || resMode == 3 {
+			if err := st.pushIntChecked(r, quiet); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if isNaN {
+		return pushRes(nil, nil)
+	}
+
+	var x, y *big.Int
+	switch {
+	case shiftMode == 0 && !mul:
+		x, y = ints[0], ints[len(ints)-1]
+	case shiftMode == 0 && mul:
+		x, y = new(big.Int).Mul(ints[0], ints[1]), ints[len(ints)-1]
+	case shiftMode == 1 && !mul:
+		x, y = ints[0], new(big.Int).Lsh(big.NewInt(1), uint(shift))
+	case shiftMode == 1 && mul:
+		x, y = new(big.Int).Mul(ints[0], ints[1]), new(big.Int).Lsh(big.NewInt(1), uint(shift))
+	case shiftMode == 2:
+		x, y = new(big.Int).Lsh(ints[0], uint(shift)), ints[len(ints)-1]
+	}
+
+	if resMode == 0 {
+		// addend is right before divisor (or last when divisor is a shift)
+		w := ints[len(ints)-1]
+		if shiftMode != 1 {
+			w = ints[len(ints)-2]
+		}
+		x = new(big.Int).Add(x, w)
+	}
+
+	if y.Sign() == 0 {
+		if !quiet {
+			return errIntOverflow()
+		}
+		return pushRes(nil, nil)
+	}
+
+	q, r := divRound(x, y, round)
+	return pushRes(q, r)
+}
@@ -0,0 +1,338 @@
+package vm
+
+func init() {
+	reg(0x00, 8, "NOP", func(st *state) error { return nil })
+	regRangeFrom(0x0, 4, 4, 1, "XCHG", func(st *state, i int) error {
+		return st.stack.exchange(0, i)
+	})
+	reg(0x10, 8, "XCHG", func(st *state) error {
+		args, err := st.argUInt(8)
+		if err != nil {
+			return err
+		}
+		i, j := int(args>>4), int(args&15)
+		if i == 0 || i >= j {
+			return throwErr(ExitCodeInvalidOpcode, "invalid XCHG arguments")
+		}
+		return st.stack.exchange(i, j)
+	})
+	reg(0x11, 8, "XCHG", func(st *state) error {
+		i, err := st.argUInt(8)
+		if err != nil {
+			return err
+		}
+		return st.stack.exchange(0, int(i))
+	})
+	regRangeFrom(0x1, 4, 4, 2, "XCHG", func(st *state, i int) error {
+		return st.stack.exchange(1, i)
+	})
+	regRange(0x2, 4, 4, "PUSH", func(st *state, i int) error {
+		return stackPush(st, i)
+	})
+	regRange(0x3, 4, 4, "POP", func(st *state, i int) error {
+		return stackPop(st, i)
+	})
+	regRange(0x4, 4, 4, "XCHG3", func(st *state, i int) error {
+		jk, err := st.argUInt(8)
+		if err != nil {
+			return err
+		}
+		return xchg3(st, i, int(jk>>4), int(jk&15))
+	})
+	regRange(0x50, 8, 8, "XCHG2", func(st *state, args int) error {
+		return swapSeq(st, [2]int{1, args >> 4}, [2]int{0, args & 15})
+	})
+	regRange(0x51, 8, 8, "XCPU", func(st *state, args int) error {
+		if err := st.stack.exchange(0, args>>4); err != nil {
+			return err
+		}
+		return stackPush(st, args&15)
+	})
+	regRange(0x52, 8, 8, "PUXC", func(st *state, args int) error {
+		if err := stackPush(st, args>>4); err != nil {
+			return err
+		}
+		return swapSeq(st, [2]int{0, 1}, [2]int{0, args & 15})
+	})
+	regRange(0x53, 8, 8, "PUSH2", func(st *state, args int) error {
+		if err := stackPush(st, args>>4); err != nil {
+			return err
+		}
+		return stackPush(st, (args&15)+1)
+	})
+	reg(0x54, 8, "XCHG3/XC2PU/XCPUXC/XCPU2/PUXC2/PUXCPU/PU2XC/PUSH3", func(st *state) error {
+		args, err := st.argUInt(16)
+		if err != nil {
+			return err
+		}
+		i, j, k := int(args>>8&15), int(args>>4&15), int(args&15)
+
+		switch args >> 12 {
+		case 0: // XCHG3
+			return xchg3(st, i, j, k)
+		case 1: // XC2PU
+			if err = swapSeq(st, [2]int{1, i}, [2]int{0, j}); err != nil {
+				return err
+			}
+			return stackPush(st, k)
+		case 2: // XCPUXC
+			if err = st.stack.exchange(1, i); err != nil {
+				return err
+			}
+			if err = stackPush(st, j); err != nil {
+				return err
+			}
+			return swapSeq(st, [2]int{0, 1}, [2]int{0, k})
+		case 3: // XCPU2
+			if err = st.stack.exchange(0, i); err != nil {
+				return err
+			}
+			if err = stackPush(st, j); err != nil {
+				return err
+			}
+			return stackPush(st, k+1)
+		case 4: // PUXC2
+			if err = stackPush(st, i); err != nil {
+				return err
+			}
+			return swapSeq(st, [2]int{2, 0}, [2]int{1, j}, [2]int{0, k})
+		case 5: // PUXCPU
+			if err = stackPush(st, i); err != nil {
+				return err
+			}
+			if err = swapSeq(st, [2]int{0, 1}, [2]int{0, j}); err != nil {
+				return err
+			}
+			return stackPush(st, k)
+		case 6: // PU2XC
+			if err = stackPush(st, i); err != nil {
+				return err
+			}
+			if err = st.stack.exchange(1, 0); err != nil {
+				return err
+			}
+			if err = stackPush(st, j); err != nil {
+				return err
+			}
+			return swapSeq(st, [2]int{1, 0}, [2]int{0, k})
+		case 7: // PUSH3
+			if err = stackPush(st, i); err != nil {
+				return err
+			}
+			if err = stackPush(st, j+1); err != nil {
+				return err
+			}
+			return stackPush(st, k+2)
+		}
+		return throwErr(ExitCodeInvalidOpcode, "invalid opcode")
+	})
+	regRange(0x55, 8, 8, "BLKSWAP", func(st *state, args int) error {
+		return blkSwap(st, args>>4+1, args&15+1)
+	})
+	regRange(0x56, 8, 8, "PUSH", func(st *state, i int) error {
+		return stackPush(st, i)
+	})
+	regRange(0x57, 8, 8, "POP", func(st *state, i int) error {
+		return stackPop(st, i)
+	})
+	reg(0x58, 8, "ROT", func(st *state) error {
+		return swapSeq(st, [2]int{1, 2}, [2]int{0, 1})
+	})
+	reg(0x59, 8, "ROTREV", func(st *state) error {
+		return swapSeq(st, [2]int{0, 1}, [2]int{1, 2})
+	})
+	reg(0x5A, 8, "SWAP2", func(st *state) error {
+		return swapSeq(st, [2]int{1, 3}, [2]int{0, 2})
+	})
+	reg(0x5B, 8, "DROP2", func(st *state) error {
+		return st.stack.drop(2)
+	})
+	reg(0x5C, 8, "DUP2", func(st *state) error {
+		if err := stackPush(st, 1); err != nil {
+			return err
+		}
+		return stackPush(st, 1)
+	})
+	reg(0x5D, 8, "OVER2", func(st *state) error {
+		if err := stackPush(st, 3); err != nil {
+			return err
+		}
+		return stackPush(st, 3)
+	})
+	regRange(0x5E, 8, 8, "REVERSE", func(st *state, args int) error {
+		return st.stack.reverse(args>>4+2, args&15)
+	})
+	regRange(0x5F, 8, 8, "BLKDROP/BLKPUSH", func(st *state, args int) error {
+		i, j := args>>4, args&15
+		if i == 0 {
+			return st.stack.drop(j)
+		}
+		if err := st.stack.checkUnderflow(j + 1); err != nil {
+			return err
+		}
+		for x := 0; x < i; x++ {
+			if err := stackPush(st, j); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	reg(0x60, 8, "PICK", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		return stackPush(st, int(i))
+	})
+	reg(0x61, 8, "ROLL", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		return blkSwap(st, 1, int(i))
+	})
+	reg(0x62, 8, "ROLLREV", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		return blkSwap(st, int(i), 1)
+	})
+	reg(0x63, 8, "BLKSWX", func(st *state) error {
+		j, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		i, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		if i == 0 || j == 0 {
+			return st.stack.checkUnderflow(int(i + j))
+		}
+		return blkSwap(st, int(i), int(j))
+	})
+	reg(0x64, 8, "REVX", func(st *state) error {
+		j, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		i, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		return st.stack.reverse(int(i), int(j))
+	})
+	reg(0x65, 8, "DROPX", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		return st.stack.drop(int(i))
+	})
+	reg(0x66, 8, "TUCK", func(st *state) error {
+		if err := st.stack.exchange(0, 1); err != nil {
+			return err
+		}
+		return stackPush(st, 1)
+	})
+	reg(0x67, 8, "XCHGX", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		return st.stack.exchange(0, int(i))
+	})
+	reg(0x68, 8, "DEPTH", func(st *state) error {
+		st.stack.pushInt(int64(st.stack.depth()))
+		return nil
+	})
+	reg(0x69, 8, "CHKDEPTH", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		return st.stack.checkUnderflow(int(i))
+	})
+	reg(0x6A, 8, "ONLYTOPX", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		if err = st.stack.checkUnderflow(int(i)); err != nil {
+			return err
+		}
+		if d := st.stack.depth() - int(i); d > 0 {
+			st.stack.dropBottom(d)
+			return st.consumeStackGas(int(i))
+		}
+		return nil
+	})
+	reg(0x6B, 8, "ONLYX", func(st *state) error {
+		i, err := st.stack.popIntRange(0, 255)
+		if err != nil {
+			return err
+		}
+		if err = st.stack.checkUnderflow(int(i)); err != nil {
+			return err
+		}
+		return st.stack.drop(st.stack.depth() - int(i))
+	})
+	regRangeFrom(0x6C, 8, 8, 0x10, "BLKDROP2", func(st *state, args int) error {
+		i, j := args>>4, args&15
+		if err := st.stack.checkUnderflow(i + j); err != nil {
+			return err
+		}
+		top, err := st.stack.splitTop(j, i)
+		if err != nil {
+			return err
+		}
+		return st.stack.moveFrom(top, j)
+	})
+}
+
+func stackPush(st *state, i int) error {
+	v, err := st.stack.get(i)
+	if err != nil {
+		return err
+	}
+	st.stack.push(v)
+	return nil
+}
+
+func stackPop(st *state, i int) error {
+	if err := st.stack.checkUnderflow(i + 1); err != nil {
+		return err
+	}
+	if err := st.stack.exchange(0, i); err != nil {
+		return err
+	}
+	return st.stack.drop(1)
+}
+
+func swapSeq(st *state, pairs ...[2]int) error {
+	for _, p := range pairs {
+		if err := st.stack.exchange(p[0], p[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func xchg3(st *state, i, j, k int) error {
+	return swapSeq(st, [2]int{2, i}, [2]int{1, j}, [2]int{0, k})
+}
+
+// blkSwap - swaps block of x elements with top block of y elements
+func blkSwap(st *state, x, y int) error {
+	if err := st.stack.checkUnderflow(x + y); err != nil {
+		return err
+	}
+	if err := st.stack.reverse(x+y, 0); err != nil {
+		return err
+	}
+	if err := st.stack.reverse(x, 0); err != nil {
+		return err
+	}
+	return st.stack.reverse(y, x)
+}
@@ -0,0 +1,354 @@
+package vm
+
+const maxTupleLen = 255
+
+func init() {
+	reg(0x6D, 8, "NULL", func(st *state) error {
+		st.stack.push(nil)
+		return nil
+	})
+	reg(0x6E, 8, "ISNULL", func(st *state) error {
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(v == nil)
+		return nil
+	})
+
+	regRange(0x6F0, 12, 4, "TUPLE", func(st *state, n int) error {
+		return makeTuple(st, n)
+	})
+	regRange(0x6F1, 12, 4, "INDEX", func(st *state, k int) error {
+		return tupleIndex(st, k, false)
+	})
+	regRange(0x6F2, 12, 4, "UNTUPLE", func(st *state, n int) error {
+		return untuple(st, n, n, false)
+	})
+	regRange(0x6F3, 12, 4, "UNPACKFIRST", func(st *state, n int) error {
+		return untuple(st, n, maxTupleLen, false)
+	})
+	regRange(0x6F4, 12, 4, "EXPLODE", func(st *state, n int) error {
+		return untuple(st, 0, n, true)
+	})
+	regRange(0x6F5, 12, 4, "SETINDEX", func(st *state, k int) error {
+		return tupleSetIndex(st, k, false)
+	})
+	regRange(0x6F6, 12, 4, "INDEXQ", func(st *state, k int) error {
+		return tupleIndex(st, k, true)
+	})
+	regRange(0x6F7, 12, 4, "SETINDEXQ", func(st *state, k int) error {
+		return tupleSetIndex(st, k, true)
+	})
+
+	reg(0x6F80, 16, "TUPLEVAR", func(st *state) error {
+		n, err := st.stack.popIntRange(0, maxTupleLen)
+		if err != nil {
+			return err
+		}
+		return makeTuple(st, int(n))
+	})
+	reg(0x6F81, 16, "INDEXVAR", func(st *state) error {
+		k, err := st.stack.popIntRange(0, maxTupleLen-1)
+		if err != nil {
+			return err
+		}
+		return tupleIndex(st, int(k), false)
+	})
+	reg(0x6F82, 16, "UNTUPLEVAR", func(st *state) error {
+		n, err := st.stack.popIntRange(0, maxTupleLen)
+		if err != nil {
+			return err
+		}
+		return untuple(st, int(n), int(n), false)
+	})
+	reg(0x6F83, 16, "UNPACKFIRSTVAR", func(st *state) error {
+		n, err := st.stack.popIntRange(0, maxTupleLen)
+		if err != nil {
+			return err
+		}
+		return untuple(st, int(n), maxTupleLen, false)
+	})
+	reg(0x6F84, 16, "EXPLODEVAR", func(st *state) error {
+		n, err := st.stack.popIntRange(0, maxTupleLen)
+		if err != nil {
+			return err
+		}
+		return untuple(st, 0, int(n), true)
+	})
+	reg(0x6F85, 16, "SETINDEXVAR", func(st *state) error {
+		k, err := st.stack.popIntRange(0, maxTupleLen-1)
+		if err != nil {
+			return err
+		}
+		return tupleSetIndex(st, int(k), false)
+	})
+	reg(0x6F86, 16, "INDEXVARQ", func(st *state) error {
+		k, err := st.stack.popIntRange(0, maxTupleLen-1)
+		if err != nil {
+			return err
+		}
+		return tupleIndex(st, int(k), true)
+	})
+	reg(0x6F87, 16, "SETINDEXVARQ", func(st *state) error {
+		k, err := st.stack.popIntRange(0, maxTupleLen-1)
+		if err != nil {
+			return err
+		}
+		return tupleSetIndex(st, int(k), true)
+	})
+	reg(0x6F88, 16, "TLEN", func(st *state) error {
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		st.stack.pushInt(int64(len(t)))
+		return nil
+	})
+	reg(0x6F89, 16, "QTLEN", func(st *state) error {
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		if t, ok := v.([]any); ok {
+			st.stack.pushInt(int64(len(t)))
+		} else {
+			st.stack.pushInt(-1)
+		}
+		return nil
+	})
+	reg(0x6F8A, 16, "ISTUPLE", func(st *state) error {
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		_, ok := v.([]any)
+		st.stack.pushBool(ok)
+		return nil
+	})
+	reg(0x6F8B, 16, "LAST", func(st *state) error {
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		if len(t) == 0 {
+			return errTypeCheck("tuple is empty")
+		}
+		st.stack.push(t[len(t)-1])
+		return nil
+	})
+	reg(0x6F8C, 16, "TPUSH", func(st *state) error {
+		x, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		if len(t) >= maxTupleLen {
+			return errTypeCheck("tuple is too long")
+		}
+		res := append(append(make([]any, 0, len(t)+1), t...), x)
+		st.stack.push(res)
+		return st.consumeTupleGas(len(res))
+	})
+	reg(0x6F8D, 16, "TPOP", func(st *state) error {
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		if len(t) == 0 {
+			return errTypeCheck("tuple is empty")
+		}
+		res := append([]any{}, t[:len(t)-1]...)
+		st.stack.push(res)
+		st.stack.push(t[len(t)-1])
+		return st.consumeTupleGas(len(res))
+	})
+
+	for i := 0; i < 8; i++ {
+		args := i
+		reg(0x6FA0|uint64(args), 16, "NULLSWAPIF", func(st *state) error {
+			return nullSwap(st, args&1 == 1, args&2 == 2, args&4 == 4)
+		})
+	}
+
+	regRange(0x6FB, 12, 4, "INDEX2", func(st *state, args int) error {
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		v, err := indexPath(t, args>>2, args&3)
+		if err != nil {
+			return err
+		}
+		st.stack.push(v)
+		return nil
+	})
+	regRange(0x1BF, 10, 6, "INDEX3", func(st *state, args int) error {
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		v, err := indexPath(t, args>>4, args>>2&3, args&3)
+		if err != nil {
+			return err
+		}
+		st.stack.push(v)
+		return nil
+	})
+}
+
+func makeTuple(st *state, n int) error {
+	if err := st.stack.checkUnderflow(n); err != nil {
+		return err
+	}
+	top, err := st.stack.splitTop(n, 0)
+	if err != nil {
+		return err
+	}
+	st.stack.push(top.elems)
+	return st.consumeTupleGas(n)
+}
+
+func tupleIndex(st *state, k int, quiet bool) error {
+	if quiet {
+		t, ok, err := st.stack.popMaybeTuple()
+		if err != nil {
+			return err
+		}
+		if !ok || k >= len(t) {
+			st.stack.push(nil)
+			return nil
+		}
+		st.stack.push(t[k])
+		return nil
+	}
+
+	t, err := st.stack.popTuple()
+	if err != nil {
+		return err
+	}
+	if k >= len(t) {
+		return errRangeCheck("tuple index is out of range")
+	}
+	st.stack.push(t[k])
+	return nil
+}
+
+func untuple(st *state, min, max int, pushLen bool) error {
+	t, err := st.stack.popTuple()
+	if err != nil {
+		return err
+	}
+	if len(t) < min || len(t) > max {
+		return errTypeCheck("not a tuple of valid size")
+	}
+
+	n := len(t)
+	if !pushLen && min < n {
+		// UNPACKFIRST
+		n = min
+	}
+	for i := 0; i < n; i++ {
+		st.stack.push(t[i])
+	}
+	if pushLen {
+		st.stack.pushInt(int64(n))
+	}
+	return st.consumeTupleGas(n)
+}
+
+func tupleSetIndex(st *state, k int, quiet bool) error {
+	x, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	var t []any
+	if quiet {
+		if t, _, err = st.stack.popMaybeTuple(); err != nil {
+			return err
+		}
+		if k >= len(t) && x == nil {
+			if t == nil {
+				st.stack.push(nil)
+			} else {
+				st.stack.push(t)
+			}
+			return nil
+		}
+	} else {
+		if t, err = st.stack.popTuple(); err != nil {
+			return err
+		}
+		if k >= len(t) {
+			return errRangeCheck("tuple index is out of range")
+		}
+	}
+
+	sz := len(t)
+	if k >= sz {
+		sz = k + 1
+	}
+	res := make([]any, sz)
+	copy(res, t)
+	res[k] = x
+
+	st.stack.push(res)
+	return st.consumeTupleGas(len(res))
+}
+
+func nullSwap(st *state, not, rot, two bool) error {
+	depth := 1
+	if rot {
+		depth = 2
+	}
+	if err := st.stack.checkUnderflow(depth); err != nil {
+		return err
+	}
+
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+
+	cond := x.Sign() != 0
+	if not {
+		cond = !cond
+	}
+
+	if cond {
+		var under any
+		if rot {
+			if under, err = st.stack.pop(); err != nil {
+				return err
+			}
+		}
+		st.stack.push(nil)
+		if two {
+			st.stack.push(nil)
+		}
+		if rot {
+			st.stack.push(under)
+		}
+	}
+	st.stack.push(x)
+	return nil
+}
+
+func indexPath(t []any, path ...int) (any, error) {
+	var cur any = t
+	for _, i := range path {
+		tup, ok := cur.([]any)
+		if !ok {
+			return nil, errTypeCheck("not a tuple")
+		}
+		if i >= len(tup) {
+			return nil, errRangeCheck("tuple index is out of range")
+		}
+		cur = tup[i]
+	}
+	return cur, nil
+}
@@ -0,0 +1,190 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// sliceBits - returns all data bits of slice without moving it
+func sliceBits(s *cell.Slice) []byte {
+	data, err := s.PreloadSlice(s.BitsLeft())
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// sliceRefs - returns all refs of slice without moving it
+func sliceRefs(s *cell.Slice) []*cell.Cell {
+	cp := s.Copy()
+	refs := make([]*cell.Cell, 0, cp.RefsNum())
+	for cp.RefsNum() > 0 {
+		ref, err := cp.LoadRefCell()
+		if err != nil {
+			break
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+func getBit(data []byte, i uint) bool {
+	return data[i/8]&(0x80>>(i%8)) != 0
+}
+
+// extractBits - returns bits [from, from+n) of data, aligned to the left
+func extractBits(data []byte, from, n uint) []byte {
+	res := make([]byte, (n+7)/8)
+	for i := uint(0); i < n; i++ {
+		if getBit(data, from+i) {
+			res[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return res
+}
+
+func buildSlice(data []byte, bits uint, refs []*cell.Cell) (*cell.Slice, error) {
+	b := cell.BeginCell()
+	if err := b.StoreSlice(data, bits); err != nil {
+		return nil, errCellOverflow()
+	}
+	for _, ref := range refs {
+		if err := b.StoreRef(ref); err != nil {
+			return nil, errCellOverflow()
+		}
+	}
+	return b.ToSlice(), nil
+}
+
+// subSlice - returns part of the slice, bits [bitOff, bitOff+bits) and refs [refOff, refOff+refs)
+func subSlice(s *cell.Slice, bitOff, bits uint, refOff, refs int) (*cell.Slice, error) {
+	if bitOff+bits > s.BitsLeft() || refOff+refs > s.RefsNum() {
+		return nil, errCellUnderflow()
+	}
+	return buildSlice(extractBits(sliceBits(s), bitOff, bits), bits, sliceRefs(s)[refOff:refOff+refs])
+}
+
+// trimCompletionTag - removes trailing 1 and all zeroes after it
+func trimCompletionTag(s *cell.Slice) (*cell.Slice, error) {
+	data := sliceBits(s)
+	n := s.BitsLeft()
+	for n > 0 {
+		n--
+		if getBit(data, n) {
+			break
+		}
+	}
+	return buildSlice(extractBits(data, 0, n), n, sliceRefs(s))
+}
+
+func slicesEqual(a, b *cell.Slice) bool {
+	if a.BitsLeft() != b.BitsLeft() || a.RefsNum() != b.RefsNum() {
+		return false
+	}
+	ad, bd := sliceBits(a), sliceBits(b)
+	for i := uint(0); i < a.BitsLeft(); i++ {
+		if getBit(ad, i) != getBit(bd, i) {
+			return false
+		}
+	}
+	ar, br := sliceRefs(a), sliceRefs(b)
+	for i := range ar {
+		if string(ar[i].Hash()) != string(br[i].Hash()) {
+			return false
+		}
+	}
+	return true
+}
+
+// bitsToInt - converts n bits to integer, two's complement is used for signed
+func bitsToInt(data []byte, n uint, signed bool) *big.Int {
+	x := new(big.Int)
+	for i := uint(0); i < n; i++ {
+		x.Lsh(x, 1)
+		if getBit(data, i) {
+			x.SetBit(x, 0, 1)
+		}
+	}
+	if signed && n > 0 && x.Bit(int(n-1)) == 1 {
+		x.Sub(x, new(big.Int).Lsh(big.NewInt(1), n))
+	}
+	return x
+}
+
+// intToBits - converts integer to n bits, it should be checked to fit before
+func intToBits(x *big.Int, n uint) []byte {
+	v := new(big.Int).Set(x)
+	if v.Sign() < 0 {
+		v.Add(v, new(big.Int).Lsh(big.NewInt(1), n))
+	}
+
+	res := make([]byte, (n+7)/8)
+	for i := uint(0); i < n; i++ {
+		if v.Bit(int(n-1-i)) == 1 {
+			res[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return res
+}
+
+func loadInt(s *cell.Slice, n uint, signed, preload bool) (*big.Int, error) {
+	if s.BitsLeft() < n {
+		return nil, errCellUnderflow()
+	}
+
+	var data []byte
+	var err error
+	if preload {
+		data, err = s.PreloadSlice(n)
+	} else {
+		data, err = s.LoadSlice(n)
+	}
+	if err != nil {
+		return nil, errCellUnderflow()
+	}
+	return bitsToInt(data, n, signed), nil
+}
+
+func storeInt(b *cell.Builder, x *big.Int, n uint, signed bool) error {
+	if !fitsBits(x, n, signed) {
+		return errRangeCheck("integer does not fit into the requested bits")
+	}
+	if b.BitsLeft() < n {
+		return errCellOverflow()
+	}
+	if n == 0 {
+		return nil
+	}
+	if err := b.StoreSlice(intToBits(x, n), n); err != nil {
+		return errCellOverflow()
+	}
+	return nil
+}
+
+// fitsBits - checks that integer can be stored as n bits (un)signed integer
+func fitsBits(x *big.Int, n uint, signed bool) bool {
+	if !signed {
+		return x.Sign() >= 0 && uint(x.BitLen()) <= n
+	}
+	if n == 0 {
+		return x.Sign() == 0
+	}
+	if x.Sign() >= 0 {
+		return uint(x.BitLen()) <= n-1
+	}
+	// for negative x, it fits when -x-1 fits into n-1 bits
+	return uint(new(big.Int).Not(x).BitLen()) <= n-1
+}
+
+func copyBuilder(b *cell.Builder) *cell.Builder {
+	return b.Copy()
+}
+
+func sliceToCell(s *cell.Slice) (*cell.Cell, error) {
+	c, err := s.ToCell()
+	if err != nil {
+		return nil, errCellUnderflow()
+	}
+	return c, nil
+}
@@ -0,0 +1,322 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// stack - tvm stack, last element is a top of the stack.
+// Values are treated as immutable, so they can be shared between stacks.
+type stack struct {
+	elems []any
+}
+
+func newStack() *stack {
+	return &stack{}
+}
+
+func (s *stack) depth() int {
+	return len(s.elems)
+}
+
+func (s *stack) copy() *stack {
+	return &stack{elems: append([]any{}, s.elems...)}
+}
+
+func (s *stack) push(v any) {
+	s.elems = append(s.elems, v)
+}
+
+func (s *stack) pushInt(v int64) {
+	s.push(big.NewInt(v))
+}
+
+func (s *stack) pushBool(v bool) {
+	if v {
+		s.push(big.NewInt(-1))
+		return
+	}
+	s.push(big.NewInt(0))
+}
+
+func (s *stack) checkUnderflow(n int) error {
+	if n < 0 || len(s.elems) < n {
+		return errStackUnderflow()
+	}
+	return nil
+}
+
+func (s *stack) pop() (any, error) {
+	if len(s.elems) == 0 {
+		return nil, errStackUnderflow()
+	}
+	v := s.elems[len(s.elems)-1]
+	s.elems = s.elems[:len(s.elems)-1]
+	return v, nil
+}
+
+// get - returns s(i), s0 is a top
+func (s *stack) get(i int) (any, error) {
+	if i < 0 || i >= len(s.elems) {
+		return nil, errStackUnderflow()
+	}
+	return s.elems[len(s.elems)-1-i], nil
+}
+
+func (s *stack) set(i int, v any) error {
+	if i < 0 || i >= len(s.elems) {
+		return errStackUnderflow()
+	}
+	s.elems[len(s.elems)-1-i] = v
+	return nil
+}
+
+func (s *stack) exchange(i, j int) error {
+	if i < 0 || j < 0 || i >= len(s.elems) || j >= len(s.elems) {
+		return errStackUnderflow()
+	}
+	a, b := len(s.elems)-1-i, len(s.elems)-1-j
+	s.elems[a], s.elems[b] = s.elems[b], s.elems[a]
+	return nil
+}
+
+func (s *stack) drop(n int) error {
+	if err := s.checkUnderflow(n); err != nil {
+		return err
+	}
+	s.elems = s.elems[:len(s.elems)-n]
+	return nil
+}
+
+// dropBottom - removes n elements from the bottom
+func (s *stack) dropBottom(n int) {
+	s.elems = append([]any{}, s.elems[n:]...)
+}
+
+// reverse - reverses order of n elements starting from s(offset)
+func (s *stack) reverse(n, offset int) error {
+	if err := s.checkUnderflow(n + offset); err != nil {
+		return err
+	}
+	end := len(s.elems) - offset
+	for i, j := end-n, end-1; i < j; i, j = i+1, j-1 {
+		s.elems[i], s.elems[j] = s.elems[j], s.elems[i]
+	}
+	return nil
+}
+
+// splitTop - moves top n elements to a new stack, and drops next drop elements
+func (s *stack) splitTop(n, drop int) (*stack, error) {
+	if err := s.checkUnderflow(n + drop); err != nil {
+		return nil, err
+	}
+	top := &stack{elems: append([]any{}, s.elems[len(s.elems)-n:]...)}
+	s.elems = s.elems[:len(s.elems)-n-drop]
+	return top, nil
+}
+
+// moveFrom - moves top n elements from another stack to the top of this one
+func (s *stack) moveFrom(from *stack, n int) error {
+	if err := from.checkUnderflow(n); err != nil {
+		return err
+	}
+	s.elems = append(s.elems, from.elems[len(from.elems)-n:]...)
+	from.elems = from.elems[:len(from.elems)-n]
+	return nil
+}
+
+func (s *stack) popInt() (*big.Int, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+
+	switch x := v.(type) {
+	case *big.Int:
+		return x, nil
+	case tlb.StackNaN:
+		return nil, errIntOverflow()
+	}
+	return nil, errTypeCheck("not an integer")
+}
+
+// popIntQuiet - pops int, NaN is returned as nil
+func (s *stack) popIntQuiet() (*big.Int, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+
+	switch x := v.(type) {
+	case *big.Int:
+		return x, nil
+	case tlb.StackNaN:
+		return nil, nil
+	}
+	return nil, errTypeCheck("not an integer")
+}
+
+// popIntRange - pops int and checks that min <= x <= max
+func (s *stack) popIntRange(min, max int64) (int64, error) {
+	x, err := s.popInt()
+	if err != nil {
+		return 0, err
+	}
+	if !x.IsInt64() || x.Int64() < min || x.Int64() > max {
+		return 0, errRangeCheck("integer is out of expected range")
+	}
+	return x.Int64(), nil
+}
+
+func (s *stack) popBool() (bool, error) {
+	x, err := s.popInt()
+	if err != nil {
+		return false, err
+	}
+	return x.Sign() != 0, nil
+}
+
+func (s *stack) popCell() (*cell.Cell, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.(*cell.Cell)
+	if !ok {
+		return nil, errTypeCheck("not a cell")
+	}
+	return c, nil
+}
+
+// popMaybeCell - pops cell or null
+func (s *stack) popMaybeCell() (*cell.Cell, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	c, ok := v.(*cell.Cell)
+	if !ok {
+		return nil, errTypeCheck("not a cell")
+	}
+	return c, nil
+}
+
+func (s *stack) popSlice() (*cell.Slice, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.(*cell.Slice)
+	if !ok {
+		return nil, errTypeCheck("not a slice")
+	}
+	return c, nil
+}
+
+func (s *stack) popBuilder() (*cell.Builder, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.(*cell.Builder)
+	if !ok {
+		return nil, errTypeCheck("not a builder")
+	}
+	return c, nil
+}
+
+func (s *stack) popTuple() ([]any, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.([]any)
+	if !ok {
+		return nil, errTypeCheck("not a tuple")
+	}
+	return c, nil
+}
+
+// popMaybeTuple - pops tuple or null
+func (s *stack) popMaybeTuple() ([]any, bool, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, false, err
+	}
+	if v == nil {
+		return nil, false, nil
+	}
+	c, ok := v.([]any)
+	if !ok {
+		return nil, false, errTypeCheck("not a tuple")
+	}
+	return c, true, nil
+}
+
+func (s *stack) popCont() (continuation, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.(continuation)
+	if !ok {
+		return nil, errTypeCheck("not a continuation")
+	}
+	return c, nil
+}
+
+// normalizeValue - converts go values to the types used inside vm
+func normalizeValue(v any) (any, error) {
+	switch x := v.(type) {
+	case nil, *cell.Cell, *cell.Slice, *cell.Builder, continuation:
+		return x, nil
+	case *big.Int:
+		if x == nil {
+			return nil, nil
+		}
+		return new(big.Int).Set(x), nil
+	case int:
+		return big.NewInt(int64(x)), nil
+	case int8:
+		return big.NewInt(int64(x)), nil
+	case int16:
+		return big.NewInt(int64(x)), nil
+	case int32:
+		return big.NewInt(int64(x)), nil
+	case int64:
+		return big.NewInt(x), nil
+	case uint:
+		return new(big.Int).SetUint64(uint64(x)), nil
+	case uint8:
+		return big.NewInt(int64(x)), nil
+	case uint16:
+		return big.NewInt(int64(x)), nil
+	case uint32:
+		return big.NewInt(int64(x)), nil
+	case uint64:
+		return new(big.Int).SetUint64(x), nil
+	case bool:
+		if x {
+			return big.NewInt(-1), nil
+		}
+		return big.NewInt(0), nil
+	case tlb.StackNaN, *tlb.StackNaN:
+		return tlb.StackNaN{}, nil
+	case []any:
+		res := make([]any, len(x))
+		for i := range x {
+			val, err := normalizeValue(x[i])
+			if err != nil {
+				return nil, err
+			}
+			res[i] = val
+		}
+		return res, nil
+	}
+	return nil, errTypeCheck("unsupported value type")
+}
@@ -0,0 +1,647 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	gasPerInstruction     = 10
+	gasPerBit             = 1
+	gasPerRef             = 5
+	gasCellLoad           = 100
+	gasCellReload         = 25
+	gasCellCreate         = 500
+	gasException          = 50
+	gasTupleEntry         = 1
+	gasImplicitJmpRef     = 10
+	gasImplicitRet        = 5
+	gasStackEntry         = 1
+	gasFreeStackDepth     = 32
+	gasFreeNestedContJump = 8
+
+	maxDataDepth = 512
+	maxLibDepth  = 16
+)
+
+type gasState struct {
+	max       int64
+	limit     int64
+	credit    int64
+	base      int64
+	remaining int64
+}
+
+func newGasState(g Gas) *gasState {
+	st := &gasState{
+		max:    g.Max,
+		limit:  g.Limit,
+		credit: g.Credit,
+	}
+	if st.max < st.limit {
+		st.max = st.limit
+	}
+	st.base = st.limit + st.credit
+	st.remaining = st.base
+	return st
+}
+
+func (g *gasState) consumed() int64 {
+	return g.base - g.remaining
+}
+
+func (g *gasState) changeBase(base int64) {
+	g.remaining += base - g.base
+	g.base = base
+}
+
+func (g *gasState) changeLimit(limit int64) {
+	if limit > g.max {
+		limit = g.max
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	g.credit = 0
+	g.limit = limit
+	g.changeBase(limit)
+}
+
+type state struct {
+	code  *cell.Slice
+	cp    int
+	stack *stack
+	regs  registers
+	gas   *gasState
+
+	libraries    map[string]*cell.Cell
+	loaded       map[string]bool
+	steps        uint64
+	halted       bool
+	exitCode     int64
+	globalVer    int
+	chksignCount int
+	committed    bool
+	commitData   *cell.Cell
+	commitActs   *cell.Cell
+
+	quit0, quit1 continuation
+}
+
+func newState(code *cell.Slice, stk *stack, gas Gas, libs map[string]*cell.Cell) *state {
+	st := &state{
+		code:      code,
+		stack:     stk,
+		gas:       newGasState(gas),
+		libraries: libs,
+		loaded:    map[string]bool{},
+		globalVer: DefaultGlobalVersion,
+		quit0:     &quitCont{exitCode: 0},
+		quit1:     &quitCont{exitCode: 1},
+	}
+	st.regs.c[0] = st.quit0
+	st.regs.c[1] = st.quit1
+	st.regs.c[2] = &excQuitCont{}
+	st.regs.c[3] = &quitCont{exitCode: 11}
+	st.regs.c7 = []any{}
+	return st
+}
+
+func (st *state) consumeGas(amount int64) error {
+	st.gas.remaining -= amount
+	if st.gas.remaining < 0 {
+		return errOutOfGas
+	}
+	return nil
+}
+
+func (st *state) consumeStackGas(depth int) error {
+	if depth > gasFreeStackDepth {
+		return st.consumeGas(int64(depth-gasFreeStackDepth) * gasStackEntry)
+	}
+	return nil
+}
+
+func (st *state) consumeTupleGas(n int) error {
+	return st.consumeGas(int64(n) * gasTupleEntry)
+}
+
+func (st *state) setCode(code *cell.Slice, cp int) {
+	st.code = code
+	if cp != -1 {
+		st.cp = cp
+	}
+}
+
+func (st *state) halt(code int64) {
+	st.halted = true
+	st.exitCode = code
+}
+
+func (st *state) adjustRegisters(save *registers) {
+	for i := 0; i < 4; i++ {
+		if save.c[i] != nil {
+			st.regs.c[i] = save.c[i]
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if save.d[i] != nil {
+			st.regs.d[i] = save.d[i]
+		}
+	}
+	if save.c7 != nil {
+		st.regs.c7 = save.c7
+	}
+}
+
+// run - executes code till the end and returns exit code
+func (st *state) run() (code int64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			code = ExitCodeFatal
+			err = fmt.Errorf("tvm panic: %v", r)
+		}
+	}()
+
+	for !st.halted {
+		err := st.step()
+		for err != nil {
+			if errors.Is(err, errOutOfGas) {
+				st.gas.remaining = 0
+				st.stack = newStack()
+				st.stack.pushInt(st.gas.consumed())
+				return ExitCodeOutOfGasFinal, nil
+			}
+
+			var vmErr vmError
+			if !errors.As(err, &vmErr) {
+				return ExitCodeFatal, err
+			}
+			err = st.throwException(vmErr)
+		}
+	}
+
+	if st.exitCode == 0 || st.exitCode == 1 {
+		if !st.tryCommit() {
+			return ExitCodeCellOverflow, nil
+		}
+	}
+	return st.exitCode, nil
+}
+
+func (st *state) step() error {
+	st.steps++
+
+	if st.code.BitsLeft() == 0 {
+		if st.code.RefsNum() > 0 {
+			// implicit jump to the first ref
+			if err := st.consumeGas(gasImplicitJmpRef); err != nil {
+				return err
+			}
+			ref, err := st.code.PreloadRefCell()
+			if err != nil {
+				return err
+			}
+			cont, err := st.refToCont(ref)
+			if err != nil {
+				return err
+			}
+			return st.jump(cont, -1)
+		}
+
+		// implicit return
+		if err := st.consumeGas(gasImplicitRet); err != nil {
+			return err
+		}
+		return st.ret()
+	}
+
+	op, bits := decodeOpcode(st.code)
+	if op == nil {
+		if err := st.consumeGas(gasPerInstruction); err != nil {
+			return err
+		}
+		return throwErr(ExitCodeInvalidOpcode, "invalid opcode")
+	}
+
+	if _, err := st.code.LoadSlice(bits); err != nil {
+		return throwErr(ExitCodeInvalidOpcode, "invalid opcode")
+	}
+
+	if err := st.consumeGas(gasPerInstruction + int64(bits)*gasPerBit); err != nil {
+		return err
+	}
+	return op.exec(st)
+}
+
+func (st *state) throwException(e vmError) error {
+	st.stack = newStack()
+	if e.arg != nil {
+		st.stack.push(e.arg)
+	} else {
+		st.stack.pushInt(0)
+	}
+	st.stack.pushInt(e.code)
+	st.code = cell.BeginCell().ToSlice()
+
+	if err := st.consumeGas(gasException); err != nil {
+		return err
+	}
+	return st.jump(st.regs.c[2], -1)
+}
+
+func (st *state) tryCommit() bool {
+	c4, c5 := st.regs.d[0], st.regs.d[1]
+	if c4 == nil || c5 == nil {
+		return false
+	}
+	if c4.Depth() > maxDataDepth || c5.Depth() > maxDataDepth {
+		return false
+	}
+	st.committed = true
+	st.commitData = c4
+	st.commitActs = c5
+	return true
+}
+
+// argUInt - loads instruction argument from code and consumes gas for its bits
+func (st *state) argUInt(bits uint) (uint64, error) {
+	v, err := st.code.LoadUInt(bits)
+	if err != nil {
+		return 0, throwErr(ExitCodeInvalidOpcode, "not enough bits for instruction argument")
+	}
+	if err = st.consumeGas(int64(bits) * gasPerBit); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+// argRef - loads instruction reference argument from code and consumes gas for it
+func (st *state) argRef() (*cell.Cell, error) {
+	ref, err := st.code.LoadRefCell()
+	if err != nil {
+		return nil, throwErr(ExitCodeInvalidOpcode, "no references left for instruction")
+	}
+	if err = st.consumeGas(gasPerRef); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// argSlice - loads inline slice argument from code and consumes gas for it
+func (st *state) argSlice(bits uint, refs int) (*cell.Slice, error) {
+	if st.code.BitsLeft() < bits || st.code.RefsNum() < refs {
+		return nil, throwErr(ExitCodeInvalidOpcode, "not enough data for instruction argument")
+	}
+
+	data, err := st.code.LoadSlice(bits)
+	if err != nil {
+		return nil, err
+	}
+
+	b := cell.BeginCell()
+	if err = b.StoreSlice(data, bits); err != nil {
+		return nil, err
+	}
+	for i := 0; i < refs; i++ {
+		ref, err := st.code.LoadRefCell()
+		if err != nil {
+			return nil, err
+		}
+		if err = b.StoreRef(ref); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = st.consumeGas(int64(bits)*gasPerBit + int64(refs)*gasPerRef); err != nil {
+		return nil, err
+	}
+	return b.ToSlice(), nil
+}
+
+func (st *state) registerCellLoad(c *cell.Cell) error {
+	key := string(c.Hash())
+	if st.loaded[key] {
+		return st.consumeGas(gasCellReload)
+	}
+	st.loaded[key] = true
+	return st.consumeGas(gasCellLoad)
+}
+
+func (st *state) registerCellCreate() error {
+	return st.consumeGas(gasCellCreate)
+}
+
+// loadCell - converts cell to slice, consuming gas, library cells are resolved
+func (st *state) loadCell(c *cell.Cell) (*cell.Slice, error) {
+	for i := 0; i < maxLibDepth; i++ {
+		if err := st.registerCellLoad(c); err != nil {
+			return nil, err
+		}
+
+		switch c.GetType() {
+		case cell.OrdinaryCellType:
+			return c.BeginParse(), nil
+		case cell.LibraryCellType:
+			lib, err := st.resolveLibrary(c)
+			if err != nil {
+				return nil, err
+			}
+			c = lib
+		default:
+			return nil, throwErr(ExitCodeCellUnderflow, "failed to load special cell")
+		}
+	}
+	return nil, throwErr(ExitCodeCellUnderflow, "too deep library chain")
+}
+
+func (st *state) resolveLibrary(c *cell.Cell) (*cell.Cell, error) {
+	s := c.BeginParse()
+	if _, err := s.LoadSlice(8); err != nil {
+		return nil, errCellUnderflow()
+	}
+	hash, err := s.LoadSlice(256)
+	if err != nil {
+		return nil, errCellUnderflow()
+	}
+
+	lib := st.libraries[string(hash)]
+	if lib == nil {
+		return nil, throwErr(ExitCodeCellUnderflow, "library is not found")
+	}
+	return lib, nil
+}
+
+func (st *state) refToCont(c *cell.Cell) (continuation, error) {
+	s, err := st.loadCell(c)
+	if err != nil {
+		return nil, err
+	}
+	return newOrdCont(s, st.cp), nil
+}
+
+// jumpTo - jumps to continuation without stack adjustments
+func (st *state) jumpTo(c continuation) error {
+	cnt := 0
+	for c != nil {
+		cnt++
+		if cnt > gasFreeNestedContJump {
+			if err := st.consumeGas(1); err != nil {
+				return err
+			}
+		}
+
+		next, err := c.jump(st)
+		if err != nil {
+			return err
+		}
+		c = next
+	}
+	return nil
+}
+
+// jump - jumps to continuation passing passArgs top elements of the stack (all when -1)
+func (st *state) jump(c continuation, passArgs int) error {
+	depth := st.stack.depth()
+
+	if d := c.data(); d != nil {
+		if passArgs > depth || d.nargs > depth {
+			return throwErr(ExitCodeStackUnderflow, "stack underflow while jumping to continuation: not enough arguments on stack")
+		}
+		if d.nargs > passArgs && passArgs >= 0 {
+			return throwErr(ExitCodeStackUnderflow, "stack underflow while jumping to closure continuation: not enough arguments passed")
+		}
+
+		cp := d.nargs
+		if passArgs >= 0 && cp < 0 {
+			cp = passArgs
+		}
+
+		if d.stack != nil && d.stack.depth() > 0 {
+			if cp < 0 {
+				cp = depth
+			}
+			newStk := d.stack.copy()
+			if err := newStk.moveFrom(st.stack, cp); err != nil {
+				return err
+			}
+			if err := st.consumeStackGas(newStk.depth()); err != nil {
+				return err
+			}
+			st.stack = newStk
+		} else if cp >= 0 && cp < depth {
+			st.stack.dropBottom(depth - cp)
+			if err := st.consumeStackGas(cp); err != nil {
+				return err
+			}
+		}
+		return st.jumpTo(c)
+	}
+
+	if passArgs >= 0 {
+		if passArgs > depth {
+			return errStackUnderflow()
+		} else if passArgs < depth {
+			st.stack.dropBottom(depth - passArgs)
+			if err := st.consumeStackGas(passArgs); err != nil {
+				return err
+			}
+		}
+	}
+	return st.jumpTo(c)
+}
+
+// call - calls continuation, current continuation is saved to c0,
+// passArgs top elements are passed (all when -1), retArgs is number of returned values (all when -1)
+func (st *state) call(c continuation, passArgs, retArgs int) error {
+	depth := st.stack.depth()
+
+	var newStk *stack
+	if d := c.data(); d != nil {
+		if d.save.c[0] != nil {
+			// call reduces to a jump
+			return st.jump(c, passArgs)
+		}
+
+		if passArgs > depth || d.nargs > depth {
+			return throwErr(ExitCodeStackUnderflow, "stack underflow while calling a continuation: not enough arguments on stack")
+		}
+		if d.nargs > passArgs && passArgs >= 0 {
+			return throwErr(ExitCodeStackUnderflow, "stack underflow while calling a closure continuation: not enough arguments passed")
+		}
+
+		cp, skip := d.nargs, 0
+		if passArgs >= 0 {
+			if cp >= 0 {
+				skip = passArgs - cp
+			} else {
+				cp = passArgs
+			}
+		}
+
+		if d.stack != nil && d.stack.depth() > 0 {
+			if cp < 0 {
+				cp = depth
+			}
+			newStk = d.stack.copy()
+			if err := newStk.moveFrom(st.stack, cp); err != nil {
+				return err
+			}
+			if skip > 0 {
+				if err := st.stack.drop(skip); err != nil {
+					return err
+				}
+			}
+			if err := st.consumeStackGas(newStk.depth()); err != nil {
+				return err
+			}
+		} else if cp >= 0 {
+			var err error
+			if newStk, err = st.stack.splitTop(cp, skip); err != nil {
+				return err
+			}
+			if err = st.consumeStackGas(cp); err != nil {
+				return err
+			}
+		} else {
+			newStk = st.stack
+			st.stack = newStack()
+		}
+	} else if passArgs >= 0 {
+		if passArgs > depth {
+			return errStackUnderflow()
+		}
+		var err error
+		if newStk, err = st.stack.splitTop(passArgs, 0); err != nil {
+			return err
+		}
+		if err = st.consumeStackGas(passArgs); err != nil {
+			return err
+		}
+	} else {
+		newStk = st.stack
+		st.stack = newStack()
+	}
+
+	// return continuation keeps the rest of the stack
+	ret := newOrdCont(st.code, st.cp)
+	ret.cdata.stack = st.stack
+	ret.cdata.nargs = retArgs
+	ret.cdata.save.c[0] = st.regs.c[0]
+
+	st.stack = newStk
+	st.regs.c[0] = ret
+	return st.jumpTo(c)
+}
+
+func (st *state) ret() error {
+	c := st.regs.c[0]
+	st.regs.c[0] = st.quit0
+	return st.jump(c, -1)
+}
+
+func (st *state) retArgs(n int) error {
+	c := st.regs.c[0]
+	st.regs.c[0] = st.quit0
+	return st.jump(c, n)
+}
+
+func (st *state) retAlt() error {
+	c := st.regs.c[1]
+	st.regs.c[1] = st.quit1
+	return st.jump(c, -1)
+}
+
+func (st *state) retAltArgs(n int) error {
+	c := st.regs.c[1]
+	st.regs.c[1] = st.quit1
+	return st.jump(c, n)
+}
+
+// extractCC - converts the rest of current code to continuation,
+// saveCr is a mask of c0, c1, c2 to be saved into it
+func (st *state) extractCC(saveCr int, stackCopy, ccArgs int) (*ordCont, error) {
+	var newStk *stack
+	if stackCopy < 0 || stackCopy == st.stack.depth() {
+		newStk = st.stack
+		st.stack = newStack()
+	} else if stackCopy > 0 {
+		var err error
+		if newStk, err = st.stack.splitTop(stackCopy, 0); err != nil {
+			return nil, err
+		}
+	} else {
+		newStk = newStack()
+	}
+
+	cc := newOrdCont(st.code, st.cp)
+	cc.cdata.stack = st.stack
+	cc.cdata.nargs = ccArgs
+	st.stack = newStk
+	st.code = cell.BeginCell().ToSlice()
+
+	if saveCr&1 != 0 {
+		cc.cdata.save.c[0] = st.regs.c[0]
+		st.regs.c[0] = st.quit0
+	}
+	if saveCr&2 != 0 {
+		cc.cdata.save.c[1] = st.regs.c[1]
+		st.regs.c[1] = st.quit1
+	}
+	if saveCr&4 != 0 {
+		cc.cdata.save.c[2] = st.regs.c[2]
+	}
+	return cc, nil
+}
+
+// c1Envelope - sets c1 to cont, saving current c0 and c1 into it
+func (st *state) c1Envelope(cont continuation, save bool) (continuation, error) {
+	if save {
+		var d *controlData
+		cont, d = forceData(cont)
+		if d.save.c[1] == nil {
+			d.save.c[1] = st.regs.c[1]
+		}
+		if d.save.c[0] == nil {
+			d.save.c[0] = st.regs.c[0]
+		}
+	}
+	st.regs.c[1] = cont
+	return cont, nil
+}
+
+func (st *state) c1EnvelopeIf(cond bool, cont continuation) (continuation, error) {
+	if cond {
+		return st.c1Envelope(cont, true)
+	}
+	return cont, nil
+}
+
+func (st *state) getParam(idx int) (any, error) {
+	if len(st.regs.c7) == 0 {
+		return nil, errRangeCheck("c7 is empty")
+	}
+	info, ok := st.regs.c7[0].([]any)
+	if !ok {
+		return nil, errTypeCheck("c7[0] is not a tuple")
+	}
+	if idx >= len(info) {
+		return nil, errRangeCheck("param index is out of range")
+	}
+	return info[idx], nil
+}
+
+func (st *state) pushIntChecked(x *big.Int, quiet bool) error {
+	if x == nil || !fitsInt257(x) {
+		if !quiet {
+			return errIntOverflow()
+		}
+		st.stack.push(nan)
+		return nil
+	}
+	st.stack.push(x)
+	return nil
+}
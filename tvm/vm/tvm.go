@@ -0,0 +1,316 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// DefaultGlobalVersion - version of tvm behaviour which is emulated
+const DefaultGlobalVersion = 9
+
+// DefaultGetMethodGasLimit - gas limit used by liteservers to run get methods
+const DefaultGetMethodGasLimit = 1_000_000
+
+// Gas - execution gas limits, when Limit is less than Max, contract can increase it up to Max using ACCEPT
+type Gas struct {
+	Max    int64
+	Limit  int64
+	Credit int64
+}
+
+// GasWithLimit - gas with the same max and limit, and without credit
+func GasWithLimit(limit int64) Gas {
+	return Gas{
+		Max:   limit,
+		Limit: limit,
+	}
+}
+
+type TVM struct {
+	libraries     map[string]*cell.Cell
+	globalVersion int
+}
+
+type ExecutionResult struct {
+	ExitCode int32
+	GasUsed  int64
+	// GasCredit - remaining gas credit, it is zero when contract accepted message
+	GasCredit int64
+	Steps     uint64
+	Stack     *tlb.Stack
+
+	// Committed - true if new data and actions were committed, by COMMIT or successful exit
+	Committed bool
+	Data      *cell.Cell
+	Actions   *cell.Cell
+}
+
+// ContractInfo - values of SmartContractInfo tuple, which is passed to contract in c7
+type ContractInfo struct {
+	Now      uint32
+	BlockLT  uint64
+	LT       uint64
+	RandSeed []byte
+	Balance  *big.Int
+	Address  *address.Address
+	// Config - root cell of config dictionary, can be nil
+	Config *cell.Cell
+	Code   *cell.Cell
+
+	IncomingValue *big.Int
+	StorageFees   *big.Int
+	DuePayment    *big.Int
+	// PrevBlocks - tuple with info about previous blocks, can be nil
+	PrevBlocks []any
+}
+
+func NewTVM() *TVM {
+	return &TVM{
+		libraries:     map[string]*cell.Cell{},
+		globalVersion: DefaultGlobalVersion,
+	}
+}
+
+// AddLibraries - adds library cells which can be loaded by contracts through library cells
+func (t *TVM) AddLibraries(libs ...*cell.Cell) {
+	for _, lib := range libs {
+		if lib != nil {
+			t.libraries[string(lib.Hash())] = lib
+		}
+	}
+}
+
+// SetGlobalVersion - sets version of tvm behaviour, it is stored in config param 8
+func (t *TVM) SetGlobalVersion(version int) {
+	t.globalVersion = version
+}
+
+// Execute - runs code with given data and stack, c7 can be built using ContractInfo.
+// Exceptions thrown by contract are returned as exit code, error is returned only when execution is impossible.
+func (t *TVM) Execute(code, data *cell.Cell, c7 []any, gas Gas, stack *tlb.Stack) (*ExecutionResult, error) {
+	if code == nil {
+		return nil, ErrNoCode
+	}
+	if data == nil {
+		data = cell.BeginCell().EndCell()
+	}
+
+	stk := newStack()
+	if stack != nil {
+		// tlb stack top is a bottom of vm stack
+		cp := *stack
+		for cp.Depth() > 0 {
+			v, err := cp.Pop()
+			if err != nil {
+				return nil, err
+			}
+			val, err := normalizeValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert stack value: %w", err)
+			}
+			stk.push(val)
+		}
+	}
+
+	var c7Norm []any
+	if c7 != nil {
+		v, err := normalizeValue(c7)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert c7 value: %w", err)
+		}
+		c7Norm = v.([]any)
+	}
+
+	st := newState(nil, stk, gas, t.libraries)
+	st.globalVer = t.globalVersion
+
+	// initial code load is not charged, special cells are wrapped to be resolved by implicit jump
+	codeSlice := code.BeginParse()
+	if code.GetType() != cell.OrdinaryCellType {
+		codeSlice = cell.BeginCell().MustStoreRef(code).ToSlice()
+	}
+
+	st.code = codeSlice
+	st.regs.c[3] = newOrdCont(codeSlice, 0)
+	st.regs.d[0] = data
+	st.regs.d[1] = cell.BeginCell().EndCell()
+	if c7Norm != nil {
+		st.regs.c7 = c7Norm
+	}
+
+	exitCode, err := st.run()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ExecutionResult{
+		ExitCode:  int32(exitCode),
+		GasUsed:   st.gas.consumed(),
+		GasCredit: st.gas.credit,
+		Steps:     st.steps,
+		Stack:     tlb.NewStack(),
+		Committed: st.committed,
+	}
+	if res.GasUsed > st.gas.limit {
+		res.GasUsed = st.gas.limit
+	}
+	if st.committed {
+		res.Data = st.commitData
+		res.Actions = st.commitActs
+	}
+
+	for i := len(st.stack.elems) - 1; i >= 0; i-- {
+		res.Stack.Push(st.stack.elems[i])
+	}
+	return res, nil
+}
+
+// RunGetMethod - executes get method of contract, params are passed in the same order as in ton.APIClient RunGetMethod
+func (t *TVM) RunGetMethod(code, data *cell.Cell, c7 []any, gas Gas, method string, params ...any) (*ExecutionResult, error) {
+	return t.RunGetMethodByID(code, data, c7, gas, int32(tlb.MethodNameHash(method)), params...)
+}
+
+// RunGetMethodByID - same as RunGetMethod but accepts method id
+func (t *TVM) RunGetMethodByID(code, data *cell.Cell, c7 []any, gas Gas, methodID int32, params ...any) (*ExecutionResult, error) {
+	// tlb stack top is a bottom of vm stack, so method id is pushed first
+	stack := tlb.NewStack()
+	stack.Push(big.NewInt(int64(methodID)))
+	for i := len(params) - 1; i >= 0; i-- {
+		stack.Push(params[i])
+	}
+	return t.Execute(code, data, c7, gas, stack)
+}
+
+// ToC7 - builds c7 register value with SmartContractInfo and empty globals
+func (c *ContractInfo) ToC7() ([]any, error) {
+	randSeed := big.NewInt(0)
+	if len(c.RandSeed) > 0 {
+		if len(c.RandSeed) != 32 {
+			return nil, fmt.Errorf("invalid rand seed size")
+		}
+		randSeed = new(big.Int).SetBytes(c.RandSeed)
+	}
+
+	balance := c.Balance
+	if balance == nil {
+		balance = big.NewInt(0)
+	}
+
+	var addr any
+	if c.Address != nil {
+		b := cell.BeginCell()
+		if err := b.StoreAddr(c.Address); err != nil {
+			return nil, fmt.Errorf("failed to store address: %w", err)
+		}
+		addr = b.ToSlice()
+	} else {
+		addr = cell.BeginCell().MustStoreUInt(0, 2).ToSlice()
+	}
+
+	var cfg, code, prevBlocks, unpacked any
+	if c.Config != nil {
+		cfg = c.Config
+		u, err := UnpackConfig(c.Config, c.Now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack config: %w", err)
+		}
+		unpacked = u
+	}
+	if c.Code != nil {
+		code = c.Code
+	}
+	if c.PrevBlocks != nil {
+		prevBlocks = c.PrevBlocks
+	}
+
+	orZero := func(x *big.Int) *big.Int {
+		if x == nil {
+			return big.NewInt(0)
+		}
+		return x
+	}
+
+	info := []any{
+		big.NewInt(0x076ef1ea),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(int64(c.Now)),
+		new(big.Int).SetUint64(c.BlockLT),
+		new(big.Int).SetUint64(c.LT),
+		randSeed,
+		[]any{balance, nil},
+		addr,
+		cfg,
+		code,
+		[]any{orZero(c.IncomingValue), nil},
+		orZero(c.StorageFees),
+		prevBlocks,
+		unpacked,
+		orZero(c.DuePayment),
+		nil,
+	}
+	return []any{info}, nil
+}
+
+// UnpackConfig - builds unpacked config tuple, which is available to contracts as a param 14
+func UnpackConfig(config *cell.Cell, now uint32) ([]any, error) {
+	dict := config.AsDict(32)
+
+	get := func(id int64) *cell.Slice {
+		v, err := dict.LoadValueByIntKey(big.NewInt(id))
+		if err != nil {
+			return nil
+		}
+		ref, err := v.LoadRefCell()
+		if err != nil {
+			return nil
+		}
+		return ref.BeginParse()
+	}
+
+	asValue := func(s *cell.Slice) any {
+		if s == nil {
+			return nil
+		}
+		return s
+	}
+
+	var storagePrices any
+	if s := get(18); s != nil {
+		root, err := s.ToCell()
+		if err != nil {
+			return nil, err
+		}
+		all, err := root.AsDict(32).LoadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load storage prices: %w", err)
+		}
+
+		var best uint64
+		for _, kv := range all {
+			since, err := kv.Value.Copy().LoadUInt(8 + 32)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load storage prices entry: %w", err)
+			}
+			since &= 0xFFFFFFFF
+			if since <= uint64(now) && (storagePrices == nil || since >= best) {
+				best = since
+				storagePrices = kv.Value
+			}
+		}
+	}
+
+	return []any{
+		storagePrices,
+		asValue(get(19)),
+		asValue(get(20)),
+		asValue(get(21)),
+		asValue(get(24)),
+		asValue(get(25)),
+		asValue(get(43)),
+	}, nil
+}
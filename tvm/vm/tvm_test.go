@@ -0,0 +1,287 @@
+package vm
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const _V3R2CodeHex = "B5EE9C724101010100710000DEFF0020DD2082014C97BA218201339CBAB19F71B0ED44D0D31FD31F31D70BFFE304E0A4F2608308D71820D31FD31FD31FF82313BBF263ED44D0D31FD31FD3FFD15132BAF2A15144BAF2A204F901541055F910F2A3F8009320D74A96D307D402FB00E8D101A4C8CB1FCB1FCBFFC9ED5410BD6DAD"
+const _V4R2CodeHex = "B5EE9C72410214010002D4000114FF00F4A413F4BCF2C80B010201200203020148040504F8F28308D71820D31FD31FD31F02F823BBF264ED44D0D31FD31FD3FFF404D15143BAF2A15151BAF2A205F901541064F910F2A3F80024A4C8CB1F5240CB1F5230CBFF5210F400C9ED54F80F01D30721C0009F6C519320D74A96D307D402FB00E830E021C001E30021C002E30001C0039130E30D03A4C8CB1F12CB1FCBFF1011121302E6D001D0D3032171B0925F04E022D749C120925F04E002D31F218210706C7567BD22821064737472BDB0925F05E003FA403020FA4401C8CA07CBFFC9D0ED44D0810140D721F404305C810108F40A6FA131B3925F07E005D33FC8258210706C7567BA923830E30D03821064737472BA925F06E30D06070201200809007801FA00F40430F8276F2230500AA121BEF2E0508210706C7567831EB17080185004CB0526CF1658FA0219F400CB6917CB1F5260CB3F20C98040FB0006008A5004810108F45930ED44D0810140D720C801CF16F400C9ED540172B08E23821064737472831EB17080185005CB055003CF1623FA0213CB6ACB1FCB3FC98040FB00925F03E20201200A0B0059BD242B6F6A2684080A06B90FA0218470D4080847A4937D29910CE6903E9FF9837812801B7810148987159F31840201580C0D0011B8C97ED44D0D70B1F8003DB29DFB513420405035C87D010C00B23281F2FFF274006040423D029BE84C600201200E0F0019ADCE76A26840206B90EB85FFC00019AF1DF6A26840106B90EB858FC0006ED207FA00D4D422F90005C8CA0715CBFFC9D077748018C8CB05CB0222CF165005FA0214CB6B12CCCCC973FB00C84014810108F451F2A7020070810108D718FA00D33FC8542047810108F451F2A782106E6F746570748018C8CB05CB025006CF165004FA0214CB6A12CB1FCB3FC973FB0002006C810108D718FA00D33F305224810108F459F2A782106473747270748018C8CB05CB025005CF165003FA0213CB6ACB1F12CB3FC973FB00000AF400C9ED54696225E5"
+
+func mustCode(t *testing.T, hexStr string) *cell.Cell {
+	boc, err := hex.DecodeString(hexStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := cell.FromBOC(boc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestOpcodesPrefixFree(t *testing.T) {
+	for _, l := range opLengths {
+		for prefix, op := range opTable[l] {
+			for _, short := range opLengths {
+				if short >= l {
+					break
+				}
+				if other := opTable[short][prefix>>(l-short)]; other != nil {
+					t.Fatalf("opcode %s (%x/%d) is shadowed by %s (%x/%d)", op.name, prefix, l, other.name, other.prefix, short)
+				}
+			}
+		}
+	}
+}
+
+func TestTVM_ExecuteSimple(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     []byte
+		gas      int64
+		exitCode int32
+		top      int64
+	}{
+		// PUSHINT 2, PUSHINT 3, ADD
+		{"add", []byte{0x72, 0x73, 0xA0}, 1000, 0, 5},
+		// THROW 42, only exception argument is left on stack
+		{"throw", []byte{0xF2, 0x2A}, 1000, 42, 0},
+		// AGAINEND
+		{"out of gas", []byte{0xEB}, 1000, ExitCodeOutOfGasFinal, 1000},
+		// unknown opcode
+		{"invalid opcode", []byte{0xFF, 0xFF}, 1000, ExitCodeInvalidOpcode, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code := cell.BeginCell().MustStoreSlice(test.code, uint(len(test.code)*8)).EndCell()
+
+			res, err := NewTVM().Execute(code, nil, nil, GasWithLimit(test.gas), tlb.NewStack())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.ExitCode != test.exitCode {
+				t.Fatal("incorrect exit code", res.ExitCode)
+			}
+
+			// tlb stack pops from the bottom of vm stack
+			var top any
+			for res.Stack.Depth() > 0 {
+				if top, err = res.Stack.Pop(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if top.(*big.Int).Int64() != test.top {
+				t.Fatal("incorrect result", top)
+			}
+
+			if test.exitCode == ExitCodeOutOfGasFinal && res.GasUsed != test.gas {
+				t.Fatal("incorrect gas used", res.GasUsed)
+			}
+		})
+	}
+}
+
+func TestTVM_RunGetMethod(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+
+	data := cell.BeginCell().
+		MustStoreUInt(7, 32).
+		MustStoreUInt(698983191, 32).
+		MustStoreSlice(pub, 256).
+		EndCell()
+
+	code := mustCode(t, _V3R2CodeHex)
+
+	res, err := NewTVM().RunGetMethod(code, data, nil, GasWithLimit(DefaultGetMethodGasLimit), "seqno")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 0 {
+		t.Fatal("incorrect exit code", res.ExitCode)
+	}
+
+	seqno, err := res.Stack.Pop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seqno.(*big.Int).Uint64() != 7 {
+		t.Fatal("incorrect seqno", seqno)
+	}
+
+	res, err = NewTVM().RunGetMethod(code, data, nil, GasWithLimit(DefaultGetMethodGasLimit), "get_public_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := res.Stack.Pop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.(*big.Int).FillBytes(make([]byte, 32)), pub) {
+		t.Fatal("incorrect public key")
+	}
+
+	// v3 throws 32 for any unknown method
+	res, err = NewTVM().RunGetMethod(code, data, nil, GasWithLimit(DefaultGetMethodGasLimit), "unknown_method")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 32 {
+		t.Fatal("incorrect exit code for unknown method", res.ExitCode)
+	}
+}
+
+func TestTVM_RunGetMethodDict(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	plugin := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+
+	plugins := cell.NewDict(8 + 256)
+	err := plugins.Set(cell.BeginCell().MustStoreInt(int64(plugin.Workchain()), 8).MustStoreSlice(plugin.Data(), 256).EndCell(),
+		cell.BeginCell().EndCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := cell.BeginCell().
+		MustStoreUInt(1, 32).
+		MustStoreUInt(698983191, 32).
+		MustStoreSlice(pub, 256).
+		MustStoreDict(plugins).
+		EndCell()
+
+	code := mustCode(t, _V4R2CodeHex)
+
+	res, err := NewTVM().RunGetMethod(code, data, nil, GasWithLimit(DefaultGetMethodGasLimit), "get_plugin_list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 0 {
+		t.Fatal("incorrect exit code", res.ExitCode)
+	}
+
+	list, err := res.Stack.Pop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := list.([]any)[0].([]any)
+	if item[0].(*big.Int).Int64() != int64(plugin.Workchain()) {
+		t.Fatal("incorrect workchain", item[0])
+	}
+	if !bytes.Equal(item[1].(*big.Int).FillBytes(make([]byte, 32)), plugin.Data()) {
+		t.Fatal("incorrect address")
+	}
+	if list.([]any)[1] != nil {
+		t.Fatal("list should have 1 element")
+	}
+
+	res, err = NewTVM().RunGetMethod(code, data, nil, GasWithLimit(DefaultGetMethodGasLimit), "is_plugin_installed",
+		int64(plugin.Workchain()), new(big.Int).SetBytes(plugin.Data()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 0 {
+		t.Fatal("incorrect exit code", res.ExitCode)
+	}
+	installed, err := res.Stack.Pop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if installed.(*big.Int).Int64() != -1 {
+		t.Fatal("plugin should be installed")
+	}
+
+	res, err = NewTVM().RunGetMethod(code, data, nil, GasWithLimit(DefaultGetMethodGasLimit), "unknown_method")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 11 {
+		t.Fatal("incorrect exit code for unknown method", res.ExitCode)
+	}
+}
+
+func TestTVM_ExternalMessage(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	const subwallet = 698983191
+	const now = 1700000000
+
+	data := cell.BeginCell().
+		MustStoreUInt(3, 32).
+		MustStoreUInt(subwallet, 32).
+		MustStoreSlice(pub, 256).
+		EndCell()
+
+	transfer := cell.BeginCell().MustStoreUInt(0x18, 6).MustStoreUInt(0, 2).MustStoreUInt(0, 4).EndCell()
+
+	payload := cell.BeginCell().
+		MustStoreUInt(subwallet, 32).
+		MustStoreUInt(now+60, 32).
+		MustStoreUInt(3, 32).
+		MustStoreUInt(3, 8).
+		MustStoreRef(transfer)
+	body := cell.BeginCell().
+		MustStoreSlice(payload.EndCell().Sign(priv), 512).
+		MustStoreBuilder(payload).
+		EndCell()
+
+	c7, err := (&ContractInfo{
+		Now:     now,
+		Balance: big.NewInt(1000000000),
+	}).ToC7()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stack := tlb.NewStack()
+	// tlb stack top is a bottom of the vm stack
+	stack.Push(int64(-1))
+	stack.Push(body.BeginParse())
+	stack.Push(cell.BeginCell().EndCell())
+	stack.Push(int64(0))
+	stack.Push(int64(1000000000))
+
+	res, err := NewTVM().Execute(mustCode(t, _V3R2CodeHex), data, c7, Gas{Max: 1000000, Credit: 10000}, stack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 0 {
+		t.Fatal("incorrect exit code", res.ExitCode)
+	}
+	if !res.Committed {
+		t.Fatal("state should be committed")
+	}
+
+	if seqno := res.Data.BeginParse().MustLoadUInt(32); seqno != 4 {
+		t.Fatal("incorrect new seqno", seqno)
+	}
+
+	act := res.Actions.BeginParse()
+	if act.RefsNum() != 2 {
+		t.Fatal("incorrect actions")
+	}
+	act.MustLoadRef()
+	if act.MustLoadUInt(32) != 0x0ec3c86d || act.MustLoadUInt(8) != 3 {
+		t.Fatal("incorrect send message action")
+	}
+	if !bytes.Equal(act.MustLoadRef().MustToCell().Hash(), transfer.Hash()) {
+		t.Fatal("incorrect message")
+	}
+
+	// replay should be rejected, because seqno is already used
+	res, err = NewTVM().Execute(mustCode(t, _V3R2CodeHex), res.Data, c7, Gas{Max: 1000000, Credit: 10000}, stack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 33 {
+		t.Fatal("incorrect exit code for replay", res.ExitCode)
+	}
+}